@@ -2,9 +2,12 @@ package main
 
 import (
 	"context"
+	"e-meetingproject/internal/auth"
 	"e-meetingproject/internal/database"
 	"e-meetingproject/internal/handlers"
+	"e-meetingproject/internal/mail"
 	"e-meetingproject/internal/middleware"
+	"e-meetingproject/internal/models"
 	"e-meetingproject/internal/services"
 	"flag"
 	"fmt"
@@ -12,6 +15,7 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
 	"time"
 
@@ -30,6 +34,83 @@ func initConfig() error {
 	return nil
 }
 
+// loadSigningKeys reads the token signing keys from JWT_KEYS_DIR. Without a
+// key directory an in-memory key is generated, which only suits development
+// as tokens stop verifying on restart.
+func loadSigningKeys() (*auth.KeySet, error) {
+	dir := viper.GetString("JWT_KEYS_DIR")
+	if dir == "" {
+		log.Println("Warning: JWT_KEYS_DIR is not set, signing tokens with an ephemeral key")
+		return auth.NewEphemeralKeySet()
+	}
+
+	return auth.LoadKeySet(dir, viper.GetString("JWT_SIGNING_KID"))
+}
+
+// loadTOTPKey reads the key TOTP secrets are encrypted with from
+// TOTP_ENCRYPTION_KEY. Without a key secrets are stored in plain text, which
+// only suits development.
+func loadTOTPKey() (*services.TOTPKey, error) {
+	value := viper.GetString("TOTP_ENCRYPTION_KEY")
+	if value == "" {
+		log.Println("Warning: TOTP_ENCRYPTION_KEY is not set, two-factor secrets are stored unencrypted")
+		return nil, nil
+	}
+
+	return services.ParseTOTPKey(value)
+}
+
+// trustedProxies lists the proxies, as IPs or CIDRs separated by commas in
+// TRUSTED_PROXIES, whose X-Forwarded-For header is believed. None by default.
+func trustedProxies() []string {
+	var proxies []string
+	for _, proxy := range strings.Split(viper.GetString("TRUSTED_PROXIES"), ",") {
+		if proxy = strings.TrimSpace(proxy); proxy != "" {
+			proxies = append(proxies, proxy)
+		}
+	}
+	return proxies
+}
+
+// newRouter returns the Gin engine. Client IPs, which login throttling and
+// the auth audit log rely on, are only taken from X-Forwarded-For when the
+// request comes from one of trustedProxies; otherwise clients could pick them.
+func newRouter(trustedProxies []string) (*gin.Engine, error) {
+	router := gin.Default()
+	if err := router.SetTrustedProxies(trustedProxies); err != nil {
+		return nil, fmt.Errorf("invalid TRUSTED_PROXIES: %v", err)
+	}
+	return router, nil
+}
+
+// loadMailer returns the Mailer selected by MAIL_DRIVER: smtp, file or log.
+// Without a driver mail is only logged, which suits development.
+func loadMailer() (mail.Mailer, error) {
+	from := viper.GetString("MAIL_FROM")
+
+	switch driver := viper.GetString("MAIL_DRIVER"); driver {
+	case "smtp":
+		return mail.NewSMTPMailer(mail.SMTPConfig{
+			Host:     viper.GetString("SMTP_HOST"),
+			Port:     viper.GetString("SMTP_PORT"),
+			Username: viper.GetString("SMTP_USERNAME"),
+			Password: viper.GetString("SMTP_PASSWORD"),
+			From:     from,
+		})
+	case "file":
+		dir := viper.GetString("MAIL_FILE_DIR")
+		if dir == "" {
+			dir = "mail" // default directory
+		}
+		return mail.NewFileMailer(dir, from)
+	case "", "log":
+		log.Println("Warning: MAIL_DRIVER is not set, emails are written to the log")
+		return mail.NewLogMailer(), nil
+	default:
+		return nil, fmt.Errorf("unknown mail driver: %s", driver)
+	}
+}
+
 func gracefulShutdown(server *http.Server, done chan bool) {
 	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
 	defer stop()
@@ -85,18 +166,74 @@ func main() {
 		os.Exit(0)
 	}
 
+	// Load the keys access tokens are signed with
+	signingKeys, err := loadSigningKeys()
+	if err != nil {
+		log.Fatalf("Failed to load signing keys: %v", err)
+	}
+
+	// Load the key two-factor secrets are encrypted with
+	totpKey, err := loadTOTPKey()
+	if err != nil {
+		log.Fatalf("Failed to load TOTP key: %v", err)
+	}
+
+	// Deliver queued emails in the background
+	mailer, err := loadMailer()
+	if err != nil {
+		log.Fatalf("Failed to configure mail: %v", err)
+	}
+	outboxCtx, stopOutbox := context.WithCancel(context.Background())
+	defer stopOutbox()
+	go services.NewEmailOutbox(mailer).Run(outboxCtx)
+
 	// Initialize services and handlers
-	authService := services.NewAuthService()
+	authService := services.NewAuthService(signingKeys, totpKey)
 	authHandler := handlers.NewAuthHandler(authService)
 
+	permissionService := services.NewPermissionService()
+	permissionHandler := handlers.NewPermissionHandler(permissionService)
+
+	apiKeyService := services.NewAPIKeyService(permissionService)
+	apiKeyHandler := handlers.NewAPIKeyHandler(apiKeyService)
+	// Service accounts authenticate with an API key instead of a token
+	apiKeyAuth := middleware.APIKeyAuthMiddleware(apiKeyService)
+
+	authAuditService := services.NewAuthAuditService()
+	authAuditHandler := handlers.NewAuthAuditHandler(authAuditService)
+
+	twoFactorService := services.NewTwoFactorService(totpKey)
+	twoFactorHandler := handlers.NewTwoFactorHandler(twoFactorService)
+
+	oidcService := services.NewOIDCService(authService)
+	oidcHandler := handlers.NewOIDCHandler(oidcService)
+
 	userService := services.NewUserService()
 	userHandler := handlers.NewUserHandler(userService)
 
 	dashboardService := services.NewDashboardService()
 	dashboardHandler := handlers.NewDashboardHandler(dashboardService)
 
-	reservationService := services.NewReservationService()
-	reservationHandler := handlers.NewReservationHandler(reservationService)
+	paymentService := services.NewPaymentService()
+	paymentHandler := handlers.NewPaymentHandler(paymentService)
+
+	reservationService := services.NewReservationService(paymentService)
+	reservationHandler := handlers.NewReservationHandler(reservationService, permissionService)
+
+	reservationSnackService := services.NewReservationSnackService(paymentService)
+	reservationSnackHandler := handlers.NewReservationSnackHandler(reservationSnackService)
+
+	cancellationPolicyService := services.NewCancellationPolicyService()
+	cancellationPolicyHandler := handlers.NewCancellationPolicyHandler(cancellationPolicyService)
+
+	organisationService := services.NewOrganisationService()
+	organisationHandler := handlers.NewOrganisationHandler(organisationService)
+
+	costCenterService := services.NewCostCenterService()
+	costCenterHandler := handlers.NewCostCenterHandler(costCenterService)
+
+	walletService := services.NewWalletService()
+	walletHandler := handlers.NewWalletHandler(walletService, permissionService)
 
 	roomService := services.NewRoomService()
 	roomHandler := handlers.NewRoomHandler(roomService)
@@ -104,31 +241,84 @@ func main() {
 	snackService := services.NewSnackService()
 	snackHandler := handlers.NewSnackHandler(snackService)
 
+	snackStockService := services.NewSnackStockService()
+	snackStockHandler := handlers.NewSnackStockHandler(snackStockService)
+
+	snackBundleService := services.NewSnackBundleService()
+	snackBundleHandler := handlers.NewSnackBundleHandler(snackBundleService)
+
+	cateringService := services.NewCateringService()
+	cateringHandler := handlers.NewCateringHandler(cateringService)
+
 	// Setup Gin router
-	router := gin.Default()
+	router, err := newRouter(trustedProxies())
+	if err != nil {
+		log.Fatalf("Failed to setup router: %v", err)
+	}
 
 	// Public routes
 	router.POST("/register", authHandler.Register)
 	router.POST("/password/reset_request", authHandler.RequestPasswordReset)
 	router.POST("/password/reset", authHandler.ResetPassword)
 
+	// Payment provider callbacks (authenticated by webhook signature)
+	router.POST("/payments/webhook", paymentHandler.Webhook)
+
+	// Public keys for verifying access tokens
+	router.GET("/.well-known/jwks.json", authHandler.GetJWKS)
+
 	// Regular user login
 	router.POST("/login", authHandler.Login)
+	router.POST("/login/two-factor", authHandler.VerifyTwoFactorLogin)
+	router.POST("/login/two-factor/enroll", authHandler.BeginChallengeEnrollment)
+	router.POST("/token/refresh", authHandler.RefreshToken)
+	router.GET("/verify-email", authHandler.VerifyEmail)
+
+	// Single sign-on through the company identity provider
+	if oidcService.Enabled() {
+		router.GET("/oidc/login", oidcHandler.Login)
+		router.GET("/oidc/callback", oidcHandler.Callback)
+	}
 
-	// Protected routes (requires authentication)
+	// Protected routes (requires authentication, not available to API keys)
 	protected := router.Group("")
-	protected.Use(middleware.JWTAuthMiddleware())
+	protected.Use(apiKeyAuth, middleware.JWTAuthMiddleware(signingKeys, authService), middleware.RejectAPIKeys())
 	{
+		protected.POST("/logout", authHandler.Logout)
+		protected.POST("/account/verify-email/resend", authHandler.ResendVerificationEmail)
+		protected.GET("/account/two-factor", twoFactorHandler.GetStatus)
+		protected.POST("/account/two-factor/enroll", twoFactorHandler.BeginEnrollment)
+		protected.POST("/account/two-factor/confirm", twoFactorHandler.ConfirmEnrollment)
+		protected.POST("/account/two-factor/recovery-codes", twoFactorHandler.RegenerateRecoveryCodes)
+		protected.DELETE("/account/two-factor", twoFactorHandler.Disable)
 		protected.GET("/users/:id", userHandler.GetProfile)
 		protected.POST("/users/:id", userHandler.UpdateProfile)
 		protected.GET("/dashboard", dashboardHandler.GetDashboardStats)
 		protected.GET("/rooms", roomHandler.GetRooms)
 		protected.GET("/rooms/:id/schedule", roomHandler.GetRoomSchedule)
 		protected.GET("/snacks", snackHandler.GetSnacks)
-		protected.POST("/reservation/calculation", reservationHandler.CalculateReservationCost)
-		protected.POST("/reservation", reservationHandler.CreateReservation)
-		protected.GET("/reservation/history", reservationHandler.GetReservationHistory)
-		protected.GET("/reservation/:id", reservationHandler.GetReservationByID)
+		protected.GET("/bundles", snackBundleHandler.GetBundles)
+		protected.GET("/wallets", walletHandler.GetMyWallets)
+		protected.GET("/wallets/:id", walletHandler.GetWallet)
+		protected.GET("/wallets/:id/transactions", walletHandler.GetTransactions)
+	}
+
+	// Reservation routes - requires a verified email address and the
+	// reservation create permission
+	reservationRoutes := router.Group("/reservation")
+	reservationRoutes.Use(apiKeyAuth, middleware.JWTAuthMiddleware(signingKeys, authService))
+	reservationRoutes.Use(middleware.RequireVerifiedEmail(authService))
+	reservationRoutes.Use(middleware.RequirePermission(permissionService, models.PermissionReservationCreate))
+	{
+		reservationRoutes.POST("/calculation", reservationHandler.CalculateReservationCost)
+		reservationRoutes.POST("", reservationHandler.CreateReservation)
+		reservationRoutes.GET("/history", reservationHandler.GetReservationHistory)
+		reservationRoutes.GET("/:id", reservationHandler.GetReservationByID)
+		reservationRoutes.GET("/:id/cancellation", reservationHandler.GetCancellationQuote)
+		reservationRoutes.POST("/:id/cancel", reservationHandler.CancelReservation)
+		reservationRoutes.POST("/:id/snacks", reservationSnackHandler.AddSnackLine)
+		reservationRoutes.PUT("/:id/snacks/:line_id", reservationSnackHandler.UpdateSnackLine)
+		reservationRoutes.DELETE("/:id/snacks/:line_id", reservationSnackHandler.RemoveSnackLine)
 	}
 
 	// Admin routes group
@@ -137,28 +327,104 @@ func main() {
 		// Admin login - public admin route
 		adminRoutes.POST("/login", authHandler.Login)
 
-		// Protected admin routes - requires admin role
+		// Protected admin routes - each requires a permission of the user's role
 		adminProtected := adminRoutes.Group("")
-		adminProtected.Use(middleware.JWTAuthMiddleware())
-		adminProtected.Use(middleware.AdminOnlyMiddleware())
+		adminProtected.Use(apiKeyAuth, middleware.JWTAuthMiddleware(signingKeys, authService))
+		can := func(permission string) gin.HandlerFunc {
+			return middleware.RequirePermission(permissionService, permission)
+		}
 		{
 			// Dashboard routes
-			adminProtected.GET("/dashboard", dashboardHandler.GetDashboardStats)
+			adminProtected.GET("/dashboard", can(models.PermissionDashboardView), dashboardHandler.GetDashboardStats)
 
 			// Reservation management
-			adminProtected.GET("/reservations/history", reservationHandler.GetReservationHistory)
-			adminProtected.POST("/reservation/status", reservationHandler.UpdateReservationStatus)
+			adminProtected.GET("/reservations/history", can(models.PermissionReservationView), reservationHandler.GetReservationHistory)
+			adminProtected.POST("/reservation/status", can(models.PermissionReservationApprove), reservationHandler.UpdateReservationStatus)
 
 			// Room management
-			adminProtected.POST("/rooms", roomHandler.CreateRoom)       // Create room
-			adminProtected.PUT("/rooms/:id", roomHandler.UpdateRoom)    // Update room
-			adminProtected.DELETE("/rooms/:id", roomHandler.DeleteRoom) // Delete room
+			adminProtected.POST("/rooms", can(models.PermissionRoomManage), roomHandler.CreateRoom)       // Create room
+			adminProtected.PUT("/rooms/:id", can(models.PermissionRoomManage), roomHandler.UpdateRoom)    // Update room
+			adminProtected.DELETE("/rooms/:id", can(models.PermissionRoomManage), roomHandler.DeleteRoom) // Delete room
+
+			// Cancellation policies
+			adminProtected.GET("/cancellation-policies", can(models.PermissionPolicyManage), cancellationPolicyHandler.GetPolicies)
+			adminProtected.POST("/cancellation-policies", can(models.PermissionPolicyManage), cancellationPolicyHandler.CreatePolicy)
+			adminProtected.PUT("/cancellation-policies/:id", can(models.PermissionPolicyManage), cancellationPolicyHandler.UpdatePolicy)
+			adminProtected.PUT("/rooms/:id/cancellation-policy", can(models.PermissionPolicyManage), cancellationPolicyHandler.AssignRoomPolicy)
+
+			// Cost centers and chargeback
+			adminProtected.GET("/cost-centers", can(models.PermissionBillingManage), costCenterHandler.GetCostCenters)
+			adminProtected.POST("/cost-centers", can(models.PermissionBillingManage), costCenterHandler.CreateCostCenter)
+			adminProtected.PUT("/cost-centers/:id", can(models.PermissionBillingManage), costCenterHandler.UpdateCostCenter)
+			adminProtected.PUT("/users/:id/cost-center", can(models.PermissionBillingManage), costCenterHandler.AssignUserCostCenter)
+			adminProtected.GET("/chargeback/statements", can(models.PermissionBillingManage), costCenterHandler.GetChargebackStatement)
+
+			// Prepaid wallets and teams
+			adminProtected.POST("/wallets", can(models.PermissionBillingManage), walletHandler.CreateWallet)
+			adminProtected.POST("/wallets/:id/topup", can(models.PermissionBillingManage), walletHandler.TopUp)
+			adminProtected.GET("/teams", can(models.PermissionBillingManage), walletHandler.GetTeams)
+			adminProtected.POST("/teams", can(models.PermissionBillingManage), walletHandler.CreateTeam)
+			adminProtected.POST("/teams/:id/members", can(models.PermissionBillingManage), walletHandler.AddTeamMember)
+			adminProtected.DELETE("/teams/:id/members/:user_id", can(models.PermissionBillingManage), walletHandler.RemoveTeamMember)
 
 			// Snack management
-			adminProtected.POST("/snacks", snackHandler.CreateSnack) // Create snack
+			adminProtected.POST("/snacks", can(models.PermissionSnackManage), snackHandler.CreateSnack)       // Create snack
+			adminProtected.PUT("/snacks/:id", can(models.PermissionSnackManage), snackHandler.UpdateSnack)    // Update snack
+			adminProtected.DELETE("/snacks/:id", can(models.PermissionSnackManage), snackHandler.RetireSnack) // Retire snack
+
+			// User management
+			adminProtected.GET("/users", can(models.PermissionUserManage), userHandler.ListUsers)
+			adminProtected.POST("/users", can(models.PermissionUserManage), userHandler.CreateUser)
+			adminProtected.PUT("/users/:id/role", can(models.PermissionUserManage), userHandler.UpdateRole)
+			adminProtected.POST("/users/:id/suspend", can(models.PermissionUserManage), userHandler.SuspendUser)
+			adminProtected.POST("/users/:id/reactivate", can(models.PermissionUserManage), userHandler.ReactivateUser)
+			adminProtected.POST("/users/:id/password-reset", can(models.PermissionUserManage), userHandler.ForcePasswordReset)
+			adminProtected.POST("/users/:id/sessions/revoke", can(models.PermissionUserManage), authHandler.RevokeUserSessions)
+
+			// Login lockout and auth audit log
+			adminProtected.POST("/users/:id/unlock", can(models.PermissionUserManage), authHandler.UnlockUser)
+			adminProtected.GET("/auth-audit", can(models.PermissionAuditView), authAuditHandler.GetEvents)
+
+			// Roles, permissions and two-factor requirements
+			adminProtected.GET("/roles", can(models.PermissionRoleManage), permissionHandler.GetRoles)
+			adminProtected.PUT("/roles/:role/permissions", can(models.PermissionRoleManage), permissionHandler.SetRolePermissions)
+			adminProtected.PUT("/roles/:role/two-factor", can(models.PermissionRoleManage), twoFactorHandler.SetRoleRequirement)
+			adminProtected.DELETE("/users/:id/two-factor", can(models.PermissionUserManage), twoFactorHandler.ResetUser)
+
+			// Organisations
+			adminProtected.GET("/organisations", can(models.PermissionOrganisationManage), organisationHandler.GetOrganisations)
+			adminProtected.POST("/organisations", can(models.PermissionOrganisationManage), organisationHandler.CreateOrganisation)
+			adminProtected.PUT("/organisations/:id", can(models.PermissionOrganisationManage), organisationHandler.UpdateOrganisation)
+
+			// Service accounts and API keys
+			adminProtected.GET("/service-accounts", can(models.PermissionAPIKeyManage), apiKeyHandler.GetServiceAccounts)
+			adminProtected.POST("/service-accounts", can(models.PermissionAPIKeyManage), apiKeyHandler.CreateServiceAccount)
+			adminProtected.GET("/service-accounts/:id/api-keys", can(models.PermissionAPIKeyManage), apiKeyHandler.GetAPIKeys)
+			adminProtected.POST("/service-accounts/:id/api-keys", can(models.PermissionAPIKeyManage), apiKeyHandler.CreateAPIKey)
+			adminProtected.POST("/api-keys/:id/rotate", can(models.PermissionAPIKeyManage), apiKeyHandler.RotateAPIKey)
+			adminProtected.DELETE("/api-keys/:id", can(models.PermissionAPIKeyManage), apiKeyHandler.RevokeAPIKey)
+			adminProtected.GET("/api-keys/:id/usage", can(models.PermissionAPIKeyManage), apiKeyHandler.GetAPIKeyUsage)
+
+			// Snack stock
+			adminProtected.POST("/snacks/:id/stock", can(models.PermissionSnackManage), snackStockHandler.Restock)
+			adminProtected.GET("/snacks/low-stock", can(models.PermissionSnackManage), snackStockHandler.GetLowStock)
+
+			// Snack bundles
+			adminProtected.POST("/bundles", can(models.PermissionSnackManage), snackBundleHandler.CreateBundle)       // Create bundle
+			adminProtected.PUT("/bundles/:id", can(models.PermissionSnackManage), snackBundleHandler.UpdateBundle)    // Update bundle
+			adminProtected.DELETE("/bundles/:id", can(models.PermissionSnackManage), snackBundleHandler.RetireBundle) // Retire bundle
 		}
 	}
 
+	// Catering routes - requires the catering prep permission
+	cateringRoutes := router.Group("/catering")
+	cateringRoutes.Use(apiKeyAuth, middleware.JWTAuthMiddleware(signingKeys, authService))
+	cateringRoutes.Use(middleware.RequirePermission(permissionService, models.PermissionCateringPrep))
+	{
+		cateringRoutes.GET("/prep-sheet", cateringHandler.GetPrepSheet)
+		cateringRoutes.PUT("/orders/:id/status", cateringHandler.UpdatePrepStatus)
+	}
+
 	// Create HTTP server
 	server := &http.Server{
 		Addr:    fmt.Sprintf(":%s", viper.GetString("PORT")),
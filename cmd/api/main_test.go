@@ -0,0 +1,65 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// failedLogins counts failed logins per client IP, like the login throttle.
+func failedLogins(t *testing.T, trustedProxies []string) (*gin.Engine, map[string]int) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	router, err := newRouter(trustedProxies)
+	require.NoError(t, err)
+
+	failures := map[string]int{}
+	router.POST("/login", func(c *gin.Context) {
+		failures[c.ClientIP()]++
+		c.Status(http.StatusUnauthorized)
+	})
+	return router, failures
+}
+
+func login(router *gin.Engine, remoteAddr, forwardedFor string) {
+	req := httptest.NewRequest(http.MethodPost, "/login", nil)
+	req.RemoteAddr = remoteAddr
+	if forwardedFor != "" {
+		req.Header.Set("X-Forwarded-For", forwardedFor)
+	}
+	router.ServeHTTP(httptest.NewRecorder(), req)
+}
+
+func TestSpoofedForwardedForKeepsClientIP(t *testing.T) {
+	router, failures := failedLogins(t, nil)
+
+	// A client rotating X-Forwarded-For keeps failing as the same IP
+	for _, spoofed := range []string{"10.0.0.1", "10.0.0.2", "192.168.1.7"} {
+		login(router, "203.0.113.9:51234", spoofed)
+	}
+
+	assert.Equal(t, map[string]int{"203.0.113.9": 3}, failures)
+}
+
+func TestTrustedProxyForwardsClientIP(t *testing.T) {
+	router, failures := failedLogins(t, []string{"10.1.0.0/16"})
+
+	// The load balancer forwards the client's IP
+	login(router, "10.1.2.3:443", "198.51.100.20")
+	login(router, "10.1.2.3:443", "198.51.100.20")
+
+	// A client reaching the API directly cannot claim another IP
+	login(router, "203.0.113.9:51234", "198.51.100.20")
+
+	assert.Equal(t, map[string]int{"198.51.100.20": 2, "203.0.113.9": 1}, failures)
+}
+
+func TestNewRouterInvalidTrustedProxies(t *testing.T) {
+	_, err := newRouter([]string{"not-an-ip"})
+	assert.Error(t, err)
+}
@@ -0,0 +1,9 @@
+-- Drop indexes
+DROP INDEX IF EXISTS idx_payments_status;
+DROP INDEX IF EXISTS idx_payments_reservation_id;
+
+-- Drop table
+DROP TABLE IF EXISTS payments;
+
+-- Drop custom types
+DROP TYPE IF EXISTS payment_status;
@@ -0,0 +1,22 @@
+-- Create payment_status enum
+CREATE TYPE payment_status AS ENUM ('pending', 'succeeded', 'failed');
+
+-- Create payments table
+CREATE TABLE IF NOT EXISTS payments (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    reservation_id UUID NOT NULL REFERENCES reservations(id) ON DELETE CASCADE,
+    provider VARCHAR(50) NOT NULL,
+    provider_ref VARCHAR(255) NOT NULL,
+    amount DECIMAL(10,2) NOT NULL,
+    currency VARCHAR(3) NOT NULL,
+    status payment_status NOT NULL DEFAULT 'pending',
+    failure_reason TEXT,
+    last_event_id VARCHAR(255),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT payments_provider_ref_unique UNIQUE (provider, provider_ref)
+);
+
+-- Create indexes
+CREATE INDEX IF NOT EXISTS idx_payments_reservation_id ON payments(reservation_id);
+CREATE INDEX IF NOT EXISTS idx_payments_status ON payments(status);
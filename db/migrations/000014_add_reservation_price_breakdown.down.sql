@@ -0,0 +1,7 @@
+-- Drop price breakdown columns
+ALTER TABLE reservations
+    DROP COLUMN IF EXISTS adjustments,
+    DROP COLUMN IF EXISTS snack_subtotal,
+    DROP COLUMN IF EXISTS room_subtotal,
+    DROP COLUMN IF EXISTS billed_hours,
+    DROP COLUMN IF EXISTS hourly_rate;
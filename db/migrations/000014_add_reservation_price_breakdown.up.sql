@@ -0,0 +1,31 @@
+-- Add price breakdown columns to reservations
+ALTER TABLE reservations
+    ADD COLUMN hourly_rate DECIMAL(10,2),
+    ADD COLUMN billed_hours DECIMAL(8,4),
+    ADD COLUMN room_subtotal DECIMAL(10,2),
+    ADD COLUMN snack_subtotal DECIMAL(10,2),
+    ADD COLUMN adjustments DECIMAL(10,2) NOT NULL DEFAULT 0;
+
+-- Backfill existing reservations from their stored snack lines
+UPDATE reservations r
+SET snack_subtotal = COALESCE((
+        SELECT SUM(rs.price * rs.quantity)
+        FROM reservation_snacks rs
+        WHERE rs.reservation_id = r.id
+    ), 0),
+    billed_hours = EXTRACT(EPOCH FROM (r.end_time - r.start_time)) / 3600;
+
+UPDATE reservations
+SET room_subtotal = price - snack_subtotal,
+    hourly_rate = CASE
+        WHEN billed_hours > 0 THEN ROUND((price - snack_subtotal) / billed_hours, 2)
+        ELSE 0
+    END;
+
+-- Every reservation carries a breakdown from now on
+ALTER TABLE reservations
+    ALTER COLUMN hourly_rate SET NOT NULL,
+    ALTER COLUMN billed_hours SET NOT NULL,
+    ALTER COLUMN room_subtotal SET NOT NULL,
+    ALTER COLUMN snack_subtotal SET NOT NULL,
+    ALTER COLUMN snack_subtotal SET DEFAULT 0;
@@ -0,0 +1,15 @@
+-- Drop index
+DROP INDEX IF EXISTS idx_reservations_cancelled_at;
+
+-- Drop cancellation columns
+ALTER TABLE reservations
+    DROP COLUMN IF EXISTS cancelled_at,
+    DROP COLUMN IF EXISTS refund_amount,
+    DROP COLUMN IF EXISTS cancellation_fee;
+
+ALTER TABLE rooms DROP COLUMN IF EXISTS cancellation_policy_id;
+
+-- Drop tables
+DROP TABLE IF EXISTS cancellation_policy_rules;
+DROP INDEX IF EXISTS idx_cancellation_policies_default;
+DROP TABLE IF EXISTS cancellation_policies;
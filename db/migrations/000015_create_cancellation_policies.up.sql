@@ -0,0 +1,45 @@
+-- Create cancellation_policies table
+CREATE TABLE IF NOT EXISTS cancellation_policies (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    name VARCHAR(100) NOT NULL,
+    is_default BOOLEAN NOT NULL DEFAULT false,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT cancellation_policies_name_unique UNIQUE (name)
+);
+
+-- Only one policy can be the default
+CREATE UNIQUE INDEX IF NOT EXISTS idx_cancellation_policies_default
+    ON cancellation_policies(is_default) WHERE is_default;
+
+-- Create cancellation_policy_rules table
+-- A rule applies when the reservation is cancelled at least min_hours_before its start
+CREATE TABLE IF NOT EXISTS cancellation_policy_rules (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    policy_id UUID NOT NULL REFERENCES cancellation_policies(id) ON DELETE CASCADE,
+    min_hours_before DECIMAL(8,2) NOT NULL CHECK (min_hours_before >= 0),
+    fee_percent DECIMAL(5,2) NOT NULL CHECK (fee_percent >= 0 AND fee_percent <= 100),
+    CONSTRAINT cancellation_policy_rules_unique UNIQUE (policy_id, min_hours_before)
+);
+
+-- Rooms can use a specific policy, otherwise the default policy applies
+ALTER TABLE rooms
+    ADD COLUMN cancellation_policy_id UUID REFERENCES cancellation_policies(id) ON DELETE SET NULL;
+
+-- Store the outcome of the policy on the reservation
+ALTER TABLE reservations
+    ADD COLUMN cancellation_fee DECIMAL(10,2),
+    ADD COLUMN refund_amount DECIMAL(10,2),
+    ADD COLUMN cancelled_at TIMESTAMP;
+
+CREATE INDEX IF NOT EXISTS idx_reservations_cancelled_at ON reservations(cancelled_at);
+
+-- Seed the standard policy: free up to 48h, 50% up to 24h, 100% afterwards
+WITH standard AS (
+    INSERT INTO cancellation_policies (name, is_default)
+    VALUES ('Standard', true)
+    RETURNING id
+)
+INSERT INTO cancellation_policy_rules (policy_id, min_hours_before, fee_percent)
+SELECT id, rule.min_hours_before, rule.fee_percent
+FROM standard, (VALUES (48, 0), (24, 50), (0, 100)) AS rule(min_hours_before, fee_percent);
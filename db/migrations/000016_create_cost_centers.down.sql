@@ -0,0 +1,10 @@
+-- Drop indexes
+DROP INDEX IF EXISTS idx_reservations_cost_center_id;
+DROP INDEX IF EXISTS idx_users_cost_center_id;
+
+-- Drop cost center columns
+ALTER TABLE reservations DROP COLUMN IF EXISTS cost_center_id;
+ALTER TABLE users DROP COLUMN IF EXISTS cost_center_id;
+
+-- Drop table
+DROP TABLE IF EXISTS cost_centers;
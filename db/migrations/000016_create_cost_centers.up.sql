@@ -0,0 +1,22 @@
+-- Create cost_centers table
+CREATE TABLE IF NOT EXISTS cost_centers (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    code VARCHAR(50) NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    is_active BOOLEAN NOT NULL DEFAULT true,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT cost_centers_code_unique UNIQUE (code)
+);
+
+-- Default cost center of each user
+ALTER TABLE users
+    ADD COLUMN cost_center_id UUID REFERENCES cost_centers(id) ON DELETE SET NULL;
+
+-- Cost center charged for a reservation, resolved when it is booked
+ALTER TABLE reservations
+    ADD COLUMN cost_center_id UUID REFERENCES cost_centers(id) ON DELETE RESTRICT;
+
+-- Create indexes
+CREATE INDEX IF NOT EXISTS idx_users_cost_center_id ON users(cost_center_id);
+CREATE INDEX IF NOT EXISTS idx_reservations_cost_center_id ON reservations(cost_center_id);
@@ -0,0 +1,14 @@
+-- Drop indexes
+DROP INDEX IF EXISTS idx_wallet_transactions_reservation_id;
+DROP INDEX IF EXISTS idx_wallet_transactions_wallet_id;
+DROP INDEX IF EXISTS idx_team_members_user_id;
+
+-- Drop wallet column
+ALTER TABLE reservations DROP COLUMN IF EXISTS wallet_id;
+
+-- Drop tables
+DROP TABLE IF EXISTS wallet_transactions;
+DROP TYPE IF EXISTS wallet_transaction_type;
+DROP TABLE IF EXISTS wallets;
+DROP TABLE IF EXISTS team_members;
+DROP TABLE IF EXISTS teams;
@@ -0,0 +1,58 @@
+-- Create teams table
+CREATE TABLE IF NOT EXISTS teams (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    name VARCHAR(255) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT teams_name_unique UNIQUE (name)
+);
+
+-- Create team_members table
+CREATE TABLE IF NOT EXISTS team_members (
+    team_id UUID NOT NULL REFERENCES teams(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (team_id, user_id)
+);
+
+-- Create wallets table, owned by either a user or a team
+-- balance is the spendable amount, held is reserved for pending reservations
+CREATE TABLE IF NOT EXISTS wallets (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id UUID REFERENCES users(id) ON DELETE RESTRICT,
+    team_id UUID REFERENCES teams(id) ON DELETE RESTRICT,
+    currency VARCHAR(3) NOT NULL,
+    balance DECIMAL(12,2) NOT NULL DEFAULT 0 CHECK (balance >= 0),
+    held DECIMAL(12,2) NOT NULL DEFAULT 0 CHECK (held >= 0),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT wallets_single_owner CHECK ((user_id IS NULL) <> (team_id IS NULL)),
+    CONSTRAINT wallets_user_unique UNIQUE (user_id),
+    CONSTRAINT wallets_team_unique UNIQUE (team_id)
+);
+
+-- Create wallet_transaction_type enum
+CREATE TYPE wallet_transaction_type AS ENUM ('topup', 'hold', 'capture', 'release', 'refund');
+
+-- Create wallet_transactions ledger
+CREATE TABLE IF NOT EXISTS wallet_transactions (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    wallet_id UUID NOT NULL REFERENCES wallets(id) ON DELETE RESTRICT,
+    reservation_id UUID REFERENCES reservations(id) ON DELETE SET NULL,
+    type wallet_transaction_type NOT NULL,
+    amount DECIMAL(12,2) NOT NULL CHECK (amount > 0),
+    balance_after DECIMAL(12,2) NOT NULL,
+    held_after DECIMAL(12,2) NOT NULL,
+    description TEXT,
+    created_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Wallet paying for a reservation
+ALTER TABLE reservations
+    ADD COLUMN wallet_id UUID REFERENCES wallets(id) ON DELETE RESTRICT;
+
+-- Create indexes
+CREATE INDEX IF NOT EXISTS idx_team_members_user_id ON team_members(user_id);
+CREATE INDEX IF NOT EXISTS idx_wallet_transactions_wallet_id ON wallet_transactions(wallet_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_wallet_transactions_reservation_id ON wallet_transactions(reservation_id);
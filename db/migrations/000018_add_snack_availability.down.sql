@@ -0,0 +1,7 @@
+-- Drop index
+DROP INDEX IF EXISTS idx_snacks_active;
+
+-- Drop availability columns
+ALTER TABLE snacks
+    DROP COLUMN IF EXISTS retired_at,
+    DROP COLUMN IF EXISTS is_available;
@@ -0,0 +1,8 @@
+-- Snacks can be made unavailable temporarily or retired for good.
+-- Retired snacks stay referenced by past reservation_snacks rows.
+ALTER TABLE snacks
+    ADD COLUMN is_available BOOLEAN NOT NULL DEFAULT true,
+    ADD COLUMN retired_at TIMESTAMP WITH TIME ZONE;
+
+-- Create index for listing the active catalogue
+CREATE INDEX IF NOT EXISTS idx_snacks_active ON snacks(category, name) WHERE retired_at IS NULL;
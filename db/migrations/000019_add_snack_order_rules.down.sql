@@ -0,0 +1,5 @@
+-- Drop ordering rules
+ALTER TABLE snacks
+    DROP COLUMN IF EXISTS order_cutoff_minutes,
+    DROP COLUMN IF EXISTS max_per_visitor,
+    DROP COLUMN IF EXISTS min_quantity;
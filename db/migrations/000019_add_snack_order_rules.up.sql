@@ -0,0 +1,6 @@
+-- Ordering rules per snack
+-- max_per_visitor NULL means no per-visitor limit
+ALTER TABLE snacks
+    ADD COLUMN min_quantity INTEGER NOT NULL DEFAULT 1 CHECK (min_quantity >= 1),
+    ADD COLUMN max_per_visitor INTEGER CHECK (max_per_visitor >= 1),
+    ADD COLUMN order_cutoff_minutes INTEGER NOT NULL DEFAULT 0 CHECK (order_cutoff_minutes >= 0);
@@ -0,0 +1,9 @@
+-- Drop indexes
+DROP INDEX IF EXISTS idx_snack_stock_stock_date;
+
+-- Drop stock columns and table
+ALTER TABLE reservation_snacks DROP COLUMN IF EXISTS stock_date;
+DROP TABLE IF EXISTS snack_stock;
+ALTER TABLE snacks
+    DROP COLUMN IF EXISTS low_stock_threshold,
+    DROP COLUMN IF EXISTS daily_stock;
@@ -0,0 +1,23 @@
+-- Stock settings per snack
+-- daily_stock NULL means the snack is not stock-limited unless restocked for a day
+ALTER TABLE snacks
+    ADD COLUMN daily_stock INTEGER CHECK (daily_stock >= 0),
+    ADD COLUMN low_stock_threshold INTEGER NOT NULL DEFAULT 0 CHECK (low_stock_threshold >= 0);
+
+-- Create snack_stock table, one row per snack and day once stock is used or restocked
+CREATE TABLE IF NOT EXISTS snack_stock (
+    snack_id UUID NOT NULL REFERENCES snacks(id) ON DELETE CASCADE,
+    stock_date DATE NOT NULL,
+    quantity INTEGER NOT NULL CHECK (quantity >= 0),
+    reserved INTEGER NOT NULL DEFAULT 0 CHECK (reserved >= 0),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (snack_id, stock_date),
+    CONSTRAINT snack_stock_not_oversold CHECK (reserved <= quantity)
+);
+
+-- Day the snack order was taken from stock, NULL when the snack was not stock-limited
+ALTER TABLE reservation_snacks
+    ADD COLUMN stock_date DATE;
+
+-- Create indexes
+CREATE INDEX IF NOT EXISTS idx_snack_stock_stock_date ON snack_stock(stock_date);
@@ -0,0 +1,8 @@
+-- Add catering staff role
+ALTER TYPE user_role ADD VALUE IF NOT EXISTS 'catering';
+
+-- Allow the new role; compare as text since the new enum value
+-- cannot be used before this transaction commits
+ALTER TABLE users DROP CONSTRAINT IF EXISTS valid_role;
+ALTER TABLE users
+    ADD CONSTRAINT valid_role CHECK (role::text IN ('admin', 'user', 'catering'));
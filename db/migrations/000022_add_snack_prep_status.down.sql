@@ -0,0 +1,8 @@
+-- Drop prep status columns
+ALTER TABLE reservation_snacks
+    DROP COLUMN IF EXISTS prep_updated_by,
+    DROP COLUMN IF EXISTS prep_updated_at,
+    DROP COLUMN IF EXISTS prep_status;
+
+-- Drop snack_prep_status enum
+DROP TYPE IF EXISTS snack_prep_status;
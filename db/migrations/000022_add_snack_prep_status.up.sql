@@ -0,0 +1,8 @@
+-- Create snack_prep_status enum
+CREATE TYPE snack_prep_status AS ENUM ('pending', 'preparing', 'delivered');
+
+-- Kitchen progress of each snack order
+ALTER TABLE reservation_snacks
+    ADD COLUMN prep_status snack_prep_status NOT NULL DEFAULT 'pending',
+    ADD COLUMN prep_updated_at TIMESTAMP WITH TIME ZONE,
+    ADD COLUMN prep_updated_by UUID REFERENCES users(id) ON DELETE SET NULL;
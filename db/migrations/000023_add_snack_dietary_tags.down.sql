@@ -0,0 +1,9 @@
+-- Drop indexes
+DROP INDEX IF EXISTS idx_snacks_allergens;
+DROP INDEX IF EXISTS idx_snacks_dietary_tags;
+
+-- Drop tag columns
+ALTER TABLE reservations DROP COLUMN IF EXISTS excluded_allergens;
+ALTER TABLE snacks
+    DROP COLUMN IF EXISTS allergens,
+    DROP COLUMN IF EXISTS dietary_tags;
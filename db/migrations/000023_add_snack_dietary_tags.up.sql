@@ -0,0 +1,12 @@
+-- Dietary tags (e.g. halal, vegan) and allergens per snack
+ALTER TABLE snacks
+    ADD COLUMN dietary_tags TEXT[] NOT NULL DEFAULT '{}',
+    ADD COLUMN allergens TEXT[] NOT NULL DEFAULT '{}';
+
+-- Allergens the organiser wants kept out of the order
+ALTER TABLE reservations
+    ADD COLUMN excluded_allergens TEXT[] NOT NULL DEFAULT '{}';
+
+-- Create indexes for tag filters
+CREATE INDEX IF NOT EXISTS idx_snacks_dietary_tags ON snacks USING GIN (dietary_tags);
+CREATE INDEX IF NOT EXISTS idx_snacks_allergens ON snacks USING GIN (allergens);
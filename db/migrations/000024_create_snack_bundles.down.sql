@@ -0,0 +1,11 @@
+-- Drop indexes
+DROP INDEX IF EXISTS idx_reservation_snacks_bundle_id;
+DROP INDEX IF EXISTS idx_snack_bundle_items_snack_id;
+DROP INDEX IF EXISTS idx_snack_bundles_active;
+
+-- Drop bundle reference
+ALTER TABLE reservation_snacks DROP COLUMN IF EXISTS bundle_id;
+
+-- Drop tables
+DROP TABLE IF EXISTS snack_bundle_items;
+DROP TABLE IF EXISTS snack_bundles;
@@ -0,0 +1,28 @@
+-- Bundles sell a fixed set of snacks at a package price
+CREATE TABLE IF NOT EXISTS snack_bundles (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    name VARCHAR(255) NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    price DECIMAL(10,2) NOT NULL CHECK (price > 0),
+    per_visitor BOOLEAN NOT NULL DEFAULT false, -- Price and quantities apply per visitor
+    is_available BOOLEAN NOT NULL DEFAULT true,
+    retired_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS snack_bundle_items (
+    bundle_id UUID NOT NULL REFERENCES snack_bundles(id) ON DELETE CASCADE,
+    snack_id UUID NOT NULL REFERENCES snacks(id) ON DELETE RESTRICT,
+    quantity INT NOT NULL CHECK (quantity > 0),
+    PRIMARY KEY (bundle_id, snack_id)
+);
+
+-- Snack lines expanded from a bundle point back to it
+ALTER TABLE reservation_snacks
+    ADD COLUMN bundle_id UUID REFERENCES snack_bundles(id) ON DELETE RESTRICT;
+
+-- Create indexes
+CREATE INDEX IF NOT EXISTS idx_snack_bundles_active ON snack_bundles(name) WHERE retired_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_snack_bundle_items_snack_id ON snack_bundle_items(snack_id);
+CREATE INDEX IF NOT EXISTS idx_reservation_snacks_bundle_id ON reservation_snacks(bundle_id);
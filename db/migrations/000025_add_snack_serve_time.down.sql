@@ -0,0 +1,5 @@
+-- Drop index
+DROP INDEX IF EXISTS idx_reservation_snacks_serve_at;
+
+-- Drop serve time
+ALTER TABLE reservation_snacks DROP COLUMN IF EXISTS serve_at;
@@ -0,0 +1,6 @@
+-- Optional delivery time of a snack line; NULL means the start of the reservation
+ALTER TABLE reservation_snacks
+    ADD COLUMN serve_at TIMESTAMP WITH TIME ZONE;
+
+-- Create index for the kitchen schedule
+CREATE INDEX IF NOT EXISTS idx_reservation_snacks_serve_at ON reservation_snacks(serve_at);
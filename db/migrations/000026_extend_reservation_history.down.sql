@@ -0,0 +1,10 @@
+-- Drop index
+DROP INDEX IF EXISTS idx_reservation_history_reservation_id;
+
+-- Drop change details
+ALTER TABLE reservation_history
+    DROP COLUMN IF EXISTS price_after,
+    DROP COLUMN IF EXISTS price_before,
+    DROP COLUMN IF EXISTS changed_by,
+    DROP COLUMN IF EXISTS description,
+    DROP COLUMN IF EXISTS change_type;
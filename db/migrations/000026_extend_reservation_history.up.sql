@@ -0,0 +1,10 @@
+-- Record what changed on a reservation, not only its status
+ALTER TABLE reservation_history
+    ADD COLUMN change_type VARCHAR(50) NOT NULL DEFAULT 'status',
+    ADD COLUMN description TEXT,
+    ADD COLUMN changed_by UUID REFERENCES users(id),
+    ADD COLUMN price_before DECIMAL(10,2),
+    ADD COLUMN price_after DECIMAL(10,2);
+
+-- Create index for reading a reservation's history
+CREATE INDEX IF NOT EXISTS idx_reservation_history_reservation_id ON reservation_history(reservation_id, created_at);
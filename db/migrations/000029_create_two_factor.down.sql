@@ -0,0 +1,9 @@
+-- Drop indexes
+DROP INDEX IF EXISTS idx_login_challenges_expires_at;
+DROP INDEX IF EXISTS idx_user_recovery_codes_user_id;
+
+-- Drop tables
+DROP TABLE IF EXISTS role_policies;
+DROP TABLE IF EXISTS login_challenges;
+DROP TABLE IF EXISTS user_recovery_codes;
+DROP TABLE IF EXISTS user_totp;
@@ -0,0 +1,14 @@
+-- Drop indexes
+DROP INDEX IF EXISTS idx_auth_audit_log_event;
+DROP INDEX IF EXISTS idx_auth_audit_log_user_id;
+DROP INDEX IF EXISTS idx_auth_audit_log_created_at;
+
+-- Drop tables
+DROP TABLE IF EXISTS auth_audit_log;
+DROP TABLE IF EXISTS login_ip_attempts;
+
+-- Drop columns
+ALTER TABLE users
+    DROP COLUMN IF EXISTS locked_until,
+    DROP COLUMN IF EXISTS last_failed_login_at,
+    DROP COLUMN IF EXISTS failed_login_count;
@@ -0,0 +1,31 @@
+-- Failed password attempts per account; reset on a successful login
+ALTER TABLE users
+    ADD COLUMN failed_login_count INT NOT NULL DEFAULT 0,
+    ADD COLUMN last_failed_login_at TIMESTAMP WITH TIME ZONE,
+    ADD COLUMN locked_until TIMESTAMP WITH TIME ZONE;
+
+-- Failed password attempts per client IP within a window
+CREATE TABLE IF NOT EXISTS login_ip_attempts (
+    ip VARCHAR(45) PRIMARY KEY,
+    failures INT NOT NULL DEFAULT 0,
+    window_started_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    last_failed_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    locked_until TIMESTAMP WITH TIME ZONE
+);
+
+-- Authentication events: logins, failures, lockouts and unlocks
+CREATE TABLE IF NOT EXISTS auth_audit_log (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    event VARCHAR(50) NOT NULL,
+    user_id UUID REFERENCES users(id) ON DELETE SET NULL,
+    username VARCHAR(255),
+    ip VARCHAR(45),
+    actor_id UUID REFERENCES users(id) ON DELETE SET NULL,
+    detail TEXT,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Create indexes
+CREATE INDEX IF NOT EXISTS idx_auth_audit_log_created_at ON auth_audit_log(created_at);
+CREATE INDEX IF NOT EXISTS idx_auth_audit_log_user_id ON auth_audit_log(user_id);
+CREATE INDEX IF NOT EXISTS idx_auth_audit_log_event ON auth_audit_log(event);
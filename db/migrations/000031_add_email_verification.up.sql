@@ -0,0 +1,4 @@
+-- New registrations stay pending_verification until the email link is opened
+ALTER TABLE users
+    ADD COLUMN email_verified_at TIMESTAMP WITH TIME ZONE,
+    ADD COLUMN verification_sent_at TIMESTAMP WITH TIME ZONE;
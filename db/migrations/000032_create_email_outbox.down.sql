@@ -0,0 +1,5 @@
+-- Drop indexes
+DROP INDEX IF EXISTS idx_email_outbox_due;
+
+-- Drop tables
+DROP TABLE IF EXISTS email_outbox;
@@ -0,0 +1,16 @@
+-- Receptionists become regular users
+UPDATE users SET role = 'user' WHERE role::text = 'receptionist';
+DELETE FROM role_policies WHERE role::text = 'receptionist';
+
+-- Recreate the role enum without the receptionist role
+ALTER TABLE users DROP CONSTRAINT IF EXISTS valid_role;
+ALTER TABLE users ALTER COLUMN role DROP DEFAULT;
+ALTER TYPE user_role RENAME TO user_role_old;
+CREATE TYPE user_role AS ENUM ('admin', 'user', 'catering');
+ALTER TABLE users ALTER COLUMN role TYPE user_role USING role::text::user_role;
+ALTER TABLE role_policies ALTER COLUMN role TYPE user_role USING role::text::user_role;
+ALTER TABLE users ALTER COLUMN role SET DEFAULT 'user'::user_role;
+DROP TYPE user_role_old;
+
+ALTER TABLE users
+    ADD CONSTRAINT valid_role CHECK (role IN ('admin', 'user', 'catering'));
@@ -0,0 +1,39 @@
+-- Named permissions checked by the API
+CREATE TABLE IF NOT EXISTS permissions (
+    name VARCHAR(100) PRIMARY KEY,
+    description TEXT NOT NULL
+);
+
+-- Permissions granted to each role
+CREATE TABLE IF NOT EXISTS role_permissions (
+    role user_role NOT NULL,
+    permission VARCHAR(100) NOT NULL REFERENCES permissions(name) ON DELETE CASCADE,
+    PRIMARY KEY (role, permission)
+);
+
+INSERT INTO permissions (name, description) VALUES
+    ('dashboard.view', 'View the admin dashboard'),
+    ('reservation.view', 'View the reservations of all users'),
+    ('reservation.approve', 'Confirm, cancel or complete reservations'),
+    ('room.manage', 'Create, update and delete rooms'),
+    ('policy.manage', 'Manage cancellation policies'),
+    ('billing.manage', 'Manage cost centers, chargeback, wallets and teams'),
+    ('snack.manage', 'Manage snacks, stock and bundles'),
+    ('catering.prep', 'Use the catering prep sheet and update order status'),
+    ('user.manage', 'Manage users, sessions, lockouts and two-factor resets'),
+    ('role.manage', 'Manage role permissions and two-factor requirements'),
+    ('audit.view', 'View the auth audit log')
+ON CONFLICT (name) DO NOTHING;
+
+-- Admins can do everything
+INSERT INTO role_permissions (role, permission)
+SELECT 'admin', name FROM permissions
+ON CONFLICT DO NOTHING;
+
+INSERT INTO role_permissions (role, permission) VALUES
+    ('receptionist', 'dashboard.view'),
+    ('receptionist', 'reservation.view'),
+    ('receptionist', 'reservation.approve'),
+    ('catering', 'snack.manage'),
+    ('catering', 'catering.prep')
+ON CONFLICT DO NOTHING;
@@ -0,0 +1,18 @@
+-- Super admins become admins
+UPDATE users SET role = 'admin' WHERE role::text = 'super_admin';
+DELETE FROM role_policies WHERE role::text = 'super_admin';
+DELETE FROM role_permissions WHERE role::text = 'super_admin';
+
+-- Recreate the role enum without the super admin role
+ALTER TABLE users DROP CONSTRAINT IF EXISTS valid_role;
+ALTER TABLE users ALTER COLUMN role DROP DEFAULT;
+ALTER TYPE user_role RENAME TO user_role_old;
+CREATE TYPE user_role AS ENUM ('admin', 'user', 'catering', 'receptionist');
+ALTER TABLE users ALTER COLUMN role TYPE user_role USING role::text::user_role;
+ALTER TABLE role_policies ALTER COLUMN role TYPE user_role USING role::text::user_role;
+ALTER TABLE role_permissions ALTER COLUMN role TYPE user_role USING role::text::user_role;
+ALTER TABLE users ALTER COLUMN role SET DEFAULT 'user'::user_role;
+DROP TYPE user_role_old;
+
+ALTER TABLE users
+    ADD CONSTRAINT valid_role CHECK (role IN ('admin', 'user', 'catering', 'receptionist'));
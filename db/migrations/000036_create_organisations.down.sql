@@ -0,0 +1,22 @@
+-- Super admins become admins again
+UPDATE users SET role = 'admin' WHERE role = 'super_admin';
+DELETE FROM role_policies WHERE role = 'super_admin';
+DELETE FROM role_permissions WHERE role = 'super_admin';
+INSERT INTO role_permissions (role, permission) VALUES ('admin', 'role.manage')
+ON CONFLICT DO NOTHING;
+DELETE FROM permissions WHERE name = 'organisation.manage';
+
+-- Drop indexes
+DROP INDEX IF EXISTS idx_snack_bundles_organisation_id;
+DROP INDEX IF EXISTS idx_snacks_organisation_id;
+DROP INDEX IF EXISTS idx_rooms_organisation_id;
+DROP INDEX IF EXISTS idx_users_organisation_id;
+
+-- Drop columns
+ALTER TABLE snack_bundles DROP COLUMN IF EXISTS organisation_id;
+ALTER TABLE snacks DROP COLUMN IF EXISTS organisation_id;
+ALTER TABLE rooms DROP COLUMN IF EXISTS organisation_id;
+ALTER TABLE users DROP COLUMN IF EXISTS organisation_id;
+
+-- Drop tables
+DROP TABLE IF EXISTS organisations;
@@ -0,0 +1,53 @@
+-- Tenant companies sharing the building
+CREATE TABLE IF NOT EXISTS organisations (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    name VARCHAR(255) NOT NULL,
+    slug VARCHAR(100) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT organisations_slug_unique UNIQUE (slug)
+);
+
+-- Existing data, and rows created without an organisation, belong to the
+-- building operator
+INSERT INTO organisations (id, name, slug)
+VALUES ('00000000-0000-0000-0000-000000000001', 'Default', 'default')
+ON CONFLICT (id) DO NOTHING;
+
+ALTER TABLE users
+    ADD COLUMN organisation_id UUID NOT NULL DEFAULT '00000000-0000-0000-0000-000000000001'
+        REFERENCES organisations(id) ON DELETE RESTRICT;
+ALTER TABLE rooms
+    ADD COLUMN organisation_id UUID NOT NULL DEFAULT '00000000-0000-0000-0000-000000000001'
+        REFERENCES organisations(id) ON DELETE RESTRICT;
+ALTER TABLE snacks
+    ADD COLUMN organisation_id UUID NOT NULL DEFAULT '00000000-0000-0000-0000-000000000001'
+        REFERENCES organisations(id) ON DELETE RESTRICT;
+ALTER TABLE snack_bundles
+    ADD COLUMN organisation_id UUID NOT NULL DEFAULT '00000000-0000-0000-0000-000000000001'
+        REFERENCES organisations(id) ON DELETE RESTRICT;
+
+INSERT INTO permissions (name, description) VALUES
+    ('organisation.manage', 'Create and rename organisations')
+ON CONFLICT (name) DO NOTHING;
+
+-- Super admins can do everything
+INSERT INTO role_permissions (role, permission)
+SELECT 'super_admin', name FROM permissions
+ON CONFLICT DO NOTHING;
+
+-- Roles are shared by every organisation, so only super admins change them
+DELETE FROM role_permissions WHERE role = 'admin' AND permission = 'role.manage';
+
+INSERT INTO role_policies (role, require_two_factor)
+SELECT 'super_admin', require_two_factor FROM role_policies WHERE role = 'admin'
+ON CONFLICT (role) DO NOTHING;
+
+-- The existing admins run the building
+UPDATE users SET role = 'super_admin' WHERE role = 'admin';
+
+-- Create indexes
+CREATE INDEX IF NOT EXISTS idx_users_organisation_id ON users(organisation_id);
+CREATE INDEX IF NOT EXISTS idx_rooms_organisation_id ON rooms(organisation_id);
+CREATE INDEX IF NOT EXISTS idx_snacks_organisation_id ON snacks(organisation_id);
+CREATE INDEX IF NOT EXISTS idx_snack_bundles_organisation_id ON snack_bundles(organisation_id);
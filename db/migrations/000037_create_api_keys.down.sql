@@ -0,0 +1,11 @@
+DELETE FROM permissions WHERE name = 'api_key.manage';
+
+-- Drop indexes
+DROP INDEX IF EXISTS idx_api_keys_user_id;
+
+-- Drop tables
+DROP TABLE IF EXISTS api_key_usage;
+DROP TABLE IF EXISTS api_keys;
+
+-- Service accounts stay as users without a password, who cannot sign in
+ALTER TABLE users DROP COLUMN IF EXISTS is_service_account;
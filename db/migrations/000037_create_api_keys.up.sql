@@ -0,0 +1,46 @@
+-- Service accounts are users without a password that integrations act as
+-- through API keys
+ALTER TABLE users
+    ADD COLUMN is_service_account BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- API keys are stored as SHA-256 hashes. The prefix identifies a key in
+-- listings. A key may only use the permissions it lists, and only the rooms
+-- it lists unless room_ids is empty.
+CREATE TABLE IF NOT EXISTS api_keys (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name VARCHAR(255) NOT NULL,
+    prefix VARCHAR(20) NOT NULL,
+    key_hash VARCHAR(64) NOT NULL,
+    permissions TEXT[] NOT NULL DEFAULT '{}',
+    room_ids UUID[] NOT NULL DEFAULT '{}',
+    expires_at TIMESTAMP WITH TIME ZONE,
+    revoked_at TIMESTAMP WITH TIME ZONE,
+    replaced_by UUID REFERENCES api_keys(id) ON DELETE SET NULL,
+    last_used_at TIMESTAMP WITH TIME ZONE,
+    last_used_ip VARCHAR(45),
+    use_count BIGINT NOT NULL DEFAULT 0,
+    created_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT api_keys_key_hash_unique UNIQUE (key_hash)
+);
+
+-- Requests made with each key per day
+CREATE TABLE IF NOT EXISTS api_key_usage (
+    api_key_id UUID NOT NULL REFERENCES api_keys(id) ON DELETE CASCADE,
+    day DATE NOT NULL,
+    requests BIGINT NOT NULL DEFAULT 0,
+    PRIMARY KEY (api_key_id, day)
+);
+
+INSERT INTO permissions (name, description) VALUES
+    ('api_key.manage', 'Manage service accounts and API keys')
+ON CONFLICT (name) DO NOTHING;
+
+INSERT INTO role_permissions (role, permission) VALUES
+    ('super_admin', 'api_key.manage'),
+    ('admin', 'api_key.manage')
+ON CONFLICT DO NOTHING;
+
+-- Create indexes
+CREATE INDEX IF NOT EXISTS idx_api_keys_user_id ON api_keys(user_id);
@@ -0,0 +1,5 @@
+-- Drop indexes
+DROP INDEX IF EXISTS idx_payment_events_payment_id;
+
+-- Drop tables
+DROP TABLE IF EXISTS payment_events;
@@ -0,0 +1,17 @@
+-- Provider webhook events already applied, so redeliveries are ignored even
+-- when they arrive out of order
+CREATE TABLE IF NOT EXISTS payment_events (
+    provider VARCHAR(50) NOT NULL,
+    event_id VARCHAR(255) NOT NULL,
+    payment_id UUID NOT NULL REFERENCES payments(id) ON DELETE CASCADE,
+    received_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT payment_events_provider_event_unique UNIQUE (provider, event_id)
+);
+
+-- Events applied before this table existed
+INSERT INTO payment_events (provider, event_id, payment_id)
+SELECT provider, last_event_id, id FROM payments WHERE last_event_id IS NOT NULL
+ON CONFLICT DO NOTHING;
+
+-- Create indexes
+CREATE INDEX IF NOT EXISTS idx_payment_events_payment_id ON payment_events(payment_id);
@@ -0,0 +1,2 @@
+ALTER TABLE reservations
+    ALTER COLUMN cancelled_at TYPE TIMESTAMP;
@@ -0,0 +1,4 @@
+-- Store cancellation times with their time zone, like every other timestamp.
+-- Existing values are read in the server's time zone.
+ALTER TABLE reservations
+    ALTER COLUMN cancelled_at TYPE TIMESTAMP WITH TIME ZONE;
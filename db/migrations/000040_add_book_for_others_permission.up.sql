@@ -0,0 +1,10 @@
+-- Reservations are booked for the caller; booking for another user, who is
+-- then charged, needs its own permission
+INSERT INTO permissions (name, description) VALUES
+    ('reservation.book_for_others', 'Book reservations on behalf of other users')
+ON CONFLICT (name) DO NOTHING;
+
+INSERT INTO role_permissions (role, permission) VALUES
+    ('super_admin', 'reservation.book_for_others'),
+    ('admin', 'reservation.book_for_others')
+ON CONFLICT DO NOTHING;
@@ -0,0 +1,3 @@
+-- Encrypted secrets do not fit back; their users have to enrol again
+DELETE FROM user_totp WHERE LENGTH(secret) > 64;
+ALTER TABLE user_totp ALTER COLUMN secret TYPE VARCHAR(64);
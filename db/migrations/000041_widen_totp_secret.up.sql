@@ -0,0 +1,2 @@
+-- Encrypted TOTP secrets are longer than the plain base32 ones
+ALTER TABLE user_totp ALTER COLUMN secret TYPE TEXT;
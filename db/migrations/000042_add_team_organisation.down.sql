@@ -0,0 +1,4 @@
+-- Drop indexes
+DROP INDEX IF EXISTS idx_teams_organisation_id;
+
+ALTER TABLE teams DROP COLUMN IF EXISTS organisation_id;
@@ -0,0 +1,19 @@
+-- Teams, and so their wallets, belong to an organisation like their members
+ALTER TABLE teams
+    ADD COLUMN organisation_id UUID NOT NULL DEFAULT '00000000-0000-0000-0000-000000000001'
+        REFERENCES organisations(id) ON DELETE RESTRICT;
+
+-- Existing teams whose members all work for one organisation belong to it
+UPDATE teams t
+SET organisation_id = m.organisation_id
+FROM (
+    SELECT tm.team_id, (ARRAY_AGG(DISTINCT u.organisation_id))[1] AS organisation_id
+    FROM team_members tm
+    JOIN users u ON u.id = tm.user_id
+    GROUP BY tm.team_id
+    HAVING COUNT(DISTINCT u.organisation_id) = 1
+) m
+WHERE m.team_id = t.id;
+
+-- Create indexes
+CREATE INDEX IF NOT EXISTS idx_teams_organisation_id ON teams(organisation_id);
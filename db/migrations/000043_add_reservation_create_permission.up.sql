@@ -0,0 +1,14 @@
+-- Booking, changing and cancelling one's own reservations needs a permission
+-- so that API keys only reach the reservation routes when granted it. Every
+-- role keeps the access it had.
+INSERT INTO permissions (name, description) VALUES
+    ('reservation.create', 'Book, change and cancel own reservations')
+ON CONFLICT (name) DO NOTHING;
+
+INSERT INTO role_permissions (role, permission) VALUES
+    ('super_admin', 'reservation.create'),
+    ('admin', 'reservation.create'),
+    ('receptionist', 'reservation.create'),
+    ('catering', 'reservation.create'),
+    ('user', 'reservation.create')
+ON CONFLICT DO NOTHING;
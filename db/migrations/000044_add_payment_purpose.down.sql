@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_payments_reservation_purpose;
+ALTER TABLE payments DROP COLUMN IF EXISTS purpose;
+DROP TYPE IF EXISTS payment_purpose;
@@ -0,0 +1,17 @@
+-- A reservation's booking payment and the payments requested for snack order
+-- increases are told apart, so only the booking payment confirms it
+CREATE TYPE payment_purpose AS ENUM ('booking', 'snack_topup');
+
+ALTER TABLE payments ADD COLUMN purpose payment_purpose NOT NULL DEFAULT 'booking';
+
+-- The first payment of a reservation is its booking payment, later ones were
+-- requested for snack orders
+UPDATE payments p
+SET purpose = 'snack_topup'
+WHERE EXISTS (
+    SELECT 1 FROM payments earlier
+    WHERE earlier.reservation_id = p.reservation_id
+    AND (earlier.created_at, earlier.id) < (p.created_at, p.id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_payments_reservation_purpose ON payments(reservation_id, purpose);
@@ -0,0 +1,11 @@
+DROP INDEX IF EXISTS idx_cancellation_policies_default;
+ALTER TABLE cancellation_policies DROP CONSTRAINT IF EXISTS cancellation_policies_organisation_name_unique;
+
+-- Rooms of other organisations fall back to the remaining default policy
+DELETE FROM cancellation_policies WHERE organisation_id <> '00000000-0000-0000-0000-000000000001';
+
+ALTER TABLE cancellation_policies DROP COLUMN IF EXISTS organisation_id;
+ALTER TABLE cancellation_policies
+    ADD CONSTRAINT cancellation_policies_name_unique UNIQUE (name);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_cancellation_policies_default
+    ON cancellation_policies(is_default) WHERE is_default;
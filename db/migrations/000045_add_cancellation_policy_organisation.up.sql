@@ -0,0 +1,41 @@
+-- Cancellation policies belong to an organisation, each with its own default
+ALTER TABLE cancellation_policies
+    ADD COLUMN organisation_id UUID NOT NULL DEFAULT '00000000-0000-0000-0000-000000000001'
+        REFERENCES organisations(id) ON DELETE RESTRICT;
+
+ALTER TABLE cancellation_policies DROP CONSTRAINT IF EXISTS cancellation_policies_name_unique;
+DROP INDEX IF EXISTS idx_cancellation_policies_default;
+
+-- Every other organisation gets its own copy of the policies it used: the
+-- default one and those assigned to its rooms
+CREATE TEMPORARY TABLE cancellation_policy_copies AS
+SELECT o.id AS organisation_id, p.id AS source_id, uuid_generate_v4() AS id
+FROM organisations o
+JOIN cancellation_policies p ON p.is_default OR p.id IN (
+    SELECT cancellation_policy_id FROM rooms WHERE organisation_id = o.id
+)
+WHERE o.id <> '00000000-0000-0000-0000-000000000001';
+
+INSERT INTO cancellation_policies (id, organisation_id, name, is_default, created_at, updated_at)
+SELECT c.id, c.organisation_id, p.name, p.is_default, p.created_at, p.updated_at
+FROM cancellation_policy_copies c
+JOIN cancellation_policies p ON p.id = c.source_id;
+
+INSERT INTO cancellation_policy_rules (policy_id, min_hours_before, fee_percent)
+SELECT c.id, r.min_hours_before, r.fee_percent
+FROM cancellation_policy_copies c
+JOIN cancellation_policy_rules r ON r.policy_id = c.source_id;
+
+UPDATE rooms rm
+SET cancellation_policy_id = c.id
+FROM cancellation_policy_copies c
+WHERE c.organisation_id = rm.organisation_id AND c.source_id = rm.cancellation_policy_id;
+
+DROP TABLE cancellation_policy_copies;
+
+ALTER TABLE cancellation_policies
+    ADD CONSTRAINT cancellation_policies_organisation_name_unique UNIQUE (organisation_id, name);
+
+-- Only one policy of an organisation can be its default
+CREATE UNIQUE INDEX IF NOT EXISTS idx_cancellation_policies_default
+    ON cancellation_policies(organisation_id) WHERE is_default;
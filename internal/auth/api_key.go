@@ -0,0 +1,7 @@
+package auth
+
+// APIKeyAuthenticator returns the claims of a service account's API key used
+// from ip, or nil claims when the key is unknown, expired or revoked.
+type APIKeyAuthenticator interface {
+	AuthenticateAPIKey(key, ip string) (*Claims, error)
+}
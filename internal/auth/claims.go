@@ -6,8 +6,32 @@ import (
 )
 
 type Claims struct {
-	UserID   uuid.UUID `json:"user_id"`
-	Username string    `json:"username"`
-	Role     string    `json:"role"`
+	UserID         uuid.UUID `json:"user_id"`
+	OrganisationID uuid.UUID `json:"org_id"` // Tenant whose data the user works with
+	Username       string    `json:"username"`
+	Role           string    `json:"role"`
+	TokenVersion   int       `json:"ver"` // Must match the user's token version
 	jwt.RegisteredClaims
+
+	// Set for requests made with an API key, which may only use its own
+	// permissions and, when RoomIDs is not empty, those rooms
+	APIKeyID    uuid.UUID   `json:"-"`
+	Permissions []string    `json:"-"`
+	RoomIDs     []uuid.UUID `json:"-"`
+}
+
+// IsAPIKey reports whether the claims belong to an API key rather than a
+// signed in user.
+func (c *Claims) IsAPIKey() bool {
+	return c.APIKeyID != uuid.Nil
+}
+
+// KeyAllows reports whether the API key of the claims was granted permission.
+func (c *Claims) KeyAllows(permission string) bool {
+	for _, p := range c.Permissions {
+		if p == permission {
+			return true
+		}
+	}
+	return false
 }
@@ -0,0 +1,315 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// reloadInterval is how often a file-based KeySet looks for rotated keys.
+const reloadInterval = time.Minute
+
+// SigningKey is one key of a KeySet. Keys without a private key only verify
+// tokens, e.g. a retired key kept until the tokens it signed have expired.
+type SigningKey struct {
+	ID      string
+	Method  jwt.SigningMethod
+	Private crypto.Signer // nil for verify-only keys
+	Public  crypto.PublicKey
+}
+
+// KeySet holds the keys access tokens are signed and verified with, looked up
+// by their kid. Keys are read from a directory with one PEM file per key, the
+// file name without extension being the kid. The signing key is the one named
+// by signingKID, or else the private key with the greatest kid, so date-based
+// kids rotate by simply adding a newer file.
+type KeySet struct {
+	dir        string
+	signingKID string
+
+	mu       sync.RWMutex
+	keys     map[string]*SigningKey
+	active   *SigningKey
+	loadedAt time.Time
+}
+
+// JWKS is the JSON Web Key Set published at /.well-known/jwks.json.
+type JWKS struct {
+	Keys []JSONWebKey `json:"keys"`
+}
+
+// JSONWebKey is the public part of a signing key (RFC 7517).
+type JSONWebKey struct {
+	Kty string `json:"kty"`
+	Use string `json:"use"`
+	Kid string `json:"kid"`
+	Alg string `json:"alg"`
+	N   string `json:"n,omitempty"`   // RSA modulus
+	E   string `json:"e,omitempty"`   // RSA exponent
+	Crv string `json:"crv,omitempty"` // OKP curve
+	X   string `json:"x,omitempty"`   // OKP public key
+}
+
+// LoadKeySet reads the keys in dir. It fails when there is no key to sign
+// with.
+func LoadKeySet(dir, signingKID string) (*KeySet, error) {
+	k := &KeySet{dir: dir, signingKID: signingKID}
+	if err := k.Reload(); err != nil {
+		return nil, err
+	}
+	return k, nil
+}
+
+// NewEphemeralKeySet returns a KeySet with a single Ed25519 key generated in
+// memory. Tokens it signs stop verifying once the process exits, so it is
+// only meant for development.
+func NewEphemeralKeySet() (*KeySet, error) {
+	public, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("error generating signing key: %v", err)
+	}
+
+	id := make([]byte, 8)
+	if _, err := rand.Read(id); err != nil {
+		return nil, fmt.Errorf("error generating key ID: %v", err)
+	}
+
+	key := &SigningKey{
+		ID:      "ephemeral-" + hex.EncodeToString(id),
+		Method:  jwt.SigningMethodEdDSA,
+		Private: private,
+		Public:  public,
+	}
+	return &KeySet{
+		keys:     map[string]*SigningKey{key.ID: key},
+		active:   key,
+		loadedAt: time.Now(),
+	}, nil
+}
+
+// Reload re-reads the key directory. On error the previous keys stay in use.
+func (k *KeySet) Reload() error {
+	if k.dir == "" {
+		return nil
+	}
+
+	paths, err := filepath.Glob(filepath.Join(k.dir, "*.pem"))
+	if err != nil {
+		return fmt.Errorf("error listing signing keys: %v", err)
+	}
+
+	keys := make(map[string]*SigningKey, len(paths))
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("error reading signing key %s: %v", path, err)
+		}
+
+		key, err := parseSigningKey(strings.TrimSuffix(filepath.Base(path), ".pem"), data)
+		if err != nil {
+			return err
+		}
+		keys[key.ID] = key
+	}
+
+	active, err := selectSigningKey(keys, k.signingKID)
+	if err != nil {
+		return err
+	}
+
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	k.keys = keys
+	k.active = active
+	k.loadedAt = time.Now()
+
+	return nil
+}
+
+// Sign signs claims with the active key and sets the kid header.
+func (k *KeySet) Sign(claims jwt.Claims) (string, error) {
+	k.reloadIfStale()
+
+	k.mu.RLock()
+	key := k.active
+	k.mu.RUnlock()
+
+	token := jwt.NewWithClaims(key.Method, claims)
+	token.Header["kid"] = key.ID
+
+	return token.SignedString(key.Private)
+}
+
+// Keyfunc returns the verification key for token's kid, for use with
+// jwt.Parse. The token must be signed with the algorithm of that key.
+func (k *KeySet) Keyfunc(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+	if kid == "" {
+		return nil, errors.New("token has no key ID")
+	}
+
+	key := k.lookup(kid)
+	if key == nil {
+		// The key may have been added since the last reload
+		k.reloadIfStale()
+		if key = k.lookup(kid); key == nil {
+			return nil, fmt.Errorf("unknown key ID: %s", kid)
+		}
+	}
+
+	if token.Method.Alg() != key.Method.Alg() {
+		return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+	}
+
+	return key.Public, nil
+}
+
+// Methods lists the algorithms tokens may be signed with.
+func (k *KeySet) Methods() []string {
+	return []string{jwt.SigningMethodRS256.Alg(), jwt.SigningMethodEdDSA.Alg()}
+}
+
+// JWKS returns the public keys of the set, ordered by kid.
+func (k *KeySet) JWKS() JWKS {
+	k.reloadIfStale()
+
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+
+	jwks := JWKS{Keys: make([]JSONWebKey, 0, len(k.keys))}
+	for _, key := range k.keys {
+		jwks.Keys = append(jwks.Keys, key.jwk())
+	}
+	sort.Slice(jwks.Keys, func(i, j int) bool {
+		return jwks.Keys[i].Kid < jwks.Keys[j].Kid
+	})
+
+	return jwks
+}
+
+func (k *KeySet) lookup(kid string) *SigningKey {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	return k.keys[kid]
+}
+
+func (k *KeySet) reloadIfStale() {
+	k.mu.RLock()
+	stale := k.dir != "" && time.Since(k.loadedAt) > reloadInterval
+	k.mu.RUnlock()
+
+	if stale {
+		if err := k.Reload(); err != nil {
+			fmt.Printf("Error reloading signing keys: %v\n", err)
+			// Keep the previous keys and retry after the next interval
+			k.mu.Lock()
+			k.loadedAt = time.Now()
+			k.mu.Unlock()
+		}
+	}
+}
+
+func (key *SigningKey) jwk() JSONWebKey {
+	jwk := JSONWebKey{Use: "sig", Kid: key.ID, Alg: key.Method.Alg()}
+
+	switch public := key.Public.(type) {
+	case *rsa.PublicKey:
+		jwk.Kty = "RSA"
+		jwk.N = base64.RawURLEncoding.EncodeToString(public.N.Bytes())
+		jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(public.E)).Bytes())
+	case ed25519.PublicKey:
+		jwk.Kty = "OKP"
+		jwk.Crv = "Ed25519"
+		jwk.X = base64.RawURLEncoding.EncodeToString(public)
+	}
+
+	return jwk
+}
+
+// parseSigningKey reads an RSA or Ed25519 key from a PEM block. Private keys
+// may be PKCS#8 or PKCS#1, public keys PKIX.
+func parseSigningKey(kid string, data []byte) (*SigningKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("signing key %s: no PEM data found", kid)
+	}
+
+	var parsed interface{}
+	var err error
+	switch block.Type {
+	case "PRIVATE KEY":
+		parsed, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "RSA PRIVATE KEY":
+		parsed, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "PUBLIC KEY":
+		parsed, err = x509.ParsePKIXPublicKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("signing key %s: unsupported PEM type %q", kid, block.Type)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("signing key %s: %v", kid, err)
+	}
+
+	key := &SigningKey{ID: kid}
+	switch parsed := parsed.(type) {
+	case *rsa.PrivateKey:
+		key.Method, key.Private, key.Public = jwt.SigningMethodRS256, parsed, &parsed.PublicKey
+	case *rsa.PublicKey:
+		key.Method, key.Public = jwt.SigningMethodRS256, parsed
+	case ed25519.PrivateKey:
+		key.Method, key.Private, key.Public = jwt.SigningMethodEdDSA, parsed, parsed.Public()
+	case ed25519.PublicKey:
+		key.Method, key.Public = jwt.SigningMethodEdDSA, parsed
+	default:
+		return nil, fmt.Errorf("signing key %s: unsupported key type %T", kid, parsed)
+	}
+
+	if public, ok := key.Public.(*rsa.PublicKey); ok && public.N.BitLen() < 2048 {
+		return nil, fmt.Errorf("signing key %s: RSA keys must be at least 2048 bits", kid)
+	}
+
+	return key, nil
+}
+
+// selectSigningKey picks the key named signingKID, or the private key with
+// the greatest kid when signingKID is empty.
+func selectSigningKey(keys map[string]*SigningKey, signingKID string) (*SigningKey, error) {
+	if signingKID != "" {
+		key, ok := keys[signingKID]
+		if !ok {
+			return nil, fmt.Errorf("signing key %s not found", signingKID)
+		}
+		if key.Private == nil {
+			return nil, fmt.Errorf("signing key %s has no private key", signingKID)
+		}
+		return key, nil
+	}
+
+	var active *SigningKey
+	for _, key := range keys {
+		if key.Private != nil && (active == nil || key.ID > active.ID) {
+			active = key
+		}
+	}
+	if active == nil {
+		return nil, errors.New("no private signing key found")
+	}
+
+	return active, nil
+}
@@ -0,0 +1,145 @@
+package auth
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeKey(t *testing.T, dir, kid, pemType string, der []byte) {
+	t.Helper()
+	data := pem.EncodeToMemory(&pem.Block{Type: pemType, Bytes: der})
+	require.NoError(t, os.WriteFile(filepath.Join(dir, kid+".pem"), data, 0o600))
+}
+
+func writeEd25519Key(t *testing.T, dir, kid string) ed25519.PublicKey {
+	t.Helper()
+	public, private, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	der, err := x509.MarshalPKCS8PrivateKey(private)
+	require.NoError(t, err)
+	writeKey(t, dir, kid, "PRIVATE KEY", der)
+	return public
+}
+
+func testClaims() *Claims {
+	return &Claims{
+		Username: "alice",
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Minute)),
+		},
+	}
+}
+
+func TestKeySetSignAndVerify(t *testing.T) {
+	dir := t.TempDir()
+	writeEd25519Key(t, dir, "2025-01")
+	writeEd25519Key(t, dir, "2025-02")
+
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	writeKey(t, dir, "2024-12", "RSA PRIVATE KEY", x509.MarshalPKCS1PrivateKey(rsaKey))
+
+	keys, err := LoadKeySet(dir, "")
+	require.NoError(t, err)
+
+	// The greatest kid signs by default
+	signed, err := keys.Sign(testClaims())
+	require.NoError(t, err)
+
+	claims := &Claims{}
+	token, err := jwt.ParseWithClaims(signed, claims, keys.Keyfunc, jwt.WithValidMethods(keys.Methods()))
+	require.NoError(t, err)
+	assert.Equal(t, "2025-02", token.Header["kid"])
+	assert.Equal(t, "EdDSA", token.Header["alg"])
+	assert.Equal(t, "alice", claims.Username)
+
+	// An explicitly chosen RSA key signs with RS256
+	keys, err = LoadKeySet(dir, "2024-12")
+	require.NoError(t, err)
+	signed, err = keys.Sign(testClaims())
+	require.NoError(t, err)
+	token, err = jwt.ParseWithClaims(signed, &Claims{}, keys.Keyfunc, jwt.WithValidMethods(keys.Methods()))
+	require.NoError(t, err)
+	assert.Equal(t, "RS256", token.Header["alg"])
+}
+
+func TestKeySetRejectsUnknownKeys(t *testing.T) {
+	dir := t.TempDir()
+	writeEd25519Key(t, dir, "current")
+	keys, err := LoadKeySet(dir, "")
+	require.NoError(t, err)
+
+	other, err := NewEphemeralKeySet()
+	require.NoError(t, err)
+	signed, err := other.Sign(testClaims())
+	require.NoError(t, err)
+
+	_, err = jwt.ParseWithClaims(signed, &Claims{}, keys.Keyfunc, jwt.WithValidMethods(keys.Methods()))
+	assert.Error(t, err)
+
+	// HMAC tokens are no longer accepted
+	hmac := jwt.NewWithClaims(jwt.SigningMethodHS256, testClaims())
+	hmac.Header["kid"] = "current"
+	signed, err = hmac.SignedString([]byte("secret"))
+	require.NoError(t, err)
+
+	_, err = jwt.ParseWithClaims(signed, &Claims{}, keys.Keyfunc, jwt.WithValidMethods(keys.Methods()))
+	assert.Error(t, err)
+}
+
+func TestKeySetRetiredKeys(t *testing.T) {
+	dir := t.TempDir()
+	writeEd25519Key(t, dir, "2025-02")
+	retired := writeEd25519Key(t, dir, "2025-01")
+
+	keys, err := LoadKeySet(dir, "")
+	require.NoError(t, err)
+	signedByRetired, err := (&KeySet{keys: keys.keys, active: keys.keys["2025-01"]}).Sign(testClaims())
+	require.NoError(t, err)
+
+	// Keep only the public half of the retired key
+	der, err := x509.MarshalPKIXPublicKey(retired)
+	require.NoError(t, err)
+	writeKey(t, dir, "2025-01", "PUBLIC KEY", der)
+	require.NoError(t, keys.Reload())
+
+	_, err = jwt.ParseWithClaims(signedByRetired, &Claims{}, keys.Keyfunc, jwt.WithValidMethods(keys.Methods()))
+	assert.NoError(t, err)
+
+	_, err = LoadKeySet(dir, "2025-01")
+	assert.EqualError(t, err, "signing key 2025-01 has no private key")
+
+	jwks := keys.JWKS()
+	require.Len(t, jwks.Keys, 2)
+	assert.Equal(t, "2025-01", jwks.Keys[0].Kid)
+	assert.Equal(t, "OKP", jwks.Keys[0].Kty)
+	assert.Equal(t, "Ed25519", jwks.Keys[0].Crv)
+	assert.NotEmpty(t, jwks.Keys[0].X)
+}
+
+func TestLoadKeySetErrors(t *testing.T) {
+	_, err := LoadKeySet(t.TempDir(), "")
+	assert.EqualError(t, err, "no private signing key found")
+
+	dir := t.TempDir()
+	writeEd25519Key(t, dir, "current")
+	_, err = LoadKeySet(dir, "missing")
+	assert.EqualError(t, err, "signing key missing not found")
+
+	small, err := rsa.GenerateKey(rand.Reader, 1024)
+	require.NoError(t, err)
+	writeKey(t, dir, "small", "RSA PRIVATE KEY", x509.MarshalPKCS1PrivateKey(small))
+	_, err = LoadKeySet(dir, "")
+	assert.EqualError(t, err, "signing key small: RSA keys must be at least 2048 bits")
+}
@@ -0,0 +1,15 @@
+package auth
+
+// PermissionChecker reports whether a role has been granted a permission.
+type PermissionChecker interface {
+	HasPermission(role, permission string) (bool, error)
+}
+
+// Allows reports whether claims carry permission: through the key for an API
+// key, otherwise through the role.
+func Allows(checker PermissionChecker, claims *Claims, permission string) (bool, error) {
+	if claims.IsAPIKey() {
+		return claims.KeyAllows(permission), nil
+	}
+	return checker.HasPermission(claims.Role, permission)
+}
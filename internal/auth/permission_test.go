@@ -0,0 +1,61 @@
+package auth
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+// rolePermissions grants each role the permissions it lists.
+type rolePermissions map[string][]string
+
+func (r rolePermissions) HasPermission(role, permission string) (bool, error) {
+	if role == "broken" {
+		return false, errors.New("database unavailable")
+	}
+	for _, p := range r[role] {
+		if p == permission {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func TestAllows(t *testing.T) {
+	checker := rolePermissions{"admin": {"reservation.book_for_others"}}
+
+	tests := []struct {
+		name        string
+		claims      Claims
+		expected    bool
+		expectError bool
+	}{
+		{name: "Role granted", claims: Claims{Role: "admin"}, expected: true},
+		{name: "Role not granted", claims: Claims{Role: "user"}},
+		{name: "Role check fails", claims: Claims{Role: "broken"}, expectError: true},
+		{
+			name:     "API key granted",
+			claims:   Claims{Role: "user", APIKeyID: uuid.New(), Permissions: []string{"reservation.book_for_others"}},
+			expected: true,
+		},
+		{
+			// The key's permissions apply, not those of the service account's role
+			name:   "API key not granted",
+			claims: Claims{Role: "admin", APIKeyID: uuid.New(), Permissions: []string{"reservation.view"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			allowed, err := Allows(checker, &tt.claims, "reservation.book_for_others")
+			if tt.expectError {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, allowed)
+		})
+	}
+}
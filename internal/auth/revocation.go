@@ -0,0 +1,8 @@
+package auth
+
+// RevocationChecker reports whether a validly signed access token has been
+// revoked before it expired, e.g. on logout or when an admin ends a user's
+// sessions.
+type RevocationChecker interface {
+	IsRevoked(claims *Claims) (bool, error)
+}
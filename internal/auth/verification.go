@@ -0,0 +1,7 @@
+package auth
+
+// VerificationChecker reports whether the user of a token has verified their
+// email address.
+type VerificationChecker interface {
+	IsEmailVerified(claims *Claims) (bool, error)
+}
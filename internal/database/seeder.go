@@ -20,10 +20,12 @@ func SeedUsers() error {
 		status   string
 	}{
 		{
+			// The bootstrap account runs the building; migration 000036
+			// promoted existing admins, which a fresh install has none of
 			username: "admin",
 			email:    "admin@example.com",
 			password: "admin123",
-			role:     "admin",
+			role:     "super_admin",
 			status:   "active",
 		},
 		{
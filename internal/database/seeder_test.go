@@ -0,0 +1,49 @@
+package database
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+)
+
+// migrateUp applies the up migrations in order, as the migrate tool does.
+func migrateUp(t *testing.T) {
+	t.Helper()
+
+	files, err := filepath.Glob(filepath.Join("..", "..", "db", "migrations", "*.up.sql"))
+	if err != nil {
+		t.Fatalf("could not list migrations: %v", err)
+	}
+	sort.Strings(files)
+
+	for _, file := range files {
+		migration, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatalf("could not read migration %s: %v", file, err)
+		}
+		if _, err := GetDB().Exec(string(migration)); err != nil {
+			t.Fatalf("could not apply migration %s: %v", file, err)
+		}
+	}
+}
+
+func TestSeedUsersSuperAdmin(t *testing.T) {
+	if err := InitDB(host, port, username, password, database); err != nil {
+		t.Fatalf("could not connect to database: %v", err)
+	}
+	migrateUp(t)
+
+	if err := SeedUsers(); err != nil {
+		t.Fatalf("expected SeedUsers() to succeed, got %v", err)
+	}
+
+	var superAdmins int
+	err := GetDB().QueryRow(`SELECT COUNT(*) FROM users WHERE role = 'super_admin'`).Scan(&superAdmins)
+	if err != nil {
+		t.Fatalf("could not count super admins: %v", err)
+	}
+	if superAdmins != 1 {
+		t.Fatalf("expected one super admin after seeding, got %d", superAdmins)
+	}
+}
@@ -0,0 +1,276 @@
+package handlers
+
+import (
+	"e-meetingproject/internal/models"
+	"e-meetingproject/internal/services"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type APIKeyHandler struct {
+	service *services.APIKeyService
+}
+
+func NewAPIKeyHandler(service *services.APIKeyService) *APIKeyHandler {
+	return &APIKeyHandler{
+		service: service,
+	}
+}
+
+// GetServiceAccounts godoc
+// @Summary List service accounts
+// @Description List the accounts integrations use through API keys
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} models.ServiceAccountListResponse
+// @Router /admin/service-accounts [get]
+func (h *APIKeyHandler) GetServiceAccounts(c *gin.Context) {
+	tenant, ok := currentTenant(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	response, err := h.service.GetServiceAccounts(tenant)
+	if err != nil {
+		respondAPIKeyError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// CreateServiceAccount godoc
+// @Summary Create service account
+// @Description Create an account without a password for an integration, such as a lobby kiosk
+// @Accept json
+// @Produce json
+// @Param request body models.CreateServiceAccountRequest true "Service account"
+// @Security BearerAuth
+// @Success 201 {object} models.ServiceAccount
+// @Failure 400 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /admin/service-accounts [post]
+func (h *APIKeyHandler) CreateServiceAccount(c *gin.Context) {
+	var req models.CreateServiceAccountRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	claims, ok := currentClaims(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	account, err := h.service.CreateServiceAccount(&req, claims.UserID, services.TenantOf(claims))
+	if err != nil {
+		respondAPIKeyError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, account)
+}
+
+// GetAPIKeys godoc
+// @Summary List API keys
+// @Description List the API keys of a service account with their usage, including revoked and expired keys
+// @Produce json
+// @Param id path string true "Service account ID"
+// @Security BearerAuth
+// @Success 200 {object} models.APIKeyListResponse
+// @Failure 404 {object} map[string]string
+// @Router /admin/service-accounts/{id}/api-keys [get]
+func (h *APIKeyHandler) GetAPIKeys(c *gin.Context) {
+	accountID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid service account ID format"})
+		return
+	}
+
+	tenant, ok := currentTenant(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	response, err := h.service.GetAPIKeys(accountID, tenant)
+	if err != nil {
+		respondAPIKeyError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// CreateAPIKey godoc
+// @Summary Create API key
+// @Description Create a key for a service account, limited to permissions the admin has and optionally to some rooms. The key is only returned once.
+// @Accept json
+// @Produce json
+// @Param id path string true "Service account ID"
+// @Param request body models.CreateAPIKeyRequest true "API key"
+// @Security BearerAuth
+// @Success 201 {object} models.APIKeySecretResponse
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /admin/service-accounts/{id}/api-keys [post]
+func (h *APIKeyHandler) CreateAPIKey(c *gin.Context) {
+	accountID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid service account ID format"})
+		return
+	}
+
+	var req models.CreateAPIKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	claims, ok := currentClaims(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	response, err := h.service.CreateAPIKey(accountID, &req, claims.UserID, claims.Role, services.TenantOf(claims))
+	if err != nil {
+		respondAPIKeyError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, response)
+}
+
+// RotateAPIKey godoc
+// @Summary Rotate API key
+// @Description Replace a key with a new one of the same scope. The old key is revoked, or keeps working for grace_minutes. The new key is only returned once.
+// @Accept json
+// @Produce json
+// @Param id path string true "API key ID"
+// @Param request body models.RotateAPIKeyRequest true "Rotation"
+// @Security BearerAuth
+// @Success 201 {object} models.APIKeySecretResponse
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /admin/api-keys/{id}/rotate [post]
+func (h *APIKeyHandler) RotateAPIKey(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid API key ID format"})
+		return
+	}
+
+	var req models.RotateAPIKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	claims, ok := currentClaims(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	response, err := h.service.RotateAPIKey(id, &req, claims.UserID, services.TenantOf(claims))
+	if err != nil {
+		respondAPIKeyError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, response)
+}
+
+// RevokeAPIKey godoc
+// @Summary Revoke API key
+// @Description Stop a key from working at once
+// @Produce json
+// @Param id path string true "API key ID"
+// @Security BearerAuth
+// @Success 200 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /admin/api-keys/{id} [delete]
+func (h *APIKeyHandler) RevokeAPIKey(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid API key ID format"})
+		return
+	}
+
+	claims, ok := currentClaims(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	if err := h.service.RevokeAPIKey(id, claims.UserID, services.TenantOf(claims)); err != nil {
+		respondAPIKeyError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "API key revoked successfully"})
+}
+
+// GetAPIKeyUsage godoc
+// @Summary API key usage
+// @Description Requests made with a key per day, with its total and last use
+// @Produce json
+// @Param id path string true "API key ID"
+// @Param days query int false "Number of days, 30 by default"
+// @Security BearerAuth
+// @Success 200 {object} models.APIKeyUsageResponse
+// @Failure 404 {object} map[string]string
+// @Router /admin/api-keys/{id}/usage [get]
+func (h *APIKeyHandler) GetAPIKeyUsage(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid API key ID format"})
+		return
+	}
+
+	var query models.APIKeyUsageQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	tenant, ok := currentTenant(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	response, err := h.service.GetAPIKeyUsage(id, &query, tenant)
+	if err != nil {
+		respondAPIKeyError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+func respondAPIKeyError(c *gin.Context, err error) {
+	switch {
+	case err.Error() == "service account not found", err.Error() == "api key not found":
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case err.Error() == "organisation not found", err.Error() == "room not found",
+		strings.HasPrefix(err.Error(), "invalid"):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case strings.HasPrefix(err.Error(), "cannot grant permission"):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case err.Error() == "username already exists", err.Error() == "api key is revoked or expired":
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		fmt.Printf("Error managing API keys: %v\n", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
+	}
+}
@@ -0,0 +1,63 @@
+package handlers
+
+import (
+	"e-meetingproject/internal/models"
+	"e-meetingproject/internal/services"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+type AuthAuditHandler struct {
+	service *services.AuthAuditService
+}
+
+func NewAuthAuditHandler(service *services.AuthAuditService) *AuthAuditHandler {
+	return &AuthAuditHandler{
+		service: service,
+	}
+}
+
+// GetEvents godoc
+// @Summary Auth audit log
+// @Description List logins, failed attempts, lockouts and unlocks, newest first
+// @Produce json
+// @Param user_id query string false "User ID"
+// @Param event query string false "Event"
+// @Param ip query string false "Client IP"
+// @Param from query string false "From date (YYYY-MM-DD)"
+// @Param to query string false "To date (YYYY-MM-DD)"
+// @Param page query int false "Page number"
+// @Param page_size query int false "Page size"
+// @Security BearerAuth
+// @Success 200 {object} models.AuthAuditResponse
+// @Failure 400 {object} map[string]string
+// @Router /admin/auth-audit [get]
+func (h *AuthAuditHandler) GetEvents(c *gin.Context) {
+	var query models.AuthAuditQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	tenant, ok := currentTenant(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+		return
+	}
+
+	response, err := h.service.GetEvents(&query, tenant)
+	if err != nil {
+		if strings.HasPrefix(err.Error(), "invalid") {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		fmt.Printf("Error listing auth events: %v\n", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
@@ -3,10 +3,12 @@ package handlers
 import (
 	"e-meetingproject/internal/models"
 	"e-meetingproject/internal/services"
+	"errors"
 	"fmt"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 )
 
 type AuthHandler struct {
@@ -57,15 +59,81 @@ func (h *AuthHandler) Register(c *gin.Context) {
 	c.JSON(http.StatusCreated, response)
 }
 
+// VerifyEmail godoc
+// @Summary Verify email address
+// @Description Activate a new account with the link sent to its email address
+// @Produce json
+// @Param token query string true "Verification token"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} map[string]string
+// @Router /verify-email [get]
+func (h *AuthHandler) VerifyEmail(c *gin.Context) {
+	token := c.Query("token")
+	if token == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "token is required"})
+		return
+	}
+
+	if err := h.authService.VerifyEmail(token); err != nil {
+		if err.Error() == "invalid or expired verification link" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		fmt.Printf("Error verifying email: %v\n", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "email address verified successfully"})
+}
+
+// ResendVerificationEmail godoc
+// @Summary Resend verification email
+// @Description Send a new verification link to the current user's email address
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 429 {object} map[string]string
+// @Router /account/verify-email/resend [post]
+func (h *AuthHandler) ResendVerificationEmail(c *gin.Context) {
+	claims, ok := currentClaims(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	if err := h.authService.ResendVerificationEmail(claims.UserID); err != nil {
+		switch err.Error() {
+		case "user not found":
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case "email address already verified":
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		case "verification email sent recently, try again later":
+			c.JSON(http.StatusTooManyRequests, gin.H{"error": err.Error()})
+		default:
+			fmt.Printf("Error resending verification email: %v\n", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "verification email sent"})
+}
+
 // Login godoc
 // @Summary User login
-// @Description Authenticate user and return JWT token
+// @Description Authenticate user and return JWT token, or a challenge when two-factor authentication is needed
 // @Accept json
 // @Produce json
 // @Param login body models.LoginRequest true "Login credentials"
 // @Success 200 {object} models.LoginResponse
+// @Success 202 {object} models.LoginChallengeResponse
 // @Failure 400 {object} map[string]string
 // @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 429 {object} map[string]string
 // @Router /login [post]
 func (h *AuthHandler) Login(c *gin.Context) {
 	var loginReq models.LoginRequest
@@ -86,16 +154,265 @@ func (h *AuthHandler) Login(c *gin.Context) {
 	}
 
 	// Attempt login
-	response, err := h.authService.Login(loginReq.Username, loginReq.Password)
+	response, challenge, err := h.authService.Login(loginReq.Username, loginReq.Password, c.ClientIP())
 	if err != nil {
 		fmt.Printf("Login failed: %v\n", err)
-		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		var tooMany *models.TooManyAttemptsError
+		switch {
+		case errors.As(err, &tooMany):
+			c.Header("Retry-After", tooMany.RetryAfterSeconds())
+			c.JSON(http.StatusTooManyRequests, gin.H{"error": err.Error()})
+		case err.Error() == "account is not active":
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		case err.Error() == "an account with this email already exists and must be linked explicitly":
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	// The password was right but a second factor is needed
+	if challenge != nil {
+		c.JSON(http.StatusAccepted, challenge)
 		return
 	}
 
 	c.JSON(http.StatusOK, response)
 }
 
+// VerifyTwoFactorLogin godoc
+// @Summary Two-factor login
+// @Description Answer a login challenge with a TOTP or recovery code and return JWT tokens
+// @Accept json
+// @Produce json
+// @Param request body models.TwoFactorLoginRequest true "Challenge and code"
+// @Success 200 {object} models.LoginResponse
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 429 {object} map[string]string
+// @Router /login/two-factor [post]
+func (h *AuthHandler) VerifyTwoFactorLogin(c *gin.Context) {
+	var req models.TwoFactorLoginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	response, err := h.authService.VerifyTwoFactorLogin(&req, c.ClientIP())
+	if err != nil {
+		var tooMany *models.TooManyAttemptsError
+		if errors.As(err, &tooMany) {
+			c.Header("Retry-After", tooMany.RetryAfterSeconds())
+			c.JSON(http.StatusTooManyRequests, gin.H{"error": err.Error()})
+			return
+		}
+
+		switch err.Error() {
+		case "invalid or expired challenge", "invalid two-factor code":
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		case "two-factor enrollment not started":
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		default:
+			fmt.Printf("Error verifying two-factor login: %v\n", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// BeginChallengeEnrollment godoc
+// @Summary Enroll in two-factor authentication at login
+// @Description Start TOTP enrollment for a login challenge whose role requires two-factor authentication
+// @Accept json
+// @Produce json
+// @Param request body models.TwoFactorEnrollChallengeRequest true "Challenge"
+// @Success 200 {object} models.TOTPEnrollmentResponse
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /login/two-factor/enroll [post]
+func (h *AuthHandler) BeginChallengeEnrollment(c *gin.Context) {
+	var req models.TwoFactorEnrollChallengeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	response, err := h.authService.BeginChallengeEnrollment(req.ChallengeToken)
+	if err != nil {
+		switch err.Error() {
+		case "invalid or expired challenge":
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		case "two-factor authentication is already enabled":
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			fmt.Printf("Error starting two-factor enrollment: %v\n", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// RefreshToken godoc
+// @Summary Refresh access token
+// @Description Exchange a refresh token for a new access token and refresh token
+// @Accept json
+// @Produce json
+// @Param request body models.RefreshTokenRequest true "Refresh token"
+// @Success 200 {object} models.LoginResponse
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Router /token/refresh [post]
+func (h *AuthHandler) RefreshToken(c *gin.Context) {
+	var req models.RefreshTokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	response, err := h.authService.RefreshToken(req.RefreshToken)
+	if err != nil {
+		switch err.Error() {
+		case "invalid refresh token", "refresh token has expired":
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		default:
+			fmt.Printf("Error refreshing token: %v\n", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// Logout godoc
+// @Summary Logout
+// @Description Revoke the current access token and, when given, the session of the refresh token
+// @Accept json
+// @Produce json
+// @Param request body models.LogoutRequest false "Refresh token to revoke"
+// @Success 200 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Router /logout [post]
+func (h *AuthHandler) Logout(c *gin.Context) {
+	claims, ok := currentClaims(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	// The body is optional
+	var req models.LogoutRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	if err := h.authService.Logout(claims, req.RefreshToken); err != nil {
+		fmt.Printf("Error logging out: %v\n", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "logged out successfully"})
+}
+
+// RevokeUserSessions godoc
+// @Summary Revoke user sessions
+// @Description Invalidate every access token and refresh token of a user
+// @Produce json
+// @Param id path string true "User ID"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /admin/users/{id}/sessions/revoke [post]
+func (h *AuthHandler) RevokeUserSessions(c *gin.Context) {
+	userID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user ID format"})
+		return
+	}
+
+	claims, ok := currentClaims(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	if err := h.authService.RevokeUserSessions(userID, claims.UserID, services.TenantOf(claims)); err != nil {
+		if err.Error() == "user not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		if err.Error() == "only super admins can manage super admins" {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
+		fmt.Printf("Error revoking sessions: %v\n", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "user sessions revoked successfully"})
+}
+
+// UnlockUser godoc
+// @Summary Unlock a user
+// @Description Clear the failed login attempts and lockout of a user
+// @Produce json
+// @Param id path string true "User ID"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /admin/users/{id}/unlock [post]
+func (h *AuthHandler) UnlockUser(c *gin.Context) {
+	userID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user ID format"})
+		return
+	}
+
+	claims, ok := currentClaims(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	if err := h.authService.UnlockUser(userID, claims.UserID, services.TenantOf(claims)); err != nil {
+		if err.Error() == "user not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		if err.Error() == "only super admins can manage super admins" {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
+		fmt.Printf("Error unlocking user: %v\n", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "user unlocked successfully"})
+}
+
+// GetJWKS godoc
+// @Summary JSON Web Key Set
+// @Description Public keys access tokens are signed with, identified by kid
+// @Produce json
+// @Success 200 {object} auth.JWKS
+// @Router /.well-known/jwks.json [get]
+func (h *AuthHandler) GetJWKS(c *gin.Context) {
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, h.authService.JWKS())
+}
+
 // RequestPasswordReset godoc
 // @Summary Request password reset
 // @Description Send a password reset link to the user's email
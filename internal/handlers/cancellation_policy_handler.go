@@ -0,0 +1,133 @@
+package handlers
+
+import (
+	"e-meetingproject/internal/models"
+	"e-meetingproject/internal/services"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type CancellationPolicyHandler struct {
+	service *services.CancellationPolicyService
+}
+
+func NewCancellationPolicyHandler(service *services.CancellationPolicyService) *CancellationPolicyHandler {
+	return &CancellationPolicyHandler{
+		service: service,
+	}
+}
+
+func (h *CancellationPolicyHandler) GetPolicies(c *gin.Context) {
+	tenant, ok := currentTenant(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+		return
+	}
+
+	response, err := h.service.GetPolicies(tenant)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+func (h *CancellationPolicyHandler) CreatePolicy(c *gin.Context) {
+	var req models.CancellationPolicyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	tenant, ok := currentTenant(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+		return
+	}
+
+	policy, err := h.service.CreatePolicy(&req, tenant)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, policy)
+}
+
+func (h *CancellationPolicyHandler) UpdatePolicy(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid cancellation policy ID format"})
+		return
+	}
+
+	var req models.CancellationPolicyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	tenant, ok := currentTenant(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+		return
+	}
+
+	policy, err := h.service.UpdatePolicy(id, &req, tenant)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, policy)
+}
+
+func (h *CancellationPolicyHandler) AssignRoomPolicy(c *gin.Context) {
+	roomID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid room ID format"})
+		return
+	}
+
+	var req models.AssignRoomPolicyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	tenant, ok := currentTenant(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+		return
+	}
+
+	if err := h.service.AssignRoomPolicy(roomID, &req, tenant); err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "cancellation policy assigned successfully"})
+}
+
+func (h *CancellationPolicyHandler) handleError(c *gin.Context, err error) {
+	if respondValidationError(c, err) {
+		return
+	}
+
+	switch {
+	case err.Error() == "cancellation policy not found", err.Error() == "room not found":
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case err.Error() == "organisation not found":
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case err.Error() == "cancellation policy name already exists":
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case strings.HasPrefix(err.Error(), "invalid"):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
@@ -0,0 +1,176 @@
+package handlers
+
+import (
+	"e-meetingproject/internal/models"
+	"e-meetingproject/internal/services"
+	"encoding/csv"
+	"fmt"
+	"html/template"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type CateringHandler struct {
+	service *services.CateringService
+}
+
+func NewCateringHandler(service *services.CateringService) *CateringHandler {
+	return &CateringHandler{
+		service: service,
+	}
+}
+
+// GetPrepSheet godoc
+// @Summary Daily catering prep sheet
+// @Description Confirmed snack orders of a day grouped by delivery time and room, with totals per snack
+// @Produce json
+// @Produce text/csv
+// @Produce text/html
+// @Param date query string true "Date (YYYY-MM-DD)"
+// @Param format query string false "json (default), csv or html"
+// @Security BearerAuth
+// @Success 200 {object} models.PrepSheet
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Router /catering/prep-sheet [get]
+func (h *CateringHandler) GetPrepSheet(c *gin.Context) {
+	var query models.PrepSheetQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	tenant, ok := currentTenant(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+		return
+	}
+
+	sheet, err := h.service.GetPrepSheet(query.Date, tenant)
+	if err != nil {
+		if strings.HasPrefix(err.Error(), "invalid") {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	switch query.Format {
+	case "csv":
+		c.Header("Content-Type", "text/csv")
+		c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=prep-sheet-%s.csv", sheet.Date))
+		c.Status(http.StatusOK)
+		if err := writePrepSheetCSV(csv.NewWriter(c.Writer), sheet); err != nil {
+			fmt.Printf("Error writing prep sheet: %v\n", err)
+		}
+	case "html":
+		c.Header("Content-Type", "text/html; charset=utf-8")
+		c.Status(http.StatusOK)
+		if err := writePrepSheetHTML(c.Writer, sheet); err != nil {
+			fmt.Printf("Error writing prep sheet: %v\n", err)
+		}
+	default:
+		c.JSON(http.StatusOK, sheet)
+	}
+}
+
+func (h *CateringHandler) UpdatePrepStatus(c *gin.Context) {
+	orderID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid order ID format"})
+		return
+	}
+
+	claims, ok := currentClaims(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+		return
+	}
+
+	var req models.UpdatePrepStatusRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	response, err := h.service.UpdatePrepStatus(orderID, claims.UserID, &req, services.TenantOf(claims))
+	if err != nil {
+		switch {
+		case err.Error() == "snack order not found":
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case strings.HasPrefix(err.Error(), "invalid status change"):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+func writePrepSheetCSV(w *csv.Writer, sheet *models.PrepSheet) error {
+	records := [][]string{{
+		"date", "delivery_time", "room", "reservation_id", "order_id",
+		"category", "snack", "quantity", "visitors", "status",
+	}}
+	for _, slot := range sheet.Slots {
+		for _, order := range slot.Orders {
+			records = append(records, []string{
+				sheet.Date,
+				slot.DeliveryTime.Format("15:04"),
+				slot.RoomName,
+				order.ReservationID.String(),
+				order.OrderID.String(),
+				order.Category,
+				order.SnackName,
+				strconv.Itoa(order.Quantity),
+				strconv.Itoa(order.VisitorCount),
+				string(order.Status),
+			})
+		}
+	}
+
+	return w.WriteAll(records)
+}
+
+var prepSheetTemplate = template.Must(template.New("prep-sheet").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Prep sheet {{.Date}}</title>
+<style>
+body { font-family: sans-serif; }
+table { border-collapse: collapse; margin-bottom: 1.5em; }
+th, td { border: 1px solid #999; padding: 4px 8px; text-align: left; }
+@media print { .slot { page-break-inside: avoid; } }
+</style>
+</head>
+<body>
+<h1>Catering prep sheet {{.Date}}</h1>
+<h2>Totals</h2>
+<table>
+<tr><th>Category</th><th>Snack</th><th>Quantity</th></tr>
+{{range .SnackTotals}}<tr><td>{{.Category}}</td><td>{{.SnackName}}</td><td>{{.Quantity}}</td></tr>
+{{end}}</table>
+{{range .Slots}}<div class="slot">
+<h2>{{.DeliveryTime.Format "15:04"}} &middot; {{.RoomName}}</h2>
+<table>
+<tr><th>Snack</th><th>Quantity</th><th>Visitors</th><th>Status</th></tr>
+{{range .Orders}}<tr><td>{{.SnackName}}</td><td>{{.Quantity}}</td><td>{{.VisitorCount}}</td><td>{{.Status}}</td></tr>
+{{end}}</table>
+</div>
+{{else}}<p>No confirmed snack orders.</p>
+{{end}}</body>
+</html>
+`))
+
+func writePrepSheetHTML(w io.Writer, sheet *models.PrepSheet) error {
+	return prepSheetTemplate.Execute(w, sheet)
+}
@@ -0,0 +1,28 @@
+package handlers
+
+import (
+	"e-meetingproject/internal/auth"
+	"e-meetingproject/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// currentClaims returns the claims stored in the context by JWTAuthMiddleware.
+func currentClaims(c *gin.Context) (*auth.Claims, bool) {
+	claims, exists := c.Get("claims")
+	if !exists {
+		return nil, false
+	}
+
+	userClaims, ok := claims.(*auth.Claims)
+	return userClaims, ok
+}
+
+// currentTenant returns the organisation the authenticated user works for.
+func currentTenant(c *gin.Context) (services.Tenant, bool) {
+	claims, ok := currentClaims(c)
+	if !ok {
+		return services.Tenant{}, false
+	}
+	return services.TenantOf(claims), true
+}
@@ -0,0 +1,190 @@
+package handlers
+
+import (
+	"e-meetingproject/internal/models"
+	"e-meetingproject/internal/services"
+	"encoding/csv"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type CostCenterHandler struct {
+	service *services.CostCenterService
+}
+
+func NewCostCenterHandler(service *services.CostCenterService) *CostCenterHandler {
+	return &CostCenterHandler{
+		service: service,
+	}
+}
+
+func (h *CostCenterHandler) GetCostCenters(c *gin.Context) {
+	response, err := h.service.GetCostCenters()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+func (h *CostCenterHandler) CreateCostCenter(c *gin.Context) {
+	var req models.CreateCostCenterRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	costCenter, err := h.service.CreateCostCenter(&req)
+	if err != nil {
+		if err.Error() == "cost center code already exists" {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, costCenter)
+}
+
+func (h *CostCenterHandler) UpdateCostCenter(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid cost center ID format"})
+		return
+	}
+
+	var req models.UpdateCostCenterRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	costCenter, err := h.service.UpdateCostCenter(id, &req)
+	if err != nil {
+		if err.Error() == "cost center not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, costCenter)
+}
+
+func (h *CostCenterHandler) AssignUserCostCenter(c *gin.Context) {
+	userID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user ID format"})
+		return
+	}
+
+	var req models.AssignUserCostCenterRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	tenant, ok := currentTenant(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+		return
+	}
+
+	if err := h.service.AssignUserCostCenter(userID, &req, tenant); err != nil {
+		switch err.Error() {
+		case "user not found":
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case "only super admins can manage super admins":
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		case "cost center not found or inactive":
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "cost center assigned successfully"})
+}
+
+// GetChargebackStatement godoc
+// @Summary Monthly chargeback statement
+// @Description Totals completed reservations and snack orders in the organisation's rooms per cost center for a month
+// @Produce json
+// @Produce text/csv
+// @Param month query string true "Month (YYYY-MM)"
+// @Param format query string false "json (default) or csv"
+// @Security BearerAuth
+// @Success 200 {object} models.ChargebackStatement
+// @Failure 400 {object} map[string]string
+// @Router /admin/chargeback/statements [get]
+func (h *CostCenterHandler) GetChargebackStatement(c *gin.Context) {
+	var query models.ChargebackStatementQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	tenant, ok := currentTenant(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+		return
+	}
+
+	statement, err := h.service.GenerateStatement(query.Month, tenant)
+	if err != nil {
+		if strings.HasPrefix(err.Error(), "invalid") {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if query.Format != "csv" {
+		c.JSON(http.StatusOK, statement)
+		return
+	}
+
+	c.Header("Content-Type", "text/csv")
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=chargeback-%s.csv", statement.Month))
+	c.Status(http.StatusOK)
+	if err := writeChargebackCSV(csv.NewWriter(c.Writer), statement); err != nil {
+		fmt.Printf("Error writing chargeback statement: %v\n", err)
+	}
+}
+
+func writeChargebackCSV(w *csv.Writer, statement *models.ChargebackStatement) error {
+	formatAmount := func(amount float64) string {
+		return strconv.FormatFloat(amount, 'f', 2, 64)
+	}
+
+	records := [][]string{{
+		"month", "cost_center_code", "cost_center_name", "reservations", "snack_orders",
+		"snack_items", "room_subtotal", "snack_subtotal", "adjustments", "total",
+	}}
+	for _, line := range statement.Lines {
+		records = append(records, []string{
+			statement.Month,
+			line.CostCenterCode,
+			line.CostCenterName,
+			strconv.Itoa(line.Reservations),
+			strconv.Itoa(line.SnackOrders),
+			strconv.Itoa(line.SnackItems),
+			formatAmount(line.RoomSubtotal),
+			formatAmount(line.SnackSubtotal),
+			formatAmount(line.Adjustments),
+			formatAmount(line.Total),
+		})
+	}
+
+	return w.WriteAll(records)
+}
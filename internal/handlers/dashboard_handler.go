@@ -37,7 +37,13 @@ func (h *DashboardHandler) GetDashboardStats(c *gin.Context) {
 		return
 	}
 
-	stats, err := h.dashboardService.GetDashboardStats(&query)
+	tenant, ok := currentTenant(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+		return
+	}
+
+	stats, err := h.dashboardService.GetDashboardStats(&query, tenant)
 	if err != nil {
 		if err.Error() == "invalid start_date format" || err.Error() == "invalid end_date format" {
 			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
@@ -0,0 +1,24 @@
+package handlers
+
+import (
+	"e-meetingproject/internal/models"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// respondValidationError writes a 400 response listing the rejected fields
+// when err is a *models.ValidationError, and reports whether it did.
+func respondValidationError(c *gin.Context, err error) bool {
+	var validationErr *models.ValidationError
+	if !errors.As(err, &validationErr) {
+		return false
+	}
+
+	c.JSON(http.StatusBadRequest, gin.H{
+		"error":  validationErr.Error(),
+		"fields": validationErr.Fields,
+	})
+	return true
+}
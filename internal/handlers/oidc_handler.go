@@ -0,0 +1,93 @@
+package handlers
+
+import (
+	"e-meetingproject/internal/services"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+type OIDCHandler struct {
+	service *services.OIDCService
+}
+
+func NewOIDCHandler(service *services.OIDCService) *OIDCHandler {
+	return &OIDCHandler{
+		service: service,
+	}
+}
+
+// Login godoc
+// @Summary Single sign-on login
+// @Description Redirect to the company identity provider to sign in
+// @Success 302
+// @Failure 502 {object} map[string]string
+// @Router /oidc/login [get]
+func (h *OIDCHandler) Login(c *gin.Context) {
+	authURL, err := h.service.BeginLogin()
+	if err != nil {
+		fmt.Printf("Error starting SSO login: %v\n", err)
+		c.JSON(http.StatusBadGateway, gin.H{"error": "identity provider unavailable"})
+		return
+	}
+
+	c.Redirect(http.StatusFound, authURL)
+}
+
+// Callback godoc
+// @Summary Single sign-on callback
+// @Description Complete the sign-in at the identity provider and return JWT tokens
+// @Produce json
+// @Param code query string true "Authorization code"
+// @Param state query string true "State from the login redirect"
+// @Success 200 {object} models.LoginResponse
+// @Success 202 {object} models.LoginChallengeResponse
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Router /oidc/callback [get]
+func (h *OIDCHandler) Callback(c *gin.Context) {
+	// The provider reports a cancelled or refused sign-in as an error
+	if providerErr := c.Query("error"); providerErr != "" {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error":             "sign-in failed at identity provider",
+			"provider_error":    providerErr,
+			"error_description": c.Query("error_description"),
+		})
+		return
+	}
+
+	code, state := c.Query("code"), c.Query("state")
+	if code == "" || state == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "code and state are required"})
+		return
+	}
+
+	response, challenge, err := h.service.CompleteLogin(state, code, c.ClientIP())
+	if err != nil {
+		switch {
+		case err.Error() == "invalid login state", err.Error() == "login request has expired",
+			strings.HasPrefix(err.Error(), "invalid ID token"), strings.HasPrefix(err.Error(), "token exchange failed"):
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		case err.Error() == "not a member of an allowed group", err.Error() == "email address is not verified",
+			err.Error() == "identity provider did not return an email", err.Error() == "account is not active":
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		case err.Error() == "an account with this email already exists and must be linked explicitly":
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			fmt.Printf("Error completing SSO login: %v\n", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
+		}
+		return
+	}
+
+	// The tokens are issued once the challenge is answered at /login/two-factor
+	if challenge != nil {
+		c.JSON(http.StatusAccepted, challenge)
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
@@ -0,0 +1,80 @@
+package handlers
+
+import (
+	"e-meetingproject/internal/models"
+	"e-meetingproject/internal/services"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type OrganisationHandler struct {
+	service *services.OrganisationService
+}
+
+func NewOrganisationHandler(service *services.OrganisationService) *OrganisationHandler {
+	return &OrganisationHandler{
+		service: service,
+	}
+}
+
+func (h *OrganisationHandler) GetOrganisations(c *gin.Context) {
+	response, err := h.service.GetOrganisations()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+func (h *OrganisationHandler) CreateOrganisation(c *gin.Context) {
+	var req models.CreateOrganisationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	organisation, err := h.service.CreateOrganisation(&req)
+	if err != nil {
+		switch {
+		case err.Error() == "organisation slug already exists":
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		case strings.HasPrefix(err.Error(), "invalid"):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, organisation)
+}
+
+func (h *OrganisationHandler) UpdateOrganisation(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid organisation ID format"})
+		return
+	}
+
+	var req models.UpdateOrganisationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	organisation, err := h.service.UpdateOrganisation(id, &req)
+	if err != nil {
+		if err.Error() == "organisation not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, organisation)
+}
@@ -0,0 +1,58 @@
+package handlers
+
+import (
+	"e-meetingproject/internal/services"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+type PaymentHandler struct {
+	service *services.PaymentService
+}
+
+func NewPaymentHandler(service *services.PaymentService) *PaymentHandler {
+	return &PaymentHandler{
+		service: service,
+	}
+}
+
+// Webhook godoc
+// @Summary Payment provider webhook
+// @Description Receive a signed payment event and update the matching payment
+// @Accept json
+// @Produce json
+// @Success 200 {object} models.PaymentWebhookResponse
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /payments/webhook [post]
+func (h *PaymentHandler) Webhook(c *gin.Context) {
+	// The signature covers the raw body, so it must be read before any binding
+	payload, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "unable to read request body"})
+		return
+	}
+
+	response, err := h.service.HandleWebhook(payload, c.Request.Header)
+	if err != nil {
+		switch {
+		case err.Error() == "invalid webhook signature":
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		case strings.HasPrefix(err.Error(), "invalid webhook payload"):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case err.Error() == "payment not found":
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		default:
+			fmt.Printf("Error processing payment webhook: %v\n", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
@@ -0,0 +1,78 @@
+package handlers
+
+import (
+	"e-meetingproject/internal/models"
+	"e-meetingproject/internal/services"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+type PermissionHandler struct {
+	service *services.PermissionService
+}
+
+func NewPermissionHandler(service *services.PermissionService) *PermissionHandler {
+	return &PermissionHandler{
+		service: service,
+	}
+}
+
+// GetRoles godoc
+// @Summary Role permissions
+// @Description List every role with its permissions, and the permissions that can be granted
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} models.RolePermissionsResponse
+// @Router /admin/roles [get]
+func (h *PermissionHandler) GetRoles(c *gin.Context) {
+	response, err := h.service.GetRoles()
+	if err != nil {
+		fmt.Printf("Error listing role permissions: %v\n", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// SetRolePermissions godoc
+// @Summary Set role permissions
+// @Description Replace the permissions granted to a role
+// @Accept json
+// @Produce json
+// @Param role path string true "Role"
+// @Param request body models.UpdateRolePermissionsRequest true "Permissions"
+// @Security BearerAuth
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} map[string]string
+// @Router /admin/roles/{role}/permissions [put]
+func (h *PermissionHandler) SetRolePermissions(c *gin.Context) {
+	claims, ok := currentClaims(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	var req models.UpdateRolePermissionsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.service.SetRolePermissions(c.Param("role"), &req, claims.UserID); err != nil {
+		switch {
+		case err.Error() == "invalid role", err.Error() == "super_admin permissions cannot be changed",
+			strings.HasPrefix(err.Error(), "unknown permission"):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		default:
+			fmt.Printf("Error updating role permissions: %v\n", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "role permissions updated successfully"})
+}
@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"e-meetingproject/internal/auth"
 	"e-meetingproject/internal/models"
 	"e-meetingproject/internal/services"
 	"fmt"
@@ -12,12 +13,14 @@ import (
 )
 
 type ReservationHandler struct {
-	service *services.ReservationService
+	service     *services.ReservationService
+	permissions auth.PermissionChecker
 }
 
-func NewReservationHandler(service *services.ReservationService) *ReservationHandler {
+func NewReservationHandler(service *services.ReservationService, permissions auth.PermissionChecker) *ReservationHandler {
 	return &ReservationHandler{
-		service: service,
+		service:     service,
+		permissions: permissions,
 	}
 }
 
@@ -42,7 +45,13 @@ func (h *ReservationHandler) GetReservationHistory(c *gin.Context) {
 		return
 	}
 
-	response, err := h.service.GetReservationHistory(&query, userUUID)
+	tenant, ok := currentTenant(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+		return
+	}
+
+	response, err := h.service.GetReservationHistory(&query, userUUID, tenant)
 	if err != nil {
 		if strings.Contains(err.Error(), "invalid") {
 			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
@@ -62,12 +71,22 @@ func (h *ReservationHandler) UpdateReservationStatus(c *gin.Context) {
 		return
 	}
 
-	updatedReservation, err := h.service.UpdateReservationStatus(&req)
+	tenant, ok := currentTenant(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+		return
+	}
+
+	updatedReservation, err := h.service.UpdateReservationStatus(&req, tenant)
 	if err != nil {
 		if err.Error() == "reservation not found" {
 			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
 			return
 		}
+		if strings.HasPrefix(err.Error(), "invalid status transition") {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
 		if strings.Contains(err.Error(), "invalid status") {
 			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 			return
@@ -92,9 +111,18 @@ func (h *ReservationHandler) CalculateReservationCost(c *gin.Context) {
 		return
 	}
 
+	tenant, ok := currentTenant(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+		return
+	}
+
 	// Calculate costs
-	response, err := h.service.CalculateReservationCost(&req)
+	response, err := h.service.CalculateReservationCost(&req, tenant)
 	if err != nil {
+		if respondValidationError(c, err) {
+			return
+		}
 		if err.Error() == "room not found or inactive" {
 			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
 			return
@@ -114,8 +142,14 @@ func (h *ReservationHandler) GetReservationByID(c *gin.Context) {
 		return
 	}
 
+	tenant, ok := currentTenant(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+		return
+	}
+
 	// Get reservation details from service
-	reservation, err := h.service.GetReservationByID(reservationID)
+	reservation, err := h.service.GetReservationByID(reservationID, tenant)
 	if err != nil {
 		if err.Error() == "reservation not found" {
 			c.JSON(http.StatusNotFound, gin.H{"error": "reservation not found"})
@@ -141,13 +175,53 @@ func (h *ReservationHandler) CreateReservation(c *gin.Context) {
 		return
 	}
 
+	claims, ok := currentClaims(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+		return
+	}
+
+	// The caller books, and pays, for themselves unless allowed to book for others
+	if req.UserID == uuid.Nil {
+		req.UserID = claims.UserID
+	} else if req.UserID != claims.UserID {
+		allowed, err := auth.Allows(h.permissions, claims, models.PermissionReservationBookForOthers)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error checking permissions"})
+			return
+		}
+		if !allowed {
+			c.JSON(http.StatusForbidden, gin.H{"error": "forbidden: missing permission " + models.PermissionReservationBookForOthers})
+			return
+		}
+	}
+
 	// Create reservation
-	response, err := h.service.CreateReservation(&req)
+	response, err := h.service.CreateReservation(&req, services.TenantOf(claims))
 	if err != nil {
+		if respondValidationError(c, err) {
+			return
+		}
 		if err.Error() == "room not found or inactive" {
 			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
 			return
 		}
+		if err.Error() == "cost center not found or inactive" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if err.Error() == "user not found" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if err.Error() == "wallet not found" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if err.Error() == "insufficient wallet funds" {
+			c.JSON(http.StatusPaymentRequired, gin.H{"error": err.Error()})
+			return
+		}
 		if err.Error() == "visitor count exceeds room capacity" {
 			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 			return
@@ -162,3 +236,68 @@ func (h *ReservationHandler) CreateReservation(c *gin.Context) {
 
 	c.JSON(http.StatusCreated, response)
 }
+
+func (h *ReservationHandler) GetCancellationQuote(c *gin.Context) {
+	reservationID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid reservation ID format"})
+		return
+	}
+
+	claims, ok := currentClaims(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+		return
+	}
+
+	quote, err := h.service.GetCancellationQuote(reservationID, claims.UserID)
+	if err != nil {
+		switch {
+		case err.Error() == "reservation not found":
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case strings.HasPrefix(err.Error(), "reservation cannot be cancelled"):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, quote)
+}
+
+func (h *ReservationHandler) CancelReservation(c *gin.Context) {
+	reservationID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid reservation ID format"})
+		return
+	}
+
+	claims, ok := currentClaims(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+		return
+	}
+
+	var req models.CancelReservationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	response, err := h.service.CancelReservation(reservationID, claims.UserID, &req)
+	if err != nil {
+		switch {
+		case err.Error() == "reservation not found":
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case err.Error() == "cancellation fee has changed",
+			strings.HasPrefix(err.Error(), "reservation cannot be cancelled"):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
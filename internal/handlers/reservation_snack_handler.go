@@ -0,0 +1,129 @@
+package handlers
+
+import (
+	"e-meetingproject/internal/models"
+	"e-meetingproject/internal/services"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type ReservationSnackHandler struct {
+	service *services.ReservationSnackService
+}
+
+func NewReservationSnackHandler(service *services.ReservationSnackService) *ReservationSnackHandler {
+	return &ReservationSnackHandler{
+		service: service,
+	}
+}
+
+func (h *ReservationSnackHandler) AddSnackLine(c *gin.Context) {
+	reservationID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid reservation ID format"})
+		return
+	}
+
+	claims, ok := currentClaims(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+		return
+	}
+
+	var req models.SnackOrderItem
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	response, err := h.service.AddSnackLine(reservationID, claims.UserID, &req)
+	if err != nil {
+		respondSnackOrderError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, response)
+}
+
+func (h *ReservationSnackHandler) UpdateSnackLine(c *gin.Context) {
+	reservationID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid reservation ID format"})
+		return
+	}
+
+	lineID, err := uuid.Parse(c.Param("line_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid snack line ID format"})
+		return
+	}
+
+	claims, ok := currentClaims(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+		return
+	}
+
+	var req models.UpdateReservationSnackRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	response, err := h.service.UpdateSnackLine(reservationID, lineID, claims.UserID, &req)
+	if err != nil {
+		respondSnackOrderError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+func (h *ReservationSnackHandler) RemoveSnackLine(c *gin.Context) {
+	reservationID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid reservation ID format"})
+		return
+	}
+
+	lineID, err := uuid.Parse(c.Param("line_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid snack line ID format"})
+		return
+	}
+
+	claims, ok := currentClaims(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+		return
+	}
+
+	response, err := h.service.RemoveSnackLine(reservationID, lineID, claims.UserID)
+	if err != nil {
+		respondSnackOrderError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+func respondSnackOrderError(c *gin.Context, err error) {
+	if respondValidationError(c, err) {
+		return
+	}
+
+	switch {
+	case err.Error() == "reservation not found", err.Error() == "snack line not found":
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case err.Error() == "insufficient wallet funds":
+		c.JSON(http.StatusPaymentRequired, gin.H{"error": err.Error()})
+	case strings.HasPrefix(err.Error(), "reservation cannot be edited"),
+		strings.HasPrefix(err.Error(), "snack line cannot be edited"):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
@@ -27,8 +27,18 @@ func (h *RoomHandler) CreateRoom(c *gin.Context) {
 		return
 	}
 
-	room, err := h.service.CreateRoom(&req)
+	tenant, ok := currentTenant(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+		return
+	}
+
+	room, err := h.service.CreateRoom(&req, tenant)
 	if err != nil {
+		if err.Error() == "organisation not found" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -49,7 +59,13 @@ func (h *RoomHandler) UpdateRoom(c *gin.Context) {
 		return
 	}
 
-	room, err := h.service.UpdateRoom(id, &req)
+	tenant, ok := currentTenant(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+		return
+	}
+
+	room, err := h.service.UpdateRoom(id, &req, tenant)
 	if err != nil {
 		if err.Error() == "room not found" {
 			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
@@ -69,7 +85,13 @@ func (h *RoomHandler) DeleteRoom(c *gin.Context) {
 		return
 	}
 
-	err = h.service.DeleteRoom(id)
+	tenant, ok := currentTenant(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+		return
+	}
+
+	err = h.service.DeleteRoom(id, tenant)
 	if err != nil {
 		switch err.Error() {
 		case "room not found":
@@ -102,8 +124,14 @@ func (h *RoomHandler) GetRooms(c *gin.Context) {
 		}
 	}
 
+	tenant, ok := currentTenant(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+		return
+	}
+
 	// Get rooms with filter and pagination
-	response, err := h.service.GetRooms(&filter, &pagination)
+	response, err := h.service.GetRooms(&filter, &pagination, tenant)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -133,8 +161,14 @@ func (h *RoomHandler) GetRoomSchedule(c *gin.Context) {
 		return
 	}
 
+	tenant, ok := currentTenant(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+		return
+	}
+
 	// Get room schedule from service
-	response, err := h.service.GetRoomSchedule(roomID, &query)
+	response, err := h.service.GetRoomSchedule(roomID, &query, tenant)
 	if err != nil {
 		if err.Error() == "room not found" {
 			c.JSON(http.StatusNotFound, gin.H{"error": "room not found"})
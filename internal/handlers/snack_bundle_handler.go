@@ -0,0 +1,127 @@
+package handlers
+
+import (
+	"e-meetingproject/internal/models"
+	"e-meetingproject/internal/services"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type SnackBundleHandler struct {
+	service *services.SnackBundleService
+}
+
+func NewSnackBundleHandler(service *services.SnackBundleService) *SnackBundleHandler {
+	return &SnackBundleHandler{
+		service: service,
+	}
+}
+
+func (h *SnackBundleHandler) GetBundles(c *gin.Context) {
+	tenant, ok := currentTenant(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+		return
+	}
+
+	response, err := h.service.GetBundles(tenant)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+func (h *SnackBundleHandler) CreateBundle(c *gin.Context) {
+	var req models.CreateSnackBundleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	tenant, ok := currentTenant(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+		return
+	}
+
+	bundle, err := h.service.CreateBundle(&req, tenant)
+	if err != nil {
+		if respondValidationError(c, err) {
+			return
+		}
+		if err.Error() == "organisation not found" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, bundle)
+}
+
+func (h *SnackBundleHandler) UpdateBundle(c *gin.Context) {
+	// Parse bundle ID from URL
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid bundle ID format"})
+		return
+	}
+
+	var req models.UpdateSnackBundleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	tenant, ok := currentTenant(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+		return
+	}
+
+	bundle, err := h.service.UpdateBundle(id, &req, tenant)
+	if err != nil {
+		if respondValidationError(c, err) {
+			return
+		}
+		if err.Error() == "bundle not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, bundle)
+}
+
+func (h *SnackBundleHandler) RetireBundle(c *gin.Context) {
+	// Parse bundle ID from URL
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid bundle ID format"})
+		return
+	}
+
+	tenant, ok := currentTenant(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+		return
+	}
+
+	if err := h.service.RetireBundle(id, tenant); err != nil {
+		if err.Error() == "bundle not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "bundle retired successfully"})
+}
@@ -4,8 +4,10 @@ import (
 	"e-meetingproject/internal/models"
 	"e-meetingproject/internal/services"
 	"net/http"
+	"strings"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 )
 
 type SnackHandler struct {
@@ -19,9 +21,37 @@ func NewSnackHandler(service *services.SnackService) *SnackHandler {
 }
 
 func (h *SnackHandler) GetSnacks(c *gin.Context) {
+	// Parse pagination query parameters
+	var pagination models.PaginationQuery
+	if err := c.ShouldBindQuery(&pagination); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid pagination parameters"})
+		return
+	}
+
+	// Parse filter query parameters
+	var filter models.SnackFilter
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid filter parameters"})
+		return
+	}
+	if filter.MinPrice != nil && filter.MaxPrice != nil && *filter.MinPrice > *filter.MaxPrice {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "min_price must not exceed max_price"})
+		return
+	}
+
+	tenant, ok := currentTenant(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+		return
+	}
+
 	// Get snacks from service
-	response, err := h.service.GetSnacks()
+	response, err := h.service.GetSnacks(&filter, &pagination, tenant)
 	if err != nil {
+		if strings.HasPrefix(err.Error(), "invalid") {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -42,12 +72,83 @@ func (h *SnackHandler) CreateSnack(c *gin.Context) {
 		return
 	}
 
+	tenant, ok := currentTenant(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+		return
+	}
+
 	// Create snack
-	response, err := h.service.CreateSnack(&req)
+	response, err := h.service.CreateSnack(&req, tenant)
 	if err != nil {
+		if err.Error() == "organisation not found" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
 	c.JSON(http.StatusCreated, response)
 }
+
+func (h *SnackHandler) UpdateSnack(c *gin.Context) {
+	// Parse snack ID from URL
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid snack ID format"})
+		return
+	}
+
+	var req models.UpdateSnackRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	tenant, ok := currentTenant(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+		return
+	}
+
+	// Update snack
+	snack, err := h.service.UpdateSnack(id, &req, tenant)
+	if err != nil {
+		if err.Error() == "snack not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, snack)
+}
+
+func (h *SnackHandler) RetireSnack(c *gin.Context) {
+	// Parse snack ID from URL
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid snack ID format"})
+		return
+	}
+
+	tenant, ok := currentTenant(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+		return
+	}
+
+	// Retire snack
+	if err := h.service.RetireSnack(id, tenant); err != nil {
+		if err.Error() == "snack not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "snack retired successfully"})
+}
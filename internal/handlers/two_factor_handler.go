@@ -0,0 +1,187 @@
+package handlers
+
+import (
+	"e-meetingproject/internal/models"
+	"e-meetingproject/internal/services"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type TwoFactorHandler struct {
+	service *services.TwoFactorService
+}
+
+func NewTwoFactorHandler(service *services.TwoFactorService) *TwoFactorHandler {
+	return &TwoFactorHandler{
+		service: service,
+	}
+}
+
+func (h *TwoFactorHandler) GetStatus(c *gin.Context) {
+	claims, ok := currentClaims(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+		return
+	}
+
+	response, err := h.service.GetStatus(claims.UserID, claims.Role)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+func (h *TwoFactorHandler) BeginEnrollment(c *gin.Context) {
+	claims, ok := currentClaims(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+		return
+	}
+
+	response, err := h.service.BeginEnrollment(claims.UserID, claims.Username)
+	if err != nil {
+		respondTwoFactorError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+func (h *TwoFactorHandler) ConfirmEnrollment(c *gin.Context) {
+	claims, ok := currentClaims(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+		return
+	}
+
+	var req models.TwoFactorCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	response, err := h.service.ConfirmEnrollment(claims.UserID, req.Code)
+	if err != nil {
+		respondTwoFactorError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+func (h *TwoFactorHandler) Disable(c *gin.Context) {
+	claims, ok := currentClaims(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+		return
+	}
+
+	var req models.TwoFactorCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.service.Disable(claims.UserID, claims.Username, claims.Role, req.Code, c.ClientIP()); err != nil {
+		respondTwoFactorError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "two-factor authentication disabled"})
+}
+
+func (h *TwoFactorHandler) RegenerateRecoveryCodes(c *gin.Context) {
+	claims, ok := currentClaims(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+		return
+	}
+
+	var req models.TwoFactorCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	response, err := h.service.RegenerateRecoveryCodes(claims.UserID, claims.Username, req.Code, c.ClientIP())
+	if err != nil {
+		respondTwoFactorError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+func (h *TwoFactorHandler) ResetUser(c *gin.Context) {
+	userID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user ID format"})
+		return
+	}
+
+	tenant, ok := currentTenant(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+		return
+	}
+
+	if err := h.service.ResetUser(userID, tenant); err != nil {
+		respondTwoFactorError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "two-factor authentication reset successfully"})
+}
+
+func (h *TwoFactorHandler) SetRoleRequirement(c *gin.Context) {
+	role := c.Param("role")
+	if !models.IsValidRole(role) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid role"})
+		return
+	}
+
+	var req models.RoleTwoFactorPolicyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.service.SetRoleRequirement(role, *req.Required); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"role": role, "require_two_factor": *req.Required})
+}
+
+// respondTwoFactorError maps two-factor errors to status codes.
+func respondTwoFactorError(c *gin.Context, err error) {
+	var tooMany *models.TooManyAttemptsError
+	if errors.As(err, &tooMany) {
+		c.Header("Retry-After", tooMany.RetryAfterSeconds())
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": err.Error()})
+		return
+	}
+
+	switch err.Error() {
+	case "invalid two-factor code":
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+	case "two-factor enrollment not started", "two-factor authentication is not enabled":
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case "two-factor authentication is already enabled":
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case "two-factor authentication is required for your role", "only super admins can manage super admins":
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case "user not found":
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	default:
+		fmt.Printf("Error managing two-factor authentication: %v\n", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"net/http"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 )
 
 type UserHandler struct {
@@ -129,3 +130,205 @@ func (h *UserHandler) UpdateProfile(c *gin.Context) {
 
 	c.JSON(http.StatusOK, profile)
 }
+
+// ListUsers godoc
+// @Summary List users
+// @Description Search users by username or email, filtered by role and status
+// @Produce json
+// @Param search query string false "Part of the username or email"
+// @Param role query string false "Role"
+// @Param status query string false "Status"
+// @Param page query int false "Page number"
+// @Param page_size query int false "Page size"
+// @Security BearerAuth
+// @Success 200 {object} models.AdminUserListResponse
+// @Failure 400 {object} map[string]string
+// @Router /admin/users [get]
+func (h *UserHandler) ListUsers(c *gin.Context) {
+	var query models.AdminUserQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	tenant, ok := currentTenant(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	response, err := h.userService.ListUsers(&query, tenant)
+	if err != nil {
+		fmt.Printf("Error listing users: %v\n", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// CreateUser godoc
+// @Summary Create user
+// @Description Create an active account, e.g. for catering staff
+// @Accept json
+// @Produce json
+// @Param request body models.CreateUserRequest true "User"
+// @Security BearerAuth
+// @Success 201 {object} models.AdminUserResponse
+// @Failure 400 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /admin/users [post]
+func (h *UserHandler) CreateUser(c *gin.Context) {
+	claims, ok := currentClaims(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	var req models.CreateUserRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, err := h.userService.CreateUser(&req, claims.UserID, services.TenantOf(claims))
+	if err != nil {
+		switch err.Error() {
+		case "username already exists", "email already exists":
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		case "organisation not found":
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case "only super admins can grant the super_admin role":
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		default:
+			fmt.Printf("Error creating user: %v\n", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, user)
+}
+
+// UpdateRole godoc
+// @Summary Change user role
+// @Description Change the role of a user and end their sessions
+// @Accept json
+// @Produce json
+// @Param id path string true "User ID"
+// @Param request body models.UpdateUserRoleRequest true "Role"
+// @Security BearerAuth
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /admin/users/{id}/role [put]
+func (h *UserHandler) UpdateRole(c *gin.Context) {
+	userID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user ID format"})
+		return
+	}
+
+	claims, ok := currentClaims(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	var req models.UpdateUserRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.userService.UpdateRole(userID, &req, claims.UserID, services.TenantOf(claims)); err != nil {
+		respondUserAdminError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "user role updated successfully"})
+}
+
+// SuspendUser godoc
+// @Summary Suspend user
+// @Description Stop a user from signing in and end their sessions
+// @Produce json
+// @Param id path string true "User ID"
+// @Security BearerAuth
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /admin/users/{id}/suspend [post]
+func (h *UserHandler) SuspendUser(c *gin.Context) {
+	h.userAction(c, h.userService.SuspendUser, "user suspended successfully")
+}
+
+// ReactivateUser godoc
+// @Summary Reactivate user
+// @Description Let a suspended user sign in again
+// @Produce json
+// @Param id path string true "User ID"
+// @Security BearerAuth
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /admin/users/{id}/reactivate [post]
+func (h *UserHandler) ReactivateUser(c *gin.Context) {
+	h.userAction(c, h.userService.ReactivateUser, "user reactivated successfully")
+}
+
+// ForcePasswordReset godoc
+// @Summary Force password reset
+// @Description Disable the user's password, end their sessions and email them a reset link
+// @Produce json
+// @Param id path string true "User ID"
+// @Security BearerAuth
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /admin/users/{id}/password-reset [post]
+func (h *UserHandler) ForcePasswordReset(c *gin.Context) {
+	h.userAction(c, h.userService.ForcePasswordReset, "password reset email sent")
+}
+
+// userAction runs an admin action on the user in the path.
+func (h *UserHandler) userAction(c *gin.Context, action func(userID, actorID uuid.UUID, tenant services.Tenant) error, message string) {
+	userID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user ID format"})
+		return
+	}
+
+	claims, ok := currentClaims(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	if err := action(userID, claims.UserID, services.TenantOf(claims)); err != nil {
+		respondUserAdminError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": message})
+}
+
+func respondUserAdminError(c *gin.Context, err error) {
+	switch err.Error() {
+	case "user not found":
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case "cannot change your own role", "cannot suspend your own account",
+		"service accounts are managed through their API keys":
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case "only super admins can manage super admins", "only super admins can grant the super_admin role":
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case "user is already suspended", "user is not suspended", "user has no email address":
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		fmt.Printf("Error managing user: %v\n", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
+	}
+}
@@ -0,0 +1,104 @@
+package mail
+
+import (
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/mail"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildMessage(t *testing.T) {
+	data, err := buildMessage("E-Meeting <noreply@example.com>", Message{
+		To:      "jane@example.com",
+		Subject: "Atur ulang kata sandi – segera",
+		Text:    "Hello Jane",
+		HTML:    "<p>Hello Jane</p>",
+	}, time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+
+	parsed, err := mail.ReadMessage(strings.NewReader(string(data)))
+	require.NoError(t, err)
+
+	subject, err := new(mime.WordDecoder).DecodeHeader(parsed.Header.Get("Subject"))
+	require.NoError(t, err)
+	assert.Equal(t, "Atur ulang kata sandi – segera", subject)
+	assert.Equal(t, "jane@example.com", parsed.Header.Get("To"))
+	assert.True(t, strings.HasSuffix(parsed.Header.Get("Message-ID"), "@example.com>"))
+
+	mediaType, params, err := mime.ParseMediaType(parsed.Header.Get("Content-Type"))
+	require.NoError(t, err)
+	assert.Equal(t, "multipart/alternative", mediaType)
+
+	reader := multipart.NewReader(parsed.Body, params["boundary"])
+	var bodies []string
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		body, err := io.ReadAll(part)
+		require.NoError(t, err)
+		bodies = append(bodies, part.Header.Get("Content-Type")+": "+string(body))
+	}
+	assert.Equal(t, []string{
+		"text/plain; charset=UTF-8: Hello Jane",
+		"text/html; charset=UTF-8: <p>Hello Jane</p>",
+	}, bodies)
+}
+
+func TestRender(t *testing.T) {
+	data := map[string]interface{}{
+		"Username":         "jane",
+		"Link":             "https://example.com/password/reset?token=abc&x=<1>",
+		"ExpiresInMinutes": 15,
+	}
+
+	tests := []struct {
+		name            string
+		language        string
+		expectedSubject string
+	}{
+		{name: "English", language: "en", expectedSubject: "Reset your password"},
+		{name: "Indonesian", language: "id", expectedSubject: "Atur ulang kata sandi Anda"},
+		{name: "Region subtag", language: "id-ID", expectedSubject: "Atur ulang kata sandi Anda"},
+		{name: "No language", expectedSubject: "Reset your password"},
+		{name: "Missing language", language: "fr", expectedSubject: "Reset your password"},
+		{name: "Path in language", language: "../en", expectedSubject: "Reset your password"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			msg, err := Render(TemplatePasswordReset, tt.language, data)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedSubject, msg.Subject)
+			assert.Contains(t, msg.Text, "https://example.com/password/reset?token=abc&x=<1>")
+			assert.Contains(t, msg.Text, "15")
+			assert.Contains(t, msg.HTML, `href="https://example.com/password/reset?token=abc&amp;x=%3c1%3e"`)
+		})
+	}
+
+	_, err := Render("no_such_template", "en", data)
+	assert.Error(t, err)
+}
+
+func TestFileMailer(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "mail")
+	mailer, err := NewFileMailer(dir, "noreply@example.com")
+	require.NoError(t, err)
+
+	require.NoError(t, mailer.Send(Message{To: "jane@example.com", Subject: "Hi", Text: "Hello"}))
+
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.True(t, strings.HasSuffix(entries[0].Name(), ".eml"))
+}
@@ -0,0 +1,56 @@
+package mail
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/smtp"
+	"time"
+)
+
+// SMTPConfig configures delivery through an SMTP relay. The connection is
+// upgraded with STARTTLS when the server offers it.
+type SMTPConfig struct {
+	Host     string
+	Port     string // Defaults to 587
+	Username string // No authentication when empty
+	Password string
+	From     string
+}
+
+type SMTPMailer struct {
+	config SMTPConfig
+}
+
+func NewSMTPMailer(config SMTPConfig) (*SMTPMailer, error) {
+	if config.Host == "" {
+		return nil, errors.New("SMTP host is required")
+	}
+	if config.From == "" {
+		return nil, errors.New("sender address is required")
+	}
+	if config.Port == "" {
+		config.Port = "587"
+	}
+
+	return &SMTPMailer{config: config}, nil
+}
+
+func (m *SMTPMailer) Send(msg Message) error {
+	data, err := buildMessage(m.config.From, msg, time.Now())
+	if err != nil {
+		return fmt.Errorf("error building message: %v", err)
+	}
+
+	var auth smtp.Auth
+	if m.config.Username != "" {
+		auth = smtp.PlainAuth("", m.config.Username, m.config.Password, m.config.Host)
+	}
+
+	addr := net.JoinHostPort(m.config.Host, m.config.Port)
+	if err := smtp.SendMail(addr, auth, m.config.From, []string{msg.To}, data); err != nil {
+		return fmt.Errorf("error sending mail: %v", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,78 @@
+package mail
+
+import (
+	"bytes"
+	"embed"
+	"errors"
+	"fmt"
+	htmltemplate "html/template"
+	"io/fs"
+	"strings"
+	texttemplate "text/template"
+)
+
+// Templates
+const (
+	TemplatePasswordReset     = "password_reset"
+	TemplateEmailVerification = "email_verification"
+)
+
+// DefaultLanguage is used when a template is missing in the user's language.
+const DefaultLanguage = "en"
+
+// Templates live in templates/<language>/<name>.txt and .html. The text
+// template defines the subject in a "subject" block.
+//
+//go:embed templates
+var templates embed.FS
+
+// Render renders the named template in language, falling back to
+// DefaultLanguage. The returned message has no recipient.
+func Render(name, language string, data interface{}) (Message, error) {
+	language = templateLanguage(name, language)
+	base := fmt.Sprintf("templates/%s/%s", language, name)
+
+	text, err := texttemplate.ParseFS(templates, base+".txt")
+	if err != nil {
+		return Message{}, fmt.Errorf("error parsing template %s: %v", name, err)
+	}
+	html, err := htmltemplate.ParseFS(templates, base+".html")
+	if err != nil {
+		return Message{}, fmt.Errorf("error parsing template %s: %v", name, err)
+	}
+
+	var subject, textBody, htmlBody bytes.Buffer
+	if err := text.ExecuteTemplate(&subject, "subject", data); err != nil {
+		return Message{}, fmt.Errorf("error rendering template %s: %v", name, err)
+	}
+	if err := text.Execute(&textBody, data); err != nil {
+		return Message{}, fmt.Errorf("error rendering template %s: %v", name, err)
+	}
+	if err := html.Execute(&htmlBody, data); err != nil {
+		return Message{}, fmt.Errorf("error rendering template %s: %v", name, err)
+	}
+
+	return Message{
+		Subject: strings.TrimSpace(subject.String()),
+		Text:    strings.TrimSpace(textBody.String()) + "\n",
+		HTML:    htmlBody.String(),
+	}, nil
+}
+
+// templateLanguage picks the language to render name in. Region subtags are
+// ignored, so "id-ID" uses the "id" templates.
+func templateLanguage(name, language string) string {
+	language = strings.ToLower(language)
+	if i := strings.IndexAny(language, "-_"); i >= 0 {
+		language = language[:i]
+	}
+	if language == "" || strings.ContainsAny(language, "./") {
+		return DefaultLanguage
+	}
+
+	_, err := fs.Stat(templates, fmt.Sprintf("templates/%s/%s.txt", language, name))
+	if errors.Is(err, fs.ErrNotExist) {
+		return DefaultLanguage
+	}
+	return language
+}
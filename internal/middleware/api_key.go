@@ -0,0 +1,63 @@
+package middleware
+
+import (
+	"e-meetingproject/internal/auth"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// APIKeyHeader carries the API key of a service account.
+const APIKeyHeader = "X-API-Key"
+
+// APIKeyAuthMiddleware authenticates requests that carry an API key and sets
+// the claims of its service account in the context, as JWTAuthMiddleware does
+// for users. Requests without a key are passed on to JWTAuthMiddleware, which
+// must run after it.
+func APIKeyAuthMiddleware(apiKeys auth.APIKeyAuthenticator) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader(APIKeyHeader)
+		if key == "" {
+			c.Next()
+			return
+		}
+
+		claims, err := apiKeys.AuthenticateAPIKey(key, c.ClientIP())
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error checking API key"})
+			c.Abort()
+			return
+		}
+		if claims == nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid API key"})
+			c.Abort()
+			return
+		}
+
+		// Set claims in context for use in subsequent handlers
+		c.Set("claims", claims)
+		c.Set("userID", claims.UserID)
+		c.Set("username", claims.Username)
+		c.Set("role", claims.Role)
+
+		c.Next()
+	}
+}
+
+// RejectAPIKeys refuses API keys on routes meant for users only, which are not
+// gated by a permission a key could be granted. It must run after
+// APIKeyAuthMiddleware.
+func RejectAPIKeys() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		claims, exists := c.Get("claims")
+		if exists {
+			if userClaims, ok := claims.(*auth.Claims); ok && userClaims.IsAPIKey() {
+				c.JSON(http.StatusForbidden, gin.H{"error": "forbidden: API keys cannot use this route"})
+				c.Abort()
+				return
+			}
+		}
+
+		c.Next()
+	}
+}
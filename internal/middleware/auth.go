@@ -2,18 +2,24 @@ package middleware
 
 import (
 	"e-meetingproject/internal/auth"
-	"fmt"
 	"net/http"
 	"strings"
 
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
-	"github.com/spf13/viper"
 )
 
-// JWTAuthMiddleware validates JWT tokens and sets user claims in the context
-func JWTAuthMiddleware() gin.HandlerFunc {
+// JWTAuthMiddleware validates JWT tokens against the key named by their kid and
+// sets user claims in the context. Tokens reported by revocations are rejected
+// even before they expire. Requests already authenticated by
+// APIKeyAuthMiddleware are passed on.
+func JWTAuthMiddleware(keys *auth.KeySet, revocations auth.RevocationChecker) gin.HandlerFunc {
 	return func(c *gin.Context) {
+		if _, exists := c.Get("claims"); exists {
+			c.Next()
+			return
+		}
+
 		// Get the Authorization header
 		authHeader := c.GetHeader("Authorization")
 		if authHeader == "" {
@@ -32,13 +38,7 @@ func JWTAuthMiddleware() gin.HandlerFunc {
 
 		// Parse and validate the token
 		claims := &auth.Claims{}
-		token, err := jwt.ParseWithClaims(bearerToken[1], claims, func(token *jwt.Token) (interface{}, error) {
-			// Validate the signing method
-			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-				return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
-			}
-			return []byte(viper.GetString("JWT_SECRET_KEY")), nil
-		})
+		token, err := jwt.ParseWithClaims(bearerToken[1], claims, keys.Keyfunc, jwt.WithValidMethods(keys.Methods()))
 
 		if err != nil || !token.Valid {
 			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid token"})
@@ -46,6 +46,21 @@ func JWTAuthMiddleware() gin.HandlerFunc {
 			return
 		}
 
+		// Reject tokens revoked on logout or by an admin
+		if revocations != nil {
+			revoked, err := revocations.IsRevoked(claims)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "error checking token"})
+				c.Abort()
+				return
+			}
+			if revoked {
+				c.JSON(http.StatusUnauthorized, gin.H{"error": "token has been revoked"})
+				c.Abort()
+				return
+			}
+		}
+
 		// Set claims in context for use in subsequent handlers
 		c.Set("claims", claims)
 		// Also set individual fields for backward compatibility and convenience
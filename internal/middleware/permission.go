@@ -0,0 +1,42 @@
+package middleware
+
+import (
+	"e-meetingproject/internal/auth"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequirePermission ensures that the user's role has been granted permission,
+// or for an API key that the key has. It must run after JWTAuthMiddleware.
+func RequirePermission(checker auth.PermissionChecker, permission string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		claims, exists := c.Get("claims")
+		if !exists {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized: no claims found"})
+			c.Abort()
+			return
+		}
+
+		userClaims, ok := claims.(*auth.Claims)
+		if !ok {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "internal server error: invalid claims type"})
+			c.Abort()
+			return
+		}
+
+		allowed, err := auth.Allows(checker, userClaims, permission)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error checking permissions"})
+			c.Abort()
+			return
+		}
+		if !allowed {
+			c.JSON(http.StatusForbidden, gin.H{"error": "forbidden: missing permission " + permission})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
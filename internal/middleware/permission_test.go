@@ -0,0 +1,102 @@
+package middleware
+
+import (
+	"e-meetingproject/internal/auth"
+	"e-meetingproject/internal/models"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+// rolePermissions grants each role the permissions it lists.
+type rolePermissions map[string][]string
+
+func (r rolePermissions) HasPermission(role, permission string) (bool, error) {
+	for _, p := range r[role] {
+		if p == permission {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// serve sends a request with claims set in the context, as the auth
+// middlewares do, through the given middlewares.
+func serve(claims *auth.Claims, method, path string, middlewares ...gin.HandlerFunc) int {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		c.Set("claims", claims)
+		c.Next()
+	})
+	router.Use(middlewares...)
+	router.Handle(method, path, func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(method, path, nil))
+	return w.Code
+}
+
+func TestRequirePermissionReservation(t *testing.T) {
+	checker := rolePermissions{models.RoleUser: {models.PermissionReservationCreate}}
+	requireCreate := RequirePermission(checker, models.PermissionReservationCreate)
+
+	tests := []struct {
+		name     string
+		claims   *auth.Claims
+		expected int
+	}{
+		{
+			name:     "User with the permission",
+			claims:   &auth.Claims{Role: models.RoleUser},
+			expected: http.StatusOK,
+		},
+		{
+			name:     "API key without the permission",
+			claims:   &auth.Claims{Role: models.RoleUser, APIKeyID: uuid.New(), Permissions: []string{models.PermissionReservationView}},
+			expected: http.StatusForbidden,
+		},
+		{
+			name:     "API key with the permission",
+			claims:   &auth.Claims{Role: models.RoleUser, APIKeyID: uuid.New(), Permissions: []string{models.PermissionReservationCreate}},
+			expected: http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, serve(tt.claims, http.MethodPost, "/reservation", requireCreate))
+		})
+	}
+}
+
+func TestRejectAPIKeys(t *testing.T) {
+	tests := []struct {
+		name     string
+		claims   *auth.Claims
+		expected int
+	}{
+		{
+			name:     "User",
+			claims:   &auth.Claims{Role: models.RoleUser},
+			expected: http.StatusOK,
+		},
+		{
+			name:     "API key",
+			claims:   &auth.Claims{Role: models.RoleUser, APIKeyID: uuid.New(), Permissions: []string{models.PermissionReservationCreate}},
+			expected: http.StatusForbidden,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, serve(tt.claims, http.MethodGet, "/wallets", RejectAPIKeys()))
+		})
+	}
+}
@@ -7,8 +7,13 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
-// AdminOnlyMiddleware ensures that only users with admin role can access the protected routes
-func AdminOnlyMiddleware() gin.HandlerFunc {
+// RequireRoles ensures that only users with one of the given roles can access the protected routes
+func RequireRoles(roles ...string) gin.HandlerFunc {
+	allowed := make(map[string]bool, len(roles))
+	for _, role := range roles {
+		allowed[role] = true
+	}
+
 	return func(c *gin.Context) {
 		// Get claims from the context (set by AuthMiddleware)
 		claims, exists := c.Get("claims")
@@ -26,9 +31,9 @@ func AdminOnlyMiddleware() gin.HandlerFunc {
 			return
 		}
 
-		// Check if user has admin role
-		if userClaims.Role != "admin" {
-			c.JSON(http.StatusForbidden, gin.H{"error": "forbidden: admin access required"})
+		// Check if user has one of the allowed roles
+		if !allowed[userClaims.Role] {
+			c.JSON(http.StatusForbidden, gin.H{"error": "forbidden: insufficient role"})
 			c.Abort()
 			return
 		}
@@ -0,0 +1,42 @@
+package middleware
+
+import (
+	"e-meetingproject/internal/auth"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequireVerifiedEmail rejects users who have not verified their email
+// address yet. It must run after JWTAuthMiddleware.
+func RequireVerifiedEmail(checker auth.VerificationChecker) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		claims, exists := c.Get("claims")
+		if !exists {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized: no claims found"})
+			c.Abort()
+			return
+		}
+
+		userClaims, ok := claims.(*auth.Claims)
+		if !ok {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "internal server error: invalid claims type"})
+			c.Abort()
+			return
+		}
+
+		verified, err := checker.IsEmailVerified(userClaims)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error checking email verification"})
+			c.Abort()
+			return
+		}
+		if !verified {
+			c.JSON(http.StatusForbidden, gin.H{"error": "email address not verified"})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
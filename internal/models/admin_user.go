@@ -0,0 +1,46 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type AdminUserQuery struct {
+	Search   string `form:"search"` // Part of the username or email
+	Role     string `form:"role" binding:"omitempty,oneof=admin user catering receptionist super_admin"`
+	Status   string `form:"status" binding:"omitempty,oneof=active pending_verification suspended"`
+	Page     int    `form:"page" binding:"omitempty,min=1"`
+	PageSize int    `form:"page_size" binding:"omitempty,min=1,max=100"`
+}
+
+type AdminUserResponse struct {
+	ID              uuid.UUID  `json:"id"`
+	OrganisationID  uuid.UUID  `json:"organisation_id"`
+	Username        string     `json:"username"`
+	Email           string     `json:"email"`
+	Role            string     `json:"role"`
+	Status          string     `json:"status"`
+	EmailVerifiedAt *time.Time `json:"email_verified_at,omitempty"`
+	LockedUntil     *time.Time `json:"locked_until,omitempty"`
+	CreatedAt       time.Time  `json:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at"`
+}
+
+type AdminUserListResponse struct {
+	Users      []AdminUserResponse `json:"users"`
+	Page       int                 `json:"page"`
+	PageSize   int                 `json:"page_size"`
+	TotalItems int                 `json:"total_items"`
+	TotalPages int                 `json:"total_pages"`
+}
+
+// CreateUserRequest creates an active account, e.g. for catering staff,
+// with an initial password the admin hands over.
+type CreateUserRequest struct {
+	Username       string     `json:"username" binding:"required,min=3,max=50"`
+	Email          string     `json:"email" binding:"required,email"`
+	Password       string     `json:"password" binding:"required,min=6"`
+	Role           string     `json:"role" binding:"required,oneof=admin user catering receptionist super_admin"`
+	OrganisationID *uuid.UUID `json:"organisation_id,omitempty"` // Super admins only; defaults to the admin's organisation
+}
@@ -0,0 +1,84 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ServiceAccount is a user without a password that an integration, such as a
+// lobby kiosk, acts as through its API keys.
+type ServiceAccount struct {
+	ID             uuid.UUID `json:"id"`
+	OrganisationID uuid.UUID `json:"organisation_id"`
+	Username       string    `json:"username"`
+	Status         string    `json:"status"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+type ServiceAccountListResponse struct {
+	ServiceAccounts []ServiceAccount `json:"service_accounts"`
+}
+
+type CreateServiceAccountRequest struct {
+	Username       string     `json:"username" binding:"required,min=3,max=50"`
+	OrganisationID *uuid.UUID `json:"organisation_id,omitempty"` // Super admins only; defaults to the admin's organisation
+}
+
+// APIKey is a key of a service account. The key itself is only returned when
+// it is created or rotated; Prefix identifies it afterwards.
+type APIKey struct {
+	ID               uuid.UUID   `json:"id"`
+	ServiceAccountID uuid.UUID   `json:"service_account_id"`
+	Name             string      `json:"name"`
+	Prefix           string      `json:"prefix"`
+	Permissions      []string    `json:"permissions"`
+	RoomIDs          []uuid.UUID `json:"room_ids"` // Empty for every room of the organisation
+	ExpiresAt        *time.Time  `json:"expires_at,omitempty"`
+	RevokedAt        *time.Time  `json:"revoked_at,omitempty"`
+	LastUsedAt       *time.Time  `json:"last_used_at,omitempty"`
+	LastUsedIP       string      `json:"last_used_ip,omitempty"`
+	UseCount         int64       `json:"use_count"`
+	CreatedAt        time.Time   `json:"created_at"`
+}
+
+type APIKeyListResponse struct {
+	APIKeys []APIKey `json:"api_keys"`
+}
+
+type CreateAPIKeyRequest struct {
+	Name        string      `json:"name" binding:"required,max=255"`
+	Permissions []string    `json:"permissions" binding:"required,min=1"`
+	RoomIDs     []uuid.UUID `json:"room_ids"`
+	ExpiresAt   *time.Time  `json:"expires_at"`
+}
+
+// RotateAPIKeyRequest replaces a key with a new one of the same scope. The
+// old key keeps working for GraceMinutes, so clients can switch over.
+type RotateAPIKeyRequest struct {
+	ExpiresAt    *time.Time `json:"expires_at"`
+	GraceMinutes int        `json:"grace_minutes" binding:"omitempty,min=0,max=10080"`
+}
+
+// APIKeySecretResponse holds a new key. It is shown once and only its hash
+// is stored.
+type APIKeySecretResponse struct {
+	APIKey
+	Key string `json:"key"`
+}
+
+type APIKeyUsageQuery struct {
+	Days int `form:"days" binding:"omitempty,min=1,max=366"`
+}
+
+type APIKeyDailyUsage struct {
+	Day      string `json:"day"`
+	Requests int64  `json:"requests"`
+}
+
+type APIKeyUsageResponse struct {
+	APIKeyID   uuid.UUID          `json:"api_key_id"`
+	UseCount   int64              `json:"use_count"`
+	LastUsedAt *time.Time         `json:"last_used_at,omitempty"`
+	Days       []APIKeyDailyUsage `json:"days"`
+}
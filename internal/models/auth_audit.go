@@ -0,0 +1,84 @@
+package models
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Authentication events written to the auth audit log
+const (
+	AuthEventLoginSucceeded      = "login_succeeded"
+	AuthEventLoginFailed         = "login_failed"
+	AuthEventLoginThrottled      = "login_throttled"
+	AuthEventLoginRefused        = "login_refused" // The account is not active
+	AuthEventAccountLocked       = "account_locked"
+	AuthEventAccountUnlocked     = "account_unlocked"
+	AuthEventIPLocked            = "ip_locked"
+	AuthEventTwoFactorChallenged = "two_factor_challenged"
+	AuthEventTwoFactorFailed     = "two_factor_failed"
+
+	// Admin user management; the admin is the actor
+	AuthEventUserCreated         = "user_created"
+	AuthEventRoleChanged         = "role_changed"
+	AuthEventUserSuspended       = "user_suspended"
+	AuthEventUserReactivated     = "user_reactivated"
+	AuthEventPasswordResetForced = "password_reset_forced"
+	AuthEventSessionsRevoked     = "sessions_revoked"
+	AuthEventPermissionsChanged  = "permissions_changed"
+
+	// Service accounts and API keys; the admin is the actor
+	AuthEventServiceAccountCreated = "service_account_created"
+	AuthEventAPIKeyCreated         = "api_key_created"
+	AuthEventAPIKeyRotated         = "api_key_rotated"
+	AuthEventAPIKeyRevoked         = "api_key_revoked"
+)
+
+// TooManyAttemptsError rejects a login while the account or the client IP
+// is locked out or has to wait before the next attempt.
+type TooManyAttemptsError struct {
+	RetryAfter time.Duration
+}
+
+func (e *TooManyAttemptsError) Error() string {
+	return "too many failed login attempts"
+}
+
+// RetryAfterSeconds is the value of the Retry-After header, at least 1.
+func (e *TooManyAttemptsError) RetryAfterSeconds() string {
+	seconds := int(e.RetryAfter.Round(time.Second) / time.Second)
+	if seconds < 1 {
+		seconds = 1
+	}
+	return fmt.Sprint(seconds)
+}
+
+type AuthAuditEvent struct {
+	ID        uuid.UUID  `json:"id"`
+	Event     string     `json:"event"`
+	UserID    *uuid.UUID `json:"user_id,omitempty"`
+	Username  string     `json:"username,omitempty"`
+	IP        string     `json:"ip,omitempty"`
+	ActorID   *uuid.UUID `json:"actor_id,omitempty"` // Admin who acted on the account
+	Detail    string     `json:"detail,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+type AuthAuditQuery struct {
+	UserID   string `form:"user_id" binding:"omitempty,uuid"`
+	Event    string `form:"event"`
+	IP       string `form:"ip"`
+	From     string `form:"from"` // YYYY-MM-DD
+	To       string `form:"to"`   // YYYY-MM-DD, inclusive
+	Page     int    `form:"page" binding:"omitempty,min=1"`
+	PageSize int    `form:"page_size" binding:"omitempty,min=1,max=100"`
+}
+
+type AuthAuditResponse struct {
+	Events     []AuthAuditEvent `json:"events"`
+	Page       int              `json:"page"`
+	PageSize   int              `json:"page_size"`
+	TotalItems int              `json:"total_items"`
+	TotalPages int              `json:"total_pages"`
+}
@@ -0,0 +1,64 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// CancellationPolicyRule charges FeePercent of the reservation price when it is
+// cancelled at least MinHoursBefore hours before it starts.
+type CancellationPolicyRule struct {
+	MinHoursBefore float64 `json:"min_hours_before" binding:"min=0"`
+	FeePercent     float64 `json:"fee_percent" binding:"min=0,max=100"`
+}
+
+type CancellationPolicy struct {
+	ID             uuid.UUID                `json:"id"`
+	OrganisationID uuid.UUID                `json:"organisation_id"`
+	Name           string                   `json:"name"`
+	IsDefault      bool                     `json:"is_default"` // The default of its organisation
+	Rules          []CancellationPolicyRule `json:"rules"`
+	CreatedAt      time.Time                `json:"created_at"`
+	UpdatedAt      time.Time                `json:"updated_at"`
+}
+
+type CancellationPolicyRequest struct {
+	Name           string                   `json:"name" binding:"required,max=100"`
+	OrganisationID *uuid.UUID               `json:"organisation_id,omitempty"` // Super admins only; defaults to the admin's organisation
+	IsDefault      bool                     `json:"is_default"`
+	Rules          []CancellationPolicyRule `json:"rules" binding:"required,min=1,dive"`
+}
+
+type CancellationPolicyListResponse struct {
+	Policies []CancellationPolicy `json:"policies"`
+}
+
+type AssignRoomPolicyRequest struct {
+	PolicyID *uuid.UUID `json:"policy_id"` // null falls back to the default policy
+}
+
+// CancellationQuote is the result of evaluating the cancellation policy of a reservation.
+type CancellationQuote struct {
+	ReservationID    uuid.UUID  `json:"reservation_id"`
+	PolicyID         *uuid.UUID `json:"policy_id"`
+	PolicyName       string     `json:"policy_name"`
+	HoursBeforeStart float64    `json:"hours_before_start"`
+	FeePercent       float64    `json:"fee_percent"`
+	Price            float64    `json:"price"`
+	CancellationFee  float64    `json:"cancellation_fee"`
+	AmountPaid       float64    `json:"amount_paid"`
+	RefundAmount     float64    `json:"refund_amount"`
+	EvaluatedAt      time.Time  `json:"evaluated_at"`
+}
+
+type CancelReservationRequest struct {
+	// AcceptedFee must match the fee of the current quote, so users never pay
+	// a fee they have not seen.
+	AcceptedFee *float64 `json:"accepted_fee" binding:"required,min=0"`
+}
+
+type CancelReservationResponse struct {
+	Reservation  *ReservationEvent  `json:"reservation"`
+	Cancellation *CancellationQuote `json:"cancellation"`
+}
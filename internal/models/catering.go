@@ -0,0 +1,81 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type PrepStatus string
+
+const (
+	PrepStatusPending   PrepStatus = "pending"
+	PrepStatusPreparing PrepStatus = "preparing"
+	PrepStatusDelivered PrepStatus = "delivered"
+)
+
+// CanAdvanceTo reports whether the kitchen may move an order from s to next.
+// Orders only move forward: pending, preparing, delivered.
+func (s PrepStatus) CanAdvanceTo(next PrepStatus) bool {
+	order := map[PrepStatus]int{
+		PrepStatusPending:   0,
+		PrepStatusPreparing: 1,
+		PrepStatusDelivered: 2,
+	}
+	from, ok := order[s]
+	if !ok {
+		return false
+	}
+	to, ok := order[next]
+	return ok && to > from
+}
+
+type PrepSheetQuery struct {
+	Date   string `form:"date" binding:"required"` // Format: YYYY-MM-DD
+	Format string `form:"format,default=json" binding:"omitempty,oneof=json csv html"`
+}
+
+// PrepOrder is one snack order of a reservation as the kitchen sees it.
+type PrepOrder struct {
+	OrderID       uuid.UUID  `json:"order_id"`
+	ReservationID uuid.UUID  `json:"reservation_id"`
+	SnackID       uuid.UUID  `json:"snack_id"`
+	SnackName     string     `json:"snack_name"`
+	Category      string     `json:"category"`
+	Quantity      int        `json:"quantity"`
+	VisitorCount  int        `json:"visitor_count"`
+	Status        PrepStatus `json:"status"`
+}
+
+// PrepSlot groups the orders delivered to one room at one time.
+type PrepSlot struct {
+	DeliveryTime time.Time   `json:"delivery_time"`
+	RoomID       uuid.UUID   `json:"room_id"`
+	RoomName     string      `json:"room_name"`
+	Orders       []PrepOrder `json:"orders"`
+	TotalItems   int         `json:"total_items"`
+}
+
+type PrepSnackTotal struct {
+	SnackID   uuid.UUID `json:"snack_id"`
+	SnackName string    `json:"snack_name"`
+	Category  string    `json:"category"`
+	Quantity  int       `json:"quantity"`
+}
+
+type PrepSheet struct {
+	Date        string           `json:"date"`
+	Slots       []PrepSlot       `json:"slots"`
+	SnackTotals []PrepSnackTotal `json:"snack_totals"`
+	GeneratedAt time.Time        `json:"generated_at"`
+}
+
+type UpdatePrepStatusRequest struct {
+	Status PrepStatus `json:"status" binding:"required,oneof=preparing delivered"`
+}
+
+type PrepStatusResponse struct {
+	OrderID   uuid.UUID  `json:"order_id"`
+	Status    PrepStatus `json:"status"`
+	UpdatedAt time.Time  `json:"updated_at"`
+}
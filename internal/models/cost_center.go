@@ -0,0 +1,63 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type CostCenter struct {
+	ID        uuid.UUID `json:"id"`
+	Code      string    `json:"code"`
+	Name      string    `json:"name"`
+	IsActive  bool      `json:"is_active"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type CreateCostCenterRequest struct {
+	Code string `json:"code" binding:"required,max=50"`
+	Name string `json:"name" binding:"required,max=255"`
+}
+
+type UpdateCostCenterRequest struct {
+	Name     *string `json:"name,omitempty" binding:"omitempty,max=255"`
+	IsActive *bool   `json:"is_active,omitempty"`
+}
+
+type CostCenterListResponse struct {
+	CostCenters []CostCenter `json:"cost_centers"`
+}
+
+type AssignUserCostCenterRequest struct {
+	CostCenterID *uuid.UUID `json:"cost_center_id"` // null removes the user's cost center
+}
+
+type ChargebackStatementQuery struct {
+	Month  string `form:"month" binding:"required"` // Format: YYYY-MM
+	Format string `form:"format,default=json" binding:"omitempty,oneof=json csv"`
+}
+
+// ChargebackLine totals the completed reservations charged to one cost center.
+// Reservations without a cost center are reported on a line without an ID.
+type ChargebackLine struct {
+	CostCenterID   *uuid.UUID `json:"cost_center_id"`
+	CostCenterCode string     `json:"cost_center_code"`
+	CostCenterName string     `json:"cost_center_name"`
+	Reservations   int        `json:"reservations"`
+	SnackOrders    int        `json:"snack_orders"`
+	SnackItems     int        `json:"snack_items"`
+	RoomSubtotal   float64    `json:"room_subtotal"`
+	SnackSubtotal  float64    `json:"snack_subtotal"`
+	Adjustments    float64    `json:"adjustments"`
+	Total          float64    `json:"total"`
+}
+
+type ChargebackStatement struct {
+	Month       string           `json:"month"`
+	PeriodStart time.Time        `json:"period_start"`
+	PeriodEnd   time.Time        `json:"period_end"`
+	Lines       []ChargebackLine `json:"lines"`
+	Total       float64          `json:"total"`
+	GeneratedAt time.Time        `json:"generated_at"`
+}
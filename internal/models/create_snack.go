@@ -7,15 +7,28 @@ import (
 )
 
 type CreateSnackRequest struct {
-	Name     string  `json:"name" binding:"required"`
-	Category string  `json:"category" binding:"required"`
-	Price    float64 `json:"price" binding:"required,gt=0"`
+	Name               string     `json:"name" binding:"required"`
+	Category           string     `json:"category" binding:"required"`
+	Price              float64    `json:"price" binding:"required,gt=0"`
+	MinQuantity        int        `json:"min_quantity" binding:"omitempty,min=1"` // Defaults to 1
+	MaxPerVisitor      *int       `json:"max_per_visitor" binding:"omitempty,min=1"`
+	OrderCutoffMinutes int        `json:"order_cutoff_minutes" binding:"min=0"`
+	DailyStock         *int       `json:"daily_stock" binding:"omitempty,min=0"`
+	LowStockThreshold  int        `json:"low_stock_threshold" binding:"min=0"`
+	DietaryTags        []string   `json:"dietary_tags" binding:"omitempty,dive,oneof=halal kosher vegetarian vegan gluten_free nut_free dairy_free low_sugar"`
+	Allergens          []string   `json:"allergens" binding:"omitempty,dive,oneof=gluten crustaceans eggs fish peanuts soybeans milk tree_nuts celery mustard sesame sulphites lupin molluscs"`
+	OrganisationID     *uuid.UUID `json:"organisation_id,omitempty"` // Super admins only; defaults to the admin's organisation
 }
 
 type CreateSnackResponse struct {
-	ID        uuid.UUID `json:"id"`
-	Name      string    `json:"name"`
-	Category  string    `json:"category"`
-	Price     float64   `json:"price"`
+	ID             uuid.UUID `json:"id"`
+	OrganisationID uuid.UUID `json:"organisation_id"`
+	Name           string    `json:"name"`
+	Category       string    `json:"category"`
+	Price          float64   `json:"price"`
+	DietaryTags    []string  `json:"dietary_tags"`
+	Allergens      []string  `json:"allergens"`
+	SnackOrderRules
+	SnackStockSettings
 	CreatedAt time.Time `json:"created_at"`
 }
@@ -12,13 +12,15 @@ type RoomStats struct {
 }
 
 type DashboardResponse struct {
-	StartDate    time.Time   `json:"start_date"`
-	EndDate      time.Time   `json:"end_date"`
-	TotalOmzet   float64     `json:"total_omzet"`
-	Reservations int         `json:"total_reservations"`
-	Visitors     int         `json:"total_visitors"`
-	TotalRooms   int         `json:"total_rooms"`
-	RoomStats    []RoomStats `json:"room_stats"`
+	StartDate        time.Time   `json:"start_date"`
+	EndDate          time.Time   `json:"end_date"`
+	TotalOmzet       float64     `json:"total_omzet"`
+	CancellationFees float64     `json:"cancellation_fees"`
+	Refunds          float64     `json:"refunds"`
+	Reservations     int         `json:"total_reservations"`
+	Visitors         int         `json:"total_visitors"`
+	TotalRooms       int         `json:"total_rooms"`
+	RoomStats        []RoomStats `json:"room_stats"`
 }
 
 type DashboardQuery struct {
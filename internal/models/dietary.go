@@ -0,0 +1,22 @@
+package models
+
+import (
+	"github.com/google/uuid"
+)
+
+// DietaryWarning flags a snack line containing allergens the organiser excluded.
+type DietaryWarning struct {
+	SnackID   uuid.UUID `json:"snack_id"`
+	SnackName string    `json:"snack_name"`
+	Allergens []string  `json:"allergens"`
+}
+
+// DietarySummary describes the snack order of a reservation as a whole.
+// DietaryTags are the tags every ordered snack carries, Allergens the union of
+// all allergens in the order.
+type DietarySummary struct {
+	DietaryTags       []string         `json:"dietary_tags"`
+	Allergens         []string         `json:"allergens"`
+	ExcludedAllergens []string         `json:"excluded_allergens"`
+	Warnings          []DietaryWarning `json:"warnings"`
+}
@@ -0,0 +1,30 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Organisation is a tenant company. Users, rooms, snacks and bundles belong
+// to one organisation and are only visible within it, except to super admins.
+type Organisation struct {
+	ID        uuid.UUID `json:"id"`
+	Name      string    `json:"name"`
+	Slug      string    `json:"slug"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type OrganisationListResponse struct {
+	Organisations []Organisation `json:"organisations"`
+}
+
+type CreateOrganisationRequest struct {
+	Name string `json:"name" binding:"required,max=255"`
+	Slug string `json:"slug" binding:"required,max=100"`
+}
+
+type UpdateOrganisationRequest struct {
+	Name string `json:"name" binding:"required,max=255"`
+}
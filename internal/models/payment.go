@@ -0,0 +1,60 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type PaymentStatus string
+
+const (
+	PaymentStatusPending   PaymentStatus = "pending"
+	PaymentStatusSucceeded PaymentStatus = "succeeded"
+	PaymentStatusFailed    PaymentStatus = "failed"
+)
+
+// PaymentPurpose tells a reservation's booking payment apart from the payments
+// requested for snack order increases.
+type PaymentPurpose string
+
+const (
+	PaymentPurposeBooking    PaymentPurpose = "booking"
+	PaymentPurposeSnackTopUp PaymentPurpose = "snack_topup"
+)
+
+type Payment struct {
+	ID            uuid.UUID      `json:"id"`
+	ReservationID uuid.UUID      `json:"reservation_id"`
+	Provider      string         `json:"provider"`
+	ProviderRef   string         `json:"provider_ref"`
+	Purpose       PaymentPurpose `json:"purpose"`
+	Amount        float64        `json:"amount"`
+	Currency      string         `json:"currency"`
+	Status        PaymentStatus  `json:"status"`
+	FailureReason string         `json:"failure_reason,omitempty"`
+	CreatedAt     time.Time      `json:"created_at"`
+	UpdatedAt     time.Time      `json:"updated_at"`
+}
+
+// PaymentInfo is the payment summary embedded in reservation responses.
+// ClientSecret is only returned when the payment intent is first created.
+type PaymentInfo struct {
+	ID            uuid.UUID      `json:"id"`
+	Provider      string         `json:"provider"`
+	IntentID      string         `json:"intent_id"`
+	ClientSecret  string         `json:"client_secret,omitempty"`
+	Purpose       PaymentPurpose `json:"purpose"`
+	Amount        float64        `json:"amount"`
+	Currency      string         `json:"currency"`
+	Status        PaymentStatus  `json:"status"`
+	FailureReason string         `json:"failure_reason,omitempty"`
+	UpdatedAt     time.Time      `json:"updated_at"`
+}
+
+type PaymentWebhookResponse struct {
+	Received          bool      `json:"received"`
+	Payment           *Payment  `json:"payment"`
+	ReservationStatus string    `json:"reservation_status"`
+	ProcessedAt       time.Time `json:"processed_at"`
+}
@@ -0,0 +1,40 @@
+package models
+
+// Permissions, stored in the permissions table. Roles are granted
+// permissions in role_permissions.
+const (
+	PermissionDashboardView            = "dashboard.view"
+	PermissionReservationView          = "reservation.view"
+	PermissionReservationCreate        = "reservation.create"
+	PermissionReservationApprove       = "reservation.approve"
+	PermissionReservationBookForOthers = "reservation.book_for_others"
+	PermissionRoomManage               = "room.manage"
+	PermissionPolicyManage             = "policy.manage"
+	PermissionBillingManage            = "billing.manage"
+	PermissionSnackManage              = "snack.manage"
+	PermissionCateringPrep             = "catering.prep"
+	PermissionUserManage               = "user.manage"
+	PermissionRoleManage               = "role.manage"
+	PermissionAuditView                = "audit.view"
+	PermissionOrganisationManage       = "organisation.manage"
+	PermissionAPIKeyManage             = "api_key.manage"
+)
+
+type Permission struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+type RolePermissions struct {
+	Role        string   `json:"role"`
+	Permissions []string `json:"permissions"`
+}
+
+type RolePermissionsResponse struct {
+	Roles       []RolePermissions `json:"roles"`
+	Permissions []Permission      `json:"permissions"`
+}
+
+type UpdateRolePermissionsRequest struct {
+	Permissions []string `json:"permissions" binding:"required"`
+}
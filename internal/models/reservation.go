@@ -11,19 +11,33 @@ type RoomInfo struct {
 	PricePerHour float64 `json:"price_per_hour"`
 }
 
+// PriceBreakdown is the pricing snapshot stored with a reservation when it is
+// booked, so later room or snack price changes do not alter it.
+type PriceBreakdown struct {
+	HourlyRate    float64 `json:"hourly_rate"`
+	BilledHours   float64 `json:"billed_hours"`
+	RoomSubtotal  float64 `json:"room_subtotal"`
+	SnackSubtotal float64 `json:"snack_subtotal"`
+	Adjustments   float64 `json:"adjustments"`
+	Total         float64 `json:"total"`
+}
+
 type ReservationEvent struct {
-	ID            uuid.UUID `json:"id"`
-	RoomID        uuid.UUID `json:"room_id"`
-	RoomName      string    `json:"room_name"`
-	RoomDetails   RoomInfo  `json:"room_details"`
-	UserID        uuid.UUID `json:"user_id"`
-	Username      string    `json:"username"`
-	StartTime     time.Time `json:"start_time"`
-	EndTime       time.Time `json:"end_time"`
-	DurationHours float64   `json:"duration_hours"`
-	VisitorCount  int       `json:"visitor_count"`
-	Price         float64   `json:"price"`
-	Status        string    `json:"status"`
+	ID              uuid.UUID      `json:"id"`
+	RoomID          uuid.UUID      `json:"room_id"`
+	RoomName        string         `json:"room_name"`
+	RoomDetails     RoomInfo       `json:"room_details"`
+	UserID          uuid.UUID      `json:"user_id"`
+	Username        string         `json:"username"`
+	StartTime       time.Time      `json:"start_time"`
+	EndTime         time.Time      `json:"end_time"`
+	DurationHours   float64        `json:"duration_hours"`
+	VisitorCount    int            `json:"visitor_count"`
+	Price           float64        `json:"price"`
+	PriceBreakdown  PriceBreakdown `json:"price_breakdown"`
+	CancellationFee *float64       `json:"cancellation_fee"`
+	RefundAmount    *float64       `json:"refund_amount"`
+	Status          string         `json:"status"`
 }
 
 type ReservationHistoryQuery struct {
@@ -69,13 +83,13 @@ type UpdateReservationStatusRequest struct {
 }
 
 type ReservationCalculationRequest struct {
-	RoomID uuid.UUID `json:"room_id" binding:"required"`
-	Snacks []struct {
-		SnackID  uuid.UUID `json:"snack_id" binding:"required"`
-		Quantity int       `json:"quantity" binding:"required,min=1"`
-	} `json:"snacks" binding:"required,dive"`
-	StartTime time.Time `json:"start_time" binding:"required"`
-	EndTime   time.Time `json:"end_time" binding:"required,gtfield=StartTime"`
+	RoomID            uuid.UUID         `json:"room_id" binding:"required"`
+	Snacks            []SnackOrderItem  `json:"snacks" binding:"required_without=Bundles,dive"`
+	Bundles           []BundleOrderItem `json:"bundles" binding:"omitempty,dive"`
+	StartTime         time.Time         `json:"start_time" binding:"required"`
+	EndTime           time.Time         `json:"end_time" binding:"required,gtfield=StartTime"`
+	VisitorCount      int               `json:"visitor_count" binding:"omitempty,min=1"` // Enables per-visitor snack limits, required for per-visitor bundles
+	ExcludedAllergens []string          `json:"excluded_allergens" binding:"omitempty,dive,oneof=gluten crustaceans eggs fish peanuts soybeans milk tree_nuts celery mustard sesame sulphites lupin molluscs"`
 }
 
 type ReservationCalculationResponse struct {
@@ -87,31 +101,39 @@ type ReservationCalculationResponse struct {
 		TotalCost    float64   `json:"total_cost"`
 	} `json:"room"`
 	Snacks []struct {
-		ID       uuid.UUID `json:"id"`
-		Name     string    `json:"name"`
-		Category string    `json:"category"`
-		Price    float64   `json:"price"`
-		Quantity int       `json:"quantity"`
-		Subtotal float64   `json:"subtotal"`
+		ID       uuid.UUID  `json:"id"`
+		Name     string     `json:"name"`
+		Category string     `json:"category"`
+		Price    float64    `json:"price"`
+		Quantity int        `json:"quantity"`
+		Subtotal float64    `json:"subtotal"`
+		BundleID *uuid.UUID `json:"bundle_id,omitempty"`
+		ServeAt  time.Time  `json:"serve_at"`
 	} `json:"snacks"`
-	TotalCost float64 `json:"total_cost"`
+	Adjustments float64        `json:"adjustments"` // Rounding of bundle prices over their snack lines
+	TotalCost   float64        `json:"total_cost"`
+	Dietary     DietarySummary `json:"dietary"`
 }
 
 type CreateReservationRequest struct {
-	RoomID       uuid.UUID `json:"room_id" binding:"required"`
-	UserID       uuid.UUID `json:"user_id" binding:"required"`
-	StartTime    time.Time `json:"start_time" binding:"required"`
-	EndTime      time.Time `json:"end_time" binding:"required,gtfield=StartTime"`
-	VisitorCount int       `json:"visitor_count" binding:"required,min=1"`
-	Snacks       []struct {
-		SnackID  uuid.UUID `json:"snack_id" binding:"required"`
-		Quantity int       `json:"quantity" binding:"required,min=1"`
-	} `json:"snacks" binding:"required,dive"`
+	RoomID            uuid.UUID         `json:"room_id" binding:"required"`
+	UserID            uuid.UUID         `json:"user_id"` // Defaults to the caller; booking for another user needs reservation.book_for_others
+	StartTime         time.Time         `json:"start_time" binding:"required"`
+	EndTime           time.Time         `json:"end_time" binding:"required,gtfield=StartTime"`
+	VisitorCount      int               `json:"visitor_count" binding:"required,min=1"`
+	CostCenterID      *uuid.UUID        `json:"cost_center_id,omitempty"` // Overrides the user's cost center
+	WalletID          *uuid.UUID        `json:"wallet_id,omitempty"`      // Pays from a prepaid wallet instead of the payment provider
+	Snacks            []SnackOrderItem  `json:"snacks" binding:"required_without=Bundles,dive"`
+	Bundles           []BundleOrderItem `json:"bundles" binding:"omitempty,dive"`
+	ExcludedAllergens []string          `json:"excluded_allergens" binding:"omitempty,dive,oneof=gluten crustaceans eggs fish peanuts soybeans milk tree_nuts celery mustard sesame sulphites lupin molluscs"`
 }
 
 type CreateReservationResponse struct {
-	ReservationID uuid.UUID `json:"reservation_id"`
-	Status        string    `json:"status"`
-	TotalCost     float64   `json:"total_cost"`
-	CreatedAt     time.Time `json:"created_at"`
+	ReservationID uuid.UUID      `json:"reservation_id"`
+	Status        string         `json:"status"`
+	TotalCost     float64        `json:"total_cost"`
+	CreatedAt     time.Time      `json:"created_at"`
+	Payment       *PaymentInfo   `json:"payment,omitempty"`
+	WalletID      *uuid.UUID     `json:"wallet_id,omitempty"`
+	Dietary       DietarySummary `json:"dietary"`
 }
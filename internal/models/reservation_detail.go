@@ -9,14 +9,16 @@ import (
 // ReservationDetailResponse represents the detailed information of a reservation
 // including room, user, and snack details
 type ReservationDetailResponse struct {
-	ID           uuid.UUID `json:"id"`
-	Status       string    `json:"status"`
-	StartTime    time.Time `json:"start_time"`
-	EndTime      time.Time `json:"end_time"`
-	VisitorCount int       `json:"visitor_count"`
-	Price        float64   `json:"price"`
-	CreatedAt    time.Time `json:"created_at"`
-	UpdatedAt    time.Time `json:"updated_at"`
+	ID           uuid.UUID  `json:"id"`
+	Status       string     `json:"status"`
+	StartTime    time.Time  `json:"start_time"`
+	EndTime      time.Time  `json:"end_time"`
+	VisitorCount int        `json:"visitor_count"`
+	Price        float64    `json:"price"`
+	CostCenterID *uuid.UUID `json:"cost_center_id"`
+	WalletID     *uuid.UUID `json:"wallet_id"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
 
 	Room struct {
 		ID           uuid.UUID `json:"id"`
@@ -30,14 +32,31 @@ type ReservationDetailResponse struct {
 		Username string    `json:"username"`
 	} `json:"user"`
 
-	Snacks []struct {
-		ID       uuid.UUID `json:"id"`
-		Name     string    `json:"name"`
-		Category string    `json:"category"`
-		Price    float64   `json:"price"`
-		Quantity int       `json:"quantity"`
-		Subtotal float64   `json:"subtotal"`
-	} `json:"snacks"`
-
-	TotalCost float64 `json:"total_cost"`
-}
\ No newline at end of file
+	Snacks []ReservationSnackLine `json:"snacks"`
+
+	Dietary DietarySummary `json:"dietary"`
+
+	PriceBreakdown PriceBreakdown `json:"price_breakdown"`
+	TotalCost      float64        `json:"total_cost"`
+
+	CancellationFee *float64 `json:"cancellation_fee"`
+	RefundAmount    *float64 `json:"refund_amount"`
+
+	Payment  *PaymentInfo  `json:"payment"`  // The booking payment
+	Payments []PaymentInfo `json:"payments"` // Every payment, snack order top-ups included
+}
+
+// ReservationSnackLine is a snack ordered with a reservation, at the price it was booked.
+type ReservationSnackLine struct {
+	LineID      uuid.UUID  `json:"line_id"`
+	ID          uuid.UUID  `json:"id"`
+	Name        string     `json:"name"`
+	Category    string     `json:"category"`
+	Price       float64    `json:"price"`
+	Quantity    int        `json:"quantity"`
+	Subtotal    float64    `json:"subtotal"`
+	BundleID    *uuid.UUID `json:"bundle_id,omitempty"` // Set for lines expanded from a bundle
+	ServeAt     time.Time  `json:"serve_at"`            // When the kitchen delivers the line
+	DietaryTags []string   `json:"dietary_tags"`
+	Allergens   []string   `json:"allergens"`
+}
@@ -0,0 +1,24 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// UpdateReservationSnackRequest changes a snack line of a booked reservation.
+// Fields left out keep their current value.
+type UpdateReservationSnackRequest struct {
+	Quantity *int       `json:"quantity,omitempty" binding:"omitempty,min=1"`
+	ServeAt  *time.Time `json:"serve_at,omitempty"`
+}
+
+// SnackOrderChangeResponse is the reservation price after its snack order changed.
+type SnackOrderChangeResponse struct {
+	ReservationID  uuid.UUID             `json:"reservation_id"`
+	Line           *ReservationSnackLine `json:"line,omitempty"` // Not set when the line was removed
+	PreviousPrice  float64               `json:"previous_price"`
+	PriceBreakdown PriceBreakdown        `json:"price_breakdown"`
+	TotalCost      float64               `json:"total_cost"`
+	Payment        *PaymentInfo          `json:"payment,omitempty"` // Payment requested for a price increase
+}
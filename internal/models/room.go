@@ -7,20 +7,22 @@ import (
 )
 
 type Room struct {
-	ID           uuid.UUID `json:"id"`
-	Name         string    `json:"name" binding:"required"`
-	Capacity     int       `json:"capacity" binding:"required,min=1"`
-	PricePerHour float64   `json:"price_per_hour" binding:"required,min=0"`
-	Status       string    `json:"status" binding:"required,oneof=available maintenance occupied"`
-	CreatedAt    time.Time `json:"created_at"`
-	UpdatedAt    time.Time `json:"updated_at"`
+	ID             uuid.UUID `json:"id"`
+	OrganisationID uuid.UUID `json:"organisation_id"`
+	Name           string    `json:"name" binding:"required"`
+	Capacity       int       `json:"capacity" binding:"required,min=1"`
+	PricePerHour   float64   `json:"price_per_hour" binding:"required,min=0"`
+	Status         string    `json:"status" binding:"required,oneof=available maintenance occupied"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
 }
 
 type CreateRoomRequest struct {
-	Name         string  `json:"name" binding:"required"`
-	Capacity     int     `json:"capacity" binding:"required,min=1"`
-	PricePerHour float64 `json:"price_per_hour" binding:"required,min=0"`
-	Status       string  `json:"status" binding:"required,oneof=available maintenance occupied"`
+	Name           string     `json:"name" binding:"required"`
+	Capacity       int        `json:"capacity" binding:"required,min=1"`
+	PricePerHour   float64    `json:"price_per_hour" binding:"required,min=0"`
+	Status         string     `json:"status" binding:"required,oneof=available maintenance occupied"`
+	OrganisationID *uuid.UUID `json:"organisation_id,omitempty"` // Super admins only; defaults to the admin's organisation
 }
 
 type UpdateRoomRequest struct {
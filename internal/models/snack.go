@@ -7,14 +7,74 @@ import (
 )
 
 type Snack struct {
-	ID        uuid.UUID `json:"id"`
-	Name      string    `json:"name"`
-	Category  string    `json:"category"`
-	Price     float64   `json:"price"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	ID             uuid.UUID  `json:"id"`
+	OrganisationID uuid.UUID  `json:"organisation_id"`
+	Name           string     `json:"name"`
+	Category       string     `json:"category"`
+	Price          float64    `json:"price"`
+	IsAvailable    bool       `json:"is_available"`
+	RetiredAt      *time.Time `json:"retired_at,omitempty"`
+	DietaryTags    []string   `json:"dietary_tags"`
+	Allergens      []string   `json:"allergens"`
+	SnackOrderRules
+	SnackStockSettings
+	RemainingStock *int      `json:"remaining_stock,omitempty"` // Set when listing for a date; nil means not stock-limited
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+// SnackStockSettings control the daily stock of a snack.
+type SnackStockSettings struct {
+	DailyStock        *int `json:"daily_stock"` // Stock available each day, nil means not stock-limited
+	LowStockThreshold int  `json:"low_stock_threshold"`
+}
+
+// SnackOrderRules limit how a snack may be ordered with a reservation.
+type SnackOrderRules struct {
+	MinQuantity        int  `json:"min_quantity"`
+	MaxPerVisitor      *int `json:"max_per_visitor"`      // nil means no limit
+	OrderCutoffMinutes int  `json:"order_cutoff_minutes"` // Minimum lead time before start_time
+}
+
+// SnackOrderItem is one snack line requested with a quote or a booking.
+type SnackOrderItem struct {
+	SnackID  uuid.UUID  `json:"snack_id" binding:"required"`
+	Quantity int        `json:"quantity" binding:"required,min=1"`
+	ServeAt  *time.Time `json:"serve_at,omitempty"` // Within the booking; defaults to start_time
+}
+
+// SnackFilter narrows GET /snacks. Retired snacks are never listed.
+type SnackFilter struct {
+	Category  *string  `form:"category"`
+	MinPrice  *float64 `form:"min_price" binding:"omitempty,min=0"`
+	MaxPrice  *float64 `form:"max_price" binding:"omitempty,min=0"`
+	Available *bool    `form:"available"`
+	Date      string   `form:"date"` // YYYY-MM-DD, adds the remaining stock for that day
+
+	// Dietary lists the tags a snack must all carry, ExcludeAllergens the
+	// allergens it must not contain
+	Dietary          []string `form:"dietary" binding:"omitempty,dive,oneof=halal kosher vegetarian vegan gluten_free nut_free dairy_free low_sugar"`
+	ExcludeAllergens []string `form:"exclude_allergens" binding:"omitempty,dive,oneof=gluten crustaceans eggs fish peanuts soybeans milk tree_nuts celery mustard sesame sulphites lupin molluscs"`
 }
 
 type SnackListResponse struct {
-	Snacks []Snack `json:"snacks"`
+	Snacks     []Snack `json:"snacks"`
+	TotalCount int     `json:"total_count"`
+	Page       int     `json:"page"`
+	PageSize   int     `json:"page_size"`
+	TotalPages int     `json:"total_pages"`
+}
+
+type UpdateSnackRequest struct {
+	Name               *string  `json:"name,omitempty" binding:"omitempty,min=1"`
+	Category           *string  `json:"category,omitempty" binding:"omitempty,min=1"`
+	Price              *float64 `json:"price,omitempty" binding:"omitempty,gt=0"`
+	IsAvailable        *bool    `json:"is_available,omitempty"`
+	MinQuantity        *int     `json:"min_quantity,omitempty" binding:"omitempty,min=1"`
+	MaxPerVisitor      *int     `json:"max_per_visitor,omitempty" binding:"omitempty,min=0"` // 0 removes the limit
+	OrderCutoffMinutes *int     `json:"order_cutoff_minutes,omitempty" binding:"omitempty,min=0"`
+	DailyStock         *int     `json:"daily_stock,omitempty" binding:"omitempty,min=-1"` // -1 removes the stock limit
+	LowStockThreshold  *int     `json:"low_stock_threshold,omitempty" binding:"omitempty,min=0"`
+	DietaryTags        []string `json:"dietary_tags,omitempty" binding:"omitempty,dive,oneof=halal kosher vegetarian vegan gluten_free nut_free dairy_free low_sugar"`
+	Allergens          []string `json:"allergens,omitempty" binding:"omitempty,dive,oneof=gluten crustaceans eggs fish peanuts soybeans milk tree_nuts celery mustard sesame sulphites lupin molluscs"`
 }
@@ -0,0 +1,61 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// SnackBundle is a package of snacks sold at one price. Per-visitor bundles
+// are priced and filled per visitor of the reservation.
+type SnackBundle struct {
+	ID             uuid.UUID         `json:"id"`
+	OrganisationID uuid.UUID         `json:"organisation_id"`
+	Name           string            `json:"name"`
+	Description    string            `json:"description"`
+	Price          float64           `json:"price"`
+	PerVisitor     bool              `json:"per_visitor"`
+	IsAvailable    bool              `json:"is_available"`
+	RetiredAt      *time.Time        `json:"retired_at,omitempty"`
+	Items          []SnackBundleItem `json:"items"`
+	CreatedAt      time.Time         `json:"created_at"`
+	UpdatedAt      time.Time         `json:"updated_at"`
+}
+
+// SnackBundleItem is a snack contained in a bundle. Quantity is per visitor
+// for per-visitor bundles.
+type SnackBundleItem struct {
+	SnackID   uuid.UUID `json:"snack_id" binding:"required"`
+	SnackName string    `json:"snack_name,omitempty"`
+	Quantity  int       `json:"quantity" binding:"required,min=1"`
+}
+
+// BundleOrderItem is one bundle requested with a quote or a booking.
+// Per-visitor bundles are multiplied by the visitor count as well.
+type BundleOrderItem struct {
+	BundleID uuid.UUID  `json:"bundle_id" binding:"required"`
+	Quantity int        `json:"quantity" binding:"omitempty,min=1"` // Defaults to 1
+	ServeAt  *time.Time `json:"serve_at,omitempty"`                 // Within the booking; defaults to start_time
+}
+
+type CreateSnackBundleRequest struct {
+	Name           string            `json:"name" binding:"required"`
+	Description    string            `json:"description"`
+	Price          float64           `json:"price" binding:"required,gt=0"`
+	PerVisitor     bool              `json:"per_visitor"`
+	Items          []SnackBundleItem `json:"items" binding:"required,min=1,dive"`
+	OrganisationID *uuid.UUID        `json:"organisation_id,omitempty"` // Super admins only; defaults to the admin's organisation
+}
+
+type UpdateSnackBundleRequest struct {
+	Name        *string           `json:"name,omitempty" binding:"omitempty,min=1"`
+	Description *string           `json:"description,omitempty"`
+	Price       *float64          `json:"price,omitempty" binding:"omitempty,gt=0"`
+	PerVisitor  *bool             `json:"per_visitor,omitempty"`
+	IsAvailable *bool             `json:"is_available,omitempty"`
+	Items       []SnackBundleItem `json:"items,omitempty" binding:"omitempty,min=1,dive"` // Replaces the contents
+}
+
+type SnackBundleListResponse struct {
+	Bundles []SnackBundle `json:"bundles"`
+}
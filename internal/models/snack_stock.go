@@ -0,0 +1,32 @@
+package models
+
+import (
+	"github.com/google/uuid"
+)
+
+// SnackStock is the stock of a snack on one day.
+type SnackStock struct {
+	SnackID           uuid.UUID `json:"snack_id"`
+	SnackName         string    `json:"snack_name"`
+	Date              string    `json:"date"` // YYYY-MM-DD
+	Quantity          int       `json:"quantity"`
+	Reserved          int       `json:"reserved"`
+	Remaining         int       `json:"remaining"`
+	LowStockThreshold int       `json:"low_stock_threshold"`
+}
+
+type RestockRequest struct {
+	Date     string `json:"date" binding:"required"` // Format: YYYY-MM-DD
+	Quantity int    `json:"quantity" binding:"required,min=1"`
+}
+
+type LowStockQuery struct {
+	From string `form:"from"`                                  // Format: YYYY-MM-DD, defaults to today
+	Days int    `form:"days,default=7" binding:"min=1,max=31"` // Number of days to check from From
+}
+
+type LowStockResponse struct {
+	From  string       `json:"from"`
+	To    string       `json:"to"`
+	Items []SnackStock `json:"items"`
+}
@@ -0,0 +1,48 @@
+package models
+
+import "time"
+
+// LoginChallengeResponse is returned by the password step when the account
+// must also pass two-factor authentication. The tokens are issued once the
+// challenge is answered with a TOTP or recovery code.
+type LoginChallengeResponse struct {
+	TwoFactorRequired  bool      `json:"two_factor_required"`
+	ChallengeToken     string    `json:"challenge_token"`
+	ExpiresAt          time.Time `json:"expires_at"`
+	EnrollmentRequired bool      `json:"enrollment_required"` // The role requires 2FA and the user has not enrolled yet
+}
+
+type TwoFactorLoginRequest struct {
+	ChallengeToken string `json:"challenge_token" binding:"required"`
+	Code           string `json:"code" binding:"required"` // TOTP code or recovery code
+}
+
+type TwoFactorEnrollChallengeRequest struct {
+	ChallengeToken string `json:"challenge_token" binding:"required"`
+}
+
+// TOTPEnrollmentResponse holds the secret of a new enrollment. ProvisioningURI
+// is the otpauth:// URI authenticator apps read from a QR code.
+type TOTPEnrollmentResponse struct {
+	Secret          string `json:"secret"`
+	ProvisioningURI string `json:"provisioning_uri"`
+}
+
+type TwoFactorCodeRequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
+// RecoveryCodesResponse lists new recovery codes; they are only shown once.
+type RecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+type TwoFactorStatusResponse struct {
+	Enabled                bool `json:"enabled"`
+	Required               bool `json:"required"` // By the user's role
+	RecoveryCodesRemaining int  `json:"recovery_codes_remaining"`
+}
+
+type RoleTwoFactorPolicyRequest struct {
+	Required *bool `json:"required" binding:"required"`
+}
@@ -8,23 +8,52 @@ import (
 	"github.com/google/uuid"
 )
 
+// User roles, stored in the user_role enum.
+const (
+	RoleAdmin        = "admin"
+	RoleUser         = "user"
+	RoleCatering     = "catering"
+	RoleReceptionist = "receptionist"
+	RoleSuperAdmin   = "super_admin" // Works across organisations
+)
+
+// IsValidRole reports whether role is one of the user_role values.
+func IsValidRole(role string) bool {
+	switch role {
+	case RoleAdmin, RoleUser, RoleCatering, RoleReceptionist, RoleSuperAdmin:
+		return true
+	}
+	return false
+}
+
+// User statuses. Self-registered users wait in pending_verification until
+// they open the link sent to their email address; suspended users cannot
+// sign in.
+const (
+	UserStatusActive              = "active"
+	UserStatusPendingVerification = "pending_verification"
+	UserStatusSuspended           = "suspended"
+)
+
 type User struct {
-	ID        uuid.UUID      `json:"id"`
-	Username  string         `json:"username"`
-	Email     sql.NullString `json:"email"`
-	Password  string         `json:"-"` // "-" means this field won't be included in JSON
-	Role      string         `json:"role"`
-	Language  sql.NullString `json:"language"`
-	ProfPic   sql.NullString `json:"profpic"`
-	Status    string         `json:"status"`
-	CreatedAt time.Time      `json:"created_at"`
-	UpdatedAt time.Time      `json:"updated_at"`
+	ID             uuid.UUID      `json:"id"`
+	OrganisationID uuid.UUID      `json:"organisation_id"`
+	Username       string         `json:"username"`
+	Email          sql.NullString `json:"email"`
+	Password       string         `json:"-"` // "-" means this field won't be included in JSON
+	Role           string         `json:"role"`
+	Language       sql.NullString `json:"language"`
+	ProfPic        sql.NullString `json:"profpic"`
+	Status         string         `json:"status"`
+	CreatedAt      time.Time      `json:"created_at"`
+	UpdatedAt      time.Time      `json:"updated_at"`
 }
 
 type UserResponse struct {
 	ID       uuid.UUID `json:"id"`
 	Username string    `json:"username"`
 	Role     string    `json:"role"`
+	Status   string    `json:"status,omitempty"`
 }
 
 type LoginRequest struct {
@@ -33,8 +62,21 @@ type LoginRequest struct {
 }
 
 type LoginResponse struct {
-	Token string       `json:"token"`
-	User  UserResponse `json:"user"`
+	Token            string       `json:"token"` // Short-lived access token
+	TokenType        string       `json:"token_type"`
+	ExpiresAt        time.Time    `json:"expires_at"`
+	RefreshToken     string       `json:"refresh_token"`
+	RefreshExpiresAt time.Time    `json:"refresh_expires_at"`
+	User             UserResponse `json:"user"`
+	RecoveryCodes    []string     `json:"recovery_codes,omitempty"` // Set when 2FA enrollment completed at login
+}
+
+type RefreshTokenRequest struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
+type LogoutRequest struct {
+	RefreshToken string `json:"refresh_token"` // Also ends the session this refresh token belongs to
 }
 
 type RegisterRequest struct {
@@ -54,8 +96,7 @@ type PasswordResetRequest struct {
 }
 
 type PasswordResetResponse struct {
-	Message   string `json:"message"`
-	ResetLink string `json:"reset_link"`
+	Message string `json:"message"`
 }
 
 type PasswordResetToken struct {
@@ -104,3 +145,7 @@ type Claims struct {
 	Role     string    `json:"role"`
 	jwt.RegisteredClaims
 }
+
+type UpdateUserRoleRequest struct {
+	Role string `json:"role" binding:"required,oneof=admin user catering receptionist super_admin"`
+}
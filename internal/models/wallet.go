@@ -0,0 +1,87 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type WalletTransactionType string
+
+const (
+	WalletTransactionTopUp   WalletTransactionType = "topup"
+	WalletTransactionHold    WalletTransactionType = "hold"
+	WalletTransactionCapture WalletTransactionType = "capture"
+	WalletTransactionRelease WalletTransactionType = "release"
+	WalletTransactionRefund  WalletTransactionType = "refund"
+)
+
+// Wallet holds prepaid booking credit of a user or a team. Balance is the
+// spendable amount; Held is reserved for reservations awaiting confirmation.
+type Wallet struct {
+	ID        uuid.UUID  `json:"id"`
+	UserID    *uuid.UUID `json:"user_id,omitempty"`
+	TeamID    *uuid.UUID `json:"team_id,omitempty"`
+	Currency  string     `json:"currency"`
+	Balance   float64    `json:"balance"`
+	Held      float64    `json:"held"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+}
+
+type WalletTransaction struct {
+	ID            uuid.UUID             `json:"id"`
+	WalletID      uuid.UUID             `json:"wallet_id"`
+	ReservationID *uuid.UUID            `json:"reservation_id,omitempty"`
+	Type          WalletTransactionType `json:"type"`
+	Amount        float64               `json:"amount"`
+	BalanceAfter  float64               `json:"balance_after"`
+	HeldAfter     float64               `json:"held_after"`
+	Description   string                `json:"description,omitempty"`
+	CreatedBy     *uuid.UUID            `json:"created_by,omitempty"`
+	CreatedAt     time.Time             `json:"created_at"`
+}
+
+type WalletListResponse struct {
+	Wallets []Wallet `json:"wallets"`
+}
+
+type WalletTransactionListResponse struct {
+	WalletID     uuid.UUID           `json:"wallet_id"`
+	Transactions []WalletTransaction `json:"transactions"`
+	TotalCount   int                 `json:"total_count"`
+	Page         int                 `json:"page"`
+	PageSize     int                 `json:"page_size"`
+	TotalPages   int                 `json:"total_pages"`
+}
+
+type CreateWalletRequest struct {
+	UserID *uuid.UUID `json:"user_id"`
+	TeamID *uuid.UUID `json:"team_id"`
+}
+
+type WalletTopUpRequest struct {
+	Amount      float64 `json:"amount" binding:"required,gt=0"`
+	Description string  `json:"description" binding:"max=255"`
+}
+
+type Team struct {
+	ID             uuid.UUID   `json:"id"`
+	OrganisationID uuid.UUID   `json:"organisation_id"`
+	Name           string      `json:"name"`
+	MemberIDs      []uuid.UUID `json:"member_ids"`
+	CreatedAt      time.Time   `json:"created_at"`
+	UpdatedAt      time.Time   `json:"updated_at"`
+}
+
+type CreateTeamRequest struct {
+	Name string `json:"name" binding:"required,max=255"`
+}
+
+type TeamMemberRequest struct {
+	UserID uuid.UUID `json:"user_id" binding:"required"`
+}
+
+type TeamListResponse struct {
+	Teams []Team `json:"teams"`
+}
@@ -0,0 +1,576 @@
+package services
+
+import (
+	"crypto/rand"
+	"database/sql"
+	"e-meetingproject/internal/auth"
+	"e-meetingproject/internal/database"
+	"e-meetingproject/internal/models"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+const (
+	// apiKeyPrefix starts every API key, so leaked keys are easy to spot
+	apiKeyPrefix = "emk_"
+
+	// serviceAccountEmailDomain gives service accounts the unique email
+	// address every user needs; the domain cannot receive mail
+	serviceAccountEmailDomain = "service-accounts.invalid"
+)
+
+// APIKeyService manages service accounts and their API keys, and
+// authenticates requests made with a key.
+type APIKeyService struct {
+	db          *sql.DB
+	permissions auth.PermissionChecker
+}
+
+func NewAPIKeyService(permissions auth.PermissionChecker) *APIKeyService {
+	return &APIKeyService{
+		db:          database.GetDB(),
+		permissions: permissions,
+	}
+}
+
+// AuthenticateAPIKey implements auth.APIKeyAuthenticator. A key works while
+// it is neither expired nor revoked and its service account is active. Every
+// use is counted.
+func (s *APIKeyService) AuthenticateAPIKey(key, ip string) (*auth.Claims, error) {
+	now := time.Now()
+
+	claims := &auth.Claims{}
+	var permissions pq.StringArray
+	err := s.db.QueryRow(`
+		SELECT k.id, k.permissions, k.room_ids, u.id, u.organisation_id, u.username, u.role
+		FROM api_keys k
+		JOIN users u ON k.user_id = u.id
+		WHERE k.key_hash = $1 AND k.revoked_at IS NULL AND (k.expires_at IS NULL OR k.expires_at > $2)
+			AND u.is_service_account AND u.status = $3
+	`, hashToken(key), now, models.UserStatusActive).Scan(
+		&claims.APIKeyID, &permissions, pq.Array(&claims.RoomIDs),
+		&claims.UserID, &claims.OrganisationID, &claims.Username, &claims.Role,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("error checking API key: %v", err)
+	}
+	claims.Permissions = permissions
+
+	// A failure to count the use should not fail the request
+	if err := s.recordUse(claims.APIKeyID, ip, now); err != nil {
+		fmt.Printf("Error recording API key use: %v\n", err)
+	}
+
+	return claims, nil
+}
+
+// recordUse counts a request made with a key, in total and per day.
+func (s *APIKeyService) recordUse(keyID uuid.UUID, ip string, now time.Time) error {
+	_, err := s.db.Exec(`
+		WITH used AS (
+			UPDATE api_keys
+			SET last_used_at = $2, last_used_ip = NULLIF($3, ''), use_count = use_count + 1
+			WHERE id = $1
+			RETURNING id
+		)
+		INSERT INTO api_key_usage (api_key_id, day, requests)
+		SELECT id, $4, 1 FROM used
+		ON CONFLICT (api_key_id, day) DO UPDATE SET requests = api_key_usage.requests + 1
+	`, keyID, now, ip, now.UTC().Format("2006-01-02"))
+	if err != nil {
+		return fmt.Errorf("error recording API key use: %v", err)
+	}
+	return nil
+}
+
+// GetServiceAccounts lists the service accounts of the tenant.
+func (s *APIKeyService) GetServiceAccounts(tenant Tenant) (*models.ServiceAccountListResponse, error) {
+	rows, err := s.db.Query(`
+		SELECT id, organisation_id, username, status, created_at
+		FROM users
+		WHERE is_service_account AND `+tenantCondition("organisation_id", 1)+`
+		ORDER BY username ASC
+	`, tenant.arg())
+	if err != nil {
+		return nil, fmt.Errorf("error querying service accounts: %v", err)
+	}
+	defer rows.Close()
+
+	accounts := []models.ServiceAccount{}
+	for rows.Next() {
+		var account models.ServiceAccount
+		err := rows.Scan(&account.ID, &account.OrganisationID, &account.Username, &account.Status, &account.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning service account: %v", err)
+		}
+		accounts = append(accounts, account)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating service accounts: %v", err)
+	}
+
+	return &models.ServiceAccountListResponse{
+		ServiceAccounts: accounts,
+	}, nil
+}
+
+// CreateServiceAccount creates a service account on behalf of the admin
+// actorID. It has no password, so it can only be used through API keys.
+func (s *APIKeyService) CreateServiceAccount(req *models.CreateServiceAccountRequest, actorID uuid.UUID, tenant Tenant) (*models.ServiceAccount, error) {
+	// Start transaction
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("error starting transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	organisationID, err := tenant.organisationFor(tx, req.OrganisationID)
+	if err != nil {
+		return nil, err
+	}
+
+	account := models.ServiceAccount{
+		ID:             uuid.New(),
+		OrganisationID: organisationID,
+		Username:       req.Username,
+		Status:         models.UserStatusActive,
+	}
+	err = tx.QueryRow(`
+		INSERT INTO users (id, organisation_id, username, email, password, role, status, is_service_account, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, '', $5, $6, TRUE, NOW(), NOW())
+		RETURNING created_at
+	`, account.ID, account.OrganisationID, account.Username,
+		fmt.Sprintf("%s@%s", account.ID, serviceAccountEmailDomain), models.RoleUser, account.Status,
+	).Scan(&account.CreatedAt)
+	if err != nil {
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code.Name() == "unique_violation" {
+			return nil, errors.New("username already exists")
+		}
+		return nil, fmt.Errorf("error creating service account: %v", err)
+	}
+
+	err = recordAuthEvent(tx, authAuditEntry{
+		Event:    models.AuthEventServiceAccountCreated,
+		UserID:   account.ID,
+		Username: account.Username,
+		ActorID:  actorID,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Commit transaction
+	if err = tx.Commit(); err != nil {
+		return nil, fmt.Errorf("error committing transaction: %v", err)
+	}
+
+	return &account, nil
+}
+
+// serviceAccountOrganisation returns the organisation of a service account
+// of the tenant.
+func serviceAccountOrganisation(q queryRower, accountID uuid.UUID, tenant Tenant) (uuid.UUID, error) {
+	var organisationID uuid.UUID
+	err := q.QueryRow(`
+		SELECT organisation_id FROM users WHERE id = $1 AND is_service_account
+	`, accountID).Scan(&organisationID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return uuid.Nil, errors.New("service account not found")
+		}
+		return uuid.Nil, fmt.Errorf("error fetching service account: %v", err)
+	}
+	if !tenant.canAccess(organisationID) {
+		return uuid.Nil, errors.New("service account not found")
+	}
+	return organisationID, nil
+}
+
+const apiKeyColumns = `id, user_id, name, prefix, permissions, room_ids, expires_at, revoked_at,
+	last_used_at, COALESCE(last_used_ip, ''), use_count, created_at`
+
+func scanAPIKey(row rowScanner) (*models.APIKey, error) {
+	var key models.APIKey
+	var permissions pq.StringArray
+	var expiresAt, revokedAt, lastUsedAt sql.NullTime
+	err := row.Scan(
+		&key.ID, &key.ServiceAccountID, &key.Name, &key.Prefix, &permissions, pq.Array(&key.RoomIDs),
+		&expiresAt, &revokedAt, &lastUsedAt, &key.LastUsedIP, &key.UseCount, &key.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	key.Permissions = permissions
+	if key.RoomIDs == nil {
+		key.RoomIDs = []uuid.UUID{}
+	}
+	if expiresAt.Valid {
+		key.ExpiresAt = &expiresAt.Time
+	}
+	if revokedAt.Valid {
+		key.RevokedAt = &revokedAt.Time
+	}
+	if lastUsedAt.Valid {
+		key.LastUsedAt = &lastUsedAt.Time
+	}
+	return &key, nil
+}
+
+// GetAPIKeys lists the keys of a service account, newest first, including
+// revoked and expired ones.
+func (s *APIKeyService) GetAPIKeys(accountID uuid.UUID, tenant Tenant) (*models.APIKeyListResponse, error) {
+	if _, err := serviceAccountOrganisation(s.db, accountID, tenant); err != nil {
+		return nil, err
+	}
+
+	rows, err := s.db.Query(`
+		SELECT `+apiKeyColumns+`
+		FROM api_keys
+		WHERE user_id = $1
+		ORDER BY created_at DESC
+	`, accountID)
+	if err != nil {
+		return nil, fmt.Errorf("error querying API keys: %v", err)
+	}
+	defer rows.Close()
+
+	keys := []models.APIKey{}
+	for rows.Next() {
+		key, err := scanAPIKey(rows)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning API key: %v", err)
+		}
+		keys = append(keys, *key)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating API keys: %v", err)
+	}
+
+	return &models.APIKeyListResponse{
+		APIKeys: keys,
+	}, nil
+}
+
+// CreateAPIKey creates a key for a service account on behalf of the admin
+// actorID, whose role actorRole must have every permission given to the key.
+func (s *APIKeyService) CreateAPIKey(accountID uuid.UUID, req *models.CreateAPIKeyRequest, actorID uuid.UUID, actorRole string, tenant Tenant) (*models.APIKeySecretResponse, error) {
+	now := time.Now()
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(now) {
+		return nil, errors.New("invalid expires_at: must be in the future")
+	}
+
+	permissions := uniqueStrings(req.Permissions)
+	for _, permission := range permissions {
+		allowed, err := s.permissions.HasPermission(actorRole, permission)
+		if err != nil {
+			return nil, err
+		}
+		if !allowed {
+			return nil, fmt.Errorf("cannot grant permission: %s", permission)
+		}
+	}
+
+	// Start transaction
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("error starting transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	organisationID, err := serviceAccountOrganisation(tx, accountID, tenant)
+	if err != nil {
+		return nil, err
+	}
+
+	roomIDs, err := checkAPIKeyRooms(tx, organisationID, req.RoomIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	response, err := insertAPIKey(tx, accountID, req.Name, permissions, roomIDs, req.ExpiresAt, actorID)
+	if err != nil {
+		return nil, err
+	}
+
+	err = recordAuthEvent(tx, authAuditEntry{
+		Event:   models.AuthEventAPIKeyCreated,
+		UserID:  accountID,
+		ActorID: actorID,
+		Detail:  fmt.Sprintf("%s (%s): %s", response.Name, response.Prefix, strings.Join(permissions, ", ")),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Commit transaction
+	if err = tx.Commit(); err != nil {
+		return nil, fmt.Errorf("error committing transaction: %v", err)
+	}
+
+	return response, nil
+}
+
+// checkAPIKeyRooms returns the distinct rooms of a key, which must all belong
+// to organisationID.
+func checkAPIKeyRooms(q queryRower, organisationID uuid.UUID, roomIDs []uuid.UUID) ([]uuid.UUID, error) {
+	seen := make(map[uuid.UUID]bool)
+	unique := []uuid.UUID{}
+	for _, id := range roomIDs {
+		if !seen[id] {
+			seen[id] = true
+			unique = append(unique, id)
+		}
+	}
+	if len(unique) == 0 {
+		return unique, nil
+	}
+
+	var found int
+	err := q.QueryRow(`
+		SELECT COUNT(*) FROM rooms WHERE id = ANY($1) AND organisation_id = $2
+	`, pq.Array(unique), organisationID).Scan(&found)
+	if err != nil {
+		return nil, fmt.Errorf("error checking rooms: %v", err)
+	}
+	if found != len(unique) {
+		return nil, errors.New("room not found")
+	}
+
+	return unique, nil
+}
+
+// insertAPIKey stores a new key of a service account and returns it with the
+// key itself.
+func insertAPIKey(tx *sql.Tx, accountID uuid.UUID, name string, permissions []string, roomIDs []uuid.UUID, expiresAt *time.Time, actorID uuid.UUID) (*models.APIKeySecretResponse, error) {
+	prefix, secret, err := generateAPIKey()
+	if err != nil {
+		return nil, fmt.Errorf("error generating API key: %v", err)
+	}
+	key := prefix + "_" + secret
+
+	created, err := scanAPIKey(tx.QueryRow(`
+		INSERT INTO api_keys (user_id, name, prefix, key_hash, permissions, room_ids, expires_at, created_by)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING `+apiKeyColumns,
+		accountID, name, prefix, hashToken(key), pq.Array(permissions), pq.Array(roomIDs), expiresAt, nullUUID(actorID),
+	))
+	if err != nil {
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code.Name() == "foreign_key_violation" {
+			return nil, errors.New("service account not found")
+		}
+		return nil, fmt.Errorf("error creating API key: %v", err)
+	}
+
+	return &models.APIKeySecretResponse{APIKey: *created, Key: key}, nil
+}
+
+// generateAPIKey returns the prefix identifying a new key and its secret.
+func generateAPIKey() (string, string, error) {
+	b := make([]byte, 4)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", err
+	}
+	secret, err := generateSecureToken()
+	if err != nil {
+		return "", "", err
+	}
+	return apiKeyPrefix + hex.EncodeToString(b), secret, nil
+}
+
+// RotateAPIKey replaces a key with a new one of the same name, permissions
+// and rooms on behalf of the admin actorID. The old key is revoked, or
+// expires after the grace period.
+func (s *APIKeyService) RotateAPIKey(id uuid.UUID, req *models.RotateAPIKeyRequest, actorID uuid.UUID, tenant Tenant) (*models.APIKeySecretResponse, error) {
+	now := time.Now()
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(now) {
+		return nil, errors.New("invalid expires_at: must be in the future")
+	}
+
+	// Start transaction
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("error starting transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	old, err := lockAPIKey(tx, id, tenant)
+	if err != nil {
+		return nil, err
+	}
+	if old.RevokedAt != nil || (old.ExpiresAt != nil && !old.ExpiresAt.After(now)) {
+		return nil, errors.New("api key is revoked or expired")
+	}
+
+	response, err := insertAPIKey(tx, old.ServiceAccountID, old.Name, old.Permissions, old.RoomIDs, req.ExpiresAt, actorID)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.GraceMinutes > 0 {
+		_, err = tx.Exec(`
+			UPDATE api_keys
+			SET expires_at = LEAST(COALESCE(expires_at, $2), $2), replaced_by = $3
+			WHERE id = $1
+		`, id, now.Add(time.Duration(req.GraceMinutes)*time.Minute), response.ID)
+	} else {
+		_, err = tx.Exec(`
+			UPDATE api_keys SET revoked_at = $2, replaced_by = $3 WHERE id = $1
+		`, id, now, response.ID)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error replacing API key: %v", err)
+	}
+
+	err = recordAuthEvent(tx, authAuditEntry{
+		Event:   models.AuthEventAPIKeyRotated,
+		UserID:  old.ServiceAccountID,
+		ActorID: actorID,
+		Detail:  fmt.Sprintf("%s: %s replaced by %s", old.Name, old.Prefix, response.Prefix),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Commit transaction
+	if err = tx.Commit(); err != nil {
+		return nil, fmt.Errorf("error committing transaction: %v", err)
+	}
+
+	return response, nil
+}
+
+// RevokeAPIKey stops a key from working at once, on behalf of the admin
+// actorID. Revoking a revoked key succeeds.
+func (s *APIKeyService) RevokeAPIKey(id, actorID uuid.UUID, tenant Tenant) error {
+	// Start transaction
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("error starting transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	key, err := lockAPIKey(tx, id, tenant)
+	if err != nil {
+		return err
+	}
+	if key.RevokedAt != nil {
+		return nil
+	}
+
+	_, err = tx.Exec(`UPDATE api_keys SET revoked_at = NOW() WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("error revoking API key: %v", err)
+	}
+
+	err = recordAuthEvent(tx, authAuditEntry{
+		Event:   models.AuthEventAPIKeyRevoked,
+		UserID:  key.ServiceAccountID,
+		ActorID: actorID,
+		Detail:  fmt.Sprintf("%s (%s)", key.Name, key.Prefix),
+	})
+	if err != nil {
+		return err
+	}
+
+	// Commit transaction
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("error committing transaction: %v", err)
+	}
+
+	return nil
+}
+
+// lockAPIKey returns a key of the tenant's service accounts, locked for
+// update.
+func lockAPIKey(tx *sql.Tx, id uuid.UUID, tenant Tenant) (*models.APIKey, error) {
+	key, err := scanAPIKey(tx.QueryRow(`
+		SELECT `+apiKeyColumns+`
+		FROM api_keys
+		WHERE id = $1
+		FOR UPDATE
+	`, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.New("api key not found")
+		}
+		return nil, fmt.Errorf("error fetching API key: %v", err)
+	}
+
+	if _, err := serviceAccountOrganisation(tx, key.ServiceAccountID, tenant); err != nil {
+		return nil, errors.New("api key not found")
+	}
+
+	return key, nil
+}
+
+// GetAPIKeyUsage returns the requests made with a key per day over the last
+// days (30 by default), oldest first. Days without requests are left out.
+func (s *APIKeyService) GetAPIKeyUsage(id uuid.UUID, query *models.APIKeyUsageQuery, tenant Tenant) (*models.APIKeyUsageResponse, error) {
+	days := 30
+	if query.Days > 0 {
+		days = query.Days
+	}
+
+	key, err := scanAPIKey(s.db.QueryRow(`SELECT `+apiKeyColumns+` FROM api_keys WHERE id = $1`, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.New("api key not found")
+		}
+		return nil, fmt.Errorf("error fetching API key: %v", err)
+	}
+	if _, err := serviceAccountOrganisation(s.db, key.ServiceAccountID, tenant); err != nil {
+		return nil, errors.New("api key not found")
+	}
+
+	since := time.Now().UTC().AddDate(0, 0, 1-days).Format("2006-01-02")
+	rows, err := s.db.Query(`
+		SELECT TO_CHAR(day, 'YYYY-MM-DD'), requests
+		FROM api_key_usage
+		WHERE api_key_id = $1 AND day >= $2
+		ORDER BY day ASC
+	`, id, since)
+	if err != nil {
+		return nil, fmt.Errorf("error querying API key usage: %v", err)
+	}
+	defer rows.Close()
+
+	response := &models.APIKeyUsageResponse{
+		APIKeyID:   key.ID,
+		UseCount:   key.UseCount,
+		LastUsedAt: key.LastUsedAt,
+		Days:       []models.APIKeyDailyUsage{},
+	}
+	for rows.Next() {
+		var usage models.APIKeyDailyUsage
+		if err := rows.Scan(&usage.Day, &usage.Requests); err != nil {
+			return nil, fmt.Errorf("error scanning API key usage: %v", err)
+		}
+		response.Days = append(response.Days, usage)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating API key usage: %v", err)
+	}
+
+	return response, nil
+}
+
+// uniqueStrings returns the distinct values, sorted.
+func uniqueStrings(values []string) []string {
+	set := make(map[string]bool, len(values))
+	for _, v := range values {
+		set[v] = true
+	}
+	return sortedKeys(set)
+}
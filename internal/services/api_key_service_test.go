@@ -0,0 +1,48 @@
+package services
+
+import (
+	"e-meetingproject/internal/auth"
+	"e-meetingproject/internal/models"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGenerateAPIKey(t *testing.T) {
+	prefix, secret, err := generateAPIKey()
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(prefix, apiKeyPrefix))
+	assert.Len(t, prefix, len(apiKeyPrefix)+8)
+	assert.NotEmpty(t, secret)
+
+	other, _, err := generateAPIKey()
+	assert.NoError(t, err)
+	assert.NotEqual(t, prefix, other)
+}
+
+func TestUniqueStrings(t *testing.T) {
+	assert.Equal(t, []string{"room.manage", "snack.manage"}, uniqueStrings([]string{"snack.manage", "room.manage", "snack.manage"}))
+	assert.Equal(t, []string{}, uniqueStrings(nil))
+}
+
+func TestTenantOfAPIKey(t *testing.T) {
+	roomID := uuid.New()
+	claims := &auth.Claims{
+		Role:           models.RoleUser,
+		OrganisationID: uuid.New(),
+		APIKeyID:       uuid.New(),
+		Permissions:    []string{models.PermissionReservationView},
+		RoomIDs:        []uuid.UUID{roomID},
+	}
+
+	tenant := TenantOf(claims)
+	assert.Equal(t, []uuid.UUID{roomID}, tenant.RoomIDs)
+	assert.NotNil(t, tenant.roomsArg())
+	assert.True(t, claims.KeyAllows(models.PermissionReservationView))
+	assert.False(t, claims.KeyAllows(models.PermissionReservationApprove))
+
+	assert.Nil(t, Tenant{}.roomsArg())
+	assert.Equal(t, "($2::uuid[] IS NULL OR rm.id = ANY($2))", roomCondition("rm.id", 2))
+}
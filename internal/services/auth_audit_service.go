@@ -0,0 +1,153 @@
+package services
+
+import (
+	"database/sql"
+	"e-meetingproject/internal/database"
+	"e-meetingproject/internal/models"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type execer interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+}
+
+// authAuditEntry is one event for the auth audit log. UserID and ActorID are
+// left out when uuid.Nil.
+type authAuditEntry struct {
+	Event    string
+	UserID   uuid.UUID
+	Username string
+	IP       string
+	ActorID  uuid.UUID
+	Detail   string
+}
+
+// recordAuthEvent writes entry to the auth audit log.
+func recordAuthEvent(e execer, entry authAuditEntry) error {
+	_, err := e.Exec(`
+		INSERT INTO auth_audit_log (event, user_id, username, ip, actor_id, detail)
+		VALUES ($1, $2, NULLIF($3, ''), NULLIF($4, ''), $5, NULLIF($6, ''))
+	`, entry.Event, nullUUID(entry.UserID), entry.Username, entry.IP, nullUUID(entry.ActorID), entry.Detail)
+	if err != nil {
+		return fmt.Errorf("error recording auth event: %v", err)
+	}
+	return nil
+}
+
+func nullUUID(id uuid.UUID) uuid.NullUUID {
+	return uuid.NullUUID{UUID: id, Valid: id != uuid.Nil}
+}
+
+// AuthAuditService lists the auth audit log for admins.
+type AuthAuditService struct {
+	db *sql.DB
+}
+
+func NewAuthAuditService() *AuthAuditService {
+	return &AuthAuditService{
+		db: database.GetDB(),
+	}
+}
+
+// GetEvents lists auth events, newest first. Admins of one organisation only
+// see the events of its users.
+func (s *AuthAuditService) GetEvents(query *models.AuthAuditQuery, tenant Tenant) (*models.AuthAuditResponse, error) {
+	// Set default pagination values
+	page := 1
+	pageSize := 20
+	if query.Page > 0 {
+		page = query.Page
+	}
+	if query.PageSize > 0 {
+		pageSize = query.PageSize
+	}
+
+	var conditions []string
+	var args []interface{}
+	addCondition := func(condition string, arg interface{}) {
+		args = append(args, arg)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+
+	if !tenant.AllOrganisations {
+		addCondition("user_id IN (SELECT id FROM users WHERE organisation_id = $%d)", tenant.OrganisationID)
+	}
+	if query.UserID != "" {
+		addCondition("user_id = $%d", query.UserID)
+	}
+	if query.Event != "" {
+		addCondition("event = $%d", query.Event)
+	}
+	if query.IP != "" {
+		addCondition("ip = $%d", query.IP)
+	}
+	if query.From != "" {
+		from, err := time.Parse("2006-01-02", query.From)
+		if err != nil {
+			return nil, fmt.Errorf("invalid from date format (required: YYYY-MM-DD): %v", err)
+		}
+		addCondition("created_at >= $%d", from)
+	}
+	if query.To != "" {
+		to, err := time.Parse("2006-01-02", query.To)
+		if err != nil {
+			return nil, fmt.Errorf("invalid to date format (required: YYYY-MM-DD): %v", err)
+		}
+		addCondition("created_at < $%d", to.AddDate(0, 0, 1))
+	}
+
+	where := ""
+	if len(conditions) > 0 {
+		where = "WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	var totalItems int
+	err := s.db.QueryRow(`SELECT COUNT(*) FROM auth_audit_log `+where, args...).Scan(&totalItems)
+	if err != nil {
+		return nil, fmt.Errorf("error counting auth events: %v", err)
+	}
+
+	args = append(args, pageSize, (page-1)*pageSize)
+	rows, err := s.db.Query(fmt.Sprintf(`
+		SELECT id, event, user_id, COALESCE(username, ''), COALESCE(ip, ''), actor_id, COALESCE(detail, ''), created_at
+		FROM auth_audit_log
+		%s
+		ORDER BY created_at DESC
+		LIMIT $%d OFFSET $%d
+	`, where, len(args)-1, len(args)), args...)
+	if err != nil {
+		return nil, fmt.Errorf("error querying auth events: %v", err)
+	}
+	defer rows.Close()
+
+	events := []models.AuthAuditEvent{}
+	for rows.Next() {
+		var event models.AuthAuditEvent
+		var userID, actorID uuid.NullUUID
+		if err := rows.Scan(&event.ID, &event.Event, &userID, &event.Username, &event.IP, &actorID, &event.Detail, &event.CreatedAt); err != nil {
+			return nil, fmt.Errorf("error scanning auth event: %v", err)
+		}
+		if userID.Valid {
+			event.UserID = &userID.UUID
+		}
+		if actorID.Valid {
+			event.ActorID = &actorID.UUID
+		}
+		events = append(events, event)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating auth events: %v", err)
+	}
+
+	return &models.AuthAuditResponse{
+		Events:     events,
+		Page:       page,
+		PageSize:   pageSize,
+		TotalItems: totalItems,
+		TotalPages: (totalItems + pageSize - 1) / pageSize,
+	}, nil
+}
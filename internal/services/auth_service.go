@@ -2,13 +2,18 @@ package services
 
 import (
 	"crypto/rand"
+	"crypto/sha256"
 	"database/sql"
 	"e-meetingproject/internal/auth"
 	"e-meetingproject/internal/database"
+	"e-meetingproject/internal/mail"
 	"e-meetingproject/internal/models"
 	"encoding/base64"
+	"encoding/hex"
 	"errors"
 	"fmt"
+	"net/url"
+	"strings"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
@@ -18,13 +23,66 @@ import (
 	"golang.org/x/crypto/bcrypt"
 )
 
+// Password logins of two-factor accounts wait this long for the second factor
+const (
+	loginChallengeTTL         = 5 * time.Minute
+	maxLoginChallengeAttempts = 5
+)
+
 type AuthService struct {
-	db *sql.DB
+	db              *sql.DB
+	keys            *auth.KeySet
+	authenticators  []Authenticator
+	throttle        loginThrottle
+	totpIssuer      string
+	totpKey         *TOTPKey
+	accessTokenTTL  time.Duration
+	refreshTokenTTL time.Duration
+
+	verificationTTL            time.Duration
+	verificationResendInterval time.Duration
 }
 
-func NewAuthService() *AuthService {
+func NewAuthService(keys *auth.KeySet, totpKey *TOTPKey) *AuthService {
+	accessMinutes := viper.GetInt("JWT_ACCESS_TOKEN_MINUTES")
+	if accessMinutes == 0 {
+		accessMinutes = 15 // default to 15 minutes
+	}
+	refreshHours := viper.GetInt("REFRESH_TOKEN_TTL_HOURS")
+	if refreshHours == 0 {
+		refreshHours = 30 * 24 // default to 30 days
+	}
+	verificationHours := viper.GetInt("EMAIL_VERIFICATION_TTL_HOURS")
+	if verificationHours == 0 {
+		verificationHours = 24 // default to 1 day
+	}
+	resendSeconds := viper.GetInt("EMAIL_VERIFICATION_RESEND_SECONDS")
+	if resendSeconds == 0 {
+		resendSeconds = 60 // default to 1 minute
+	}
+
+	db := database.GetDB()
+
+	// Directory users sign in first; local accounts, such as the seeded
+	// admin, remain as a fallback
+	var authenticators []Authenticator
+	if viper.GetString("LDAP_URL") != "" {
+		authenticators = append(authenticators, NewLDAPAuthenticator(db, newLDAPConfig()))
+	}
+	authenticators = append(authenticators, NewLocalAuthenticator(db))
+
 	return &AuthService{
-		db: database.GetDB(),
+		db:              db,
+		keys:            keys,
+		authenticators:  authenticators,
+		throttle:        newLoginThrottle(),
+		totpIssuer:      totpIssuer(),
+		totpKey:         totpKey,
+		accessTokenTTL:  time.Duration(accessMinutes) * time.Minute,
+		refreshTokenTTL: time.Duration(refreshHours) * time.Hour,
+
+		verificationTTL:            time.Duration(verificationHours) * time.Hour,
+		verificationResendInterval: time.Duration(resendSeconds) * time.Second,
 	}
 }
 
@@ -43,18 +101,19 @@ func (s *AuthService) Register(req *models.RegisterRequest) (*models.RegisterRes
 	defer tx.Rollback()
 
 	// Insert new user
+	now := time.Now()
 	var userID uuid.UUID
 	err = tx.QueryRow(`
-		INSERT INTO users (id, username, email, password, role, status, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $7)
+		INSERT INTO users (id, username, email, password, role, status, verification_sent_at, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $7, $7)
 		RETURNING id`,
 		uuid.New(), // Generate new UUID
 		req.Username,
 		req.Email,
 		hashedPassword,
-		"user",   // default role
-		"active", // default status
-		time.Now(),
+		"user", // default role
+		models.UserStatusPendingVerification,
+		now,
 	).Scan(&userID)
 
 	if err != nil {
@@ -72,69 +131,712 @@ func (s *AuthService) Register(req *models.RegisterRequest) (*models.RegisterRes
 		return nil, fmt.Errorf("error creating user: %v", err)
 	}
 
+	// The account stays pending until the link in this email is opened
+	if err := s.queueVerificationEmail(tx, userID, req.Username, req.Email, "", now); err != nil {
+		return nil, err
+	}
+
 	// Commit transaction
 	if err = tx.Commit(); err != nil {
 		return nil, fmt.Errorf("error committing transaction: %v", err)
 	}
 
 	return &models.RegisterResponse{
-		Message: "User registered successfully",
+		Message: "User registered successfully, check your email to verify your address",
 		UserID:  userID,
 	}, nil
 }
 
-func (s *AuthService) Login(username, password string) (*models.LoginResponse, error) {
-	var user models.User
-	err := s.db.QueryRow(`
-		SELECT id, username, password, role 
-		FROM users 
-		WHERE username = $1
-	`, username).Scan(&user.ID, &user.Username, &user.Password, &user.Role)
+// Login checks the password. Users with two-factor authentication, or whose
+// role requires it, get a challenge instead of tokens, to be answered with
+// VerifyTwoFactorLogin. Repeated wrong passwords for an account or from
+// clientIP are throttled with a *models.TooManyAttemptsError.
+func (s *AuthService) Login(username, password, clientIP string) (*models.LoginResponse, *models.LoginChallengeResponse, error) {
+	now := time.Now()
+	if err := s.throttle.check(s.db, username, clientIP, now); err != nil {
+		var tooMany *models.TooManyAttemptsError
+		if errors.As(err, &tooMany) {
+			s.auditLogin(authAuditEntry{
+				Event:    models.AuthEventLoginThrottled,
+				Username: username,
+				IP:       clientIP,
+			})
+		}
+		return nil, nil, err
+	}
+
+	user, err := s.authenticate(username, password)
+	if err != nil {
+		if err.Error() == "invalid credentials" {
+			if recordErr := s.throttle.recordFailure(s.db, username, clientIP, models.AuthEventLoginFailed, now); recordErr != nil {
+				return nil, nil, recordErr
+			}
+		}
+		return nil, nil, err
+	}
+
+	// Start transaction
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, nil, fmt.Errorf("error starting transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	tokenVersion, err := lockLoginUser(tx, user)
+	if err != nil {
+		if err.Error() == "account is not active" {
+			s.auditLogin(authAuditEntry{
+				Event:    models.AuthEventLoginRefused,
+				UserID:   user.ID,
+				Username: user.Username,
+				IP:       clientIP,
+			})
+		}
+		return nil, nil, err
+	}
+
+	enabled, required, err := twoFactorState(tx, user.ID, user.Role)
+	if err != nil {
+		return nil, nil, err
+	}
+	if enabled || required {
+		challenge, err := createLoginChallenge(tx, user.ID, now)
+		if err != nil {
+			return nil, nil, err
+		}
+		challenge.EnrollmentRequired = !enabled
+
+		err = recordAuthEvent(tx, authAuditEntry{
+			Event:    models.AuthEventTwoFactorChallenged,
+			UserID:   user.ID,
+			Username: user.Username,
+			IP:       clientIP,
+		})
+		if err != nil {
+			return nil, nil, err
+		}
+
+		// Commit transaction
+		if err = tx.Commit(); err != nil {
+			return nil, nil, fmt.Errorf("error committing transaction: %v", err)
+		}
+		return nil, challenge, nil
+	}
+
+	// The password was right and no second factor is needed, so the account
+	// starts over; with a second factor it does once that is answered
+	if err := s.throttle.reset(tx, user.ID); err != nil {
+		return nil, nil, err
+	}
+
+	// Every login starts a new refresh token family
+	response, _, err := s.issueTokens(tx, user, tokenVersion, uuid.New())
+	if err != nil {
+		return nil, nil, err
+	}
+
+	err = recordAuthEvent(tx, authAuditEntry{
+		Event:    models.AuthEventLoginSucceeded,
+		UserID:   user.ID,
+		Username: user.Username,
+		IP:       clientIP,
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Commit transaction
+	if err = tx.Commit(); err != nil {
+		return nil, nil, fmt.Errorf("error committing transaction: %v", err)
+	}
+
+	return response, nil, nil
+}
+
+// VerifyTwoFactorLogin completes a login with a TOTP or recovery code. For a
+// user who had to enroll at login the code confirms the enrollment, and the
+// response carries the new recovery codes. Wrong codes are throttled like
+// wrong passwords, against the account and clientIP.
+func (s *AuthService) VerifyTwoFactorLogin(req *models.TwoFactorLoginRequest, clientIP string) (*models.LoginResponse, error) {
+	now := time.Now()
+
+	// Start transaction
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("error starting transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	challengeID, user, tokenVersion, err := lockLoginChallenge(tx, req.ChallengeToken, now)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.throttle.check(tx, user.Username, clientIP, now); err != nil {
+		var tooMany *models.TooManyAttemptsError
+		if errors.As(err, &tooMany) {
+			s.auditLogin(authAuditEntry{
+				Event:    models.AuthEventLoginThrottled,
+				UserID:   user.ID,
+				Username: user.Username,
+				IP:       clientIP,
+			})
+		}
+		return nil, err
+	}
+
+	enabled, _, err := twoFactorState(tx, user.ID, user.Role)
+	if err != nil {
+		return nil, err
+	}
+
+	var recoveryCodes []string
+	if enabled {
+		err = checkSecondFactor(tx, s.totpKey, user.ID, req.Code, now)
+	} else {
+		recoveryCodes, err = confirmTOTPEnrollment(tx, s.totpKey, user.ID, req.Code, now)
+	}
+	if err != nil {
+		if err.Error() != "invalid two-factor code" {
+			return nil, err
+		}
+		// Count the failed attempt; too many end the challenge
+		if _, updateErr := tx.Exec(`
+			UPDATE login_challenges SET attempts = attempts + 1 WHERE id = $1
+		`, challengeID); updateErr != nil {
+			return nil, fmt.Errorf("error updating challenge: %v", updateErr)
+		}
+		if countErr := s.throttle.countFailure(tx, user.Username, clientIP, models.AuthEventTwoFactorFailed, now); countErr != nil {
+			return nil, countErr
+		}
+		if commitErr := tx.Commit(); commitErr != nil {
+			return nil, fmt.Errorf("error committing transaction: %v", commitErr)
+		}
+		return nil, err
+	}
 
+	_, err = tx.Exec(`DELETE FROM login_challenges WHERE id = $1`, challengeID)
+	if err != nil {
+		return nil, fmt.Errorf("error deleting challenge: %v", err)
+	}
+
+	// Both factors were right, so the account starts over
+	if err := s.throttle.reset(tx, user.ID); err != nil {
+		return nil, err
+	}
+
+	response, _, err := s.issueTokens(tx, user, tokenVersion, uuid.New())
+	if err != nil {
+		return nil, err
+	}
+	response.RecoveryCodes = recoveryCodes
+
+	err = recordAuthEvent(tx, authAuditEntry{
+		Event:    models.AuthEventLoginSucceeded,
+		UserID:   user.ID,
+		Username: user.Username,
+		IP:       clientIP,
+		Detail:   "two-factor",
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Commit transaction
+	if err = tx.Commit(); err != nil {
+		return nil, fmt.Errorf("error committing transaction: %v", err)
+	}
+
+	return response, nil
+}
+
+// BeginChallengeEnrollment starts the TOTP enrollment of a user whose role
+// requires two-factor authentication, during login.
+func (s *AuthService) BeginChallengeEnrollment(challengeToken string) (*models.TOTPEnrollmentResponse, error) {
+	// Start transaction
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("error starting transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	_, user, _, err := lockLoginChallenge(tx, challengeToken, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	response, err := beginTOTPEnrollment(tx, s.totpKey, user.ID, user.Username, s.totpIssuer)
+	if err != nil {
+		return nil, err
+	}
+
+	// Commit transaction
+	if err = tx.Commit(); err != nil {
+		return nil, fmt.Errorf("error committing transaction: %v", err)
+	}
+
+	return response, nil
+}
+
+// authenticate tries the authenticators in order until one knows the user. A
+// source that fails is skipped, so local accounts can still sign in while the
+// directory is unavailable.
+func (s *AuthService) authenticate(username, password string) (*models.User, error) {
+	for _, authenticator := range s.authenticators {
+		user, err := authenticator.Authenticate(username, password)
+		switch {
+		case err == nil:
+			return user, nil
+		case errors.Is(err, errUnknownUser):
+			continue
+		case err.Error() == "invalid credentials", errors.Is(err, errLinkRefused):
+			return nil, err
+		default:
+			fmt.Printf("Error authenticating with %s: %v\n", authenticator.Name(), err)
+		}
+	}
+
+	return nil, errors.New("invalid credentials")
+}
+
+// RefreshToken exchanges a refresh token for a new access token and a new
+// refresh token. The presented token is used up; presenting it again revokes
+// every token issued from the same login.
+func (s *AuthService) RefreshToken(refreshToken string) (*models.LoginResponse, error) {
+	// Start transaction
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("error starting transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	var user models.User
+	var tokenID, familyID uuid.UUID
+	var tokenVersion int
+	var expiresAt time.Time
+	var revokedAt sql.NullTime
+	err = tx.QueryRow(`
+		SELECT rt.id, rt.family_id, rt.expires_at, rt.revoked_at,
+			u.id, u.username, u.role, u.status, u.token_version, u.organisation_id
+		FROM refresh_tokens rt
+		JOIN users u ON rt.user_id = u.id
+		WHERE rt.token_hash = $1
+		FOR UPDATE OF rt
+	`, hashToken(refreshToken)).Scan(
+		&tokenID, &familyID, &expiresAt, &revokedAt,
+		&user.ID, &user.Username, &user.Role, &user.Status, &tokenVersion, &user.OrganisationID,
+	)
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return nil, fmt.Errorf("invalid credentials")
+			return nil, errors.New("invalid refresh token")
 		}
 		return nil, fmt.Errorf("database error: %v", err)
 	}
 
-	// Compare passwords
-	err = bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password))
+	if !canLogin(user.Status) {
+		return nil, errors.New("invalid refresh token")
+	}
+
+	if err := checkRefreshToken(revokedAt, expiresAt, time.Now()); err != nil {
+		if revokedAt.Valid {
+			// A used token came back: assume it was stolen and end the session
+			if revokeErr := revokeRefreshTokenFamily(tx, familyID); revokeErr != nil {
+				return nil, revokeErr
+			}
+			if commitErr := tx.Commit(); commitErr != nil {
+				return nil, fmt.Errorf("error committing transaction: %v", commitErr)
+			}
+		}
+		return nil, err
+	}
+
+	response, newTokenID, err := s.issueTokens(tx, &user, tokenVersion, familyID)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = tx.Exec(`
+		UPDATE refresh_tokens
+		SET revoked_at = NOW(), replaced_by = $1
+		WHERE id = $2
+	`, newTokenID, tokenID)
+	if err != nil {
+		return nil, fmt.Errorf("error rotating refresh token: %v", err)
+	}
+
+	// Commit transaction
+	if err = tx.Commit(); err != nil {
+		return nil, fmt.Errorf("error committing transaction: %v", err)
+	}
+
+	return response, nil
+}
+
+// Logout revokes the access token in claims and, when given, the session of
+// the refresh token.
+func (s *AuthService) Logout(claims *auth.Claims, refreshToken string) error {
+	// Start transaction
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("error starting transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	if jti, err := uuid.Parse(claims.ID); err == nil && claims.ExpiresAt != nil {
+		_, err = tx.Exec(`
+			INSERT INTO revoked_tokens (jti, user_id, expires_at)
+			VALUES ($1, $2, $3)
+			ON CONFLICT (jti) DO NOTHING
+		`, jti, claims.UserID, claims.ExpiresAt.Time)
+		if err != nil {
+			return fmt.Errorf("error revoking access token: %v", err)
+		}
+	}
+
+	// Revoked access tokens are only needed until they expire
+	_, err = tx.Exec(`DELETE FROM revoked_tokens WHERE expires_at < NOW()`)
+	if err != nil {
+		return fmt.Errorf("error purging revoked tokens: %v", err)
+	}
+
+	if refreshToken != "" {
+		var familyID uuid.UUID
+		err = tx.QueryRow(`
+			SELECT family_id
+			FROM refresh_tokens
+			WHERE token_hash = $1 AND user_id = $2
+		`, hashToken(refreshToken), claims.UserID).Scan(&familyID)
+		if err != nil && err != sql.ErrNoRows {
+			return fmt.Errorf("database error: %v", err)
+		}
+		if err == nil {
+			if err := revokeRefreshTokenFamily(tx, familyID); err != nil {
+				return err
+			}
+		}
+	}
+
+	// Commit transaction
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("error committing transaction: %v", err)
+	}
+
+	return nil
+}
+
+// JWKS returns the public keys access tokens can be verified with.
+func (s *AuthService) JWKS() auth.JWKS {
+	return s.keys.JWKS()
+}
+
+// RevokeUserSessions ends every session of a user on behalf of the admin
+// actorID: access tokens issued so far stop working and refresh tokens can no
+// longer be used.
+func (s *AuthService) RevokeUserSessions(userID, actorID uuid.UUID, tenant Tenant) error {
+	// Start transaction
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("error starting transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	if err := checkUserTenant(tx, userID, tenant); err != nil {
+		return err
+	}
+
+	if err := revokeUserSessions(tx, userID); err != nil {
+		return err
+	}
+
+	err = recordAuthEvent(tx, authAuditEntry{
+		Event:   models.AuthEventSessionsRevoked,
+		UserID:  userID,
+		ActorID: actorID,
+	})
 	if err != nil {
-		return nil, fmt.Errorf("invalid credentials")
+		return err
 	}
 
+	// Commit transaction
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("error committing transaction: %v", err)
+	}
+
+	return nil
+}
+
+// IsRevoked implements auth.RevocationChecker. A token is revoked when its
+// jti was revoked on logout or the user's token version moved on.
+func (s *AuthService) IsRevoked(claims *auth.Claims) (bool, error) {
+	var jti uuid.NullUUID
+	if id, err := uuid.Parse(claims.ID); err == nil {
+		jti = uuid.NullUUID{UUID: id, Valid: true}
+	}
+
+	var revoked bool
+	err := s.db.QueryRow(`
+		SELECT u.token_version <> $2 OR EXISTS (SELECT 1 FROM revoked_tokens WHERE jti = $3)
+		FROM users u
+		WHERE u.id = $1
+	`, claims.UserID, claims.TokenVersion, jti).Scan(&revoked)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return true, nil // the user no longer exists
+		}
+		return false, fmt.Errorf("error checking token revocation: %v", err)
+	}
+
+	return revoked, nil
+}
+
+// issueTokens signs a new access token for user and stores a new refresh
+// token of the given family. It returns the ID of the stored refresh token.
+func (s *AuthService) issueTokens(tx *sql.Tx, user *models.User, tokenVersion int, familyID uuid.UUID) (*models.LoginResponse, uuid.UUID, error) {
+	now := time.Now()
+
 	// Create claims
 	claims := &auth.Claims{
-		UserID:   user.ID,
-		Username: user.Username,
-		Role:     user.Role,
+		UserID:         user.ID,
+		OrganisationID: user.OrganisationID,
+		Username:       user.Username,
+		Role:           user.Role,
+		TokenVersion:   tokenVersion,
 		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(24 * time.Hour)),
-			IssuedAt:  jwt.NewNumericDate(time.Now()),
+			ID:        uuid.NewString(),
+			ExpiresAt: jwt.NewNumericDate(now.Add(s.accessTokenTTL)),
+			IssuedAt:  jwt.NewNumericDate(now),
 		},
 	}
 
-	// Create token
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	// Sign with the active key of the key set
+	tokenString, err := s.keys.Sign(claims)
+	if err != nil {
+		return nil, uuid.Nil, fmt.Errorf("error creating token: %v", err)
+	}
 
-	// Sign and get the complete encoded token as a string
-	tokenString, err := token.SignedString([]byte(viper.GetString("JWT_SECRET_KEY")))
+	refreshToken, err := generateSecureToken()
 	if err != nil {
-		return nil, fmt.Errorf("error creating token: %v", err)
+		return nil, uuid.Nil, fmt.Errorf("error generating token: %v", err)
+	}
+	refreshExpiresAt := now.Add(s.refreshTokenTTL)
+
+	var refreshTokenID uuid.UUID
+	err = tx.QueryRow(`
+		INSERT INTO refresh_tokens (user_id, family_id, token_hash, expires_at)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id
+	`, user.ID, familyID, hashToken(refreshToken), refreshExpiresAt).Scan(&refreshTokenID)
+	if err != nil {
+		return nil, uuid.Nil, fmt.Errorf("error storing refresh token: %v", err)
 	}
 
 	return &models.LoginResponse{
-		Token: tokenString,
+		Token:            tokenString,
+		TokenType:        "Bearer",
+		ExpiresAt:        claims.ExpiresAt.Time,
+		RefreshToken:     refreshToken,
+		RefreshExpiresAt: refreshExpiresAt,
 		User: models.UserResponse{
 			ID:       user.ID,
 			Username: user.Username,
 			Role:     user.Role,
+			Status:   user.Status,
 		},
+	}, refreshTokenID, nil
+}
+
+func createLoginChallenge(tx *sql.Tx, userID uuid.UUID, now time.Time) (*models.LoginChallengeResponse, error) {
+	// Drop challenges that were never answered
+	_, err := tx.Exec(`DELETE FROM login_challenges WHERE expires_at < $1`, now)
+	if err != nil {
+		return nil, fmt.Errorf("error purging challenges: %v", err)
+	}
+
+	token, err := generateSecureToken()
+	if err != nil {
+		return nil, fmt.Errorf("error generating token: %v", err)
+	}
+	expiresAt := now.Add(loginChallengeTTL)
+
+	_, err = tx.Exec(`
+		INSERT INTO login_challenges (user_id, token_hash, expires_at)
+		VALUES ($1, $2, $3)
+	`, userID, hashToken(token), expiresAt)
+	if err != nil {
+		return nil, fmt.Errorf("error storing challenge: %v", err)
+	}
+
+	return &models.LoginChallengeResponse{
+		TwoFactorRequired: true,
+		ChallengeToken:    token,
+		ExpiresAt:         expiresAt,
 	}, nil
 }
 
-func generateResetToken() (string, error) {
+// lockLoginUser locks the user's row for a login, sets the user's status and
+// organisation and returns the token version. Only active users and users
+// who have yet to verify their email address may sign in.
+func lockLoginUser(tx *sql.Tx, user *models.User) (int, error) {
+	var tokenVersion int
+	err := tx.QueryRow(`
+		SELECT token_version, status, organisation_id FROM users WHERE id = $1 FOR UPDATE
+	`, user.ID).Scan(&tokenVersion, &user.Status, &user.OrganisationID)
+	if err != nil {
+		return 0, fmt.Errorf("database error: %v", err)
+	}
+	if !canLogin(user.Status) {
+		return 0, errors.New("account is not active")
+	}
+	return tokenVersion, nil
+}
+
+func canLogin(status string) bool {
+	return status == models.UserStatusActive || status == models.UserStatusPendingVerification
+}
+
+// auditLogin records an event outside the login transaction, which is rolled
+// back when the login is refused. Failures are only logged.
+func (s *AuthService) auditLogin(entry authAuditEntry) {
+	if err := recordAuthEvent(s.db, entry); err != nil {
+		fmt.Printf("Error recording %s: %v\n", entry.Event, err)
+	}
+}
+
+// UnlockUser clears the failed logins and lockout of a user, on behalf of
+// the admin actorID.
+func (s *AuthService) UnlockUser(userID, actorID uuid.UUID, tenant Tenant) error {
+	// Start transaction
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("error starting transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	if err := checkUserTenant(tx, userID, tenant); err != nil {
+		return err
+	}
+
+	var username string
+	err = tx.QueryRow(`
+		UPDATE users
+		SET failed_login_count = 0, last_failed_login_at = NULL, locked_until = NULL, updated_at = NOW()
+		WHERE id = $1
+		RETURNING username
+	`, userID).Scan(&username)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return errors.New("user not found")
+		}
+		return fmt.Errorf("error unlocking user: %v", err)
+	}
+
+	err = recordAuthEvent(tx, authAuditEntry{
+		Event:    models.AuthEventAccountUnlocked,
+		UserID:   userID,
+		Username: username,
+		ActorID:  actorID,
+	})
+	if err != nil {
+		return err
+	}
+
+	// Commit transaction
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("error committing transaction: %v", err)
+	}
+
+	return nil
+}
+
+// lockLoginChallenge returns the open challenge of token with its user.
+func lockLoginChallenge(tx *sql.Tx, token string, now time.Time) (uuid.UUID, *models.User, int, error) {
+	var challengeID uuid.UUID
+	var attempts, tokenVersion int
+	var expiresAt time.Time
+	var user models.User
+	err := tx.QueryRow(`
+		SELECT c.id, c.attempts, c.expires_at, u.id, u.username, u.role, u.status, u.token_version, u.organisation_id
+		FROM login_challenges c
+		JOIN users u ON c.user_id = u.id
+		WHERE c.token_hash = $1
+		FOR UPDATE OF c
+	`, hashToken(token)).Scan(&challengeID, &attempts, &expiresAt, &user.ID, &user.Username, &user.Role, &user.Status, &tokenVersion, &user.OrganisationID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return uuid.Nil, nil, 0, errors.New("invalid or expired challenge")
+		}
+		return uuid.Nil, nil, 0, fmt.Errorf("database error: %v", err)
+	}
+	if now.After(expiresAt) || attempts >= maxLoginChallengeAttempts || !canLogin(user.Status) {
+		return uuid.Nil, nil, 0, errors.New("invalid or expired challenge")
+	}
+
+	return challengeID, &user, tokenVersion, nil
+}
+
+// checkRefreshToken rejects refresh tokens that were already used or revoked,
+// or have expired.
+func checkRefreshToken(revokedAt sql.NullTime, expiresAt, now time.Time) error {
+	if revokedAt.Valid {
+		return errors.New("invalid refresh token")
+	}
+	if now.After(expiresAt) {
+		return errors.New("refresh token has expired")
+	}
+	return nil
+}
+
+// hashToken is the form in which opaque tokens are stored.
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func revokeRefreshTokenFamily(tx *sql.Tx, familyID uuid.UUID) error {
+	_, err := tx.Exec(`
+		UPDATE refresh_tokens
+		SET revoked_at = NOW()
+		WHERE family_id = $1 AND revoked_at IS NULL
+	`, familyID)
+	if err != nil {
+		return fmt.Errorf("error revoking refresh tokens: %v", err)
+	}
+	return nil
+}
+
+// revokeUserSessions bumps the user's token version and revokes all of the
+// user's refresh tokens within tx.
+func revokeUserSessions(tx *sql.Tx, userID uuid.UUID) error {
+	result, err := tx.Exec(`
+		UPDATE users
+		SET token_version = token_version + 1, updated_at = NOW()
+		WHERE id = $1
+	`, userID)
+	if err != nil {
+		return fmt.Errorf("error revoking access tokens: %v", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("error getting rows affected: %v", err)
+	}
+	if rowsAffected == 0 {
+		return errors.New("user not found")
+	}
+
+	_, err = tx.Exec(`
+		UPDATE refresh_tokens
+		SET revoked_at = NOW()
+		WHERE user_id = $1 AND revoked_at IS NULL
+	`, userID)
+	if err != nil {
+		return fmt.Errorf("error revoking refresh tokens: %v", err)
+	}
+
+	return nil
+}
+
+func generateSecureToken() (string, error) {
 	b := make([]byte, 32)
 	_, err := rand.Read(b)
 	if err != nil {
@@ -143,26 +845,27 @@ func generateResetToken() (string, error) {
 	return base64.URLEncoding.EncodeToString(b), nil
 }
 
+// RequestPasswordReset emails a reset link to the user with email. The
+// response is the same whether or not the address is registered.
 func (s *AuthService) RequestPasswordReset(email string) (*models.PasswordResetResponse, error) {
+	// For security reasons, don't reveal if email exists
+	response := &models.PasswordResetResponse{
+		Message: "If your email is registered, you will receive a password reset link",
+	}
+
 	// Check if user exists
 	var userID uuid.UUID
-	err := s.db.QueryRow("SELECT id FROM users WHERE email = $1 AND status = 'active'", email).Scan(&userID)
+	var username, language string
+	err := s.db.QueryRow(`
+		SELECT id, username, COALESCE(language, '') FROM users WHERE email = $1 AND status = 'active'
+	`, email).Scan(&userID, &username, &language)
 	if err != nil {
 		if err == sql.ErrNoRows {
-			// For security reasons, don't reveal if email exists
-			return &models.PasswordResetResponse{
-				Message: "If your email is registered, you will receive a password reset link",
-			}, nil
+			return response, nil
 		}
 		return nil, fmt.Errorf("database error: %v", err)
 	}
 
-	// Generate reset token
-	token, err := generateResetToken()
-	if err != nil {
-		return nil, fmt.Errorf("error generating token: %v", err)
-	}
-
 	// Start transaction
 	tx, err := s.db.Begin()
 	if err != nil {
@@ -170,6 +873,27 @@ func (s *AuthService) RequestPasswordReset(email string) (*models.PasswordResetR
 	}
 	defer tx.Rollback()
 
+	if err := queuePasswordReset(tx, userID, username, email, language); err != nil {
+		return nil, err
+	}
+
+	// Commit transaction
+	if err = tx.Commit(); err != nil {
+		return nil, fmt.Errorf("error committing transaction: %v", err)
+	}
+
+	return response, nil
+}
+
+// queuePasswordReset replaces the user's reset token with a new one and
+// queues an email with its link; the link only goes to the user's mailbox.
+func queuePasswordReset(tx *sql.Tx, userID uuid.UUID, username, email, language string) error {
+	// Generate reset token
+	token, err := generateSecureToken()
+	if err != nil {
+		return fmt.Errorf("error generating token: %v", err)
+	}
+
 	// Invalidate any existing unused tokens for this user
 	_, err = tx.Exec(`
 		UPDATE password_reset_tokens 
@@ -177,7 +901,7 @@ func (s *AuthService) RequestPasswordReset(email string) (*models.PasswordResetR
 		WHERE user_id = $1 AND used = false`,
 		userID)
 	if err != nil {
-		return nil, fmt.Errorf("error invalidating existing tokens: %v", err)
+		return fmt.Errorf("error invalidating existing tokens: %v", err)
 	}
 
 	// Get expiration time from environment variable
@@ -193,27 +917,28 @@ func (s *AuthService) RequestPasswordReset(email string) (*models.PasswordResetR
 		VALUES ($1, $2, $3)`,
 		userID, token, expiresAt)
 	if err != nil {
-		return nil, fmt.Errorf("error storing reset token: %v", err)
+		return fmt.Errorf("error storing reset token: %v", err)
 	}
 
-	// Commit transaction
-	if err = tx.Commit(); err != nil {
-		return nil, fmt.Errorf("error committing transaction: %v", err)
-	}
+	return enqueueEmail(tx, email, mail.TemplatePasswordReset, language, map[string]interface{}{
+		"Username":         username,
+		"Link":             tokenLink(linkBaseURL(), "/password/reset", token),
+		"ExpiresInMinutes": expirationMinutes,
+	})
+}
+
+// tokenLink is the link at path of baseURL that carries token as its query.
+func tokenLink(baseURL, path, token string) string {
+	return strings.TrimRight(baseURL, "/") + path + "?" + url.Values{"token": {token}}.Encode()
+}
 
-	// Generate reset link using base URL from environment
+// linkBaseURL is the address links sent to users start with.
+func linkBaseURL() string {
 	baseURL := viper.GetString("RESET_LINK_BASE_URL")
 	if baseURL == "" {
 		baseURL = "http://localhost:8080" // default fallback
 	}
-	resetLink := fmt.Sprintf("%s/password/reset?token=%s", baseURL, token)
-
-	// In a real application, you would send this link via email
-	// For now, we'll include it in the response for testing purposes
-	return &models.PasswordResetResponse{
-		Message:   "Password reset link has been generated",
-		ResetLink: resetLink,
-	}, nil
+	return baseURL
 }
 
 func (s *AuthService) ResetPassword(req *models.PasswordResetConfirmRequest) (*models.PasswordResetConfirmResponse, error) {
@@ -264,6 +989,11 @@ func (s *AuthService) ResetPassword(req *models.PasswordResetConfirmRequest) (*m
 		return nil, fmt.Errorf("error updating password: %v", err)
 	}
 
+	// A new password ends every existing session
+	if err := revokeUserSessions(tx, resetToken.UserID); err != nil {
+		return nil, err
+	}
+
 	// Mark token as used
 	_, err = tx.Exec(`
 		UPDATE password_reset_tokens 
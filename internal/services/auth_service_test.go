@@ -0,0 +1,82 @@
+package services
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckRefreshToken(t *testing.T) {
+	now := time.Date(2025, 3, 10, 9, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name          string
+		revokedAt     sql.NullTime
+		expiresAt     time.Time
+		expectedError string
+	}{
+		{name: "Active token", expiresAt: now.Add(time.Hour)},
+		{
+			name:          "Already rotated or revoked",
+			revokedAt:     sql.NullTime{Time: now.Add(-time.Minute), Valid: true},
+			expiresAt:     now.Add(time.Hour),
+			expectedError: "invalid refresh token",
+		},
+		{
+			name:          "Revoked and expired reports reuse",
+			revokedAt:     sql.NullTime{Time: now.Add(-2 * time.Hour), Valid: true},
+			expiresAt:     now.Add(-time.Hour),
+			expectedError: "invalid refresh token",
+		},
+		{name: "Expired", expiresAt: now.Add(-time.Second), expectedError: "refresh token has expired"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkRefreshToken(tt.revokedAt, tt.expiresAt, now)
+			if tt.expectedError != "" {
+				assert.EqualError(t, err, tt.expectedError)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestHashToken(t *testing.T) {
+	hash := hashToken("refresh-token")
+
+	assert.Len(t, hash, 64)
+	assert.Equal(t, hash, hashToken("refresh-token"))
+	assert.NotEqual(t, hash, hashToken("other-token"))
+}
+
+func TestTokenLink(t *testing.T) {
+	tests := []struct {
+		name     string
+		baseURL  string
+		token    string
+		expected string
+	}{
+		{
+			name:     "URL-safe token",
+			baseURL:  "https://meet.example.com",
+			token:    "abc-DEF_123",
+			expected: "https://meet.example.com/password/reset?token=abc-DEF_123",
+		},
+		{
+			name:     "Token with reserved characters",
+			baseURL:  "https://meet.example.com/",
+			token:    "a+b/c=d&admin=1#x",
+			expected: "https://meet.example.com/password/reset?token=a%2Bb%2Fc%3Dd%26admin%3D1%23x",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, tokenLink(tt.baseURL, "/password/reset", tt.token))
+		})
+	}
+}
@@ -0,0 +1,66 @@
+package services
+
+import (
+	"database/sql"
+	"e-meetingproject/internal/models"
+	"errors"
+	"fmt"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// errUnknownUser is returned by an Authenticator that does not know the
+// username, so that the next one is tried.
+var errUnknownUser = errors.New("unknown user")
+
+// Authenticator checks a username and password against one identity source
+// and returns the matching local user. AuthService.Login tries its
+// authenticators in order until one knows the user.
+type Authenticator interface {
+	// Name identifies the source in logs.
+	Name() string
+
+	// Authenticate returns errUnknownUser when the source has no such user
+	// and an "invalid credentials" error when the password is wrong.
+	Authenticate(username, password string) (*models.User, error)
+}
+
+// LocalAuthenticator checks passwords against the bcrypt hashes in
+// users.password.
+type LocalAuthenticator struct {
+	db *sql.DB
+}
+
+func NewLocalAuthenticator(db *sql.DB) *LocalAuthenticator {
+	return &LocalAuthenticator{
+		db: db,
+	}
+}
+
+func (a *LocalAuthenticator) Name() string {
+	return "local"
+}
+
+func (a *LocalAuthenticator) Authenticate(username, password string) (*models.User, error) {
+	var user models.User
+	err := a.db.QueryRow(`
+		SELECT id, username, password, role
+		FROM users 
+		WHERE username = $1
+	`, username).Scan(&user.ID, &user.Username, &user.Password, &user.Role)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errUnknownUser
+		}
+		return nil, fmt.Errorf("database error: %v", err)
+	}
+
+	// Compare passwords; externally managed users have no local password
+	err = bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password))
+	if err != nil {
+		return nil, errors.New("invalid credentials")
+	}
+
+	return &user, nil
+}
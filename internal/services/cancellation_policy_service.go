@@ -0,0 +1,434 @@
+package services
+
+import (
+	"database/sql"
+	"e-meetingproject/internal/database"
+	"e-meetingproject/internal/models"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+type CancellationPolicyService struct {
+	db *sql.DB
+}
+
+func NewCancellationPolicyService() *CancellationPolicyService {
+	return &CancellationPolicyService{
+		db: database.GetDB(),
+	}
+}
+
+// GetPolicies lists the cancellation policies of the tenant's organisation.
+func (s *CancellationPolicyService) GetPolicies(tenant Tenant) (*models.CancellationPolicyListResponse, error) {
+	// Start transaction
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("error starting transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.Query(`
+		SELECT id, organisation_id, name, is_default, created_at, updated_at
+		FROM cancellation_policies
+		WHERE `+tenantCondition("organisation_id", 1)+`
+		ORDER BY organisation_id, is_default DESC, name ASC
+	`, tenant.arg())
+	if err != nil {
+		return nil, fmt.Errorf("error querying cancellation policies: %v", err)
+	}
+	defer rows.Close()
+
+	var policies []models.CancellationPolicy
+	for rows.Next() {
+		var policy models.CancellationPolicy
+		err := rows.Scan(&policy.ID, &policy.OrganisationID, &policy.Name, &policy.IsDefault, &policy.CreatedAt, &policy.UpdatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning cancellation policy: %v", err)
+		}
+		policies = append(policies, policy)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating cancellation policies: %v", err)
+	}
+
+	// Attach rules to each policy
+	for i := range policies {
+		policies[i].Rules, err = getCancellationPolicyRules(tx, policies[i].ID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Commit transaction
+	if err = tx.Commit(); err != nil {
+		return nil, fmt.Errorf("error committing transaction: %v", err)
+	}
+
+	return &models.CancellationPolicyListResponse{
+		Policies: policies,
+	}, nil
+}
+
+// CreatePolicy creates a cancellation policy of the tenant's organisation, or
+// for a super admin of the requested one.
+func (s *CancellationPolicyService) CreatePolicy(req *models.CancellationPolicyRequest, tenant Tenant) (*models.CancellationPolicy, error) {
+	if err := validateCancellationPolicyRules(req.Rules); err != nil {
+		return nil, err
+	}
+
+	// Start transaction
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("error starting transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	organisationID, err := tenant.organisationFor(tx, req.OrganisationID)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.IsDefault {
+		if err := clearDefaultCancellationPolicy(tx, organisationID); err != nil {
+			return nil, err
+		}
+	}
+
+	policy := models.CancellationPolicy{
+		OrganisationID: organisationID,
+		Name:           req.Name,
+		IsDefault:      req.IsDefault,
+	}
+	err = tx.QueryRow(`
+		INSERT INTO cancellation_policies (organisation_id, name, is_default)
+		VALUES ($1, $2, $3)
+		RETURNING id, created_at, updated_at
+	`, organisationID, req.Name, req.IsDefault).Scan(&policy.ID, &policy.CreatedAt, &policy.UpdatedAt)
+	if err != nil {
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code.Name() == "unique_violation" {
+			return nil, fmt.Errorf("cancellation policy name already exists")
+		}
+		return nil, fmt.Errorf("error creating cancellation policy: %v", err)
+	}
+
+	policy.Rules, err = replaceCancellationPolicyRules(tx, policy.ID, req.Rules)
+	if err != nil {
+		return nil, err
+	}
+
+	// Commit transaction
+	if err = tx.Commit(); err != nil {
+		return nil, fmt.Errorf("error committing transaction: %v", err)
+	}
+
+	return &policy, nil
+}
+
+// UpdatePolicy replaces a cancellation policy of the tenant. The policy stays
+// with its organisation.
+func (s *CancellationPolicyService) UpdatePolicy(id uuid.UUID, req *models.CancellationPolicyRequest, tenant Tenant) (*models.CancellationPolicy, error) {
+	if err := validateCancellationPolicyRules(req.Rules); err != nil {
+		return nil, err
+	}
+
+	// Start transaction
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("error starting transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	organisationID, err := lockCancellationPolicy(tx, id, tenant)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.IsDefault {
+		if err := clearDefaultCancellationPolicy(tx, organisationID); err != nil {
+			return nil, err
+		}
+	}
+
+	policy := models.CancellationPolicy{
+		ID:             id,
+		OrganisationID: organisationID,
+		Name:           req.Name,
+		IsDefault:      req.IsDefault,
+	}
+	err = tx.QueryRow(`
+		UPDATE cancellation_policies
+		SET name = $1, is_default = $2, updated_at = NOW()
+		WHERE id = $3
+		RETURNING created_at, updated_at
+	`, req.Name, req.IsDefault, id).Scan(&policy.CreatedAt, &policy.UpdatedAt)
+	if err != nil {
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code.Name() == "unique_violation" {
+			return nil, fmt.Errorf("cancellation policy name already exists")
+		}
+		return nil, fmt.Errorf("error updating cancellation policy: %v", err)
+	}
+
+	policy.Rules, err = replaceCancellationPolicyRules(tx, id, req.Rules)
+	if err != nil {
+		return nil, err
+	}
+
+	// Commit transaction
+	if err = tx.Commit(); err != nil {
+		return nil, fmt.Errorf("error committing transaction: %v", err)
+	}
+
+	return &policy, nil
+}
+
+// AssignRoomPolicy sets the cancellation policy of a room to one of the room's
+// organisation. A nil policy makes the room fall back to its organisation's
+// default policy.
+func (s *CancellationPolicyService) AssignRoomPolicy(roomID uuid.UUID, req *models.AssignRoomPolicyRequest, tenant Tenant) error {
+	// Start transaction
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("error starting transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	var organisationID uuid.UUID
+	err = tx.QueryRow(`
+		SELECT organisation_id FROM rooms
+		WHERE id = $1 AND `+tenantCondition("organisation_id", 2)+` AND `+roomCondition("id", 3)+`
+		FOR UPDATE`,
+		roomID, tenant.arg(), tenant.roomsArg(),
+	).Scan(&organisationID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return fmt.Errorf("room not found")
+		}
+		return fmt.Errorf("error fetching room: %v", err)
+	}
+
+	if req.PolicyID != nil {
+		var exists bool
+		err := tx.QueryRow(`
+			SELECT EXISTS(SELECT 1 FROM cancellation_policies WHERE id = $1 AND organisation_id = $2)
+		`, *req.PolicyID, organisationID).Scan(&exists)
+		if err != nil {
+			return fmt.Errorf("error checking cancellation policy: %v", err)
+		}
+		if !exists {
+			return fmt.Errorf("cancellation policy not found")
+		}
+	}
+
+	_, err = tx.Exec(`
+		UPDATE rooms
+		SET cancellation_policy_id = $1, updated_at = NOW()
+		WHERE id = $2`,
+		req.PolicyID, roomID,
+	)
+	if err != nil {
+		return fmt.Errorf("error assigning cancellation policy: %v", err)
+	}
+
+	// Commit transaction
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("error committing transaction: %v", err)
+	}
+
+	return nil
+}
+
+// lockCancellationPolicy locks a policy of the tenant and returns its
+// organisation.
+func lockCancellationPolicy(tx *sql.Tx, id uuid.UUID, tenant Tenant) (uuid.UUID, error) {
+	var organisationID uuid.UUID
+	err := tx.QueryRow(`
+		SELECT organisation_id FROM cancellation_policies
+		WHERE id = $1 AND `+tenantCondition("organisation_id", 2)+`
+		FOR UPDATE`,
+		id, tenant.arg(),
+	).Scan(&organisationID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return uuid.Nil, fmt.Errorf("cancellation policy not found")
+		}
+		return uuid.Nil, fmt.Errorf("error fetching cancellation policy: %v", err)
+	}
+	return organisationID, nil
+}
+
+// maxMinHoursBefore is the largest min_hours_before the rules table stores.
+const maxMinHoursBefore = 999999.99
+
+// validateCancellationPolicyRules checks the rules a policy is saved with,
+// returning a *models.ValidationError naming each rejected field.
+func validateCancellationPolicyRules(rules []models.CancellationPolicyRule) error {
+	var fieldErrors []models.FieldError
+	seen := make(map[float64]int, len(rules))
+	for i, rule := range rules {
+		field := fmt.Sprintf("rules[%d].", i)
+		switch {
+		case rule.MinHoursBefore < 0:
+			fieldErrors = append(fieldErrors, models.FieldError{Field: field + "min_hours_before", Message: "must not be negative"})
+		case rule.MinHoursBefore > maxMinHoursBefore:
+			fieldErrors = append(fieldErrors, models.FieldError{Field: field + "min_hours_before", Message: fmt.Sprintf("must be at most %v", maxMinHoursBefore)})
+		default:
+			if first, ok := seen[rule.MinHoursBefore]; ok {
+				fieldErrors = append(fieldErrors, models.FieldError{
+					Field:   field + "min_hours_before",
+					Message: fmt.Sprintf("duplicate of rules[%d]", first),
+				})
+			} else {
+				seen[rule.MinHoursBefore] = i
+			}
+		}
+
+		if rule.FeePercent < 0 || rule.FeePercent > 100 {
+			fieldErrors = append(fieldErrors, models.FieldError{Field: field + "fee_percent", Message: "must be between 0 and 100"})
+		}
+	}
+
+	if len(fieldErrors) > 0 {
+		return &models.ValidationError{Fields: fieldErrors}
+	}
+	return nil
+}
+
+// clearDefaultCancellationPolicy unsets the default policy of an organisation.
+func clearDefaultCancellationPolicy(tx *sql.Tx, organisationID uuid.UUID) error {
+	_, err := tx.Exec(`
+		UPDATE cancellation_policies SET is_default = false, updated_at = NOW()
+		WHERE is_default AND organisation_id = $1
+	`, organisationID)
+	if err != nil {
+		return fmt.Errorf("error clearing default cancellation policy: %v", err)
+	}
+	return nil
+}
+
+func replaceCancellationPolicyRules(tx *sql.Tx, policyID uuid.UUID, rules []models.CancellationPolicyRule) ([]models.CancellationPolicyRule, error) {
+	_, err := tx.Exec(`DELETE FROM cancellation_policy_rules WHERE policy_id = $1`, policyID)
+	if err != nil {
+		return nil, fmt.Errorf("error removing cancellation policy rules: %v", err)
+	}
+
+	for _, rule := range rules {
+		_, err = tx.Exec(`
+			INSERT INTO cancellation_policy_rules (policy_id, min_hours_before, fee_percent)
+			VALUES ($1, $2, $3)
+		`, policyID, rule.MinHoursBefore, rule.FeePercent)
+		if err != nil {
+			return nil, fmt.Errorf("error creating cancellation policy rule: %v", err)
+		}
+	}
+
+	return getCancellationPolicyRules(tx, policyID)
+}
+
+func getCancellationPolicyRules(tx *sql.Tx, policyID uuid.UUID) ([]models.CancellationPolicyRule, error) {
+	rows, err := tx.Query(`
+		SELECT min_hours_before, fee_percent
+		FROM cancellation_policy_rules
+		WHERE policy_id = $1
+		ORDER BY min_hours_before DESC
+	`, policyID)
+	if err != nil {
+		return nil, fmt.Errorf("error querying cancellation policy rules: %v", err)
+	}
+	defer rows.Close()
+
+	var rules []models.CancellationPolicyRule
+	for rows.Next() {
+		var rule models.CancellationPolicyRule
+		if err := rows.Scan(&rule.MinHoursBefore, &rule.FeePercent); err != nil {
+			return nil, fmt.Errorf("error scanning cancellation policy rule: %v", err)
+		}
+		rules = append(rules, rule)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating cancellation policy rules: %v", err)
+	}
+
+	return rules, nil
+}
+
+// cancellationFeePercent picks the rule with the largest MinHoursBefore that is
+// still satisfied. Cancelling after the start counts as zero hours before it,
+// and no matching rule means free cancellation.
+func cancellationFeePercent(rules []models.CancellationPolicyRule, hoursBefore float64) float64 {
+	hoursBefore = math.Max(hoursBefore, 0)
+
+	matched := -1.0
+	feePercent := 0.0
+	for _, rule := range rules {
+		if rule.MinHoursBefore <= hoursBefore && rule.MinHoursBefore > matched {
+			matched = rule.MinHoursBefore
+			feePercent = rule.FeePercent
+		}
+	}
+	return feePercent
+}
+
+// quoteCancellation evaluates the cancellation policy of a reservation as of now.
+// The room's policy is used when set, otherwise the default policy of the
+// room's organisation.
+func quoteCancellation(tx *sql.Tx, reservationID uuid.UUID, now time.Time) (*models.CancellationQuote, error) {
+	quote := models.CancellationQuote{
+		ReservationID: reservationID,
+		EvaluatedAt:   now,
+	}
+
+	var startTime time.Time
+	var policyID uuid.NullUUID
+	var policyName sql.NullString
+	err := tx.QueryRow(`
+		SELECT r.start_time, r.price, p.id, p.name
+		FROM reservations r
+		JOIN rooms rm ON r.room_id = rm.id
+		LEFT JOIN cancellation_policies p ON p.id = COALESCE(
+			rm.cancellation_policy_id,
+			(SELECT id FROM cancellation_policies WHERE is_default AND organisation_id = rm.organisation_id)
+		)
+		WHERE r.id = $1
+	`, reservationID).Scan(&startTime, &quote.Price, &policyID, &policyName)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("reservation not found")
+		}
+		return nil, fmt.Errorf("error fetching reservation: %v", err)
+	}
+
+	quote.HoursBeforeStart = math.Round(startTime.Sub(now).Hours()*100) / 100
+	if policyID.Valid {
+		quote.PolicyID = &policyID.UUID
+		quote.PolicyName = policyName.String
+
+		rules, err := getCancellationPolicyRules(tx, policyID.UUID)
+		if err != nil {
+			return nil, err
+		}
+		quote.FeePercent = cancellationFeePercent(rules, startTime.Sub(now).Hours())
+	}
+
+	quote.AmountPaid, err = succeededPaymentTotal(tx, reservationID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Captured wallet funds count as paid; funds still held are released instead
+	_, captured, err := reservationWalletFunds(tx, reservationID)
+	if err != nil {
+		return nil, err
+	}
+	quote.AmountPaid = roundCurrency(quote.AmountPaid + captured)
+
+	quote.CancellationFee = roundCurrency(quote.Price * quote.FeePercent / 100)
+	quote.RefundAmount = roundCurrency(math.Max(quote.AmountPaid-quote.CancellationFee, 0))
+
+	return &quote, nil
+}
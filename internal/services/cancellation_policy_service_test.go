@@ -0,0 +1,126 @@
+package services
+
+import (
+	"database/sql/driver"
+	"e-meetingproject/internal/models"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCancellationFeePercent(t *testing.T) {
+	standard := []models.CancellationPolicyRule{
+		{MinHoursBefore: 48, FeePercent: 0},
+		{MinHoursBefore: 24, FeePercent: 50},
+		{MinHoursBefore: 0, FeePercent: 100},
+	}
+
+	tests := []struct {
+		name        string
+		rules       []models.CancellationPolicyRule
+		hoursBefore float64
+		expected    float64
+	}{
+		{name: "More than 48 hours before", rules: standard, hoursBefore: 72, expected: 0},
+		{name: "Exactly 48 hours before", rules: standard, hoursBefore: 48, expected: 0},
+		{name: "Between 24 and 48 hours before", rules: standard, hoursBefore: 30, expected: 50},
+		{name: "Less than 24 hours before", rules: standard, hoursBefore: 2, expected: 100},
+		{name: "After the reservation started", rules: standard, hoursBefore: -1, expected: 100},
+		{
+			name:        "Rules in any order",
+			rules:       []models.CancellationPolicyRule{standard[2], standard[0], standard[1]},
+			hoursBefore: 30,
+			expected:    50,
+		},
+		{
+			name:        "No matching rule is free",
+			rules:       []models.CancellationPolicyRule{{MinHoursBefore: 12, FeePercent: 25}},
+			hoursBefore: 6,
+			expected:    0,
+		},
+		{name: "No rules", rules: nil, hoursBefore: 1, expected: 0},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, cancellationFeePercent(tc.rules, tc.hoursBefore))
+		})
+	}
+}
+
+func TestValidateCancellationPolicyRules(t *testing.T) {
+	tests := []struct {
+		name     string
+		rules    []models.CancellationPolicyRule
+		expected []models.FieldError
+	}{
+		{
+			name:  "Valid",
+			rules: []models.CancellationPolicyRule{{MinHoursBefore: 48, FeePercent: 0}, {MinHoursBefore: 24, FeePercent: 50}, {MinHoursBefore: 0, FeePercent: 100}},
+		},
+		{
+			name:     "Negative hours",
+			rules:    []models.CancellationPolicyRule{{MinHoursBefore: -1, FeePercent: 50}},
+			expected: []models.FieldError{{Field: "rules[0].min_hours_before", Message: "must not be negative"}},
+		},
+		{
+			name:     "Hours too large to store",
+			rules:    []models.CancellationPolicyRule{{MinHoursBefore: 1e6, FeePercent: 50}},
+			expected: []models.FieldError{{Field: "rules[0].min_hours_before", Message: "must be at most 999999.99"}},
+		},
+		{
+			name:  "Fee out of range",
+			rules: []models.CancellationPolicyRule{{MinHoursBefore: 24, FeePercent: 150}, {MinHoursBefore: 0, FeePercent: -10}},
+			expected: []models.FieldError{
+				{Field: "rules[0].fee_percent", Message: "must be between 0 and 100"},
+				{Field: "rules[1].fee_percent", Message: "must be between 0 and 100"},
+			},
+		},
+		{
+			name:     "Duplicate hours",
+			rules:    []models.CancellationPolicyRule{{MinHoursBefore: 24, FeePercent: 50}, {MinHoursBefore: 48}, {MinHoursBefore: 24, FeePercent: 100}},
+			expected: []models.FieldError{{Field: "rules[2].min_hours_before", Message: "duplicate of rules[0]"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateCancellationPolicyRules(tt.rules)
+			if tt.expected == nil {
+				assert.NoError(t, err)
+				return
+			}
+			assert.Equal(t, &models.ValidationError{Fields: tt.expected}, err)
+		})
+	}
+}
+
+func TestGetPoliciesTenant(t *testing.T) {
+	organisationID := uuid.New()
+
+	tests := []struct {
+		name        string
+		tenant      Tenant
+		expectedOrg driver.Value
+	}{
+		{name: "Admin sees the policies of their organisation", tenant: Tenant{OrganisationID: organisationID}, expectedOrg: organisationID.String()},
+		{name: "Super admin sees every organisation", tenant: Tenant{OrganisationID: organisationID, AllOrganisations: true}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, recorder := openRecordingDB(t)
+			service := &CancellationPolicyService{db: db}
+
+			response, err := service.GetPolicies(tt.tenant)
+			require.NoError(t, err)
+			assert.Empty(t, response.Policies)
+
+			assert.Contains(t, recorder.query, tenantCondition("organisation_id", 1))
+			require.Len(t, recorder.args, 1)
+			assert.Equal(t, tt.expectedOrg, recorder.args[0])
+		})
+	}
+}
@@ -0,0 +1,207 @@
+package services
+
+import (
+	"database/sql"
+	"e-meetingproject/internal/database"
+	"e-meetingproject/internal/models"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type CateringService struct {
+	db *sql.DB
+}
+
+func NewCateringService() *CateringService {
+	return &CateringService{
+		db: database.GetDB(),
+	}
+}
+
+// prepLine is a confirmed snack order with the delivery details of its reservation.
+type prepLine struct {
+	models.PrepOrder
+	DeliveryTime time.Time
+	RoomID       uuid.UUID
+	RoomName     string
+}
+
+// GetPrepSheet lists the snack orders of confirmed reservations served on the
+// given day (YYYY-MM-DD), grouped by delivery time and room. Lines without a
+// serve time are delivered at the start of the reservation.
+func (s *CateringService) GetPrepSheet(date string, tenant Tenant) (*models.PrepSheet, error) {
+	dayStart, err := time.Parse("2006-01-02", date)
+	if err != nil {
+		return nil, fmt.Errorf("invalid date format (required: YYYY-MM-DD): %v", err)
+	}
+	dayEnd := dayStart.AddDate(0, 0, 1)
+
+	rows, err := s.db.Query(`
+		SELECT
+			rs.id, r.id, s.id, s.name, s.category, rs.quantity, r.visitor_count, rs.prep_status,
+			COALESCE(rs.serve_at, r.start_time), rm.id, rm.name
+		FROM reservation_snacks rs
+		JOIN reservations r ON rs.reservation_id = r.id
+		JOIN rooms rm ON r.room_id = rm.id
+		JOIN snacks s ON rs.snack_id = s.id
+		WHERE r.status = 'confirmed'
+			AND COALESCE(rs.serve_at, r.start_time) >= $1
+			AND COALESCE(rs.serve_at, r.start_time) < $2
+			AND `+tenantCondition("rm.organisation_id", 3)+`
+		ORDER BY COALESCE(rs.serve_at, r.start_time), rm.name, s.category, s.name`,
+		dayStart, dayEnd, tenant.arg(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error querying snack orders: %v", err)
+	}
+	defer rows.Close()
+
+	var lines []prepLine
+	for rows.Next() {
+		var line prepLine
+		err := rows.Scan(
+			&line.OrderID,
+			&line.ReservationID,
+			&line.SnackID,
+			&line.SnackName,
+			&line.Category,
+			&line.Quantity,
+			&line.VisitorCount,
+			&line.Status,
+			&line.DeliveryTime,
+			&line.RoomID,
+			&line.RoomName,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning snack order: %v", err)
+		}
+		lines = append(lines, line)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating snack orders: %v", err)
+	}
+
+	sheet := buildPrepSheet(date, lines)
+	sheet.GeneratedAt = time.Now()
+	return sheet, nil
+}
+
+// UpdatePrepStatus moves a snack order forward on the kitchen board.
+func (s *CateringService) UpdatePrepStatus(orderID, staffID uuid.UUID, req *models.UpdatePrepStatusRequest, tenant Tenant) (*models.PrepStatusResponse, error) {
+	// Start transaction
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("error starting transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	var current models.PrepStatus
+	var reservationStatus models.ReservationStatus
+	err = tx.QueryRow(`
+		SELECT rs.prep_status, r.status
+		FROM reservation_snacks rs
+		JOIN reservations r ON rs.reservation_id = r.id
+		JOIN rooms rm ON r.room_id = rm.id
+		WHERE rs.id = $1 AND `+tenantCondition("rm.organisation_id", 2)+`
+		FOR UPDATE OF rs
+	`, orderID, tenant.arg()).Scan(&current, &reservationStatus)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("snack order not found")
+		}
+		return nil, fmt.Errorf("error fetching snack order: %v", err)
+	}
+
+	if reservationStatus != models.ReservationStatusConfirmed {
+		return nil, fmt.Errorf("invalid status change: reservation is %s", reservationStatus)
+	}
+	if !current.CanAdvanceTo(req.Status) {
+		return nil, fmt.Errorf("invalid status change from %s to %s", current, req.Status)
+	}
+
+	response := models.PrepStatusResponse{
+		OrderID: orderID,
+		Status:  req.Status,
+	}
+	err = tx.QueryRow(`
+		UPDATE reservation_snacks
+		SET prep_status = $1, prep_updated_at = NOW(), prep_updated_by = $2, updated_at = NOW()
+		WHERE id = $3
+		RETURNING prep_updated_at
+	`, req.Status, staffID, orderID).Scan(&response.UpdatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("error updating snack order status: %v", err)
+	}
+
+	// Commit transaction
+	if err = tx.Commit(); err != nil {
+		return nil, fmt.Errorf("error committing transaction: %v", err)
+	}
+
+	return &response, nil
+}
+
+// buildPrepSheet groups order lines into delivery slots per time and room and
+// totals the quantity of each snack for the day. Slots are ordered by time,
+// then room name; snack totals by category, then name.
+func buildPrepSheet(date string, lines []prepLine) *models.PrepSheet {
+	sheet := &models.PrepSheet{
+		Date:        date,
+		Slots:       []models.PrepSlot{},
+		SnackTotals: []models.PrepSnackTotal{},
+	}
+
+	type slotKey struct {
+		deliveryTime int64
+		roomID       uuid.UUID
+	}
+	slotIndex := make(map[slotKey]int)
+	totalIndex := make(map[uuid.UUID]int)
+
+	for _, line := range lines {
+		key := slotKey{deliveryTime: line.DeliveryTime.Unix(), roomID: line.RoomID}
+		i, ok := slotIndex[key]
+		if !ok {
+			i = len(sheet.Slots)
+			slotIndex[key] = i
+			sheet.Slots = append(sheet.Slots, models.PrepSlot{
+				DeliveryTime: line.DeliveryTime,
+				RoomID:       line.RoomID,
+				RoomName:     line.RoomName,
+			})
+		}
+		sheet.Slots[i].Orders = append(sheet.Slots[i].Orders, line.PrepOrder)
+		sheet.Slots[i].TotalItems += line.Quantity
+
+		j, ok := totalIndex[line.SnackID]
+		if !ok {
+			j = len(sheet.SnackTotals)
+			totalIndex[line.SnackID] = j
+			sheet.SnackTotals = append(sheet.SnackTotals, models.PrepSnackTotal{
+				SnackID:   line.SnackID,
+				SnackName: line.SnackName,
+				Category:  line.Category,
+			})
+		}
+		sheet.SnackTotals[j].Quantity += line.Quantity
+	}
+
+	sort.SliceStable(sheet.Slots, func(a, b int) bool {
+		if !sheet.Slots[a].DeliveryTime.Equal(sheet.Slots[b].DeliveryTime) {
+			return sheet.Slots[a].DeliveryTime.Before(sheet.Slots[b].DeliveryTime)
+		}
+		return sheet.Slots[a].RoomName < sheet.Slots[b].RoomName
+	})
+	sort.SliceStable(sheet.SnackTotals, func(a, b int) bool {
+		if sheet.SnackTotals[a].Category != sheet.SnackTotals[b].Category {
+			return sheet.SnackTotals[a].Category < sheet.SnackTotals[b].Category
+		}
+		return sheet.SnackTotals[a].SnackName < sheet.SnackTotals[b].SnackName
+	})
+
+	return sheet
+}
@@ -0,0 +1,71 @@
+package services
+
+import (
+	"e-meetingproject/internal/models"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBuildPrepSheet(t *testing.T) {
+	nine := time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC)
+	noon := time.Date(2026, 3, 2, 12, 0, 0, 0, time.UTC)
+	roomA := uuid.New()
+	roomB := uuid.New()
+	coffee := uuid.New()
+	cake := uuid.New()
+
+	line := func(at time.Time, roomID uuid.UUID, roomName string, snackID uuid.UUID, snackName, category string, quantity int) prepLine {
+		return prepLine{
+			PrepOrder: models.PrepOrder{
+				OrderID:   uuid.New(),
+				SnackID:   snackID,
+				SnackName: snackName,
+				Category:  category,
+				Quantity:  quantity,
+				Status:    models.PrepStatusPending,
+			},
+			DeliveryTime: at,
+			RoomID:       roomID,
+			RoomName:     roomName,
+		}
+	}
+
+	lines := []prepLine{
+		line(noon, roomA, "Alpha", cake, "Cake", "Pastry", 4),
+		line(nine, roomB, "Beta", coffee, "Coffee", "Drinks", 10),
+		line(nine, roomA, "Alpha", coffee, "Coffee", "Drinks", 6),
+		line(nine, roomA, "Alpha", cake, "Cake", "Pastry", 6),
+	}
+
+	sheet := buildPrepSheet("2026-03-02", lines)
+
+	assert.Equal(t, "2026-03-02", sheet.Date)
+	if assert.Len(t, sheet.Slots, 3) {
+		assert.Equal(t, nine, sheet.Slots[0].DeliveryTime)
+		assert.Equal(t, "Alpha", sheet.Slots[0].RoomName)
+		assert.Len(t, sheet.Slots[0].Orders, 2)
+		assert.Equal(t, 12, sheet.Slots[0].TotalItems)
+
+		assert.Equal(t, nine, sheet.Slots[1].DeliveryTime)
+		assert.Equal(t, "Beta", sheet.Slots[1].RoomName)
+		assert.Equal(t, 10, sheet.Slots[1].TotalItems)
+
+		assert.Equal(t, noon, sheet.Slots[2].DeliveryTime)
+		assert.Equal(t, 4, sheet.Slots[2].TotalItems)
+	}
+	assert.Equal(t, []models.PrepSnackTotal{
+		{SnackID: coffee, SnackName: "Coffee", Category: "Drinks", Quantity: 16},
+		{SnackID: cake, SnackName: "Cake", Category: "Pastry", Quantity: 10},
+	}, sheet.SnackTotals)
+}
+
+func TestBuildPrepSheetEmpty(t *testing.T) {
+	sheet := buildPrepSheet("2026-03-02", nil)
+
+	assert.Empty(t, sheet.Slots)
+	assert.NotNil(t, sheet.Slots)
+	assert.NotNil(t, sheet.SnackTotals)
+}
@@ -0,0 +1,269 @@
+package services
+
+import (
+	"database/sql"
+	"e-meetingproject/internal/database"
+	"e-meetingproject/internal/models"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+type CostCenterService struct {
+	db *sql.DB
+}
+
+func NewCostCenterService() *CostCenterService {
+	return &CostCenterService{
+		db: database.GetDB(),
+	}
+}
+
+func (s *CostCenterService) GetCostCenters() (*models.CostCenterListResponse, error) {
+	rows, err := s.db.Query(`
+		SELECT id, code, name, is_active, created_at, updated_at
+		FROM cost_centers
+		ORDER BY code ASC
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("error querying cost centers: %v", err)
+	}
+	defer rows.Close()
+
+	var costCenters []models.CostCenter
+	for rows.Next() {
+		var costCenter models.CostCenter
+		err := rows.Scan(
+			&costCenter.ID,
+			&costCenter.Code,
+			&costCenter.Name,
+			&costCenter.IsActive,
+			&costCenter.CreatedAt,
+			&costCenter.UpdatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning cost center: %v", err)
+		}
+		costCenters = append(costCenters, costCenter)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating cost centers: %v", err)
+	}
+
+	return &models.CostCenterListResponse{
+		CostCenters: costCenters,
+	}, nil
+}
+
+func (s *CostCenterService) CreateCostCenter(req *models.CreateCostCenterRequest) (*models.CostCenter, error) {
+	costCenter := models.CostCenter{
+		Code:     req.Code,
+		Name:     req.Name,
+		IsActive: true,
+	}
+
+	err := s.db.QueryRow(`
+		INSERT INTO cost_centers (code, name, is_active)
+		VALUES ($1, $2, $3)
+		RETURNING id, created_at, updated_at
+	`, costCenter.Code, costCenter.Name, costCenter.IsActive).Scan(&costCenter.ID, &costCenter.CreatedAt, &costCenter.UpdatedAt)
+	if err != nil {
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code.Name() == "unique_violation" {
+			return nil, fmt.Errorf("cost center code already exists")
+		}
+		return nil, fmt.Errorf("error creating cost center: %v", err)
+	}
+
+	return &costCenter, nil
+}
+
+func (s *CostCenterService) UpdateCostCenter(id uuid.UUID, req *models.UpdateCostCenterRequest) (*models.CostCenter, error) {
+	var costCenter models.CostCenter
+	err := s.db.QueryRow(`
+		UPDATE cost_centers
+		SET name = COALESCE($1, name), is_active = COALESCE($2, is_active), updated_at = NOW()
+		WHERE id = $3
+		RETURNING id, code, name, is_active, created_at, updated_at
+	`, req.Name, req.IsActive, id).Scan(
+		&costCenter.ID,
+		&costCenter.Code,
+		&costCenter.Name,
+		&costCenter.IsActive,
+		&costCenter.CreatedAt,
+		&costCenter.UpdatedAt,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("cost center not found")
+		}
+		return nil, fmt.Errorf("error updating cost center: %v", err)
+	}
+
+	return &costCenter, nil
+}
+
+// AssignUserCostCenter sets the default cost center charged for a user's bookings.
+func (s *CostCenterService) AssignUserCostCenter(userID uuid.UUID, req *models.AssignUserCostCenterRequest, tenant Tenant) error {
+	if err := checkUserTenant(s.db, userID, tenant); err != nil {
+		return err
+	}
+
+	if req.CostCenterID != nil {
+		var isActive bool
+		err := s.db.QueryRow(`SELECT is_active FROM cost_centers WHERE id = $1`, *req.CostCenterID).Scan(&isActive)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				return fmt.Errorf("cost center not found or inactive")
+			}
+			return fmt.Errorf("error checking cost center: %v", err)
+		}
+		if !isActive {
+			return fmt.Errorf("cost center not found or inactive")
+		}
+	}
+
+	result, err := s.db.Exec(`
+		UPDATE users
+		SET cost_center_id = $1, updated_at = NOW()
+		WHERE id = $2`,
+		req.CostCenterID, userID,
+	)
+	if err != nil {
+		return fmt.Errorf("error assigning cost center: %v", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("error getting rows affected: %v", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("user not found")
+	}
+
+	return nil
+}
+
+// GenerateStatement totals the completed reservations that started in the given
+// month (YYYY-MM) per cost center, using the price breakdown stored with each
+// reservation; line totals add up to the reservation prices the dashboard reports.
+// Only reservations of the tenant's rooms are included.
+func (s *CostCenterService) GenerateStatement(month string, tenant Tenant) (*models.ChargebackStatement, error) {
+	periodStart, periodEnd, err := statementPeriod(month)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := s.db.Query(`
+		WITH snack_orders AS (
+			SELECT reservation_id, COUNT(*) as orders, SUM(quantity) as items
+			FROM reservation_snacks
+			GROUP BY reservation_id
+		)
+		SELECT
+			cc.id,
+			COALESCE(cc.code, ''),
+			COALESCE(cc.name, 'Unassigned'),
+			COUNT(r.id) as reservations,
+			COALESCE(SUM(so.orders), 0) as snack_orders,
+			COALESCE(SUM(so.items), 0) as snack_items,
+			COALESCE(SUM(r.room_subtotal), 0) as room_subtotal,
+			COALESCE(SUM(r.snack_subtotal), 0) as snack_subtotal,
+			COALESCE(SUM(r.adjustments), 0) as adjustments,
+			COALESCE(SUM(r.price), 0) as total
+		FROM reservations r
+		JOIN rooms rm ON rm.id = r.room_id
+		LEFT JOIN cost_centers cc ON cc.id = r.cost_center_id
+		LEFT JOIN snack_orders so ON so.reservation_id = r.id
+		WHERE r.status = 'completed'
+			AND r.start_time >= $1
+			AND r.start_time < $2
+			AND `+tenantCondition("rm.organisation_id", 3)+`
+		GROUP BY cc.id, cc.code, cc.name
+		ORDER BY cc.code ASC NULLS LAST`,
+		periodStart, periodEnd, tenant.arg(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error querying chargeback lines: %v", err)
+	}
+	defer rows.Close()
+
+	statement := &models.ChargebackStatement{
+		Month:       month,
+		PeriodStart: periodStart,
+		PeriodEnd:   periodEnd,
+		Lines:       []models.ChargebackLine{},
+		GeneratedAt: time.Now(),
+	}
+	for rows.Next() {
+		var line models.ChargebackLine
+		var costCenterID uuid.NullUUID
+		err := rows.Scan(
+			&costCenterID,
+			&line.CostCenterCode,
+			&line.CostCenterName,
+			&line.Reservations,
+			&line.SnackOrders,
+			&line.SnackItems,
+			&line.RoomSubtotal,
+			&line.SnackSubtotal,
+			&line.Adjustments,
+			&line.Total,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning chargeback line: %v", err)
+		}
+		if costCenterID.Valid {
+			line.CostCenterID = &costCenterID.UUID
+		}
+
+		statement.Lines = append(statement.Lines, line)
+		statement.Total = roundCurrency(statement.Total + line.Total)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating chargeback lines: %v", err)
+	}
+
+	return statement, nil
+}
+
+// statementPeriod returns the start of month (YYYY-MM) and the start of the
+// month after it.
+func statementPeriod(month string) (time.Time, time.Time, error) {
+	periodStart, err := time.Parse("2006-01", month)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("invalid month format (required: YYYY-MM): %v", err)
+	}
+
+	return periodStart, periodStart.AddDate(0, 1, 0), nil
+}
+
+// resolveReservationCostCenter returns the cost center to charge for a new
+// reservation: the requested override, otherwise the user's own cost center.
+func resolveReservationCostCenter(tx *sql.Tx, userID uuid.UUID, override *uuid.UUID) (*uuid.UUID, error) {
+	if override != nil {
+		var isActive bool
+		err := tx.QueryRow(`SELECT is_active FROM cost_centers WHERE id = $1`, *override).Scan(&isActive)
+		if err != nil && err != sql.ErrNoRows {
+			return nil, fmt.Errorf("error checking cost center: %v", err)
+		}
+		if err == sql.ErrNoRows || !isActive {
+			return nil, fmt.Errorf("cost center not found or inactive")
+		}
+		return override, nil
+	}
+
+	var costCenterID uuid.NullUUID
+	err := tx.QueryRow(`SELECT cost_center_id FROM users WHERE id = $1`, userID).Scan(&costCenterID)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, fmt.Errorf("error fetching user cost center: %v", err)
+	}
+	if !costCenterID.Valid {
+		return nil, nil
+	}
+
+	return &costCenterID.UUID, nil
+}
@@ -0,0 +1,150 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recordingDriver is a database driver that answers every query with no rows
+// and remembers the last query and its arguments.
+type recordingDriver struct {
+	query string
+	args  []driver.Value
+}
+
+func (d *recordingDriver) Open(string) (driver.Conn, error) { return recordingConn{d}, nil }
+
+type recordingConn struct{ driver *recordingDriver }
+
+func (c recordingConn) Prepare(query string) (driver.Stmt, error) {
+	return recordingStmt{c.driver, query}, nil
+}
+func (c recordingConn) Close() error              { return nil }
+func (c recordingConn) Begin() (driver.Tx, error) { return recordingTx{}, nil }
+
+type recordingTx struct{}
+
+func (recordingTx) Commit() error   { return nil }
+func (recordingTx) Rollback() error { return nil }
+
+type recordingStmt struct {
+	driver *recordingDriver
+	query  string
+}
+
+func (s recordingStmt) Close() error  { return nil }
+func (s recordingStmt) NumInput() int { return -1 }
+func (s recordingStmt) Exec([]driver.Value) (driver.Result, error) {
+	return nil, driver.ErrSkip
+}
+func (s recordingStmt) Query(args []driver.Value) (driver.Rows, error) {
+	s.driver.query, s.driver.args = s.query, args
+	return emptyRows{}, nil
+}
+
+type emptyRows struct{}
+
+func (emptyRows) Columns() []string         { return nil }
+func (emptyRows) Close() error              { return nil }
+func (emptyRows) Next([]driver.Value) error { return io.EOF }
+
+func openRecordingDB(t *testing.T) (*sql.DB, *recordingDriver) {
+	t.Helper()
+	recorder := &recordingDriver{}
+	db := sql.OpenDB(recordingConnector{recorder})
+	t.Cleanup(func() { db.Close() })
+	return db, recorder
+}
+
+type recordingConnector struct{ driver *recordingDriver }
+
+func (c recordingConnector) Connect(context.Context) (driver.Conn, error) {
+	return c.driver.Open("")
+}
+func (c recordingConnector) Driver() driver.Driver { return c.driver }
+
+func TestStatementPeriod(t *testing.T) {
+	tests := []struct {
+		name          string
+		month         string
+		expectedStart time.Time
+		expectedEnd   time.Time
+		expectedError bool
+	}{
+		{
+			name:          "Month",
+			month:         "2026-03",
+			expectedStart: time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC),
+			expectedEnd:   time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:          "December ends in the next year",
+			month:         "2025-12",
+			expectedStart: time.Date(2025, 12, 1, 0, 0, 0, 0, time.UTC),
+			expectedEnd:   time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC),
+		},
+		{name: "Day included", month: "2026-03-01", expectedError: true},
+		{name: "Month out of range", month: "2026-13", expectedError: true},
+		{name: "Empty", month: "", expectedError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start, end, err := statementPeriod(tt.month)
+			if tt.expectedError {
+				assert.Error(t, err)
+				assert.Contains(t, err.Error(), "invalid month format")
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectedStart, start)
+			assert.Equal(t, tt.expectedEnd, end)
+		})
+	}
+}
+
+func TestGenerateStatementInvalidMonth(t *testing.T) {
+	// The month is validated before the database is used
+	service := &CostCenterService{}
+
+	_, err := service.GenerateStatement("March", Tenant{})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid month format (required: YYYY-MM)")
+}
+
+func TestGenerateStatementTenant(t *testing.T) {
+	organisationID := uuid.New()
+
+	tests := []struct {
+		name        string
+		tenant      Tenant
+		expectedOrg driver.Value
+	}{
+		{name: "Admin sees the rooms of their organisation", tenant: Tenant{OrganisationID: organisationID}, expectedOrg: organisationID.String()},
+		{name: "Super admin sees every organisation", tenant: Tenant{OrganisationID: organisationID, AllOrganisations: true}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, recorder := openRecordingDB(t)
+			service := &CostCenterService{db: db}
+
+			statement, err := service.GenerateStatement("2026-03", tt.tenant)
+			require.NoError(t, err)
+			assert.Empty(t, statement.Lines)
+
+			assert.Contains(t, recorder.query, "JOIN rooms rm ON rm.id = r.room_id")
+			assert.Contains(t, recorder.query, tenantCondition("rm.organisation_id", 3))
+			require.Len(t, recorder.args, 3)
+			assert.Equal(t, tt.expectedOrg, recorder.args[2])
+		})
+	}
+}
@@ -18,7 +18,7 @@ func NewDashboardService() *DashboardService {
 	}
 }
 
-func (s *DashboardService) GetDashboardStats(query *models.DashboardQuery) (*models.DashboardResponse, error) {
+func (s *DashboardService) GetDashboardStats(query *models.DashboardQuery, tenant Tenant) (*models.DashboardResponse, error) {
 	// Parse dates
 	endDate := time.Now()
 	startDate := endDate.AddDate(0, 0, -30) // Default to last 30 days
@@ -61,14 +61,34 @@ func (s *DashboardService) GetDashboardStats(query *models.DashboardQuery) (*mod
 		LEFT JOIN reservations r ON r.room_id = rm.id
 			AND r.start_time >= $1 
 			AND r.end_time <= $2
-			AND r.status = 'confirmed'`,
-		startDate, endDate,
+			AND r.status = 'confirmed'
+		WHERE `+tenantCondition("rm.organisation_id", 3),
+		startDate, endDate, tenant.arg(),
 	).Scan(&totalOmzet, &totalReservations, &totalVisitors, &totalRooms)
 
 	if err != nil {
 		return nil, fmt.Errorf("error getting total statistics: %v", err)
 	}
 
+	// Cancellation fees and refunds are reported separately from booking revenue
+	var cancellationFees, refunds float64
+	err = tx.QueryRow(`
+		SELECT 
+			COALESCE(SUM(cancellation_fee), 0) as cancellation_fees,
+			COALESCE(SUM(refund_amount), 0) as refunds
+		FROM reservations r
+		JOIN rooms rm ON r.room_id = rm.id
+		WHERE r.status = 'cancelled'
+			AND r.cancelled_at >= $1 
+			AND r.cancelled_at <= $2
+			AND `+tenantCondition("rm.organisation_id", 3),
+		startDate, endDate, tenant.arg(),
+	).Scan(&cancellationFees, &refunds)
+
+	if err != nil {
+		return nil, fmt.Errorf("error getting cancellation statistics: %v", err)
+	}
+
 	// Get per-room statistics
 	rows, err := tx.Query(`
 		WITH room_bookings AS (
@@ -88,6 +108,7 @@ func (s *DashboardService) GetDashboardStats(query *models.DashboardQuery) (*mod
 				AND r.start_time < $2 
 				AND r.end_time > $1
 				AND r.status = 'confirmed'
+			WHERE `+tenantCondition("rm.organisation_id", 4)+`
 			GROUP BY rm.id, rm.name
 		)
 		SELECT 
@@ -104,6 +125,7 @@ func (s *DashboardService) GetDashboardStats(query *models.DashboardQuery) (*mod
 		ORDER BY revenue DESC`,
 		startDate, endDate,
 		endDate.Sub(startDate).Hours()/24, // Total days in period
+		tenant.arg(),
 	)
 	if err != nil {
 		return nil, fmt.Errorf("error getting room statistics: %v", err)
@@ -137,12 +159,14 @@ func (s *DashboardService) GetDashboardStats(query *models.DashboardQuery) (*mod
 	}
 
 	return &models.DashboardResponse{
-		StartDate:    startDate,
-		EndDate:      endDate,
-		TotalOmzet:   totalOmzet,
-		Reservations: totalReservations,
-		Visitors:     totalVisitors,
-		TotalRooms:   totalRooms,
-		RoomStats:    roomStats,
+		StartDate:        startDate,
+		EndDate:          endDate,
+		TotalOmzet:       totalOmzet,
+		CancellationFees: cancellationFees,
+		Refunds:          refunds,
+		Reservations:     totalReservations,
+		Visitors:         totalVisitors,
+		TotalRooms:       totalRooms,
+		RoomStats:        roomStats,
 	}, nil
 }
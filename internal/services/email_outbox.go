@@ -0,0 +1,195 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"e-meetingproject/internal/database"
+	"e-meetingproject/internal/mail"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/spf13/viper"
+)
+
+const (
+	// outboxLease is how long a claimed message is left alone, so a worker
+	// that dies mid-send does not lose it
+	outboxLease    = 5 * time.Minute
+	outboxBatch    = 20
+	maxOutboxRetry = time.Hour
+)
+
+// enqueueEmail renders template for the recipient and queues it in the
+// outbox. Called inside a transaction, the email is only sent if the
+// transaction commits.
+func enqueueEmail(e execer, to, template, language string, data interface{}) error {
+	msg, err := mail.Render(template, language, data)
+	if err != nil {
+		return err
+	}
+
+	_, err = e.Exec(`
+		INSERT INTO email_outbox (template, recipient, subject, text_body, html_body)
+		VALUES ($1, $2, $3, $4, $5)
+	`, template, to, msg.Subject, msg.Text, msg.HTML)
+	if err != nil {
+		return fmt.Errorf("error queueing email: %v", err)
+	}
+
+	return nil
+}
+
+// EmailOutbox delivers queued emails with a Mailer, retrying failures with
+// exponential backoff until maxAttempts.
+type EmailOutbox struct {
+	db           *sql.DB
+	mailer       mail.Mailer
+	maxAttempts  int
+	pollInterval time.Duration
+}
+
+func NewEmailOutbox(mailer mail.Mailer) *EmailOutbox {
+	maxAttempts := viper.GetInt("MAIL_MAX_ATTEMPTS")
+	if maxAttempts == 0 {
+		maxAttempts = 8 // default to 8 attempts
+	}
+	pollSeconds := viper.GetInt("MAIL_OUTBOX_POLL_SECONDS")
+	if pollSeconds == 0 {
+		pollSeconds = 10 // default to 10 seconds
+	}
+
+	return &EmailOutbox{
+		db:           database.GetDB(),
+		mailer:       mailer,
+		maxAttempts:  maxAttempts,
+		pollInterval: time.Duration(pollSeconds) * time.Second,
+	}
+}
+
+// Run delivers due emails until ctx is cancelled.
+func (o *EmailOutbox) Run(ctx context.Context) {
+	ticker := time.NewTicker(o.pollInterval)
+	defer ticker.Stop()
+
+	for {
+		if err := o.DeliverDue(time.Now()); err != nil {
+			log.Printf("Error delivering email outbox: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+type outboxMessage struct {
+	id       uuid.UUID
+	template string
+	attempts int
+	msg      mail.Message
+}
+
+// DeliverDue sends the messages that are due at now, a batch at a time.
+func (o *EmailOutbox) DeliverDue(now time.Time) error {
+	for {
+		messages, err := o.claim(now)
+		if err != nil {
+			return err
+		}
+
+		for _, m := range messages {
+			if err := o.deliver(m, now); err != nil {
+				return err
+			}
+		}
+
+		if len(messages) < outboxBatch {
+			return nil
+		}
+	}
+}
+
+// claim leases a batch of due messages to this worker.
+func (o *EmailOutbox) claim(now time.Time) ([]outboxMessage, error) {
+	rows, err := o.db.Query(`
+		UPDATE email_outbox
+		SET next_attempt_at = $2, attempts = attempts + 1
+		WHERE id IN (
+			SELECT id FROM email_outbox
+			WHERE sent_at IS NULL AND failed_at IS NULL AND next_attempt_at <= $1
+			ORDER BY next_attempt_at
+			LIMIT $3
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING id, template, attempts, recipient, subject, text_body, html_body
+	`, now, now.Add(outboxLease), outboxBatch)
+	if err != nil {
+		return nil, fmt.Errorf("error claiming emails: %v", err)
+	}
+	defer rows.Close()
+
+	var messages []outboxMessage
+	for rows.Next() {
+		var m outboxMessage
+		if err := rows.Scan(&m.id, &m.template, &m.attempts, &m.msg.To, &m.msg.Subject, &m.msg.Text, &m.msg.HTML); err != nil {
+			return nil, fmt.Errorf("error scanning email: %v", err)
+		}
+		messages = append(messages, m)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating emails: %v", err)
+	}
+
+	return messages, nil
+}
+
+func (o *EmailOutbox) deliver(m outboxMessage, now time.Time) error {
+	sendErr := o.mailer.Send(m.msg)
+	if sendErr == nil {
+		_, err := o.db.Exec(`UPDATE email_outbox SET sent_at = NOW(), last_error = NULL WHERE id = $1`, m.id)
+		if err != nil {
+			return fmt.Errorf("error updating email: %v", err)
+		}
+		return nil
+	}
+
+	log.Printf("Error sending %s email %s (attempt %d): %v", m.template, m.id, m.attempts, sendErr)
+
+	if m.attempts >= o.maxAttempts {
+		_, err := o.db.Exec(`
+			UPDATE email_outbox SET failed_at = NOW(), last_error = $2 WHERE id = $1
+		`, m.id, sendErr.Error())
+		if err != nil {
+			return fmt.Errorf("error updating email: %v", err)
+		}
+		return nil
+	}
+
+	_, err := o.db.Exec(`
+		UPDATE email_outbox SET next_attempt_at = $2, last_error = $3 WHERE id = $1
+	`, m.id, now.Add(outboxRetryDelay(m.attempts)), sendErr.Error())
+	if err != nil {
+		return fmt.Errorf("error updating email: %v", err)
+	}
+	return nil
+}
+
+// outboxRetryDelay is the wait after a failed delivery attempt: a minute,
+// doubling with every attempt up to maxOutboxRetry.
+func outboxRetryDelay(attempts int) time.Duration {
+	if attempts < 1 {
+		attempts = 1
+	}
+	if attempts > 7 {
+		return maxOutboxRetry
+	}
+	delay := time.Minute << (attempts - 1)
+	if delay > maxOutboxRetry {
+		return maxOutboxRetry
+	}
+	return delay
+}
@@ -0,0 +1,26 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOutboxRetryDelay(t *testing.T) {
+	tests := []struct {
+		attempts int
+		expected time.Duration
+	}{
+		{attempts: 0, expected: time.Minute},
+		{attempts: 1, expected: time.Minute},
+		{attempts: 2, expected: 2 * time.Minute},
+		{attempts: 6, expected: 32 * time.Minute},
+		{attempts: 7, expected: maxOutboxRetry},
+		{attempts: 50, expected: maxOutboxRetry},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.expected, outboxRetryDelay(tt.attempts), "attempts: %d", tt.attempts)
+	}
+}
@@ -0,0 +1,176 @@
+package services
+
+import (
+	"database/sql"
+	"e-meetingproject/internal/auth"
+	"e-meetingproject/internal/mail"
+	"e-meetingproject/internal/models"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+// emailVerificationAudience keeps verification links and access tokens, which
+// are signed with the same keys, from being used for each other.
+const emailVerificationAudience = "email-verification"
+
+// emailVerificationClaims are the claims of a verification link. The link is
+// only valid for the address it was sent to.
+type emailVerificationClaims struct {
+	Email string `json:"email"`
+	jwt.RegisteredClaims
+}
+
+// signVerificationToken returns the token of a verification link for the
+// user's email address.
+func signVerificationToken(keys *auth.KeySet, userID uuid.UUID, email string, now, expiresAt time.Time) (string, error) {
+	claims := &emailVerificationClaims{
+		Email: email,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   userID.String(),
+			Audience:  jwt.ClaimStrings{emailVerificationAudience},
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+			IssuedAt:  jwt.NewNumericDate(now),
+		},
+	}
+
+	token, err := keys.Sign(claims)
+	if err != nil {
+		return "", fmt.Errorf("error signing verification token: %v", err)
+	}
+	return token, nil
+}
+
+// parseVerificationToken checks the signature, audience and expiry of a
+// verification token and returns its user and email address.
+func parseVerificationToken(keys *auth.KeySet, token string) (uuid.UUID, string, error) {
+	claims := &emailVerificationClaims{}
+	_, err := jwt.ParseWithClaims(token, claims, keys.Keyfunc,
+		jwt.WithValidMethods(keys.Methods()),
+		jwt.WithAudience(emailVerificationAudience),
+		jwt.WithExpirationRequired(),
+	)
+	if err != nil {
+		return uuid.Nil, "", errors.New("invalid or expired verification link")
+	}
+
+	userID, err := uuid.Parse(claims.Subject)
+	if err != nil || claims.Email == "" {
+		return uuid.Nil, "", errors.New("invalid or expired verification link")
+	}
+
+	return userID, claims.Email, nil
+}
+
+// VerifyEmail activates the pending account of a verification link. Opening
+// the link again after the account is active succeeds as well.
+func (s *AuthService) VerifyEmail(token string) error {
+	userID, email, err := parseVerificationToken(s.keys, token)
+	if err != nil {
+		return err
+	}
+
+	var status string
+	err = s.db.QueryRow(`
+		UPDATE users
+		SET status = CASE WHEN status = $3 THEN $4 ELSE status END,
+			email_verified_at = COALESCE(email_verified_at, NOW()),
+			updated_at = NOW()
+		WHERE id = $1 AND email = $2 AND status IN ($3, $4)
+		RETURNING status
+	`, userID, email, models.UserStatusPendingVerification, models.UserStatusActive).Scan(&status)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			// The user is gone, changed their address or was deactivated
+			return errors.New("invalid or expired verification link")
+		}
+		return fmt.Errorf("error verifying email: %v", err)
+	}
+
+	return nil
+}
+
+// ResendVerificationEmail sends a new verification link to a pending user, at
+// most once per resend interval.
+func (s *AuthService) ResendVerificationEmail(userID uuid.UUID) error {
+	now := time.Now()
+
+	// Start transaction
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("error starting transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	// Claim the send, so concurrent requests cannot both pass the limit
+	var username, email, language string
+	err = tx.QueryRow(`
+		UPDATE users
+		SET verification_sent_at = $2
+		WHERE id = $1 AND status = $3 AND email IS NOT NULL
+			AND (verification_sent_at IS NULL OR verification_sent_at <= $4)
+		RETURNING username, email, COALESCE(language, '')
+	`, userID, now, models.UserStatusPendingVerification, now.Add(-s.verificationResendInterval)).Scan(&username, &email, &language)
+	if err != nil && err != sql.ErrNoRows {
+		return fmt.Errorf("error updating verification: %v", err)
+	}
+
+	if err == sql.ErrNoRows {
+		// Work out why nothing was sent
+		var status string
+		err = tx.QueryRow(`SELECT status FROM users WHERE id = $1`, userID).Scan(&status)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				return errors.New("user not found")
+			}
+			return fmt.Errorf("database error: %v", err)
+		}
+		if status != models.UserStatusPendingVerification {
+			return errors.New("email address already verified")
+		}
+		return errors.New("verification email sent recently, try again later")
+	}
+
+	if err := s.queueVerificationEmail(tx, userID, username, email, language, now); err != nil {
+		return err
+	}
+
+	// Commit transaction
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("error committing transaction: %v", err)
+	}
+
+	return nil
+}
+
+// queueVerificationEmail queues an email with a new verification link.
+func (s *AuthService) queueVerificationEmail(e execer, userID uuid.UUID, username, email, language string, now time.Time) error {
+	token, err := signVerificationToken(s.keys, userID, email, now, now.Add(s.verificationTTL))
+	if err != nil {
+		return err
+	}
+
+	return enqueueEmail(e, email, mail.TemplateEmailVerification, language, map[string]interface{}{
+		"Username":       username,
+		"Link":           tokenLink(linkBaseURL(), "/verify-email", token),
+		"ExpiresInHours": int(s.verificationTTL.Hours()),
+	})
+}
+
+// IsEmailVerified reports whether the user of claims may use features that
+// need a verified email address.
+func (s *AuthService) IsEmailVerified(claims *auth.Claims) (bool, error) {
+	var status string
+	err := s.db.QueryRow(`SELECT status FROM users WHERE id = $1`, claims.UserID).Scan(&status)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return false, nil
+		}
+		return false, fmt.Errorf("error checking email verification: %v", err)
+	}
+
+	return status != models.UserStatusPendingVerification, nil
+}
@@ -0,0 +1,62 @@
+package services
+
+import (
+	"e-meetingproject/internal/auth"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVerificationToken(t *testing.T) {
+	keys, err := auth.NewEphemeralKeySet()
+	require.NoError(t, err)
+	otherKeys, err := auth.NewEphemeralKeySet()
+	require.NoError(t, err)
+
+	userID := uuid.New()
+	now := time.Now()
+
+	valid, err := signVerificationToken(keys, userID, "jane@example.com", now, now.Add(time.Hour))
+	require.NoError(t, err)
+	expired, err := signVerificationToken(keys, userID, "jane@example.com", now.Add(-2*time.Hour), now.Add(-time.Hour))
+	require.NoError(t, err)
+	otherKey, err := signVerificationToken(otherKeys, userID, "jane@example.com", now, now.Add(time.Hour))
+	require.NoError(t, err)
+	accessToken, err := keys.Sign(&auth.Claims{
+		UserID: userID,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   userID.String(),
+			ExpiresAt: jwt.NewNumericDate(now.Add(time.Hour)),
+		},
+	})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name          string
+		token         string
+		expectedError string
+	}{
+		{name: "Valid link", token: valid},
+		{name: "Expired link", token: expired, expectedError: "invalid or expired verification link"},
+		{name: "Signed by another key", token: otherKey, expectedError: "invalid or expired verification link"},
+		{name: "Access token", token: accessToken, expectedError: "invalid or expired verification link"},
+		{name: "Garbage", token: "not-a-token", expectedError: "invalid or expired verification link"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotUserID, email, err := parseVerificationToken(keys, tt.token)
+			if tt.expectedError != "" {
+				assert.EqualError(t, err, tt.expectedError)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, userID, gotUserID)
+			assert.Equal(t, "jane@example.com", email)
+		})
+	}
+}
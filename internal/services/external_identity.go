@@ -0,0 +1,207 @@
+package services
+
+import (
+	"database/sql"
+	"e-meetingproject/internal/models"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// ExternalIdentity is a user as known to an external identity source, such
+// as an OIDC provider or an LDAP directory.
+type ExternalIdentity struct {
+	Issuer        string // Identifies the source
+	Subject       string // Stable ID of the user at the source
+	Email         string
+	EmailVerified *bool  // nil when the source does not say
+	Username      string // Preferred username when provisioning, else derived from Email
+	Name          string
+	Groups        []string
+	MFA           bool // The source asserts the user passed multi-factor authentication
+	EmailManaged  bool // The source assigns the email, e.g. the organisation's directory
+}
+
+// errLinkRefused is returned when an identity has the email of an account
+// canLinkByEmail refuses to link.
+var errLinkRefused = errors.New("an account with this email already exists and must be linked explicitly")
+
+// linkExternalUser returns the user of identity, linking an existing account
+// by email or provisioning one, and applies the role roleFor maps the user's
+// current role to. It also returns the user's token version. Accounts that
+// canLinkByEmail refuses are not linked.
+func linkExternalUser(tx *sql.Tx, identity *ExternalIdentity, roleFor func(current string) string) (*models.User, int, error) {
+	var user models.User
+	var tokenVersion int
+	err := tx.QueryRow(`
+		SELECT u.id, u.username, u.role, u.token_version, u.organisation_id
+		FROM user_identities i
+		JOIN users u ON i.user_id = u.id
+		WHERE i.issuer = $1 AND i.subject = $2
+		FOR UPDATE OF u
+	`, identity.Issuer, identity.Subject).Scan(&user.ID, &user.Username, &user.Role, &tokenVersion, &user.OrganisationID)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, 0, fmt.Errorf("database error: %v", err)
+	}
+
+	if err == sql.ErrNoRows {
+		if identity.Email == "" {
+			return nil, 0, errors.New("identity provider did not return an email")
+		}
+		if identity.EmailVerified != nil && !*identity.EmailVerified {
+			return nil, 0, errors.New("email address is not verified")
+		}
+
+		var hasPassword bool
+		err = tx.QueryRow(`
+			SELECT id, username, role, token_version, organisation_id, password <> ''
+			FROM users
+			WHERE LOWER(email) = LOWER($1)
+			FOR UPDATE
+		`, identity.Email).Scan(&user.ID, &user.Username, &user.Role, &tokenVersion, &user.OrganisationID, &hasPassword)
+		if err != nil && err != sql.ErrNoRows {
+			return nil, 0, fmt.Errorf("database error: %v", err)
+		}
+
+		if err == sql.ErrNoRows {
+			if err := provisionExternalUser(tx, identity, &user); err != nil {
+				return nil, 0, err
+			}
+		} else if !canLinkByEmail(identity, hasPassword, user.Role) {
+			return nil, 0, errLinkRefused
+		}
+
+		_, err = tx.Exec(`
+			INSERT INTO user_identities (user_id, issuer, subject, email)
+			VALUES ($1, $2, $3, $4)
+		`, user.ID, identity.Issuer, identity.Subject, identity.Email)
+		if err != nil {
+			return nil, 0, fmt.Errorf("error linking identity: %v", err)
+		}
+	}
+
+	// Super admins are only appointed in this service, identity sources
+	// cannot grant or take away the role
+	if role := roleFor(user.Role); role != user.Role && user.Role != models.RoleSuperAdmin {
+		_, err = tx.Exec(`
+			UPDATE users SET role = $1, updated_at = NOW() WHERE id = $2
+		`, role, user.ID)
+		if err != nil {
+			return nil, 0, fmt.Errorf("error updating role: %v", err)
+		}
+		user.Role = role
+	}
+
+	_, err = tx.Exec(`
+		UPDATE user_identities
+		SET email = $1, last_login_at = NOW()
+		WHERE issuer = $2 AND subject = $3
+	`, identity.Email, identity.Issuer, identity.Subject)
+	if err != nil {
+		return nil, 0, fmt.Errorf("error updating identity: %v", err)
+	}
+
+	return &user, tokenVersion, nil
+}
+
+// canLinkByEmail reports whether identity may be linked to the existing
+// account with its email. Only an email the source asserts as verified is
+// trusted. Accounts with a local password are only linked when the source
+// assigns its emails, and accounts with an admin role are never taken over
+// this way.
+func canLinkByEmail(identity *ExternalIdentity, hasPassword bool, role string) bool {
+	if identity.EmailVerified == nil || !*identity.EmailVerified {
+		return false
+	}
+	if hasPassword && !identity.EmailManaged {
+		return false
+	}
+	return role != models.RoleAdmin && role != models.RoleSuperAdmin
+}
+
+// provisionExternalUser creates the account of a first-time external user. It
+// has no local password, so it can only sign in through its identity source.
+func provisionExternalUser(tx *sql.Tx, identity *ExternalIdentity, user *models.User) error {
+	base := usernameFromEmail(identity.Email)
+	if identity.Username != "" {
+		base = sanitizeUsername(identity.Username)
+	}
+	username := base
+	for i := 2; ; i++ {
+		var taken bool
+		err := tx.QueryRow(`SELECT EXISTS (SELECT 1 FROM users WHERE username = $1)`, username).Scan(&taken)
+		if err != nil {
+			return fmt.Errorf("database error: %v", err)
+		}
+		if !taken {
+			break
+		}
+		username = fmt.Sprintf("%s%d", base, i)
+	}
+
+	user.ID = uuid.New()
+	user.Username = username
+	user.Role = models.RoleUser
+	err := tx.QueryRow(`
+		INSERT INTO users (id, username, email, password, role, status, created_at, updated_at)
+		VALUES ($1, $2, $3, '', $4, 'active', NOW(), NOW())
+		RETURNING organisation_id
+	`, user.ID, user.Username, identity.Email, user.Role).Scan(&user.OrganisationID)
+	if err != nil {
+		return fmt.Errorf("error creating user: %v", err)
+	}
+
+	return nil
+}
+
+func inGroups(groups, wanted []string) bool {
+	for _, group := range groups {
+		for _, w := range wanted {
+			if strings.EqualFold(group, w) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// usernameFromEmail derives a username from the local part of an email.
+func usernameFromEmail(email string) string {
+	if at := strings.Index(email, "@"); at >= 0 {
+		email = email[:at]
+	}
+	return sanitizeUsername(email)
+}
+
+// sanitizeUsername lowercases name and keeps letters, digits, dots, dashes
+// and underscores.
+func sanitizeUsername(name string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(name) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') || r == '.' || r == '-' || r == '_' {
+			b.WriteRune(r)
+		}
+	}
+
+	username := b.String()
+	if len(username) > 40 {
+		username = username[:40]
+	}
+	for len(username) < 3 {
+		username += "_"
+	}
+	return username
+}
+
+// splitConfigList splits a config value listing items separated by sep.
+func splitConfigList(value, sep string) []string {
+	var items []string
+	for _, item := range strings.Split(value, sep) {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
@@ -0,0 +1,38 @@
+package services
+
+import (
+	"e-meetingproject/internal/models"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCanLinkByEmail(t *testing.T) {
+	verified, unverified := true, false
+
+	tests := []struct {
+		name        string
+		verified    *bool
+		managed     bool
+		hasPassword bool
+		role        string
+		expected    bool
+	}{
+		{name: "Verified email of an external user", verified: &verified, role: models.RoleUser, expected: true},
+		{name: "Verified email of a receptionist", verified: &verified, role: models.RoleReceptionist, expected: true},
+		{name: "Source does not say", role: models.RoleUser},
+		{name: "Unverified email", verified: &unverified, role: models.RoleUser},
+		{name: "Account with a local password", verified: &verified, hasPassword: true, role: models.RoleUser},
+		{name: "Account with a local password and a directory email", verified: &verified, managed: true, hasPassword: true, role: models.RoleUser, expected: true},
+		{name: "Admin account with a directory email", verified: &verified, managed: true, hasPassword: true, role: models.RoleAdmin},
+		{name: "Admin account", verified: &verified, role: models.RoleAdmin},
+		{name: "Super admin account", verified: &verified, role: models.RoleSuperAdmin},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			identity := &ExternalIdentity{Email: "jane.doe@example.com", EmailVerified: tt.verified, EmailManaged: tt.managed}
+			assert.Equal(t, tt.expected, canLinkByEmail(identity, tt.hasPassword, tt.role))
+		})
+	}
+}
@@ -0,0 +1,289 @@
+package services
+
+import (
+	"crypto/tls"
+	"database/sql"
+	"e-meetingproject/internal/models"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/go-ldap/ldap/v3"
+	"github.com/spf13/viper"
+)
+
+// LDAPConfig configures authentication against an LDAP or Active Directory
+// server. The defaults suit Active Directory.
+type LDAPConfig struct {
+	URL          string // ldap:// or ldaps://
+	StartTLS     bool
+	BindDN       string // Service account used for searches, anonymous when empty
+	BindPassword string
+	BaseDN       string
+
+	// UserFilter finds the user entry, %s is replaced with the escaped
+	// username
+	UserFilter        string
+	UsernameAttribute string
+	EmailAttribute    string
+
+	// GroupFilter finds the user's groups, %s is replaced with the escaped
+	// user DN. When empty only the memberOf attribute of the user is used.
+	GroupFilter string
+	GroupBaseDN string // Defaults to BaseDN
+
+	// Groups are matched by DN or CN, case-insensitively
+	AdminGroups    []string
+	CateringGroups []string
+
+	// TrustEmail treats the email attribute as verified, for directories
+	// whose addresses are assigned by the organisation. Existing accounts
+	// with that email, other than admins, are then linked at first login.
+	TrustEmail bool
+
+	Timeout time.Duration
+}
+
+// ldapConn is the part of *ldap.Conn the authenticator uses.
+type ldapConn interface {
+	Bind(username, password string) error
+	Search(request *ldap.SearchRequest) (*ldap.SearchResult, error)
+	StartTLS(config *tls.Config) error
+	Close() error
+}
+
+// LDAPAuthenticator binds as the user found by a directory search. Users are
+// linked or provisioned like SSO users, and their role follows their
+// directory groups on every login.
+type LDAPAuthenticator struct {
+	db     *sql.DB
+	config LDAPConfig
+	dial   func(config LDAPConfig) (ldapConn, error)
+}
+
+func NewLDAPAuthenticator(db *sql.DB, config LDAPConfig) *LDAPAuthenticator {
+	if config.UserFilter == "" {
+		config.UserFilter = "(&(objectClass=user)(sAMAccountName=%s))"
+	}
+	if config.UsernameAttribute == "" {
+		config.UsernameAttribute = "sAMAccountName"
+	}
+	if config.EmailAttribute == "" {
+		config.EmailAttribute = "mail"
+	}
+	if config.GroupBaseDN == "" {
+		config.GroupBaseDN = config.BaseDN
+	}
+	if config.Timeout == 0 {
+		config.Timeout = 10 * time.Second
+	}
+
+	return &LDAPAuthenticator{
+		db:     db,
+		config: config,
+		dial:   dialLDAP,
+	}
+}
+
+// newLDAPConfig reads the LDAP settings. Group lists are separated by
+// semicolons since DNs contain commas.
+func newLDAPConfig() LDAPConfig {
+	return LDAPConfig{
+		URL:               viper.GetString("LDAP_URL"),
+		StartTLS:          viper.GetBool("LDAP_START_TLS"),
+		BindDN:            viper.GetString("LDAP_BIND_DN"),
+		BindPassword:      viper.GetString("LDAP_BIND_PASSWORD"),
+		BaseDN:            viper.GetString("LDAP_BASE_DN"),
+		UserFilter:        viper.GetString("LDAP_USER_FILTER"),
+		UsernameAttribute: viper.GetString("LDAP_USERNAME_ATTRIBUTE"),
+		EmailAttribute:    viper.GetString("LDAP_EMAIL_ATTRIBUTE"),
+		GroupFilter:       viper.GetString("LDAP_GROUP_FILTER"),
+		GroupBaseDN:       viper.GetString("LDAP_GROUP_BASE_DN"),
+		AdminGroups:       splitConfigList(viper.GetString("LDAP_ADMIN_GROUPS"), ";"),
+		CateringGroups:    splitConfigList(viper.GetString("LDAP_CATERING_GROUPS"), ";"),
+		TrustEmail:        viper.GetBool("LDAP_TRUST_EMAIL"),
+	}
+}
+
+func dialLDAP(config LDAPConfig) (ldapConn, error) {
+	conn, err := ldap.DialURL(config.URL)
+	if err != nil {
+		return nil, err
+	}
+	conn.SetTimeout(config.Timeout)
+
+	if config.StartTLS {
+		parsed, err := url.Parse(config.URL)
+		if err != nil {
+			conn.Close()
+			return nil, err
+		}
+		if err := conn.StartTLS(&tls.Config{ServerName: parsed.Hostname()}); err != nil {
+			conn.Close()
+			return nil, err
+		}
+	}
+
+	return conn, nil
+}
+
+func (a *LDAPAuthenticator) Name() string {
+	return "ldap"
+}
+
+func (a *LDAPAuthenticator) Authenticate(username, password string) (*models.User, error) {
+	identity, err := a.lookup(username, password)
+	if err != nil {
+		return nil, err
+	}
+
+	// Start transaction
+	tx, err := a.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("error starting transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	user, _, err := linkExternalUser(tx, identity, func(string) string {
+		return ldapRole(identity.Groups, a.config.AdminGroups, a.config.CateringGroups)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Commit transaction
+	if err = tx.Commit(); err != nil {
+		return nil, fmt.Errorf("error committing transaction: %v", err)
+	}
+
+	return user, nil
+}
+
+// lookup finds the user's entry, checks the password by binding as the user
+// and collects the user's groups.
+func (a *LDAPAuthenticator) lookup(username, password string) (*ExternalIdentity, error) {
+	// An empty password would be an unauthenticated bind, which succeeds
+	if password == "" {
+		return nil, errors.New("invalid credentials")
+	}
+
+	conn, err := a.dial(a.config)
+	if err != nil {
+		return nil, fmt.Errorf("error connecting to LDAP: %v", err)
+	}
+	defer conn.Close()
+
+	if err := a.bindServiceAccount(conn); err != nil {
+		return nil, err
+	}
+
+	result, err := conn.Search(ldap.NewSearchRequest(
+		a.config.BaseDN, ldap.ScopeWholeSubtree, ldap.NeverDerefAliases, 2, 0, false,
+		fmt.Sprintf(a.config.UserFilter, ldap.EscapeFilter(username)),
+		[]string{a.config.UsernameAttribute, a.config.EmailAttribute, "memberOf"},
+		nil,
+	))
+	if err != nil {
+		return nil, fmt.Errorf("error searching LDAP user: %v", err)
+	}
+	if len(result.Entries) == 0 {
+		return nil, errUnknownUser
+	}
+	if len(result.Entries) > 1 {
+		return nil, fmt.Errorf("error searching LDAP user: %d entries match %s", len(result.Entries), username)
+	}
+	entry := result.Entries[0]
+
+	if err := conn.Bind(entry.DN, password); err != nil {
+		if ldap.IsErrorWithCode(err, ldap.LDAPResultInvalidCredentials) {
+			return nil, errors.New("invalid credentials")
+		}
+		return nil, fmt.Errorf("error binding LDAP user: %v", err)
+	}
+
+	// Look up groups with the service account again
+	if err := a.bindServiceAccount(conn); err != nil {
+		return nil, err
+	}
+
+	groups := entry.GetAttributeValues("memberOf")
+	if a.config.GroupFilter != "" {
+		result, err := conn.Search(ldap.NewSearchRequest(
+			a.config.GroupBaseDN, ldap.ScopeWholeSubtree, ldap.NeverDerefAliases, 0, 0, false,
+			fmt.Sprintf(a.config.GroupFilter, ldap.EscapeFilter(entry.DN)),
+			[]string{"cn"},
+			nil,
+		))
+		if err != nil {
+			return nil, fmt.Errorf("error searching LDAP groups: %v", err)
+		}
+		for _, group := range result.Entries {
+			groups = append(groups, group.DN)
+		}
+	}
+
+	preferred := entry.GetAttributeValue(a.config.UsernameAttribute)
+	if preferred == "" {
+		preferred = username
+	}
+
+	identity := &ExternalIdentity{
+		Issuer:   a.config.URL,
+		Subject:  strings.ToLower(entry.DN),
+		Email:    entry.GetAttributeValue(a.config.EmailAttribute),
+		Username: preferred,
+		Groups:   groupNames(groups),
+	}
+	if a.config.TrustEmail {
+		verified := true
+		identity.EmailVerified = &verified
+		identity.EmailManaged = true
+	}
+
+	return identity, nil
+}
+
+func (a *LDAPAuthenticator) bindServiceAccount(conn ldapConn) error {
+	if a.config.BindDN == "" {
+		return nil
+	}
+	if err := conn.Bind(a.config.BindDN, a.config.BindPassword); err != nil {
+		return fmt.Errorf("error binding LDAP service account: %v", err)
+	}
+	return nil
+}
+
+// ldapRole maps directory groups to a role; the directory decides the role of
+// its users.
+func ldapRole(groups, adminGroups, cateringGroups []string) string {
+	switch {
+	case inGroups(groups, adminGroups):
+		return models.RoleAdmin
+	case inGroups(groups, cateringGroups):
+		return models.RoleCatering
+	default:
+		return models.RoleUser
+	}
+}
+
+// groupNames returns each group DN followed by its CN, so groups can be
+// configured either way.
+func groupNames(dns []string) []string {
+	var names []string
+	for _, dn := range dns {
+		names = append(names, dn)
+
+		parsed, err := ldap.ParseDN(dn)
+		if err != nil || len(parsed.RDNs) == 0 {
+			continue
+		}
+		for _, attribute := range parsed.RDNs[0].Attributes {
+			if strings.EqualFold(attribute.Type, "cn") {
+				names = append(names, attribute.Value)
+			}
+		}
+	}
+	return names
+}
@@ -0,0 +1,253 @@
+package services
+
+import (
+	"crypto/tls"
+	"e-meetingproject/internal/models"
+	"errors"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/go-ldap/ldap/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeDirectory is an in-process LDAP stand-in. Searches return the entries
+// below the base DN that match every (attribute=value) term of the filter.
+type fakeDirectory struct {
+	passwords map[string]string // DN -> password
+	entries   []*ldap.Entry
+	boundDN   string
+	dialErr   error
+}
+
+var filterTerm = regexp.MustCompile(`\(([^=()&|!]+)=([^()]*)\)`)
+
+func (d *fakeDirectory) Bind(dn, password string) error {
+	if expected, ok := d.passwords[dn]; !ok || expected != password {
+		return ldap.NewError(ldap.LDAPResultInvalidCredentials, errors.New("invalid credentials"))
+	}
+	d.boundDN = dn
+	return nil
+}
+
+func (d *fakeDirectory) Search(request *ldap.SearchRequest) (*ldap.SearchResult, error) {
+	if d.boundDN == "" {
+		return nil, ldap.NewError(ldap.LDAPResultInsufficientAccessRights, errors.New("bind required"))
+	}
+
+	result := &ldap.SearchResult{}
+	for _, entry := range d.entries {
+		if !strings.HasSuffix(strings.ToLower(entry.DN), strings.ToLower(request.BaseDN)) {
+			continue
+		}
+		matches := true
+		for _, term := range filterTerm.FindAllStringSubmatch(request.Filter, -1) {
+			found := false
+			for _, value := range entry.GetAttributeValues(term[1]) {
+				if strings.EqualFold(ldap.EscapeFilter(value), term[2]) {
+					found = true
+				}
+			}
+			matches = matches && found
+		}
+		if matches {
+			result.Entries = append(result.Entries, entry)
+		}
+	}
+	return result, nil
+}
+
+func (d *fakeDirectory) StartTLS(*tls.Config) error { return nil }
+
+func (d *fakeDirectory) Close() error { return nil }
+
+func newFakeDirectory() *fakeDirectory {
+	const (
+		jane  = "CN=Jane Doe,OU=Staff,DC=corp,DC=example"
+		chef  = "CN=Sam Cook,OU=Staff,DC=corp,DC=example"
+		admin = "CN=Meeting Admins,OU=Groups,DC=corp,DC=example"
+	)
+	return &fakeDirectory{
+		passwords: map[string]string{
+			"CN=svc-meeting,OU=Service,DC=corp,DC=example": "service-secret",
+			jane: "jane-pass",
+			chef: "chef-pass",
+		},
+		entries: []*ldap.Entry{
+			ldap.NewEntry(jane, map[string][]string{
+				"objectClass":    {"user"},
+				"sAMAccountName": {"JDoe"},
+				"mail":           {"jane.doe@corp.example"},
+				"memberOf":       {admin},
+			}),
+			ldap.NewEntry(chef, map[string][]string{
+				"objectClass":    {"user"},
+				"sAMAccountName": {"scook"},
+				"mail":           {"sam.cook@corp.example"},
+			}),
+			ldap.NewEntry("CN=Kitchen,OU=Groups,DC=corp,DC=example", map[string][]string{
+				"objectClass": {"group"},
+				"member":      {chef},
+			}),
+		},
+	}
+}
+
+func newTestLDAPAuthenticator(directory *fakeDirectory) *LDAPAuthenticator {
+	authenticator := NewLDAPAuthenticator(nil, LDAPConfig{
+		URL:            "ldap://dc.corp.example",
+		BindDN:         "CN=svc-meeting,OU=Service,DC=corp,DC=example",
+		BindPassword:   "service-secret",
+		BaseDN:         "DC=corp,DC=example",
+		GroupFilter:    "(&(objectClass=group)(member=%s))",
+		AdminGroups:    []string{"Meeting Admins"},
+		CateringGroups: []string{"CN=Kitchen,OU=Groups,DC=corp,DC=example"},
+	})
+	authenticator.dial = func(LDAPConfig) (ldapConn, error) {
+		if directory.dialErr != nil {
+			return nil, directory.dialErr
+		}
+		directory.boundDN = ""
+		return directory, nil
+	}
+	return authenticator
+}
+
+func TestLDAPAuthenticatorLookup(t *testing.T) {
+	tests := []struct {
+		name             string
+		username         string
+		password         string
+		dialErr          error
+		expectedEmail    string
+		expectedUsername string
+		expectedRole     string
+		expectedError    string
+	}{
+		{
+			name:             "Admin by memberOf",
+			username:         "jdoe",
+			password:         "jane-pass",
+			expectedEmail:    "jane.doe@corp.example",
+			expectedUsername: "JDoe",
+			expectedRole:     models.RoleAdmin,
+		},
+		{
+			name:             "Catering by group search",
+			username:         "scook",
+			password:         "chef-pass",
+			expectedEmail:    "sam.cook@corp.example",
+			expectedUsername: "scook",
+			expectedRole:     models.RoleCatering,
+		},
+		{name: "Wrong password", username: "jdoe", password: "guess", expectedError: "invalid credentials"},
+		{name: "Empty password is never bound", username: "jdoe", password: "", expectedError: "invalid credentials"},
+		{name: "Unknown user", username: "admin", password: "admin123", expectedError: "unknown user"},
+		{name: "Filter injection", username: "*", password: "jane-pass", expectedError: "unknown user"},
+		{
+			name:          "Directory unavailable",
+			username:      "jdoe",
+			password:      "jane-pass",
+			dialErr:       errors.New("connection refused"),
+			expectedError: "error connecting to LDAP: connection refused",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			directory := newFakeDirectory()
+			directory.dialErr = tt.dialErr
+			authenticator := newTestLDAPAuthenticator(directory)
+
+			identity, err := authenticator.lookup(tt.username, tt.password)
+			if tt.expectedError != "" {
+				assert.EqualError(t, err, tt.expectedError)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, "ldap://dc.corp.example", identity.Issuer)
+			assert.Equal(t, tt.expectedEmail, identity.Email)
+			assert.Equal(t, tt.expectedUsername, identity.Username)
+			assert.Equal(t, tt.expectedRole, ldapRole(identity.Groups, authenticator.config.AdminGroups, authenticator.config.CateringGroups))
+			assert.Nil(t, identity.EmailVerified, "the directory does not say unless trusted")
+		})
+	}
+}
+
+func TestLDAPAuthenticatorTrustEmail(t *testing.T) {
+	authenticator := newTestLDAPAuthenticator(newFakeDirectory())
+	authenticator.config.TrustEmail = true
+
+	identity, err := authenticator.lookup("jdoe", "jane-pass")
+	require.NoError(t, err)
+	require.NotNil(t, identity.EmailVerified)
+	assert.True(t, *identity.EmailVerified)
+	assert.True(t, identity.EmailManaged)
+	assert.True(t, canLinkByEmail(identity, true, models.RoleUser), "existing local users are linked to their directory entry")
+}
+
+// stubAuthenticator returns a fixed result.
+type stubAuthenticator struct {
+	name  string
+	user  *models.User
+	err   error
+	calls int
+}
+
+func (a *stubAuthenticator) Name() string { return a.name }
+
+func (a *stubAuthenticator) Authenticate(string, string) (*models.User, error) {
+	a.calls++
+	return a.user, a.err
+}
+
+func TestAuthServiceAuthenticate(t *testing.T) {
+	directoryUser := &models.User{Username: "jdoe"}
+	localUser := &models.User{Username: "admin"}
+
+	tests := []struct {
+		name          string
+		directoryErr  error
+		localErr      error
+		expected      *models.User
+		localCalls    int
+		expectedError string
+	}{
+		{name: "Directory user", expected: directoryUser},
+		{name: "Falls back to local account", directoryErr: errUnknownUser, expected: localUser, localCalls: 1},
+		{name: "Directory unavailable", directoryErr: errors.New("error connecting to LDAP: timeout"), expected: localUser, localCalls: 1},
+		{name: "Wrong directory password", directoryErr: errors.New("invalid credentials"), expectedError: "invalid credentials"},
+		{
+			name:          "Directory user cannot be linked",
+			directoryErr:  errLinkRefused,
+			expectedError: "an account with this email already exists and must be linked explicitly",
+		},
+		{
+			name:          "Unknown everywhere",
+			directoryErr:  errUnknownUser,
+			localErr:      errUnknownUser,
+			localCalls:    1,
+			expectedError: "invalid credentials",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			directory := &stubAuthenticator{name: "ldap", user: directoryUser, err: tt.directoryErr}
+			local := &stubAuthenticator{name: "local", user: localUser, err: tt.localErr}
+			service := &AuthService{authenticators: []Authenticator{directory, local}}
+
+			user, err := service.authenticate("someone", "secret")
+			if tt.expectedError != "" {
+				assert.EqualError(t, err, tt.expectedError)
+			} else {
+				require.NoError(t, err)
+				assert.Same(t, tt.expected, user)
+			}
+			assert.Equal(t, tt.localCalls, local.calls)
+		})
+	}
+}
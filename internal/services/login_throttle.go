@@ -0,0 +1,231 @@
+package services
+
+import (
+	"database/sql"
+	"e-meetingproject/internal/models"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/spf13/viper"
+)
+
+// maxLoginDelay caps the wait between failed attempts on one account.
+const maxLoginDelay = 30 * time.Second
+
+// loginThrottle limits password and second factor guessing. Each failed
+// attempt on an account
+// makes the next one wait longer, and the account is locked after
+// maxFailures; once the lock expires every further failure locks it again
+// until a login succeeds or an admin unlocks it. A client IP is locked when
+// it fails ipMaxFailures times within ipWindow, whichever accounts it tries.
+type loginThrottle struct {
+	maxFailures   int
+	lockout       time.Duration
+	ipMaxFailures int
+	ipWindow      time.Duration
+}
+
+func newLoginThrottle() loginThrottle {
+	throttle := loginThrottle{
+		maxFailures:   viper.GetInt("LOGIN_MAX_FAILURES"),
+		lockout:       time.Duration(viper.GetInt("LOGIN_LOCKOUT_MINUTES")) * time.Minute,
+		ipMaxFailures: viper.GetInt("LOGIN_IP_MAX_FAILURES"),
+		ipWindow:      time.Duration(viper.GetInt("LOGIN_IP_WINDOW_MINUTES")) * time.Minute,
+	}
+	if throttle.maxFailures == 0 {
+		throttle.maxFailures = 5 // default to 5 attempts
+	}
+	if throttle.lockout == 0 {
+		throttle.lockout = 15 * time.Minute // default to 15 minutes
+	}
+	if throttle.ipMaxFailures == 0 {
+		throttle.ipMaxFailures = 20 // default to 20 attempts
+	}
+	if throttle.ipWindow == 0 {
+		throttle.ipWindow = 15 * time.Minute // default to 15 minutes
+	}
+	return throttle
+}
+
+// check returns a *models.TooManyAttemptsError while the account or the
+// client IP has to wait. Unknown usernames are only limited by IP.
+func (t loginThrottle) check(q queryRower, username, ip string, now time.Time) error {
+	var ipLockedUntil sql.NullTime
+	err := q.QueryRow(`
+		SELECT locked_until FROM login_ip_attempts WHERE ip = $1
+	`, ip).Scan(&ipLockedUntil)
+	if err != nil && err != sql.ErrNoRows {
+		return fmt.Errorf("error checking login attempts: %v", err)
+	}
+
+	var failures int
+	var lastFailed, lockedUntil sql.NullTime
+	err = q.QueryRow(`
+		SELECT failed_login_count, last_failed_login_at, locked_until FROM users WHERE username = $1
+	`, username).Scan(&failures, &lastFailed, &lockedUntil)
+	if err != nil && err != sql.ErrNoRows {
+		return fmt.Errorf("error checking login attempts: %v", err)
+	}
+
+	retryAfter := untilTime(ipLockedUntil, now)
+	if wait := t.accountRetryAfter(failures, lastFailed, lockedUntil, now); wait > retryAfter {
+		retryAfter = wait
+	}
+	if retryAfter > 0 {
+		return &models.TooManyAttemptsError{RetryAfter: retryAfter}
+	}
+
+	return nil
+}
+
+// recordFailure counts a wrong password or second factor for the account and
+// the client IP in its own transaction, see countFailure.
+func (t loginThrottle) recordFailure(db *sql.DB, username, ip, event string, now time.Time) error {
+	// Start transaction
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("error starting transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	if err := t.countFailure(tx, username, ip, event, now); err != nil {
+		return err
+	}
+
+	// Commit transaction
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("error committing transaction: %v", err)
+	}
+
+	return nil
+}
+
+// countFailure counts a failed attempt for the account and the client IP,
+// locking either when it reaches its limit, and audits it as event.
+func (t loginThrottle) countFailure(tx *sql.Tx, username, ip, event string, now time.Time) error {
+	// Forget IPs that stopped failing
+	_, err := tx.Exec(`
+		DELETE FROM login_ip_attempts
+		WHERE last_failed_at < $1 AND (locked_until IS NULL OR locked_until < $2)
+	`, now.Add(-t.ipWindow), now)
+	if err != nil {
+		return fmt.Errorf("error purging login attempts: %v", err)
+	}
+
+	var ipFailures int
+	err = tx.QueryRow(`
+		INSERT INTO login_ip_attempts (ip, failures, window_started_at, last_failed_at)
+		VALUES ($1, 1, $2, $2)
+		ON CONFLICT (ip) DO UPDATE SET
+			failures = CASE WHEN login_ip_attempts.window_started_at < $3 THEN 1 ELSE login_ip_attempts.failures + 1 END,
+			window_started_at = CASE WHEN login_ip_attempts.window_started_at < $3 THEN $2 ELSE login_ip_attempts.window_started_at END,
+			last_failed_at = $2
+		RETURNING failures
+	`, ip, now, now.Add(-t.ipWindow)).Scan(&ipFailures)
+	if err != nil {
+		return fmt.Errorf("error recording login attempt: %v", err)
+	}
+	if ipFailures >= t.ipMaxFailures {
+		_, err = tx.Exec(`UPDATE login_ip_attempts SET locked_until = $1 WHERE ip = $2`, now.Add(t.lockout), ip)
+		if err != nil {
+			return fmt.Errorf("error locking IP: %v", err)
+		}
+		if ipFailures == t.ipMaxFailures {
+			err = recordAuthEvent(tx, authAuditEntry{
+				Event:  models.AuthEventIPLocked,
+				IP:     ip,
+				Detail: fmt.Sprintf("%d failed attempts within %s", ipFailures, t.ipWindow),
+			})
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	var userID uuid.UUID
+	var failures int
+	err = tx.QueryRow(`
+		UPDATE users
+		SET failed_login_count = failed_login_count + 1, last_failed_login_at = $1
+		WHERE username = $2
+		RETURNING id, failed_login_count
+	`, now, username).Scan(&userID, &failures)
+	if err != nil && err != sql.ErrNoRows {
+		return fmt.Errorf("error recording login attempt: %v", err)
+	}
+
+	if err == nil && failures >= t.maxFailures {
+		_, err = tx.Exec(`UPDATE users SET locked_until = $1 WHERE id = $2`, now.Add(t.lockout), userID)
+		if err != nil {
+			return fmt.Errorf("error locking account: %v", err)
+		}
+		err = recordAuthEvent(tx, authAuditEntry{
+			Event:    models.AuthEventAccountLocked,
+			UserID:   userID,
+			Username: username,
+			IP:       ip,
+			Detail:   fmt.Sprintf("%d failed attempts, locked for %s", failures, t.lockout),
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return recordAuthEvent(tx, authAuditEntry{
+		Event:    event,
+		UserID:   userID,
+		Username: username,
+		IP:       ip,
+	})
+}
+
+// reset clears the failed attempts of an account once it logged in.
+func (t loginThrottle) reset(e execer, userID uuid.UUID) error {
+	_, err := e.Exec(`
+		UPDATE users
+		SET failed_login_count = 0, last_failed_login_at = NULL, locked_until = NULL
+		WHERE id = $1
+	`, userID)
+	if err != nil {
+		return fmt.Errorf("error resetting failed logins: %v", err)
+	}
+	return nil
+}
+
+// accountRetryAfter is how long an account must wait before the next
+// attempt: until its lock expires, or until the delay after the last failure
+// has passed.
+func (t loginThrottle) accountRetryAfter(failures int, lastFailed, lockedUntil sql.NullTime, now time.Time) time.Duration {
+	wait := untilTime(lockedUntil, now)
+	if lastFailed.Valid {
+		next := sql.NullTime{Time: lastFailed.Time.Add(loginDelay(failures)), Valid: true}
+		if delay := untilTime(next, now); delay > wait {
+			wait = delay
+		}
+	}
+	return wait
+}
+
+// loginDelay is the wait after failures consecutive failed attempts: none
+// after the first, then doubling from one second up to maxLoginDelay.
+func loginDelay(failures int) time.Duration {
+	if failures < 2 {
+		return 0
+	}
+	if failures > 7 {
+		return maxLoginDelay
+	}
+	delay := time.Second << (failures - 2)
+	if delay > maxLoginDelay {
+		return maxLoginDelay
+	}
+	return delay
+}
+
+func untilTime(t sql.NullTime, now time.Time) time.Duration {
+	if !t.Valid || !t.Time.After(now) {
+		return 0
+	}
+	return t.Time.Sub(now)
+}
@@ -0,0 +1,76 @@
+package services
+
+import (
+	"database/sql"
+	"e-meetingproject/internal/models"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoginDelay(t *testing.T) {
+	tests := []struct {
+		failures int
+		expected time.Duration
+	}{
+		{failures: 0, expected: 0},
+		{failures: 1, expected: 0},
+		{failures: 2, expected: time.Second},
+		{failures: 3, expected: 2 * time.Second},
+		{failures: 6, expected: 16 * time.Second},
+		{failures: 7, expected: maxLoginDelay},
+		{failures: 100, expected: maxLoginDelay},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.expected, loginDelay(tt.failures), "failures: %d", tt.failures)
+	}
+}
+
+func TestAccountRetryAfter(t *testing.T) {
+	throttle := loginThrottle{maxFailures: 5, lockout: 15 * time.Minute}
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	at := func(offset time.Duration) sql.NullTime {
+		return sql.NullTime{Time: now.Add(offset), Valid: true}
+	}
+
+	tests := []struct {
+		name        string
+		failures    int
+		lastFailed  sql.NullTime
+		lockedUntil sql.NullTime
+		expected    time.Duration
+	}{
+		{name: "No failures"},
+		{name: "First failure", failures: 1, lastFailed: at(0)},
+		{name: "Delay pending", failures: 3, lastFailed: at(-500 * time.Millisecond), expected: 1500 * time.Millisecond},
+		{name: "Delay passed", failures: 3, lastFailed: at(-time.Minute)},
+		{name: "Locked", failures: 5, lastFailed: at(-time.Minute), lockedUntil: at(14 * time.Minute), expected: 14 * time.Minute},
+		{name: "Lock expired", failures: 5, lastFailed: at(-time.Hour), lockedUntil: at(-45 * time.Minute)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, throttle.accountRetryAfter(tt.failures, tt.lastFailed, tt.lockedUntil, now))
+		})
+	}
+}
+
+func TestTooManyAttemptsErrorRetryAfterSeconds(t *testing.T) {
+	tests := []struct {
+		retryAfter time.Duration
+		expected   string
+	}{
+		{retryAfter: 0, expected: "1"},
+		{retryAfter: 400 * time.Millisecond, expected: "1"},
+		{retryAfter: 1500 * time.Millisecond, expected: "2"},
+		{retryAfter: 15 * time.Minute, expected: "900"},
+	}
+
+	for _, tt := range tests {
+		err := &models.TooManyAttemptsError{RetryAfter: tt.retryAfter}
+		assert.Equal(t, tt.expected, err.RetryAfterSeconds())
+		assert.EqualError(t, err, "too many failed login attempts")
+	}
+}
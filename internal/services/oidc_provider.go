@@ -0,0 +1,425 @@
+package services
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// oidcJWKSRefreshInterval limits how often unknown key IDs trigger a refetch
+// of the provider's JWKS.
+const oidcJWKSRefreshInterval = time.Minute
+
+// OIDCConfig configures the OpenID Connect provider used for single sign-on.
+type OIDCConfig struct {
+	IssuerURL    string
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+	Scopes       []string // "openid" is always requested
+	GroupsClaim  string   // ID token claim holding the user's groups, defaults to "groups"
+	MFAACRValues []string // acr values of the provider that mean multi-factor authentication
+	HTTPClient   *http.Client
+}
+
+// OIDCProvider runs the authorization-code flow with PKCE against an OpenID
+// Connect provider and verifies the ID tokens it returns. The provider
+// metadata is discovered on first use.
+type OIDCProvider struct {
+	config OIDCConfig
+	client *http.Client
+
+	mu            sync.Mutex
+	metadata      *oidcMetadata
+	keys          map[string]crypto.PublicKey
+	keysFetchedAt time.Time
+}
+
+type oidcMetadata struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+type oidcTokenResponse struct {
+	IDToken          string `json:"id_token"`
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description"`
+}
+
+type oidcJWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+func NewOIDCProvider(config OIDCConfig) *OIDCProvider {
+	config.IssuerURL = strings.TrimSuffix(config.IssuerURL, "/")
+	if config.GroupsClaim == "" {
+		config.GroupsClaim = "groups"
+	}
+
+	client := config.HTTPClient
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+
+	return &OIDCProvider{
+		config: config,
+		client: client,
+	}
+}
+
+// AuthCodeURL returns the provider URL the user is sent to for signing in.
+// codeVerifier is the PKCE verifier later passed to Exchange.
+func (p *OIDCProvider) AuthCodeURL(state, nonce, codeVerifier string) (string, error) {
+	metadata, err := p.discover()
+	if err != nil {
+		return "", err
+	}
+
+	scopes := []string{"openid"}
+	for _, scope := range p.config.Scopes {
+		if scope != "openid" {
+			scopes = append(scopes, scope)
+		}
+	}
+
+	query := url.Values{
+		"response_type":         {"code"},
+		"client_id":             {p.config.ClientID},
+		"redirect_uri":          {p.config.RedirectURL},
+		"scope":                 {strings.Join(scopes, " ")},
+		"state":                 {state},
+		"nonce":                 {nonce},
+		"code_challenge":        {pkceChallenge(codeVerifier)},
+		"code_challenge_method": {"S256"},
+	}
+
+	separator := "?"
+	if strings.Contains(metadata.AuthorizationEndpoint, "?") {
+		separator = "&"
+	}
+	return metadata.AuthorizationEndpoint + separator + query.Encode(), nil
+}
+
+// Exchange redeems an authorization code and returns the identity from the
+// verified ID token, which must carry nonce.
+func (p *OIDCProvider) Exchange(code, codeVerifier, nonce string) (*ExternalIdentity, error) {
+	metadata, err := p.discover()
+	if err != nil {
+		return nil, err
+	}
+
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {p.config.RedirectURL},
+		"client_id":     {p.config.ClientID},
+		"code_verifier": {codeVerifier},
+	}
+	req, err := http.NewRequest(http.MethodPost, metadata.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, fmt.Errorf("error creating token request: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if p.config.ClientSecret != "" {
+		req.SetBasicAuth(url.QueryEscape(p.config.ClientID), url.QueryEscape(p.config.ClientSecret))
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error calling token endpoint: %v", err)
+	}
+	defer resp.Body.Close()
+
+	var token oidcTokenResponse
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&token); err != nil {
+		return nil, fmt.Errorf("error decoding token response: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK || token.Error != "" {
+		return nil, fmt.Errorf("token exchange failed: %s %s", token.Error, token.ErrorDescription)
+	}
+	if token.IDToken == "" {
+		return nil, errors.New("token exchange failed: no id_token in response")
+	}
+
+	return p.verifyIDToken(token.IDToken, nonce)
+}
+
+// verifyIDToken checks the signature, issuer, audience, expiry and nonce of
+// an ID token.
+func (p *OIDCProvider) verifyIDToken(idToken, nonce string) (*ExternalIdentity, error) {
+	claims := jwt.MapClaims{}
+	_, err := jwt.ParseWithClaims(idToken, claims, p.keyfunc,
+		jwt.WithValidMethods([]string{"RS256", "RS384", "RS512", "ES256", "ES384", "EdDSA"}),
+		jwt.WithIssuer(p.config.IssuerURL),
+		jwt.WithAudience(p.config.ClientID),
+		jwt.WithExpirationRequired(),
+		jwt.WithLeeway(30*time.Second),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("invalid ID token: %v", err)
+	}
+
+	if tokenNonce, _ := claims["nonce"].(string); tokenNonce == "" || tokenNonce != nonce {
+		return nil, errors.New("invalid ID token: nonce mismatch")
+	}
+
+	identity, err := identityFromClaims(claims, p.config.GroupsClaim)
+	if err != nil {
+		return nil, err
+	}
+	identity.MFA = assertsMFA(claims, p.config.MFAACRValues)
+
+	return identity, nil
+}
+
+func (p *OIDCProvider) keyfunc(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	key, err := p.lookupKey(kid)
+	if err != nil && time.Since(p.keysFetchedAt) > oidcJWKSRefreshInterval {
+		// The provider may have rotated its keys
+		if fetchErr := p.fetchKeys(); fetchErr != nil {
+			return nil, fetchErr
+		}
+		key, err = p.lookupKey(kid)
+	}
+
+	return key, err
+}
+
+// lookupKey finds the key for kid. Tokens without a kid are accepted when the
+// provider publishes a single key.
+func (p *OIDCProvider) lookupKey(kid string) (crypto.PublicKey, error) {
+	if kid == "" {
+		if len(p.keys) == 1 {
+			for _, key := range p.keys {
+				return key, nil
+			}
+		}
+		return nil, errors.New("token has no key ID")
+	}
+
+	key, ok := p.keys[kid]
+	if !ok {
+		return nil, fmt.Errorf("unknown key ID: %s", kid)
+	}
+	return key, nil
+}
+
+// fetchKeys loads the provider's JWKS; p.mu must be held.
+func (p *OIDCProvider) fetchKeys() error {
+	p.keysFetchedAt = time.Now()
+
+	if p.metadata == nil {
+		return errors.New("OIDC provider metadata not loaded")
+	}
+
+	var jwks struct {
+		Keys []oidcJWK `json:"keys"`
+	}
+	if err := p.getJSON(p.metadata.JWKSURI, &jwks); err != nil {
+		return fmt.Errorf("error fetching provider keys: %v", err)
+	}
+
+	keys := make(map[string]crypto.PublicKey, len(jwks.Keys))
+	for _, jwk := range jwks.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		key, err := jwk.publicKey()
+		if err != nil {
+			// Skip keys of types we cannot use rather than failing the set
+			continue
+		}
+		keys[jwk.Kid] = key
+	}
+	p.keys = keys
+
+	return nil
+}
+
+// discover loads the provider metadata once.
+func (p *OIDCProvider) discover() (*oidcMetadata, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.metadata != nil {
+		return p.metadata, nil
+	}
+
+	var metadata oidcMetadata
+	if err := p.getJSON(p.config.IssuerURL+"/.well-known/openid-configuration", &metadata); err != nil {
+		return nil, fmt.Errorf("error discovering OIDC provider: %v", err)
+	}
+	if strings.TrimSuffix(metadata.Issuer, "/") != p.config.IssuerURL {
+		return nil, fmt.Errorf("error discovering OIDC provider: issuer %q does not match %q", metadata.Issuer, p.config.IssuerURL)
+	}
+	if metadata.AuthorizationEndpoint == "" || metadata.TokenEndpoint == "" || metadata.JWKSURI == "" {
+		return nil, errors.New("error discovering OIDC provider: incomplete provider metadata")
+	}
+
+	p.metadata = &metadata
+	if err := p.fetchKeys(); err != nil {
+		p.metadata = nil
+		return nil, err
+	}
+
+	return p.metadata, nil
+}
+
+func (p *OIDCProvider) getJSON(endpoint string, v interface{}) error {
+	resp, err := p.client.Get(endpoint)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %d from %s", resp.StatusCode, endpoint)
+	}
+	return json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(v)
+}
+
+func (jwk oidcJWK) publicKey() (crypto.PublicKey, error) {
+	decode := base64.RawURLEncoding.DecodeString
+
+	switch jwk.Kty {
+	case "RSA":
+		n, err := decode(jwk.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decode(jwk.E)
+		if err != nil {
+			return nil, err
+		}
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch jwk.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		default:
+			return nil, fmt.Errorf("unsupported curve %s", jwk.Crv)
+		}
+		x, err := decode(jwk.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decode(jwk.Y)
+		if err != nil {
+			return nil, err
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}, nil
+	case "OKP":
+		if jwk.Crv != "Ed25519" {
+			return nil, fmt.Errorf("unsupported curve %s", jwk.Crv)
+		}
+		x, err := decode(jwk.X)
+		if err != nil {
+			return nil, err
+		}
+		if len(x) != ed25519.PublicKeySize {
+			return nil, errors.New("invalid Ed25519 key")
+		}
+		return ed25519.PublicKey(x), nil
+	default:
+		return nil, fmt.Errorf("unsupported key type %s", jwk.Kty)
+	}
+}
+
+// identityFromClaims reads the identity from verified ID token claims. The
+// groups claim may be a list or a single string; a missing email_verified
+// claim means the email is not verified.
+func identityFromClaims(claims jwt.MapClaims, groupsClaim string) (*ExternalIdentity, error) {
+	identity := &ExternalIdentity{}
+	identity.Issuer, _ = claims["iss"].(string)
+	identity.Subject, _ = claims["sub"].(string)
+	identity.Email, _ = claims["email"].(string)
+	identity.Name, _ = claims["name"].(string)
+
+	if identity.Subject == "" {
+		return nil, errors.New("invalid ID token: missing subject")
+	}
+
+	// An email the provider does not assert as verified counts as unverified
+	verified := false
+	switch value := claims["email_verified"].(type) {
+	case bool:
+		verified = value
+	case string: // Some providers send "true"/"false"
+		verified = value == "true"
+	}
+	identity.EmailVerified = &verified
+
+	switch groups := claims[groupsClaim].(type) {
+	case []interface{}:
+		for _, group := range groups {
+			if name, ok := group.(string); ok {
+				identity.Groups = append(identity.Groups, name)
+			}
+		}
+	case string:
+		identity.Groups = []string{groups}
+	}
+
+	return identity, nil
+}
+
+// assertsMFA reports whether ID token claims say the user passed multi-factor
+// authentication: an amr claim listing "mfa" (RFC 8176), or an acr claim the
+// provider uses for it.
+func assertsMFA(claims jwt.MapClaims, mfaACRValues []string) bool {
+	if methods, ok := claims["amr"].([]interface{}); ok {
+		for _, method := range methods {
+			if method == "mfa" {
+				return true
+			}
+		}
+	}
+
+	acr, _ := claims["acr"].(string)
+	for _, value := range mfaACRValues {
+		if acr != "" && acr == value {
+			return true
+		}
+	}
+	return false
+}
+
+// pkceChallenge is the S256 code challenge for verifier (RFC 7636).
+func pkceChallenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
@@ -0,0 +1,275 @@
+package services
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// mockIdP is a minimal OpenID Connect provider issuing one authorization code.
+type mockIdP struct {
+	server    *httptest.Server
+	key       *rsa.PrivateKey
+	clientID  string
+	challenge string // code_challenge of the last authorization request
+	claims    jwt.MapClaims
+}
+
+func newMockIdP(t *testing.T) *mockIdP {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	idp := &mockIdP{key: key, clientID: "meeting-app"}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]string{
+			"issuer":                 idp.server.URL,
+			"authorization_endpoint": idp.server.URL + "/authorize",
+			"token_endpoint":         idp.server.URL + "/token",
+			"jwks_uri":               idp.server.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"keys": []map[string]string{{
+				"kty": "RSA",
+				"kid": "idp-key",
+				"use": "sig",
+				"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+				"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+			}},
+		})
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		clientID, secret, _ := r.BasicAuth()
+		if r.Form.Get("code") != "valid-code" || clientID != idp.clientID || secret != "secret" ||
+			pkceChallenge(r.Form.Get("code_verifier")) != idp.challenge {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": "invalid_grant"})
+			return
+		}
+
+		token := jwt.NewWithClaims(jwt.SigningMethodRS256, idp.claims)
+		token.Header["kid"] = "idp-key"
+		signed, _ := token.SignedString(key)
+		json.NewEncoder(w).Encode(map[string]string{"id_token": signed, "token_type": "Bearer"})
+	})
+	idp.server = httptest.NewServer(mux)
+	t.Cleanup(idp.server.Close)
+
+	return idp
+}
+
+// authorize follows the provider redirect like a browser would and records
+// the PKCE challenge and nonce.
+func (idp *mockIdP) authorize(t *testing.T, provider *OIDCProvider, verifier, nonce string) {
+	authURL, err := provider.AuthCodeURL("state-1", nonce, verifier)
+	require.NoError(t, err)
+
+	parsed, err := url.Parse(authURL)
+	require.NoError(t, err)
+	query := parsed.Query()
+	assert.Equal(t, idp.server.URL+"/authorize", parsed.Scheme+"://"+parsed.Host+parsed.Path)
+	assert.Equal(t, "S256", query.Get("code_challenge_method"))
+	assert.Equal(t, "openid email", query.Get("scope"))
+	assert.Equal(t, "state-1", query.Get("state"))
+
+	idp.challenge = query.Get("code_challenge")
+}
+
+func TestOIDCProviderExchange(t *testing.T) {
+	idp := newMockIdP(t)
+	provider := NewOIDCProvider(OIDCConfig{
+		IssuerURL:    idp.server.URL + "/",
+		ClientID:     idp.clientID,
+		ClientSecret: "secret",
+		RedirectURL:  "http://localhost:8080/oidc/callback",
+		Scopes:       []string{"email"},
+	})
+
+	validClaims := func() jwt.MapClaims {
+		return jwt.MapClaims{
+			"iss":            idp.server.URL,
+			"sub":            "employee-42",
+			"aud":            idp.clientID,
+			"exp":            time.Now().Add(time.Minute).Unix(),
+			"nonce":          "nonce-1",
+			"email":          "jane.doe@example.com",
+			"email_verified": true,
+			"groups":         []string{"staff", "meeting-admins"},
+		}
+	}
+
+	tests := []struct {
+		name          string
+		modify        func(claims jwt.MapClaims)
+		code          string
+		verifier      string
+		expectedError string
+	}{
+		{name: "Valid sign-in", modify: func(jwt.MapClaims) {}},
+		{
+			name:          "Wrong code verifier",
+			modify:        func(jwt.MapClaims) {},
+			verifier:      "another-verifier-another-verifier-another-v",
+			expectedError: "token exchange failed: invalid_grant ",
+		},
+		{
+			name:          "Unknown code",
+			modify:        func(jwt.MapClaims) {},
+			code:          "stolen-code",
+			expectedError: "token exchange failed: invalid_grant ",
+		},
+		{
+			name:          "Nonce mismatch",
+			modify:        func(claims jwt.MapClaims) { claims["nonce"] = "other" },
+			expectedError: "invalid ID token: nonce mismatch",
+		},
+		{
+			name:          "Issued for another client",
+			modify:        func(claims jwt.MapClaims) { claims["aud"] = "other-app" },
+			expectedError: "invalid ID token: token has invalid claims: token has invalid audience",
+		},
+		{
+			name:          "Expired",
+			modify:        func(claims jwt.MapClaims) { claims["exp"] = time.Now().Add(-time.Hour).Unix() },
+			expectedError: "invalid ID token: token has invalid claims: token is expired",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			verifier := "correct-verifier-correct-verifier-correct-v"
+			idp.authorize(t, provider, verifier, "nonce-1")
+
+			idp.claims = validClaims()
+			tt.modify(idp.claims)
+
+			code := tt.code
+			if code == "" {
+				code = "valid-code"
+			}
+			if tt.verifier != "" {
+				verifier = tt.verifier
+			}
+
+			identity, err := provider.Exchange(code, verifier, "nonce-1")
+			if tt.expectedError != "" {
+				assert.EqualError(t, err, tt.expectedError)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, idp.server.URL, identity.Issuer)
+			assert.Equal(t, "employee-42", identity.Subject)
+			assert.Equal(t, "jane.doe@example.com", identity.Email)
+			require.NotNil(t, identity.EmailVerified)
+			assert.True(t, *identity.EmailVerified)
+			assert.Equal(t, []string{"staff", "meeting-admins"}, identity.Groups)
+		})
+	}
+}
+
+func TestOIDCRole(t *testing.T) {
+	adminGroups := []string{"meeting-admins"}
+
+	tests := []struct {
+		name     string
+		groups   []string
+		current  string
+		expected string
+	}{
+		{name: "New user", groups: []string{"staff"}, expected: "user"},
+		{name: "New admin", groups: []string{"Meeting-Admins"}, expected: "admin"},
+		{name: "User promoted", groups: []string{"meeting-admins"}, current: "user", expected: "admin"},
+		{name: "Admin left the group", groups: []string{"staff"}, current: "admin", expected: "user"},
+		{name: "Catering role kept", groups: []string{"staff"}, current: "catering", expected: "catering"},
+		{name: "Catering staff made admin", groups: []string{"meeting-admins"}, current: "catering", expected: "admin"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, oidcRole(tt.groups, adminGroups, tt.current))
+		})
+	}
+}
+
+func TestIdentityFromClaimsEmailVerified(t *testing.T) {
+	tests := []struct {
+		name     string
+		verified interface{}
+		expected bool
+	}{
+		{name: "Verified", verified: true, expected: true},
+		{name: "Verified as string", verified: "true", expected: true},
+		{name: "Not verified", verified: false},
+		{name: "Not verified as string", verified: "false"},
+		{name: "Claim missing"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims := jwt.MapClaims{"sub": "employee-42", "email": "jane.doe@example.com"}
+			if tt.verified != nil {
+				claims["email_verified"] = tt.verified
+			}
+
+			identity, err := identityFromClaims(claims, "groups")
+			require.NoError(t, err)
+			require.NotNil(t, identity.EmailVerified)
+			assert.Equal(t, tt.expected, *identity.EmailVerified)
+		})
+	}
+}
+
+func TestUsernameFromEmail(t *testing.T) {
+	tests := []struct {
+		email    string
+		expected string
+	}{
+		{email: "Jane.Doe@example.com", expected: "jane.doe"},
+		{email: "j+meetings@example.com", expected: "jmeetings"},
+		{email: "al@example.com", expected: "al_"},
+		{email: "a-very-long-local-part-that-goes-on-and-on-and-on@example.com", expected: "a-very-long-local-part-that-goes-on-and-"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.email, func(t *testing.T) {
+			assert.Equal(t, tt.expected, usernameFromEmail(tt.email))
+		})
+	}
+}
+
+func TestAssertsMFA(t *testing.T) {
+	mfaACRValues := []string{"http://schemas.openid.net/pape/policies/2007/06/multi-factor"}
+
+	tests := []struct {
+		name     string
+		claims   jwt.MapClaims
+		expected bool
+	}{
+		{name: "No claims"},
+		{name: "Password only", claims: jwt.MapClaims{"amr": []interface{}{"pwd"}}},
+		{name: "Multi-factor method", claims: jwt.MapClaims{"amr": []interface{}{"pwd", "mfa"}}, expected: true},
+		{name: "Multi-factor acr", claims: jwt.MapClaims{"acr": mfaACRValues[0]}, expected: true},
+		{name: "Other acr", claims: jwt.MapClaims{"acr": "urn:mace:incommon:iap:silver"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, assertsMFA(tt.claims, mfaACRValues))
+		})
+	}
+}
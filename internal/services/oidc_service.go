@@ -0,0 +1,231 @@
+package services
+
+import (
+	"database/sql"
+	"e-meetingproject/internal/database"
+	"e-meetingproject/internal/models"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/spf13/viper"
+)
+
+// oidcLoginTTL is how long a user has to complete the sign-in at the provider.
+const oidcLoginTTL = 10 * time.Minute
+
+// OIDCService signs users in through the company OpenID Connect provider.
+// Users are linked to their provider identity on first sign-in, by email, or
+// provisioned when no account has that email. The provider's groups decide
+// who is an admin; other roles are managed in this service.
+type OIDCService struct {
+	db            *sql.DB
+	auth          *AuthService
+	provider      *OIDCProvider
+	adminGroups   []string
+	allowedGroups []string // Empty allows every user of the provider
+}
+
+func NewOIDCService(authService *AuthService) *OIDCService {
+	service := &OIDCService{
+		db:            database.GetDB(),
+		auth:          authService,
+		adminGroups:   splitConfigList(viper.GetString("OIDC_ADMIN_GROUPS"), ","),
+		allowedGroups: splitConfigList(viper.GetString("OIDC_ALLOWED_GROUPS"), ","),
+	}
+
+	if issuer := viper.GetString("OIDC_ISSUER_URL"); issuer != "" {
+		service.provider = NewOIDCProvider(OIDCConfig{
+			IssuerURL:    issuer,
+			ClientID:     viper.GetString("OIDC_CLIENT_ID"),
+			ClientSecret: viper.GetString("OIDC_CLIENT_SECRET"),
+			RedirectURL:  viper.GetString("OIDC_REDIRECT_URL"),
+			Scopes:       append([]string{"email", "profile"}, splitConfigList(viper.GetString("OIDC_SCOPES"), ",")...),
+			GroupsClaim:  viper.GetString("OIDC_GROUPS_CLAIM"),
+			MFAACRValues: splitConfigList(viper.GetString("OIDC_MFA_ACR_VALUES"), ","),
+		})
+	}
+
+	return service
+}
+
+// Enabled reports whether an OIDC provider is configured.
+func (s *OIDCService) Enabled() bool {
+	return s.provider != nil
+}
+
+// BeginLogin starts a sign-in and returns the provider URL to send the user
+// to. The state, nonce and PKCE verifier are kept until the callback.
+func (s *OIDCService) BeginLogin() (string, error) {
+	state, err := generateSecureToken()
+	if err != nil {
+		return "", fmt.Errorf("error generating state: %v", err)
+	}
+	nonce, err := generateSecureToken()
+	if err != nil {
+		return "", fmt.Errorf("error generating nonce: %v", err)
+	}
+	verifier, err := generateSecureToken()
+	if err != nil {
+		return "", fmt.Errorf("error generating code verifier: %v", err)
+	}
+	verifier = strings.TrimRight(verifier, "=") // PKCE verifiers are unpadded
+
+	authURL, err := s.provider.AuthCodeURL(state, nonce, verifier)
+	if err != nil {
+		return "", err
+	}
+
+	// Drop sign-ins that were never completed
+	_, err = s.db.Exec(`DELETE FROM oidc_login_requests WHERE expires_at < NOW()`)
+	if err != nil {
+		return "", fmt.Errorf("error purging login requests: %v", err)
+	}
+
+	_, err = s.db.Exec(`
+		INSERT INTO oidc_login_requests (state_hash, code_verifier, nonce, expires_at)
+		VALUES ($1, $2, $3, $4)
+	`, hashToken(state), verifier, nonce, time.Now().Add(oidcLoginTTL))
+	if err != nil {
+		return "", fmt.Errorf("error storing login request: %v", err)
+	}
+
+	return authURL, nil
+}
+
+// CompleteLogin handles the provider callback: it redeems code for the
+// user's identity, links or provisions the user and issues the same tokens as
+// a password login. Like Login, it returns a challenge instead when the user
+// must pass two-factor authentication and the provider did not assert MFA.
+// Accounts that are not active are refused.
+func (s *OIDCService) CompleteLogin(state, code, clientIP string) (*models.LoginResponse, *models.LoginChallengeResponse, error) {
+	// A state can only be used once
+	var verifier, nonce string
+	var expiresAt time.Time
+	err := s.db.QueryRow(`
+		DELETE FROM oidc_login_requests
+		WHERE state_hash = $1
+		RETURNING code_verifier, nonce, expires_at
+	`, hashToken(state)).Scan(&verifier, &nonce, &expiresAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil, errors.New("invalid login state")
+		}
+		return nil, nil, fmt.Errorf("database error: %v", err)
+	}
+	if time.Now().After(expiresAt) {
+		return nil, nil, errors.New("login request has expired")
+	}
+
+	identity, err := s.provider.Exchange(code, verifier, nonce)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !s.isAllowed(identity.Groups) {
+		return nil, nil, errors.New("not a member of an allowed group")
+	}
+
+	// Start transaction
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, nil, fmt.Errorf("error starting transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	user, _, err := linkExternalUser(tx, identity, func(current string) string {
+		return oidcRole(identity.Groups, s.adminGroups, current)
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	tokenVersion, err := lockLoginUser(tx, user)
+	if err != nil {
+		if err.Error() == "account is not active" {
+			s.auth.auditLogin(authAuditEntry{
+				Event:    models.AuthEventLoginRefused,
+				UserID:   user.ID,
+				Username: user.Username,
+				IP:       clientIP,
+				Detail:   "sso",
+			})
+		}
+		return nil, nil, err
+	}
+
+	// Users with two-factor authentication, or whose role requires it, answer
+	// a challenge as after a password, unless the provider already did MFA
+	enabled, required, err := twoFactorState(tx, user.ID, user.Role)
+	if err != nil {
+		return nil, nil, err
+	}
+	if (enabled || required) && !identity.MFA {
+		challenge, err := createLoginChallenge(tx, user.ID, time.Now())
+		if err != nil {
+			return nil, nil, err
+		}
+		challenge.EnrollmentRequired = !enabled
+
+		err = recordAuthEvent(tx, authAuditEntry{
+			Event:    models.AuthEventTwoFactorChallenged,
+			UserID:   user.ID,
+			Username: user.Username,
+			IP:       clientIP,
+			Detail:   "sso",
+		})
+		if err != nil {
+			return nil, nil, err
+		}
+
+		// Commit transaction
+		if err = tx.Commit(); err != nil {
+			return nil, nil, fmt.Errorf("error committing transaction: %v", err)
+		}
+		return nil, challenge, nil
+	}
+
+	response, _, err := s.auth.issueTokens(tx, user, tokenVersion, uuid.New())
+	if err != nil {
+		return nil, nil, err
+	}
+
+	err = recordAuthEvent(tx, authAuditEntry{
+		Event:    models.AuthEventLoginSucceeded,
+		UserID:   user.ID,
+		Username: user.Username,
+		IP:       clientIP,
+		Detail:   "sso",
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Commit transaction
+	if err = tx.Commit(); err != nil {
+		return nil, nil, fmt.Errorf("error committing transaction: %v", err)
+	}
+
+	return response, nil, nil
+}
+
+func (s *OIDCService) isAllowed(groups []string) bool {
+	if len(s.allowedGroups) == 0 {
+		return true
+	}
+	return inGroups(groups, s.allowedGroups) || inGroups(groups, s.adminGroups)
+}
+
+// oidcRole maps the provider groups to a role. Members of an admin group are
+// admins; admins who left those groups become regular users. Other roles,
+// such as catering, are kept as assigned in this service.
+func oidcRole(groups, adminGroups []string, current string) string {
+	if inGroups(groups, adminGroups) {
+		return models.RoleAdmin
+	}
+	if current == models.RoleAdmin || current == "" {
+		return models.RoleUser
+	}
+	return current
+}
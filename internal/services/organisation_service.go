@@ -0,0 +1,114 @@
+package services
+
+import (
+	"database/sql"
+	"e-meetingproject/internal/database"
+	"e-meetingproject/internal/models"
+	"errors"
+	"fmt"
+	"regexp"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// organisationSlugPattern allows lowercase letters and digits separated by
+// single hyphens, e.g. "acme-corp".
+var organisationSlugPattern = regexp.MustCompile(`^[a-z0-9]+(-[a-z0-9]+)*$`)
+
+// OrganisationService manages the tenant organisations, for super admins.
+type OrganisationService struct {
+	db *sql.DB
+}
+
+func NewOrganisationService() *OrganisationService {
+	return &OrganisationService{
+		db: database.GetDB(),
+	}
+}
+
+func (s *OrganisationService) GetOrganisations() (*models.OrganisationListResponse, error) {
+	rows, err := s.db.Query(`
+		SELECT id, name, slug, created_at, updated_at
+		FROM organisations
+		ORDER BY name ASC
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("error querying organisations: %v", err)
+	}
+	defer rows.Close()
+
+	organisations := []models.Organisation{}
+	for rows.Next() {
+		var organisation models.Organisation
+		err := rows.Scan(
+			&organisation.ID,
+			&organisation.Name,
+			&organisation.Slug,
+			&organisation.CreatedAt,
+			&organisation.UpdatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning organisation: %v", err)
+		}
+		organisations = append(organisations, organisation)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating organisations: %v", err)
+	}
+
+	return &models.OrganisationListResponse{
+		Organisations: organisations,
+	}, nil
+}
+
+func (s *OrganisationService) CreateOrganisation(req *models.CreateOrganisationRequest) (*models.Organisation, error) {
+	if !organisationSlugPattern.MatchString(req.Slug) {
+		return nil, errors.New("invalid slug: use lowercase letters, digits and hyphens")
+	}
+
+	organisation := models.Organisation{
+		Name: req.Name,
+		Slug: req.Slug,
+	}
+
+	err := s.db.QueryRow(`
+		INSERT INTO organisations (name, slug)
+		VALUES ($1, $2)
+		RETURNING id, created_at, updated_at
+	`, organisation.Name, organisation.Slug).Scan(&organisation.ID, &organisation.CreatedAt, &organisation.UpdatedAt)
+	if err != nil {
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code.Name() == "unique_violation" {
+			return nil, errors.New("organisation slug already exists")
+		}
+		return nil, fmt.Errorf("error creating organisation: %v", err)
+	}
+
+	return &organisation, nil
+}
+
+// UpdateOrganisation renames an organisation. The slug stays as created.
+func (s *OrganisationService) UpdateOrganisation(id uuid.UUID, req *models.UpdateOrganisationRequest) (*models.Organisation, error) {
+	var organisation models.Organisation
+	err := s.db.QueryRow(`
+		UPDATE organisations
+		SET name = $1, updated_at = NOW()
+		WHERE id = $2
+		RETURNING id, name, slug, created_at, updated_at
+	`, req.Name, id).Scan(
+		&organisation.ID,
+		&organisation.Name,
+		&organisation.Slug,
+		&organisation.CreatedAt,
+		&organisation.UpdatedAt,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.New("organisation not found")
+		}
+		return nil, fmt.Errorf("error updating organisation: %v", err)
+	}
+
+	return &organisation, nil
+}
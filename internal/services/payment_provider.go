@@ -0,0 +1,136 @@
+package services
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"e-meetingproject/internal/models"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/google/uuid"
+)
+
+// PaymentProvider is implemented by every payment gateway that can collect
+// reservation payments and report their outcome through webhooks.
+type PaymentProvider interface {
+	// Name identifies the provider; it is stored with every payment.
+	Name() string
+
+	// CreatePaymentIntent registers an intent to collect amount for the reservation.
+	CreatePaymentIntent(reservationID uuid.UUID, amount float64, currency string) (*PaymentIntent, error)
+
+	// ParseWebhook verifies the webhook signature and decodes the event.
+	// It returns an "invalid webhook signature" error if verification fails.
+	ParseWebhook(payload []byte, header http.Header) (*PaymentEvent, error)
+}
+
+type PaymentIntent struct {
+	ID           string
+	ClientSecret string
+	Amount       float64
+	Currency     string
+}
+
+type PaymentEvent struct {
+	ID            string
+	IntentID      string
+	Status        models.PaymentStatus
+	FailureReason string
+}
+
+const (
+	FakePaymentProviderName     = "fake"
+	FakePaymentSignatureHeader  = "X-Fake-Signature"
+	fakePaymentEventSucceeded   = "payment.succeeded"
+	fakePaymentEventFailed      = "payment.failed"
+	defaultFakePaymentSecretKey = "fake-webhook-secret"
+)
+
+// FakePaymentProvider is an in-process provider for local development and tests.
+// Intents are never charged; the outcome is reported by posting a webhook signed
+// with HMAC-SHA256 of the raw body in the X-Fake-Signature header, e.g.
+//
+//	{"event_id": "evt_1", "type": "payment.succeeded", "intent_id": "fake_pi_..."}
+type FakePaymentProvider struct {
+	secret []byte
+}
+
+type fakePaymentWebhook struct {
+	EventID       string `json:"event_id"`
+	Type          string `json:"type"`
+	IntentID      string `json:"intent_id"`
+	FailureReason string `json:"failure_reason"`
+}
+
+func NewFakePaymentProvider(secret string) *FakePaymentProvider {
+	if secret == "" {
+		secret = defaultFakePaymentSecretKey
+	}
+	return &FakePaymentProvider{
+		secret: []byte(secret),
+	}
+}
+
+func (p *FakePaymentProvider) Name() string {
+	return FakePaymentProviderName
+}
+
+func (p *FakePaymentProvider) CreatePaymentIntent(reservationID uuid.UUID, amount float64, currency string) (*PaymentIntent, error) {
+	if amount <= 0 {
+		return nil, fmt.Errorf("payment amount must be greater than zero")
+	}
+
+	intentID := "fake_pi_" + uuid.New().String()
+	return &PaymentIntent{
+		ID:           intentID,
+		ClientSecret: intentID + "_secret_" + reservationID.String(),
+		Amount:       amount,
+		Currency:     currency,
+	}, nil
+}
+
+// Sign returns the signature the fake provider expects for payload.
+func (p *FakePaymentProvider) Sign(payload []byte) string {
+	mac := hmac.New(sha256.New, p.secret)
+	mac.Write(payload)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func (p *FakePaymentProvider) ParseWebhook(payload []byte, header http.Header) (*PaymentEvent, error) {
+	signature, err := hex.DecodeString(header.Get(FakePaymentSignatureHeader))
+	if err != nil || len(signature) == 0 {
+		return nil, fmt.Errorf("invalid webhook signature")
+	}
+
+	mac := hmac.New(sha256.New, p.secret)
+	mac.Write(payload)
+	if !hmac.Equal(signature, mac.Sum(nil)) {
+		return nil, fmt.Errorf("invalid webhook signature")
+	}
+
+	var webhook fakePaymentWebhook
+	if err := json.Unmarshal(payload, &webhook); err != nil {
+		return nil, fmt.Errorf("invalid webhook payload: %v", err)
+	}
+	if webhook.EventID == "" || webhook.IntentID == "" {
+		return nil, fmt.Errorf("invalid webhook payload: event_id and intent_id are required")
+	}
+
+	event := &PaymentEvent{
+		ID:            webhook.EventID,
+		IntentID:      webhook.IntentID,
+		FailureReason: webhook.FailureReason,
+	}
+	switch webhook.Type {
+	case fakePaymentEventSucceeded:
+		event.Status = models.PaymentStatusSucceeded
+	case fakePaymentEventFailed:
+		event.Status = models.PaymentStatusFailed
+	default:
+		return nil, fmt.Errorf("invalid webhook payload: unsupported event type %q", webhook.Type)
+	}
+
+	return event, nil
+}
@@ -0,0 +1,84 @@
+package services
+
+import (
+	"e-meetingproject/internal/models"
+	"net/http"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFakePaymentProvider_CreatePaymentIntent(t *testing.T) {
+	provider := NewFakePaymentProvider("secret")
+
+	intent, err := provider.CreatePaymentIntent(uuid.New(), 150000, "IDR")
+	assert.NoError(t, err)
+	assert.Contains(t, intent.ID, "fake_pi_")
+	assert.NotEmpty(t, intent.ClientSecret)
+	assert.Equal(t, 150000.0, intent.Amount)
+	assert.Equal(t, "IDR", intent.Currency)
+
+	_, err = provider.CreatePaymentIntent(uuid.New(), 0, "IDR")
+	assert.Error(t, err)
+}
+
+func TestFakePaymentProvider_ParseWebhook(t *testing.T) {
+	provider := NewFakePaymentProvider("secret")
+
+	tests := []struct {
+		name           string
+		payload        string
+		signature      func(payload []byte) string
+		expectedError  string
+		expectedStatus models.PaymentStatus
+	}{
+		{
+			name:           "Payment succeeded",
+			payload:        `{"event_id":"evt_1","type":"payment.succeeded","intent_id":"fake_pi_1"}`,
+			signature:      provider.Sign,
+			expectedStatus: models.PaymentStatusSucceeded,
+		},
+		{
+			name:           "Payment failed",
+			payload:        `{"event_id":"evt_2","type":"payment.failed","intent_id":"fake_pi_1","failure_reason":"card declined"}`,
+			signature:      provider.Sign,
+			expectedStatus: models.PaymentStatusFailed,
+		},
+		{
+			name:          "Missing signature",
+			payload:       `{"event_id":"evt_3","type":"payment.succeeded","intent_id":"fake_pi_1"}`,
+			signature:     func([]byte) string { return "" },
+			expectedError: "invalid webhook signature",
+		},
+		{
+			name:          "Signed with another secret",
+			payload:       `{"event_id":"evt_4","type":"payment.succeeded","intent_id":"fake_pi_1"}`,
+			signature:     NewFakePaymentProvider("other").Sign,
+			expectedError: "invalid webhook signature",
+		},
+		{
+			name:          "Unsupported event type",
+			payload:       `{"event_id":"evt_5","type":"payment.refunded","intent_id":"fake_pi_1"}`,
+			signature:     provider.Sign,
+			expectedError: `invalid webhook payload: unsupported event type "payment.refunded"`,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			header := http.Header{}
+			header.Set(FakePaymentSignatureHeader, tc.signature([]byte(tc.payload)))
+
+			event, err := provider.ParseWebhook([]byte(tc.payload), header)
+			if tc.expectedError != "" {
+				assert.EqualError(t, err, tc.expectedError)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, "fake_pi_1", event.IntentID)
+			assert.Equal(t, tc.expectedStatus, event.Status)
+		})
+	}
+}
@@ -0,0 +1,272 @@
+package services
+
+import (
+	"database/sql"
+	"e-meetingproject/internal/database"
+	"e-meetingproject/internal/models"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/spf13/viper"
+)
+
+type PaymentService struct {
+	db          *sql.DB
+	provider    PaymentProvider
+	currency    string
+	autoConfirm bool
+}
+
+func NewPaymentService() *PaymentService {
+	provider, err := newPaymentProvider(viper.GetString("PAYMENT_PROVIDER"))
+	if err != nil {
+		log.Fatalf("Failed to initialize payment provider: %v", err)
+	}
+
+	currency := viper.GetString("PAYMENT_CURRENCY")
+	if currency == "" {
+		currency = "IDR" // default currency
+	}
+
+	return &PaymentService{
+		db:          database.GetDB(),
+		provider:    provider,
+		currency:    currency,
+		autoConfirm: viper.GetBool("PAYMENT_AUTO_CONFIRM"),
+	}
+}
+
+func newPaymentProvider(name string) (PaymentProvider, error) {
+	switch name {
+	case "", FakePaymentProviderName:
+		secret := viper.GetString("PAYMENT_WEBHOOK_SECRET")
+		if secret == "" {
+			log.Println("Warning: PAYMENT_WEBHOOK_SECRET is not set, fake payment provider uses the default secret")
+		}
+		return NewFakePaymentProvider(secret), nil
+	default:
+		return nil, fmt.Errorf("unknown payment provider: %s", name)
+	}
+}
+
+// createPaymentIntent asks the provider for a payment intent and records it
+// as a pending payment of the reservation for purpose through q.
+func (s *PaymentService) createPaymentIntent(q queryRower, reservationID uuid.UUID, amount float64, purpose models.PaymentPurpose) (*models.PaymentInfo, error) {
+	intent, err := s.provider.CreatePaymentIntent(reservationID, amount, s.currency)
+	if err != nil {
+		return nil, fmt.Errorf("error creating payment intent: %v", err)
+	}
+
+	payment := &models.PaymentInfo{
+		Provider:     s.provider.Name(),
+		IntentID:     intent.ID,
+		ClientSecret: intent.ClientSecret,
+		Purpose:      purpose,
+		Amount:       intent.Amount,
+		Currency:     intent.Currency,
+		Status:       models.PaymentStatusPending,
+	}
+
+	err = q.QueryRow(`
+		INSERT INTO payments (
+			reservation_id, provider, provider_ref, purpose, amount, currency, status
+		) VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id, updated_at
+	`, reservationID, payment.Provider, payment.IntentID, payment.Purpose, payment.Amount, payment.Currency, payment.Status).Scan(&payment.ID, &payment.UpdatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("error storing payment: %v", err)
+	}
+
+	return payment, nil
+}
+
+// getReservationPayments returns the payments of a reservation, oldest first.
+func getReservationPayments(tx *sql.Tx, reservationID uuid.UUID) ([]models.PaymentInfo, error) {
+	rows, err := tx.Query(`
+		SELECT id, provider, provider_ref, purpose, amount, currency, status, failure_reason, updated_at
+		FROM payments
+		WHERE reservation_id = $1
+		ORDER BY created_at, id
+	`, reservationID)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching payments: %v", err)
+	}
+	defer rows.Close()
+
+	payments := []models.PaymentInfo{}
+	for rows.Next() {
+		var payment models.PaymentInfo
+		var failureReason sql.NullString
+		err := rows.Scan(
+			&payment.ID, &payment.Provider, &payment.IntentID, &payment.Purpose, &payment.Amount,
+			&payment.Currency, &payment.Status, &failureReason, &payment.UpdatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning payment: %v", err)
+		}
+		payment.FailureReason = failureReason.String
+		payments = append(payments, payment)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating payments: %v", err)
+	}
+
+	return payments, nil
+}
+
+// bookingPayment returns the most recent booking payment among payments, or
+// nil if none was requested.
+func bookingPayment(payments []models.PaymentInfo) *models.PaymentInfo {
+	for i := len(payments) - 1; i >= 0; i-- {
+		if payments[i].Purpose == models.PaymentPurposeBooking {
+			return &payments[i]
+		}
+	}
+	return nil
+}
+
+// succeededPaymentTotal returns the amount paid through the payment provider
+// for a reservation.
+func succeededPaymentTotal(q queryRower, reservationID uuid.UUID) (float64, error) {
+	var total float64
+	err := q.QueryRow(`
+		SELECT COALESCE(SUM(amount), 0)
+		FROM payments
+		WHERE reservation_id = $1 AND status = 'succeeded'
+	`, reservationID).Scan(&total)
+	if err != nil {
+		return 0, fmt.Errorf("error fetching payments: %v", err)
+	}
+	return total, nil
+}
+
+// paymentConfirmsReservation reports whether a succeeded payment confirms its
+// reservation: the booking payment does, a snack order top-up only once the
+// succeeded payments cover the reservation's price.
+func paymentConfirmsReservation(purpose models.PaymentPurpose, paid, price float64) bool {
+	return purpose == models.PaymentPurposeBooking || roundCurrency(paid) >= roundCurrency(price)
+}
+
+// HandleWebhook verifies a provider webhook and applies it to the matching payment.
+// Replayed events are ignored and a succeeded payment is never downgraded.
+// When PAYMENT_AUTO_CONFIRM is enabled, a successful booking payment confirms
+// its pending reservation; see paymentConfirmsReservation.
+func (s *PaymentService) HandleWebhook(payload []byte, header http.Header) (*models.PaymentWebhookResponse, error) {
+	event, err := s.provider.ParseWebhook(payload, header)
+	if err != nil {
+		return nil, err
+	}
+
+	// Start transaction
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("error starting transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	// Lock the payment so concurrent deliveries of the same event serialize
+	var payment models.Payment
+	var failureReason, lastEventID sql.NullString
+	err = tx.QueryRow(`
+		SELECT id, reservation_id, provider, provider_ref, purpose, amount, currency, status,
+			failure_reason, last_event_id, created_at, updated_at
+		FROM payments
+		WHERE provider = $1 AND provider_ref = $2
+		FOR UPDATE
+	`, s.provider.Name(), event.IntentID).Scan(
+		&payment.ID, &payment.ReservationID, &payment.Provider, &payment.ProviderRef,
+		&payment.Purpose, &payment.Amount, &payment.Currency, &payment.Status,
+		&failureReason, &lastEventID, &payment.CreatedAt, &payment.UpdatedAt,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("payment not found")
+		}
+		return nil, fmt.Errorf("error fetching payment: %v", err)
+	}
+	payment.FailureReason = failureReason.String
+
+	// Every event is applied at most once, whatever order events arrive in
+	isNew, err := recordPaymentEvent(tx, s.provider.Name(), event.ID, payment.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if isNew && payment.Status != models.PaymentStatusSucceeded {
+		payment.Status = event.Status
+		payment.FailureReason = event.FailureReason
+		payment.UpdatedAt = time.Now()
+
+		_, err = tx.Exec(`
+			UPDATE payments
+			SET status = $1, failure_reason = NULLIF($2, ''), last_event_id = $3, updated_at = $4
+			WHERE id = $5`,
+			payment.Status, payment.FailureReason, event.ID, payment.UpdatedAt, payment.ID,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("error updating payment: %v", err)
+		}
+	}
+
+	var reservationStatus models.ReservationStatus
+	var price float64
+	err = tx.QueryRow(`SELECT status, price FROM reservations WHERE id = $1 FOR UPDATE`, payment.ReservationID).Scan(&reservationStatus, &price)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching reservation: %v", err)
+	}
+
+	// Auto-confirm the reservation once it has been paid
+	confirm := false
+	if s.autoConfirm && payment.Status == models.PaymentStatusSucceeded && reservationStatus == models.ReservationStatusPending {
+		paid, err := succeededPaymentTotal(tx, payment.ReservationID)
+		if err != nil {
+			return nil, err
+		}
+		confirm = paymentConfirmsReservation(payment.Purpose, paid, price)
+	}
+	if confirm {
+		updated, err := updateReservationStatus(tx, &models.UpdateReservationStatusRequest{
+			ReservationID: payment.ReservationID,
+			Status:        models.ReservationStatusConfirmed,
+		})
+		if err != nil {
+			return nil, err
+		}
+		reservationStatus = models.ReservationStatus(updated.Status)
+	}
+
+	// Commit transaction
+	if err = tx.Commit(); err != nil {
+		return nil, fmt.Errorf("error committing transaction: %v", err)
+	}
+
+	return &models.PaymentWebhookResponse{
+		Received:          true,
+		Payment:           &payment,
+		ReservationStatus: string(reservationStatus),
+		ProcessedAt:       time.Now(),
+	}, nil
+}
+
+// recordPaymentEvent stores a webhook event of a payment and reports whether
+// it was new; a redelivered event is already stored.
+func recordPaymentEvent(e execer, provider, eventID string, paymentID uuid.UUID) (bool, error) {
+	result, err := e.Exec(`
+		INSERT INTO payment_events (provider, event_id, payment_id)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (provider, event_id) DO NOTHING
+	`, provider, eventID, paymentID)
+	if err != nil {
+		return false, fmt.Errorf("error recording payment event: %v", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("error getting rows affected: %v", err)
+	}
+	return rowsAffected == 1, nil
+}
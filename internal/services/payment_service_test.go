@@ -0,0 +1,80 @@
+package services
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"e-meetingproject/internal/models"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+// paymentEventStore stands in for the payment_events table and its unique
+// constraint.
+type paymentEventStore map[string]bool
+
+func (s paymentEventStore) Exec(query string, args ...interface{}) (sql.Result, error) {
+	key := args[0].(string) + "/" + args[1].(string)
+	if s[key] {
+		return driver.RowsAffected(0), nil
+	}
+	s[key] = true
+	return driver.RowsAffected(1), nil
+}
+
+func TestRecordPaymentEvent(t *testing.T) {
+	store := paymentEventStore{}
+	paymentID := uuid.New()
+
+	// evt_2 arrives before evt_1, then both are delivered again
+	for _, tt := range []struct {
+		eventID string
+		isNew   bool
+	}{
+		{eventID: "evt_2", isNew: true},
+		{eventID: "evt_1", isNew: true},
+		{eventID: "evt_2"},
+		{eventID: "evt_1"},
+	} {
+		isNew, err := recordPaymentEvent(store, "fake", tt.eventID, paymentID)
+		assert.NoError(t, err)
+		assert.Equal(t, tt.isNew, isNew, tt.eventID)
+	}
+
+	isNew, err := recordPaymentEvent(store, "other", "evt_1", paymentID)
+	assert.NoError(t, err)
+	assert.True(t, isNew, "event IDs are per provider")
+}
+
+func TestPaymentConfirmsReservation(t *testing.T) {
+	tests := []struct {
+		name     string
+		purpose  models.PaymentPurpose
+		paid     float64
+		price    float64
+		expected bool
+	}{
+		{name: "Booking payment", purpose: models.PaymentPurposeBooking, paid: 100, price: 120, expected: true},
+		{name: "Top-up while the booking payment is pending", purpose: models.PaymentPurposeSnackTopUp, paid: 20, price: 120},
+		{name: "Top-up once the price is covered", purpose: models.PaymentPurposeSnackTopUp, paid: 120, price: 120, expected: true},
+		{name: "Top-up covering the price after rounding", purpose: models.PaymentPurposeSnackTopUp, paid: 100.1 + 19.9, price: 120, expected: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, paymentConfirmsReservation(tt.purpose, tt.paid, tt.price))
+		})
+	}
+}
+
+func TestBookingPayment(t *testing.T) {
+	booking := models.PaymentInfo{ID: uuid.New(), Purpose: models.PaymentPurposeBooking}
+	retried := models.PaymentInfo{ID: uuid.New(), Purpose: models.PaymentPurposeBooking}
+	topUp := models.PaymentInfo{ID: uuid.New(), Purpose: models.PaymentPurposeSnackTopUp}
+
+	assert.Nil(t, bookingPayment(nil))
+	assert.Nil(t, bookingPayment([]models.PaymentInfo{topUp}))
+	assert.Equal(t, booking.ID, bookingPayment([]models.PaymentInfo{booking, topUp}).ID, "a later top-up does not hide the booking payment")
+	assert.Equal(t, retried.ID, bookingPayment([]models.PaymentInfo{booking, retried, topUp}).ID)
+}
@@ -0,0 +1,186 @@
+package services
+
+import (
+	"database/sql"
+	"e-meetingproject/internal/database"
+	"e-meetingproject/internal/models"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// permissionCacheTTL is how long role permissions are cached. Changes made
+// through this service apply at once; changes made elsewhere within a minute.
+const permissionCacheTTL = time.Minute
+
+// PermissionService maps roles to the permissions stored in role_permissions.
+type PermissionService struct {
+	db *sql.DB
+
+	mu       sync.RWMutex
+	roles    map[string]map[string]bool
+	loadedAt time.Time
+}
+
+func NewPermissionService() *PermissionService {
+	return &PermissionService{
+		db: database.GetDB(),
+	}
+}
+
+// HasPermission reports whether role has been granted permission.
+func (s *PermissionService) HasPermission(role, permission string) (bool, error) {
+	s.mu.RLock()
+	roles, loadedAt := s.roles, s.loadedAt
+	s.mu.RUnlock()
+
+	if roles == nil || time.Since(loadedAt) > permissionCacheTTL {
+		var err error
+		if roles, err = s.reload(); err != nil {
+			return false, err
+		}
+	}
+
+	return roles[role][permission], nil
+}
+
+func (s *PermissionService) reload() (map[string]map[string]bool, error) {
+	rows, err := s.db.Query(`SELECT role, permission FROM role_permissions`)
+	if err != nil {
+		return nil, fmt.Errorf("error loading permissions: %v", err)
+	}
+	defer rows.Close()
+
+	roles := make(map[string]map[string]bool)
+	for rows.Next() {
+		var role, permission string
+		if err := rows.Scan(&role, &permission); err != nil {
+			return nil, fmt.Errorf("error scanning permission: %v", err)
+		}
+		if roles[role] == nil {
+			roles[role] = make(map[string]bool)
+		}
+		roles[role][permission] = true
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating permissions: %v", err)
+	}
+
+	s.mu.Lock()
+	s.roles = roles
+	s.loadedAt = time.Now()
+	s.mu.Unlock()
+
+	return roles, nil
+}
+
+// GetRoles lists every role with its permissions, and the permissions that
+// can be granted.
+func (s *PermissionService) GetRoles() (*models.RolePermissionsResponse, error) {
+	rows, err := s.db.Query(`SELECT name, description FROM permissions ORDER BY name`)
+	if err != nil {
+		return nil, fmt.Errorf("error querying permissions: %v", err)
+	}
+	defer rows.Close()
+
+	response := &models.RolePermissionsResponse{Permissions: []models.Permission{}}
+	for rows.Next() {
+		var permission models.Permission
+		if err := rows.Scan(&permission.Name, &permission.Description); err != nil {
+			return nil, fmt.Errorf("error scanning permission: %v", err)
+		}
+		response.Permissions = append(response.Permissions, permission)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating permissions: %v", err)
+	}
+
+	roles, err := s.reload()
+	if err != nil {
+		return nil, err
+	}
+	for _, role := range []string{models.RoleSuperAdmin, models.RoleAdmin, models.RoleReceptionist, models.RoleCatering, models.RoleUser} {
+		response.Roles = append(response.Roles, models.RolePermissions{
+			Role:        role,
+			Permissions: sortedKeys(roles[role]),
+		})
+	}
+
+	return response, nil
+}
+
+// SetRolePermissions replaces the permissions of role on behalf of the super
+// admin actorID. The super admin role keeps every permission, so super admins
+// cannot lock themselves out.
+func (s *PermissionService) SetRolePermissions(role string, req *models.UpdateRolePermissionsRequest, actorID uuid.UUID) error {
+	if !models.IsValidRole(role) {
+		return errors.New("invalid role")
+	}
+	if role == models.RoleSuperAdmin {
+		return errors.New("super_admin permissions cannot be changed")
+	}
+
+	// Start transaction
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("error starting transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(`DELETE FROM role_permissions WHERE role = $1`, role)
+	if err != nil {
+		return fmt.Errorf("error clearing permissions: %v", err)
+	}
+
+	for _, permission := range req.Permissions {
+		_, err = tx.Exec(`
+			INSERT INTO role_permissions (role, permission)
+			VALUES ($1, $2)
+			ON CONFLICT DO NOTHING
+		`, role, permission)
+		if err != nil {
+			if pqErr, ok := err.(*pq.Error); ok && pqErr.Code.Name() == "foreign_key_violation" {
+				return fmt.Errorf("unknown permission: %s", permission)
+			}
+			return fmt.Errorf("error granting permission: %v", err)
+		}
+	}
+
+	permissions := append([]string(nil), req.Permissions...)
+	sort.Strings(permissions)
+	err = recordAuthEvent(tx, authAuditEntry{
+		Event:   models.AuthEventPermissionsChanged,
+		ActorID: actorID,
+		Detail:  fmt.Sprintf("%s: %s", role, strings.Join(permissions, ", ")),
+	})
+	if err != nil {
+		return err
+	}
+
+	// Commit transaction
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("error committing transaction: %v", err)
+	}
+
+	// Apply the change at once
+	if _, err := s.reload(); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func sortedKeys(set map[string]bool) []string {
+	keys := make([]string, 0, len(set))
+	for key := range set {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
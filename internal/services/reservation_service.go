@@ -5,6 +5,7 @@ import (
 	"e-meetingproject/internal/database"
 	"e-meetingproject/internal/models"
 	"fmt"
+	"math"
 	"time"
 
 	"github.com/google/uuid"
@@ -12,16 +13,18 @@ import (
 )
 
 type ReservationService struct {
-	db *sql.DB
+	db       *sql.DB
+	payments *PaymentService
 }
 
-func NewReservationService() *ReservationService {
+func NewReservationService(payments *PaymentService) *ReservationService {
 	return &ReservationService{
-		db: database.GetDB(),
+		db:       database.GetDB(),
+		payments: payments,
 	}
 }
 
-func (s *ReservationService) GetReservationHistory(query *models.ReservationHistoryQuery, userID uuid.UUID) (*models.ReservationHistoryResponse, error) {
+func (s *ReservationService) GetReservationHistory(query *models.ReservationHistoryQuery, userID uuid.UUID, tenant Tenant) (*models.ReservationHistoryResponse, error) {
 	// Parse dates with default values (last 7 days if not specified)
 	endDatetime := time.Now()
 	startDatetime := endDatetime.AddDate(0, 0, -7)
@@ -83,6 +86,13 @@ func (s *ReservationService) GetReservationHistory(query *models.ReservationHist
 			r.end_time,
 			r.visitor_count,
 			r.price,
+			r.hourly_rate,
+			r.billed_hours,
+			r.room_subtotal,
+			r.snack_subtotal,
+			r.adjustments,
+			r.cancellation_fee,
+			r.refund_amount,
 			r.status,
 			rm.capacity,
 			rm.price_per_hour
@@ -92,11 +102,13 @@ func (s *ReservationService) GetReservationHistory(query *models.ReservationHist
 		WHERE r.user_id = $1
 		AND r.start_time >= $2 
 		AND r.end_time <= $3
+		AND ` + tenantCondition("rm.organisation_id", 4) + `
+		AND ` + roomCondition("rm.id", 5) + `
 	`
 
 	// Add filters
-	args := []interface{}{userID, startDatetime, endDatetime}
-	argCount := 4
+	args := []interface{}{userID, startDatetime, endDatetime, tenant.arg(), tenant.roomsArg()}
+	argCount := 6
 
 	if query != nil {
 		if query.RoomTypeID != uuid.Nil {
@@ -150,6 +162,13 @@ func (s *ReservationService) GetReservationHistory(query *models.ReservationHist
 			&event.EndTime,
 			&event.VisitorCount,
 			&event.Price,
+			&event.PriceBreakdown.HourlyRate,
+			&event.PriceBreakdown.BilledHours,
+			&event.PriceBreakdown.RoomSubtotal,
+			&event.PriceBreakdown.SnackSubtotal,
+			&event.PriceBreakdown.Adjustments,
+			&event.CancellationFee,
+			&event.RefundAmount,
 			&event.Status,
 			&roomCapacity,
 			&pricePerHour,
@@ -167,6 +186,7 @@ func (s *ReservationService) GetReservationHistory(query *models.ReservationHist
 		// Calculate duration in hours
 		duration := event.EndTime.Sub(event.StartTime).Hours()
 		event.DurationHours = duration
+		event.PriceBreakdown.Total = event.Price
 
 		events = append(events, event)
 	}
@@ -191,7 +211,7 @@ func (s *ReservationService) GetReservationHistory(query *models.ReservationHist
 	}, nil
 }
 
-func (s *ReservationService) UpdateReservationStatus(req *models.UpdateReservationStatusRequest) (*models.ReservationEvent, error) {
+func (s *ReservationService) UpdateReservationStatus(req *models.UpdateReservationStatusRequest, tenant Tenant) (*models.ReservationEvent, error) {
 	// Validate status
 	if !req.Status.IsValid() {
 		return nil, fmt.Errorf("invalid status: must be one of pending, confirmed, cancelled, or completed")
@@ -204,8 +224,202 @@ func (s *ReservationService) UpdateReservationStatus(req *models.UpdateReservati
 	}
 	defer tx.Rollback()
 
+	if err := checkReservationTenant(tx, req.ReservationID, tenant); err != nil {
+		return nil, err
+	}
+
+	event, err := updateReservationStatus(tx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	// Commit transaction
+	if err = tx.Commit(); err != nil {
+		return nil, fmt.Errorf("error committing transaction: %v", err)
+	}
+
+	return event, nil
+}
+
+// GetCancellationQuote previews what cancelling the user's reservation now would cost.
+func (s *ReservationService) GetCancellationQuote(reservationID, userID uuid.UUID) (*models.CancellationQuote, error) {
+	// Start transaction
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("error starting transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	if err := checkCancellable(tx, reservationID, userID, false); err != nil {
+		return nil, err
+	}
+
+	quote, err := quoteCancellation(tx, reservationID, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	// Commit transaction
+	if err = tx.Commit(); err != nil {
+		return nil, fmt.Errorf("error committing transaction: %v", err)
+	}
+
+	return quote, nil
+}
+
+// CancelReservation cancels the user's own reservation. The fee the user
+// accepted must still match the current quote.
+func (s *ReservationService) CancelReservation(reservationID, userID uuid.UUID, req *models.CancelReservationRequest) (*models.CancelReservationResponse, error) {
+	// Start transaction
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("error starting transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	if err := checkCancellable(tx, reservationID, userID, true); err != nil {
+		return nil, err
+	}
+
+	quote, err := quoteCancellation(tx, reservationID, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	if req.AcceptedFee == nil || roundCurrency(*req.AcceptedFee) != quote.CancellationFee {
+		return nil, fmt.Errorf("cancellation fee has changed")
+	}
+
+	event, err := updateReservationStatus(tx, &models.UpdateReservationStatusRequest{
+		ReservationID: reservationID,
+		Status:        models.ReservationStatusCancelled,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Commit transaction
+	if err = tx.Commit(); err != nil {
+		return nil, fmt.Errorf("error committing transaction: %v", err)
+	}
+
+	return &models.CancelReservationResponse{
+		Reservation:  event,
+		Cancellation: quote,
+	}, nil
+}
+
+// checkCancellable ensures the reservation belongs to the user and is still
+// pending or confirmed. Reservations of other users are reported as not found.
+func checkCancellable(tx *sql.Tx, reservationID, userID uuid.UUID, lock bool) error {
+	query := `SELECT user_id, status FROM reservations WHERE id = $1`
+	if lock {
+		query += ` FOR UPDATE`
+	}
+
+	var ownerID uuid.UUID
+	var status models.ReservationStatus
+	err := tx.QueryRow(query, reservationID).Scan(&ownerID, &status)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return fmt.Errorf("reservation not found")
+		}
+		return fmt.Errorf("error fetching reservation: %v", err)
+	}
+	if ownerID != userID {
+		return fmt.Errorf("reservation not found")
+	}
+	if status != models.ReservationStatusPending && status != models.ReservationStatusConfirmed {
+		return fmt.Errorf("reservation cannot be cancelled in status %s", status)
+	}
+
+	return nil
+}
+
+// checkReservationTenant returns "reservation not found" unless the room of
+// the reservation belongs to the tenant, and to its rooms if it is limited to
+// some.
+func checkReservationTenant(q queryRower, reservationID uuid.UUID, tenant Tenant) error {
+	var exists bool
+	err := q.QueryRow(`
+		SELECT EXISTS(
+			SELECT 1 FROM reservations r
+			JOIN rooms rm ON r.room_id = rm.id
+			WHERE r.id = $1 AND `+tenantCondition("rm.organisation_id", 2)+` AND `+roomCondition("rm.id", 3)+`
+		)`,
+		reservationID, tenant.arg(), tenant.roomsArg(),
+	).Scan(&exists)
+	if err != nil {
+		return fmt.Errorf("error fetching reservation: %v", err)
+	}
+	if !exists {
+		return fmt.Errorf("reservation not found")
+	}
+	return nil
+}
+
+// checkStatusTransition refuses to change a cancelled or completed
+// reservation. Cancelling released its wallet hold and snack stock and stored
+// its fee, which reinstating it would not undo.
+func checkStatusTransition(current, next models.ReservationStatus) error {
+	if current == next {
+		return nil
+	}
+	if current == models.ReservationStatusCancelled || current == models.ReservationStatusCompleted {
+		return fmt.Errorf("invalid status transition: reservation is already %s", current)
+	}
+	return nil
+}
+
+// updateReservationStatus changes the status of a reservation within tx and
+// returns the updated reservation.
+func updateReservationStatus(tx *sql.Tx, req *models.UpdateReservationStatusRequest) (*models.ReservationEvent, error) {
+	// Lock the reservation while its status changes
+	var currentStatus models.ReservationStatus
+	err := tx.QueryRow(`SELECT status FROM reservations WHERE id = $1 FOR UPDATE`, req.ReservationID).Scan(&currentStatus)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("reservation not found with ID: %v", req.ReservationID)
+		}
+		return nil, fmt.Errorf("error fetching reservation: %v", err)
+	}
+	if err := checkStatusTransition(currentStatus, req.Status); err != nil {
+		return nil, err
+	}
+
+	// Apply the cancellation policy the first time a reservation is cancelled
+	var cancellationFee float64
+	if req.Status == models.ReservationStatusCancelled && currentStatus != models.ReservationStatusCancelled {
+		quote, err := quoteCancellation(tx, req.ReservationID, time.Now())
+		if err != nil {
+			return nil, err
+		}
+		cancellationFee = quote.CancellationFee
+
+		_, err = tx.Exec(`
+			UPDATE reservations
+			SET cancellation_fee = $1, refund_amount = $2, cancelled_at = $3
+			WHERE id = $4`,
+			quote.CancellationFee, quote.RefundAmount, quote.EvaluatedAt, req.ReservationID,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("error storing cancellation fee: %v", err)
+		}
+
+		// Put the snack orders back into stock
+		if err := releaseSnackStock(tx, req.ReservationID); err != nil {
+			return nil, err
+		}
+	}
+
+	// Capture, release or refund wallet funds of the reservation
+	if req.Status != currentStatus {
+		if err := settleReservationWallet(tx, req.ReservationID, req.Status, cancellationFee); err != nil {
+			return nil, err
+		}
+	}
+
 	// Update reservation status
-	result, err := tx.Exec(`
+	_, err = tx.Exec(`
 		UPDATE reservations 
 		SET status = $1, updated_at = NOW()
 		WHERE id = $2`,
@@ -216,14 +430,6 @@ func (s *ReservationService) UpdateReservationStatus(req *models.UpdateReservati
 		return nil, fmt.Errorf("error updating reservation status: %v", err)
 	}
 
-	rowsAffected, err := result.RowsAffected()
-	if err != nil {
-		return nil, fmt.Errorf("error getting rows affected: %v", err)
-	}
-	if rowsAffected == 0 {
-		return nil, fmt.Errorf("reservation not found with ID: %v", req.ReservationID)
-	}
-
 	// Fetch updated reservation with all details
 	var event models.ReservationEvent
 	var roomCapacity int
@@ -240,6 +446,13 @@ func (s *ReservationService) UpdateReservationStatus(req *models.UpdateReservati
 			r.end_time,
 			r.visitor_count,
 			r.price,
+			r.hourly_rate,
+			r.billed_hours,
+			r.room_subtotal,
+			r.snack_subtotal,
+			r.adjustments,
+			r.cancellation_fee,
+			r.refund_amount,
 			r.status,
 			rm.capacity,
 			rm.price_per_hour
@@ -258,6 +471,13 @@ func (s *ReservationService) UpdateReservationStatus(req *models.UpdateReservati
 		&event.EndTime,
 		&event.VisitorCount,
 		&event.Price,
+		&event.PriceBreakdown.HourlyRate,
+		&event.PriceBreakdown.BilledHours,
+		&event.PriceBreakdown.RoomSubtotal,
+		&event.PriceBreakdown.SnackSubtotal,
+		&event.PriceBreakdown.Adjustments,
+		&event.CancellationFee,
+		&event.RefundAmount,
 		&event.Status,
 		&roomCapacity,
 		&pricePerHour,
@@ -275,16 +495,12 @@ func (s *ReservationService) UpdateReservationStatus(req *models.UpdateReservati
 	// Calculate duration in hours
 	duration := event.EndTime.Sub(event.StartTime).Hours()
 	event.DurationHours = duration
-
-	// Commit transaction
-	if err = tx.Commit(); err != nil {
-		return nil, fmt.Errorf("error committing transaction: %v", err)
-	}
+	event.PriceBreakdown.Total = event.Price
 
 	return &event, nil
 }
 
-func (s *ReservationService) CalculateReservationCost(req *models.ReservationCalculationRequest) (*models.ReservationCalculationResponse, error) {
+func (s *ReservationService) CalculateReservationCost(req *models.ReservationCalculationRequest, tenant Tenant) (*models.ReservationCalculationResponse, error) {
 	// Validate time constraints
 	now := time.Now()
 
@@ -316,15 +532,17 @@ func (s *ReservationService) CalculateReservationCost(req *models.ReservationCal
 
 	// Get room details
 	var room struct {
-		ID           uuid.UUID
-		Name         string
-		PricePerHour float64
+		ID             uuid.UUID
+		OrganisationID uuid.UUID
+		Name           string
+		PricePerHour   float64
 	}
 	err = tx.QueryRow(`
-		SELECT id, name, price_per_hour
+		SELECT id, organisation_id, name, price_per_hour
 		FROM rooms
-		WHERE id = $1 AND status = 'available'
-	`, req.RoomID).Scan(&room.ID, &room.Name, &room.PricePerHour)
+		WHERE id = $1 AND status = 'available' AND `+tenantCondition("organisation_id", 2)+` AND `+roomCondition("id", 3),
+		req.RoomID, tenant.arg(), tenant.roomsArg(),
+	).Scan(&room.ID, &room.OrganisationID, &room.Name, &room.PricePerHour)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, fmt.Errorf("room not found or inactive")
@@ -332,73 +550,30 @@ func (s *ReservationService) CalculateReservationCost(req *models.ReservationCal
 		return nil, fmt.Errorf("error querying room: %v", err)
 	}
 
-	// Calculate room cost
+	// Calculate billed hours
 	bookingDuration := req.EndTime.Sub(req.StartTime)
 	hours := bookingDuration.Hours()
-	roomCost := room.PricePerHour * hours
 
-	// Get snack details and calculate costs
-	var snackIDs []uuid.UUID
-	for _, snack := range req.Snacks {
-		snackIDs = append(snackIDs, snack.SnackID)
+	// Snacks must be served during the booking
+	if fieldErrors := validateServeTimes(req.Snacks, req.Bundles, req.StartTime, req.EndTime); len(fieldErrors) > 0 {
+		return nil, &models.ValidationError{Fields: fieldErrors}
 	}
 
-	rows, err := tx.Query(`
-		SELECT id, name, category, price
-		FROM snacks
-		WHERE id = ANY($1)
-	`, pq.Array(snackIDs))
+	// Get snack details and validate the order
+	snacks, err := loadSnackOrder(tx, room.OrganisationID, req.Snacks, req.VisitorCount, req.StartTime, now)
 	if err != nil {
-		return nil, fmt.Errorf("error querying snacks: %v", err)
+		return nil, err
 	}
-	defer rows.Close()
 
-	var snacks []struct {
-		ID       uuid.UUID
-		Name     string
-		Category string
-		Price    float64
-		Quantity int
-	}
-
-	for rows.Next() {
-		var snack struct {
-			ID       uuid.UUID
-			Name     string
-			Category string
-			Price    float64
-		}
-		err := rows.Scan(&snack.ID, &snack.Name, &snack.Category, &snack.Price)
-		if err != nil {
-			return nil, fmt.Errorf("error scanning snack: %v", err)
-		}
-
-		// Find quantity for this snack
-		for _, reqSnack := range req.Snacks {
-			if reqSnack.SnackID == snack.ID {
-				snacks = append(snacks, struct {
-					ID       uuid.UUID
-					Name     string
-					Category string
-					Price    float64
-					Quantity int
-				}{
-					ID:       snack.ID,
-					Name:     snack.Name,
-					Category: snack.Category,
-					Price:    snack.Price,
-					Quantity: reqSnack.Quantity,
-				})
-				break
-			}
-		}
-	}
-
-	if err = rows.Err(); err != nil {
-		return nil, fmt.Errorf("error iterating snacks: %v", err)
+	// Expand the bundles into snack lines
+	bundleSnacks, adjustments, err := loadBundleOrder(tx, room.OrganisationID, req.Bundles, req.VisitorCount, req.StartTime, now)
+	if err != nil {
+		return nil, err
 	}
+	snacks = append(snacks, bundleSnacks...)
 
-	// Calculate total cost
+	// Price the quote the same way CreateReservation stores it
+	breakdown := orderPriceBreakdown(room.PricePerHour, hours, snacks, adjustments)
 	response := &models.ReservationCalculationResponse{
 		Room: struct {
 			ID           uuid.UUID `json:"id"`
@@ -410,33 +585,39 @@ func (s *ReservationService) CalculateReservationCost(req *models.ReservationCal
 			ID:           room.ID,
 			Name:         room.Name,
 			PricePerHour: room.PricePerHour,
-			TotalHours:   hours,
-			TotalCost:    roomCost,
+			TotalHours:   breakdown.BilledHours,
+			TotalCost:    breakdown.RoomSubtotal,
 		},
-		TotalCost: roomCost,
+		Adjustments: breakdown.Adjustments,
+		TotalCost:   breakdown.Total,
 	}
 
-	// Calculate snack costs
+	// List the snack lines
 	for _, snack := range snacks {
-		subtotal := snack.Price * float64(snack.Quantity)
 		response.Snacks = append(response.Snacks, struct {
-			ID       uuid.UUID `json:"id"`
-			Name     string    `json:"name"`
-			Category string    `json:"category"`
-			Price    float64   `json:"price"`
-			Quantity int       `json:"quantity"`
-			Subtotal float64   `json:"subtotal"`
+			ID       uuid.UUID  `json:"id"`
+			Name     string     `json:"name"`
+			Category string     `json:"category"`
+			Price    float64    `json:"price"`
+			Quantity int        `json:"quantity"`
+			Subtotal float64    `json:"subtotal"`
+			BundleID *uuid.UUID `json:"bundle_id,omitempty"`
+			ServeAt  time.Time  `json:"serve_at"`
 		}{
 			ID:       snack.ID,
 			Name:     snack.Name,
 			Category: snack.Category,
 			Price:    snack.Price,
 			Quantity: snack.Quantity,
-			Subtotal: subtotal,
+			Subtotal: roundCurrency(snack.Subtotal()),
+			BundleID: snack.BundleID,
+			ServeAt:  snack.serveTime(req.StartTime),
 		})
-		response.TotalCost += subtotal
 	}
 
+	// Warn about allergens the organiser excluded
+	response.Dietary = summarizeDietary(dietaryItemsOf(snacks), req.ExcludedAllergens)
+
 	// Commit transaction
 	if err = tx.Commit(); err != nil {
 		return nil, fmt.Errorf("error committing transaction: %v", err)
@@ -445,7 +626,7 @@ func (s *ReservationService) CalculateReservationCost(req *models.ReservationCal
 	return response, nil
 }
 
-func (s *ReservationService) GetReservationByID(id uuid.UUID) (*models.ReservationDetailResponse, error) {
+func (s *ReservationService) GetReservationByID(id uuid.UUID, tenant Tenant) (*models.ReservationDetailResponse, error) {
 	// Start transaction
 	tx, err := s.db.Begin()
 	if err != nil {
@@ -456,19 +637,27 @@ func (s *ReservationService) GetReservationByID(id uuid.UUID) (*models.Reservati
 	// Get reservation details with room and user information
 	var reservation models.ReservationDetailResponse
 	var createdAt, updatedAt time.Time
+	var excludedAllergens []string
 
 	err = tx.QueryRow(`
 		SELECT 
-			r.id, r.status, r.start_time, r.end_time, r.visitor_count, r.price, r.created_at, r.updated_at,
+			r.id, r.status, r.start_time, r.end_time, r.visitor_count, r.price, r.cost_center_id, r.wallet_id, r.created_at, r.updated_at,
+			r.hourly_rate, r.billed_hours, r.room_subtotal, r.snack_subtotal, r.adjustments,
+			r.cancellation_fee, r.refund_amount, r.excluded_allergens,
 			rm.id, rm.name, rm.capacity, rm.price_per_hour,
 			u.id, u.username
 		FROM reservations r
 		JOIN rooms rm ON r.room_id = rm.id
 		JOIN users u ON r.user_id = u.id
-		WHERE r.id = $1
-	`, id).Scan(
+		WHERE r.id = $1 AND `+tenantCondition("rm.organisation_id", 2)+` AND `+roomCondition("rm.id", 3),
+		id, tenant.arg(), tenant.roomsArg(),
+	).Scan(
 		&reservation.ID, &reservation.Status, &reservation.StartTime, &reservation.EndTime,
-		&reservation.VisitorCount, &reservation.Price, &createdAt, &updatedAt,
+		&reservation.VisitorCount, &reservation.Price, &reservation.CostCenterID, &reservation.WalletID, &createdAt, &updatedAt,
+		&reservation.PriceBreakdown.HourlyRate, &reservation.PriceBreakdown.BilledHours,
+		&reservation.PriceBreakdown.RoomSubtotal, &reservation.PriceBreakdown.SnackSubtotal,
+		&reservation.PriceBreakdown.Adjustments,
+		&reservation.CancellationFee, &reservation.RefundAmount, pq.Array(&excludedAllergens),
 		&reservation.Room.ID, &reservation.Room.Name, &reservation.Room.Capacity, &reservation.Room.PricePerHour,
 		&reservation.User.ID, &reservation.User.Username,
 	)
@@ -486,10 +675,13 @@ func (s *ReservationService) GetReservationByID(id uuid.UUID) (*models.Reservati
 	// Get snacks for this reservation
 	rows, err := tx.Query(`
 		SELECT 
-			s.id, s.name, s.category, rs.price, rs.quantity
+			rs.id, s.id, s.name, s.category, rs.price, rs.quantity, rs.bundle_id,
+			COALESCE(rs.serve_at, r.start_time), s.dietary_tags, s.allergens
 		FROM reservation_snacks rs
+		JOIN reservations r ON rs.reservation_id = r.id
 		JOIN snacks s ON rs.snack_id = s.id
 		WHERE rs.reservation_id = $1
+		ORDER BY COALESCE(rs.serve_at, r.start_time), s.category, s.name
 	`, id)
 
 	if err != nil {
@@ -497,38 +689,24 @@ func (s *ReservationService) GetReservationByID(id uuid.UUID) (*models.Reservati
 	}
 	defer rows.Close()
 
-	var totalSnackCost float64
+	var dietaryItems []dietaryItem
 	for rows.Next() {
-		var snack struct {
-			ID       uuid.UUID
-			Name     string
-			Category string
-			Price    float64
-			Quantity int
-		}
-
-		err := rows.Scan(&snack.ID, &snack.Name, &snack.Category, &snack.Price, &snack.Quantity)
+		var snack models.ReservationSnackLine
+		err := rows.Scan(
+			&snack.LineID, &snack.ID, &snack.Name, &snack.Category, &snack.Price, &snack.Quantity, &snack.BundleID, &snack.ServeAt,
+			pq.Array(&snack.DietaryTags), pq.Array(&snack.Allergens),
+		)
 		if err != nil {
 			return nil, fmt.Errorf("error scanning snack: %v", err)
 		}
 
-		subtotal := snack.Price * float64(snack.Quantity)
-		totalSnackCost += subtotal
-
-		reservation.Snacks = append(reservation.Snacks, struct {
-			ID       uuid.UUID `json:"id"`
-			Name     string    `json:"name"`
-			Category string    `json:"category"`
-			Price    float64   `json:"price"`
-			Quantity int       `json:"quantity"`
-			Subtotal float64   `json:"subtotal"`
-		}{
-			ID:       snack.ID,
-			Name:     snack.Name,
-			Category: snack.Category,
-			Price:    snack.Price,
-			Quantity: snack.Quantity,
-			Subtotal: subtotal,
+		snack.Subtotal = snack.Price * float64(snack.Quantity)
+		reservation.Snacks = append(reservation.Snacks, snack)
+		dietaryItems = append(dietaryItems, dietaryItem{
+			SnackID:     snack.ID,
+			SnackName:   snack.Name,
+			DietaryTags: snack.DietaryTags,
+			Allergens:   snack.Allergens,
 		})
 	}
 
@@ -536,8 +714,19 @@ func (s *ReservationService) GetReservationByID(id uuid.UUID) (*models.Reservati
 		return nil, fmt.Errorf("error iterating snacks: %v", err)
 	}
 
-	// Calculate total cost (room cost + snack cost)
-	reservation.TotalCost = reservation.Price
+	// Warn about allergens the organiser excluded
+	reservation.Dietary = summarizeDietary(dietaryItems, excludedAllergens)
+
+	// Total cost is the stored price, which is the sum of the breakdown
+	reservation.PriceBreakdown.Total = reservation.Price
+	reservation.TotalCost = reservation.PriceBreakdown.Total
+
+	// Get payment status
+	reservation.Payments, err = getReservationPayments(tx, id)
+	if err != nil {
+		return nil, err
+	}
+	reservation.Payment = bookingPayment(reservation.Payments)
 
 	// Commit transaction
 	if err = tx.Commit(); err != nil {
@@ -547,7 +736,7 @@ func (s *ReservationService) GetReservationByID(id uuid.UUID) (*models.Reservati
 	return &reservation, nil
 }
 
-func (s *ReservationService) CreateReservation(req *models.CreateReservationRequest) (*models.CreateReservationResponse, error) {
+func (s *ReservationService) CreateReservation(req *models.CreateReservationRequest, tenant Tenant) (*models.CreateReservationResponse, error) {
 	// Validate time constraints
 	now := time.Now()
 
@@ -578,13 +767,15 @@ func (s *ReservationService) CreateReservation(req *models.CreateReservationRequ
 	defer tx.Rollback()
 
 	// Check room availability
+	var organisationID uuid.UUID
 	var roomCapacity int
 	var pricePerHour float64
 	err = tx.QueryRow(`
-		SELECT capacity, price_per_hour
+		SELECT organisation_id, capacity, price_per_hour
 		FROM rooms
-		WHERE id = $1 AND status = 'available'
-	`, req.RoomID).Scan(&roomCapacity, &pricePerHour)
+		WHERE id = $1 AND status = 'available' AND `+tenantCondition("organisation_id", 2)+` AND `+roomCondition("id", 3),
+		req.RoomID, tenant.arg(), tenant.roomsArg(),
+	).Scan(&organisationID, &roomCapacity, &pricePerHour)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, fmt.Errorf("room not found or inactive")
@@ -592,6 +783,16 @@ func (s *ReservationService) CreateReservation(req *models.CreateReservationRequ
 		return nil, fmt.Errorf("error checking room: %v", err)
 	}
 
+	// The booking user must belong to the organisation of the room
+	var userOrganisationID uuid.UUID
+	err = tx.QueryRow(`SELECT organisation_id FROM users WHERE id = $1`, req.UserID).Scan(&userOrganisationID)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, fmt.Errorf("error checking user: %v", err)
+	}
+	if err == sql.ErrNoRows || userOrganisationID != organisationID {
+		return nil, fmt.Errorf("user not found")
+	}
+
 	// Validate visitor count against room capacity
 	if req.VisitorCount > roomCapacity {
 		return nil, fmt.Errorf("visitor count exceeds room capacity of %d", roomCapacity)
@@ -617,82 +818,63 @@ func (s *ReservationService) CreateReservation(req *models.CreateReservationRequ
 		return nil, fmt.Errorf("room is already booked for the selected time period")
 	}
 
-	// Calculate room cost
+	// Calculate billed hours
 	bookingDuration := req.EndTime.Sub(req.StartTime)
 	hours := bookingDuration.Hours()
-	roomCost := pricePerHour * hours
 
-	// Get snack details and calculate costs
-	var snackIDs []uuid.UUID
-	for _, snack := range req.Snacks {
-		snackIDs = append(snackIDs, snack.SnackID)
+	// Snacks must be served during the booking
+	if fieldErrors := validateServeTimes(req.Snacks, req.Bundles, req.StartTime, req.EndTime); len(fieldErrors) > 0 {
+		return nil, &models.ValidationError{Fields: fieldErrors}
 	}
 
-	rows, err := tx.Query(`
-		SELECT id, name, price
-		FROM snacks
-		WHERE id = ANY($1)
-	`, pq.Array(snackIDs))
+	// Get snack details, validate the order and calculate costs
+	snacks, err := loadSnackOrder(tx, organisationID, req.Snacks, req.VisitorCount, req.StartTime, now)
 	if err != nil {
-		return nil, fmt.Errorf("error querying snacks: %v", err)
+		return nil, err
 	}
-	defer rows.Close()
 
-	var snacks []struct {
-		ID       uuid.UUID
-		Name     string
-		Price    float64
-		Quantity int
+	// Expand the bundles into snack lines
+	bundleSnacks, adjustments, err := loadBundleOrder(tx, organisationID, req.Bundles, req.VisitorCount, req.StartTime, now)
+	if err != nil {
+		return nil, err
 	}
-	var totalSnackCost float64
+	snacks = append(snacks, bundleSnacks...)
 
-	for rows.Next() {
-		var snack struct {
-			ID    uuid.UUID
-			Name  string
-			Price float64
-		}
-		err := rows.Scan(&snack.ID, &snack.Name, &snack.Price)
-		if err != nil {
-			return nil, fmt.Errorf("error scanning snack: %v", err)
-		}
+	// Take the snacks out of the stock of the meeting day
+	if err := reserveSnackStock(tx, snacks, snackStockDate(req.StartTime)); err != nil {
+		return nil, err
+	}
 
-		// Find quantity for this snack
-		for _, reqSnack := range req.Snacks {
-			if reqSnack.SnackID == snack.ID {
-				subtotal := snack.Price * float64(reqSnack.Quantity)
-				totalSnackCost += subtotal
-				snacks = append(snacks, struct {
-					ID       uuid.UUID
-					Name     string
-					Price    float64
-					Quantity int
-				}{
-					ID:       snack.ID,
-					Name:     snack.Name,
-					Price:    snack.Price,
-					Quantity: reqSnack.Quantity,
-				})
-				break
-			}
-		}
+	// Resolve the cost center charged for this booking
+	costCenterID, err := resolveReservationCostCenter(tx, req.UserID, req.CostCenterID)
+	if err != nil {
+		return nil, err
 	}
 
-	if err = rows.Err(); err != nil {
-		return nil, fmt.Errorf("error iterating snacks: %v", err)
+	// Make sure the booking user may spend from the wallet
+	if req.WalletID != nil {
+		if err := checkWalletAccess(tx, *req.WalletID, req.UserID); err != nil {
+			return nil, err
+		}
 	}
 
-	// Calculate total cost
-	totalCost := roomCost + totalSnackCost
+	// Snapshot the rates used so the price stays explainable after later changes
+	breakdown := orderPriceBreakdown(pricePerHour, hours, snacks, adjustments)
+	totalCost := breakdown.Total
 
 	// Create reservation
 	var reservationID uuid.UUID
 	err = tx.QueryRow(`
 		INSERT INTO reservations (
-			room_id, user_id, start_time, end_time, visitor_count, price, status
-		) VALUES ($1, $2, $3, $4, $5, $6, $7)
+			room_id, user_id, start_time, end_time, visitor_count, price, status,
+			hourly_rate, billed_hours, room_subtotal, snack_subtotal, adjustments, cost_center_id, wallet_id,
+			excluded_allergens
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15)
 		RETURNING id
-	`, req.RoomID, req.UserID, req.StartTime, req.EndTime, req.VisitorCount, totalCost, "pending").Scan(&reservationID)
+	`, req.RoomID, req.UserID, req.StartTime, req.EndTime, req.VisitorCount, totalCost, "pending",
+		breakdown.HourlyRate, breakdown.BilledHours, breakdown.RoomSubtotal, breakdown.SnackSubtotal, breakdown.Adjustments,
+		costCenterID, req.WalletID, pq.Array(normalizeTags(req.ExcludedAllergens)),
+	).Scan(&reservationID)
 	if err != nil {
 		return nil, fmt.Errorf("error creating reservation: %v", err)
 	}
@@ -701,14 +883,27 @@ func (s *ReservationService) CreateReservation(req *models.CreateReservationRequ
 	for _, snack := range snacks {
 		_, err = tx.Exec(`
 			INSERT INTO reservation_snacks (
-				reservation_id, snack_id, quantity, price
-			) VALUES ($1, $2, $3, $4)
-		`, reservationID, snack.ID, snack.Quantity, snack.Price)
+				reservation_id, snack_id, quantity, price, stock_date, bundle_id, serve_at
+			) VALUES ($1, $2, $3, $4, $5, $6, $7)
+		`, reservationID, snack.ID, snack.Quantity, snack.Price, snack.StockDate, snack.BundleID, snack.ServeAt)
 		if err != nil {
 			return nil, fmt.Errorf("error creating snack order: %v", err)
 		}
 	}
 
+	// Hold the price on the wallet, or request payment for the reservation
+	var payment *models.PaymentInfo
+	if req.WalletID != nil {
+		if err := holdReservationFunds(tx, *req.WalletID, req.UserID, reservationID, totalCost); err != nil {
+			return nil, err
+		}
+	} else if s.payments != nil && totalCost > 0 {
+		payment, err = s.payments.createPaymentIntent(tx, reservationID, totalCost, models.PaymentPurposeBooking)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	// Commit transaction
 	if err = tx.Commit(); err != nil {
 		return nil, fmt.Errorf("error committing transaction: %v", err)
@@ -719,5 +914,39 @@ func (s *ReservationService) CreateReservation(req *models.CreateReservationRequ
 		Status:        "pending",
 		TotalCost:     totalCost,
 		CreatedAt:     time.Now(),
+		Payment:       payment,
+		WalletID:      req.WalletID,
+		Dietary:       summarizeDietary(dietaryItemsOf(snacks), req.ExcludedAllergens),
 	}, nil
 }
+
+// newPriceBreakdown builds the price breakdown of a reservation. Subtotals are
+// rounded to whole cents before summing so the total always equals its parts.
+// Billed hours keep the four decimals stored in reservations.billed_hours.
+func newPriceBreakdown(hourlyRate, billedHours, snackSubtotal, adjustments float64) models.PriceBreakdown {
+	breakdown := models.PriceBreakdown{
+		HourlyRate:    roundCurrency(hourlyRate),
+		BilledHours:   math.Round(billedHours*10000) / 10000,
+		RoomSubtotal:  roundCurrency(hourlyRate * billedHours),
+		SnackSubtotal: roundCurrency(snackSubtotal),
+		Adjustments:   roundCurrency(adjustments),
+	}
+	breakdown.Total = roundCurrency(breakdown.RoomSubtotal + breakdown.SnackSubtotal + breakdown.Adjustments)
+	return breakdown
+}
+
+// orderPriceBreakdown builds the price breakdown of a booking of hours at
+// hourlyRate with the given snack lines. Quotes and new reservations both use
+// it so a quote always matches the price that is booked.
+func orderPriceBreakdown(hourlyRate, hours float64, snacks []orderedSnack, adjustments float64) models.PriceBreakdown {
+	var snackSubtotal float64
+	for _, snack := range snacks {
+		snackSubtotal += snack.Subtotal()
+	}
+	return newPriceBreakdown(hourlyRate, hours, snackSubtotal, adjustments)
+}
+
+// roundCurrency rounds an amount to the two decimals stored by DECIMAL(10,2) columns.
+func roundCurrency(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
@@ -0,0 +1,114 @@
+package services
+
+import (
+	"e-meetingproject/internal/models"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewPriceBreakdown(t *testing.T) {
+	tests := []struct {
+		name          string
+		hourlyRate    float64
+		billedHours   float64
+		snackSubtotal float64
+		adjustments   float64
+		expectedRoom  float64
+		expectedTotal float64
+	}{
+		{
+			name:          "Whole hours with snacks",
+			hourlyRate:    100000,
+			billedHours:   2,
+			snackSubtotal: 45000,
+			expectedRoom:  200000,
+			expectedTotal: 245000,
+		},
+		{
+			name:          "Partial hour is rounded to cents",
+			hourlyRate:    100000,
+			billedHours:   50.0 / 60.0,
+			expectedRoom:  83333.33,
+			expectedTotal: 83333.33,
+		},
+		{
+			name:          "Adjustments are part of the total",
+			hourlyRate:    150000,
+			billedHours:   1.5,
+			snackSubtotal: 20000,
+			adjustments:   -5000,
+			expectedRoom:  225000,
+			expectedTotal: 240000,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			breakdown := newPriceBreakdown(tc.hourlyRate, tc.billedHours, tc.snackSubtotal, tc.adjustments)
+
+			assert.Equal(t, tc.expectedRoom, breakdown.RoomSubtotal)
+			assert.Equal(t, tc.expectedTotal, breakdown.Total)
+			assert.Equal(t, breakdown.Total, breakdown.RoomSubtotal+breakdown.SnackSubtotal+breakdown.Adjustments)
+		})
+	}
+}
+
+func TestOrderPriceBreakdown(t *testing.T) {
+	snacks := []orderedSnack{
+		{Snack: models.Snack{Price: 12500.005}, Quantity: 3},
+		{Snack: models.Snack{Price: 7999.999}, Quantity: 2},
+	}
+
+	// Quotes report the rounded amounts a new reservation stores
+	breakdown := orderPriceBreakdown(100000, 50.0/60.0, snacks, -0.015)
+
+	assert.Equal(t, 83333.33, breakdown.RoomSubtotal)
+	assert.Equal(t, 53500.01, breakdown.SnackSubtotal)
+	assert.Equal(t, -0.02, breakdown.Adjustments)
+	assert.Equal(t, 136833.32, breakdown.Total)
+	assert.Equal(t, newPriceBreakdown(100000, 50.0/60.0, 53500.013, -0.015), breakdown)
+}
+
+func TestCheckStatusTransition(t *testing.T) {
+	tests := []struct {
+		name          string
+		current       models.ReservationStatus
+		next          models.ReservationStatus
+		expectedError string
+	}{
+		{name: "Confirm pending", current: models.ReservationStatusPending, next: models.ReservationStatusConfirmed},
+		{name: "Cancel confirmed", current: models.ReservationStatusConfirmed, next: models.ReservationStatusCancelled},
+		{name: "Complete confirmed", current: models.ReservationStatusConfirmed, next: models.ReservationStatusCompleted},
+		{name: "Cancel again", current: models.ReservationStatusCancelled, next: models.ReservationStatusCancelled},
+		{
+			name:          "Reinstate cancelled",
+			current:       models.ReservationStatusCancelled,
+			next:          models.ReservationStatusConfirmed,
+			expectedError: "invalid status transition: reservation is already cancelled",
+		},
+		{
+			name:          "Reopen cancelled as pending",
+			current:       models.ReservationStatusCancelled,
+			next:          models.ReservationStatusPending,
+			expectedError: "invalid status transition: reservation is already cancelled",
+		},
+		{
+			name:          "Cancel completed",
+			current:       models.ReservationStatusCompleted,
+			next:          models.ReservationStatusCancelled,
+			expectedError: "invalid status transition: reservation is already completed",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkStatusTransition(tt.current, tt.next)
+			if tt.expectedError != "" {
+				assert.EqualError(t, err, tt.expectedError)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
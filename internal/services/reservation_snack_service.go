@@ -0,0 +1,532 @@
+package services
+
+import (
+	"database/sql"
+	"e-meetingproject/internal/database"
+	"e-meetingproject/internal/models"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/spf13/viper"
+)
+
+// defaultSnackEditCutoff applies when SNACK_EDIT_CUTOFF_MINUTES is not set.
+const defaultSnackEditCutoff = 120 * time.Minute
+
+// ReservationSnackService changes the snack order of booked reservations.
+type ReservationSnackService struct {
+	db         *sql.DB
+	payments   *PaymentService
+	editCutoff time.Duration
+}
+
+func NewReservationSnackService(payments *PaymentService) *ReservationSnackService {
+	editCutoff := defaultSnackEditCutoff
+	if viper.IsSet("SNACK_EDIT_CUTOFF_MINUTES") {
+		editCutoff = time.Duration(viper.GetInt("SNACK_EDIT_CUTOFF_MINUTES")) * time.Minute
+	}
+
+	return &ReservationSnackService{
+		db:         database.GetDB(),
+		payments:   payments,
+		editCutoff: editCutoff,
+	}
+}
+
+// editableReservation is a reservation locked for a snack order change.
+type editableReservation struct {
+	ID             uuid.UUID
+	OrganisationID uuid.UUID // Organisation of the room, whose snacks may be ordered
+	Status         models.ReservationStatus
+	StartTime      time.Time
+	EndTime        time.Time
+	VisitorCount   int
+	Price          float64
+	WalletID       *uuid.UUID
+}
+
+// snackLine is a stored snack line of a reservation.
+type snackLine struct {
+	ID         uuid.UUID
+	SnackID    uuid.UUID
+	SnackName  string
+	Quantity   int
+	BundleID   *uuid.UUID
+	ServeAt    *time.Time
+	PrepStatus models.PrepStatus
+}
+
+// AddSnackLine orders another snack with the user's reservation at the
+// current catalogue price.
+func (s *ReservationSnackService) AddSnackLine(reservationID, userID uuid.UUID, item *models.SnackOrderItem) (*models.SnackOrderChangeResponse, error) {
+	now := time.Now()
+
+	// Start transaction
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("error starting transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	reservation, err := lockEditableReservation(tx, reservationID, userID, now, s.editCutoff)
+	if err != nil {
+		return nil, err
+	}
+
+	if fieldErrors := validateServeTimes([]models.SnackOrderItem{*item}, nil, reservation.StartTime, reservation.EndTime); len(fieldErrors) > 0 {
+		return nil, withoutFieldPrefix(&models.ValidationError{Fields: fieldErrors}, "snacks[0].")
+	}
+	if err := checkSnackLineUnique(tx, reservationID, uuid.Nil, item.SnackID, item.ServeAt); err != nil {
+		return nil, err
+	}
+
+	// Validate against the snack rules and take the quantity out of stock
+	snacks, err := loadSnackOrder(tx, reservation.OrganisationID, []models.SnackOrderItem{*item}, reservation.VisitorCount, reservation.StartTime, now)
+	if err != nil {
+		return nil, withoutFieldPrefix(err, "snacks[0].")
+	}
+	snacks[0].Field = "quantity"
+	if err := reserveSnackStock(tx, snacks, snackStockDate(reservation.StartTime)); err != nil {
+		return nil, err
+	}
+
+	var lineID uuid.UUID
+	err = tx.QueryRow(`
+		INSERT INTO reservation_snacks (
+			reservation_id, snack_id, quantity, price, stock_date, serve_at
+		) VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id
+	`, reservationID, snacks[0].ID, snacks[0].Quantity, snacks[0].Price, snacks[0].StockDate, snacks[0].ServeAt).Scan(&lineID)
+	if err != nil {
+		return nil, fmt.Errorf("error creating snack order: %v", err)
+	}
+
+	description := fmt.Sprintf("added %d x %s", snacks[0].Quantity, snacks[0].Name)
+	response, err := s.finishSnackOrderChange(tx, reservation, userID, description)
+	if err != nil {
+		return nil, err
+	}
+
+	response.Line, err = getReservationSnackLine(tx, lineID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Commit transaction
+	if err = tx.Commit(); err != nil {
+		return nil, fmt.Errorf("error committing transaction: %v", err)
+	}
+
+	return response, nil
+}
+
+// UpdateSnackLine changes the quantity or serve time of a snack line. The line
+// keeps the price it was ordered at.
+func (s *ReservationSnackService) UpdateSnackLine(reservationID, lineID, userID uuid.UUID, req *models.UpdateReservationSnackRequest) (*models.SnackOrderChangeResponse, error) {
+	now := time.Now()
+
+	// Start transaction
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("error starting transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	reservation, err := lockEditableReservation(tx, reservationID, userID, now, s.editCutoff)
+	if err != nil {
+		return nil, err
+	}
+
+	line, err := lockEditableSnackLine(tx, reservationID, lineID)
+	if err != nil {
+		return nil, err
+	}
+
+	quantity := line.Quantity
+	if req.Quantity != nil {
+		quantity = *req.Quantity
+	}
+	serveAt := line.ServeAt
+	if req.ServeAt != nil {
+		serveAt = req.ServeAt
+	}
+
+	item := models.SnackOrderItem{SnackID: line.SnackID, Quantity: quantity, ServeAt: serveAt}
+	if fieldErrors := validateServeTimes([]models.SnackOrderItem{item}, nil, reservation.StartTime, reservation.EndTime); len(fieldErrors) > 0 {
+		return nil, withoutFieldPrefix(&models.ValidationError{Fields: fieldErrors}, "snacks[0].")
+	}
+	if err := checkSnackLineUnique(tx, reservationID, lineID, line.SnackID, serveAt); err != nil {
+		return nil, err
+	}
+
+	snack, err := scanSnack(tx.QueryRow(`
+		SELECT `+snackColumns+`
+		FROM snacks s
+		WHERE s.id = $1
+	`, line.SnackID))
+	if err != nil {
+		return nil, fmt.Errorf("error fetching snack: %v", err)
+	}
+
+	// Ordering more must follow every snack rule; ordering less only the minimum
+	var fieldErrors []models.FieldError
+	if quantity > line.Quantity {
+		catalogue := map[uuid.UUID]models.Snack{snack.ID: *snack}
+		fieldErrors = validateSnackOrder([]models.SnackOrderItem{item}, catalogue, reservation.VisitorCount, reservation.StartTime, now)
+	} else if quantity < snack.MinQuantity {
+		fieldErrors = []models.FieldError{{Field: "snacks[0].quantity", Message: fmt.Sprintf("must be at least %d", snack.MinQuantity)}}
+	}
+	if len(fieldErrors) > 0 {
+		return nil, withoutFieldPrefix(&models.ValidationError{Fields: fieldErrors}, "snacks[0].")
+	}
+
+	// Swap the stock taken by the old quantity for the new one
+	if err := releaseSnackLineStock(tx, lineID); err != nil {
+		return nil, err
+	}
+	ordered := []orderedSnack{{Snack: *snack, Quantity: quantity, Field: "quantity"}}
+	if err := reserveSnackStock(tx, ordered, snackStockDate(reservation.StartTime)); err != nil {
+		return nil, err
+	}
+
+	_, err = tx.Exec(`
+		UPDATE reservation_snacks
+		SET quantity = $1, serve_at = $2, stock_date = $3, updated_at = NOW()
+		WHERE id = $4
+	`, quantity, serveAt, ordered[0].StockDate, lineID)
+	if err != nil {
+		return nil, fmt.Errorf("error updating snack order: %v", err)
+	}
+
+	var changes []string
+	if quantity != line.Quantity {
+		changes = append(changes, fmt.Sprintf("quantity %d to %d", line.Quantity, quantity))
+	}
+	if req.ServeAt != nil {
+		changes = append(changes, fmt.Sprintf("serve time to %s", req.ServeAt.Format(time.RFC3339)))
+	}
+	description := fmt.Sprintf("changed %s", line.SnackName)
+	if len(changes) > 0 {
+		description += ": " + strings.Join(changes, ", ")
+	}
+
+	response, err := s.finishSnackOrderChange(tx, reservation, userID, description)
+	if err != nil {
+		return nil, err
+	}
+
+	response.Line, err = getReservationSnackLine(tx, lineID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Commit transaction
+	if err = tx.Commit(); err != nil {
+		return nil, fmt.Errorf("error committing transaction: %v", err)
+	}
+
+	return response, nil
+}
+
+// RemoveSnackLine drops a snack line from the reservation and puts its
+// quantity back into stock.
+func (s *ReservationSnackService) RemoveSnackLine(reservationID, lineID, userID uuid.UUID) (*models.SnackOrderChangeResponse, error) {
+	// Start transaction
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("error starting transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	reservation, err := lockEditableReservation(tx, reservationID, userID, time.Now(), s.editCutoff)
+	if err != nil {
+		return nil, err
+	}
+
+	line, err := lockEditableSnackLine(tx, reservationID, lineID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := releaseSnackLineStock(tx, lineID); err != nil {
+		return nil, err
+	}
+
+	_, err = tx.Exec(`DELETE FROM reservation_snacks WHERE id = $1`, lineID)
+	if err != nil {
+		return nil, fmt.Errorf("error removing snack order: %v", err)
+	}
+
+	description := fmt.Sprintf("removed %d x %s", line.Quantity, line.SnackName)
+	response, err := s.finishSnackOrderChange(tx, reservation, userID, description)
+	if err != nil {
+		return nil, err
+	}
+
+	// Commit transaction
+	if err = tx.Commit(); err != nil {
+		return nil, fmt.Errorf("error committing transaction: %v", err)
+	}
+
+	return response, nil
+}
+
+// finishSnackOrderChange reprices the reservation after its snack lines
+// changed, settles the difference and records the change in its history.
+// Price changes are held, captured or refunded on the wallet. Without a wallet
+// an increase is requested from the payment provider; decreases are refused
+// once the provider was paid, as it cannot refund them.
+func (s *ReservationSnackService) finishSnackOrderChange(tx *sql.Tx, reservation *editableReservation, userID uuid.UUID, description string) (*models.SnackOrderChangeResponse, error) {
+	breakdown, err := repriceReservation(tx, reservation.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	response := &models.SnackOrderChangeResponse{
+		ReservationID:  reservation.ID,
+		PreviousPrice:  reservation.Price,
+		PriceBreakdown: breakdown,
+		TotalCost:      breakdown.Total,
+	}
+
+	delta := roundCurrency(breakdown.Total - reservation.Price)
+	if reservation.WalletID != nil {
+		if err := adjustReservationWallet(tx, reservation.ID, *reservation.WalletID, userID, reservation.Status, delta); err != nil {
+			return nil, err
+		}
+	} else if s.payments != nil {
+		paid, err := succeededPaymentTotal(tx, reservation.ID)
+		if err != nil {
+			return nil, err
+		}
+		due, err := providerPaymentDue(delta, paid > 0)
+		if err != nil {
+			return nil, err
+		}
+		if due > 0 {
+			response.Payment, err = s.payments.createPaymentIntent(tx, reservation.ID, due, models.PaymentPurposeSnackTopUp)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	err = recordReservationHistory(tx, reservation.ID, reservation.Status, "snack_order", description, &userID, &reservation.Price, &breakdown.Total)
+	if err != nil {
+		return nil, err
+	}
+
+	return response, nil
+}
+
+// providerPaymentDue returns the amount to request from the payment provider
+// for a price change of delta. The provider cannot refund, so a decrease is
+// refused once a payment to it succeeded.
+func providerPaymentDue(delta float64, paid bool) (float64, error) {
+	if delta < 0 && paid {
+		return 0, fmt.Errorf("reservation cannot be edited: snack orders paid through the payment provider cannot be reduced")
+	}
+	if delta < 0 {
+		return 0, nil
+	}
+	return delta, nil
+}
+
+// lockEditableReservation locks the user's reservation for a snack order
+// change. Reservations of other users are reported as not found.
+func lockEditableReservation(tx *sql.Tx, reservationID, userID uuid.UUID, now time.Time, cutoff time.Duration) (*editableReservation, error) {
+	var reservation editableReservation
+	var ownerID uuid.UUID
+	var walletID uuid.NullUUID
+	err := tx.QueryRow(`
+		SELECT r.id, rm.organisation_id, r.user_id, r.status, r.start_time, r.end_time, r.visitor_count, r.price, r.wallet_id
+		FROM reservations r
+		JOIN rooms rm ON r.room_id = rm.id
+		WHERE r.id = $1
+		FOR UPDATE OF r
+	`, reservationID).Scan(
+		&reservation.ID, &reservation.OrganisationID, &ownerID, &reservation.Status, &reservation.StartTime, &reservation.EndTime,
+		&reservation.VisitorCount, &reservation.Price, &walletID,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("reservation not found")
+		}
+		return nil, fmt.Errorf("error fetching reservation: %v", err)
+	}
+	if ownerID != userID {
+		return nil, fmt.Errorf("reservation not found")
+	}
+	if walletID.Valid {
+		reservation.WalletID = &walletID.UUID
+	}
+
+	if err := checkSnackOrderEditable(reservation.Status, reservation.StartTime, now, cutoff); err != nil {
+		return nil, err
+	}
+
+	return &reservation, nil
+}
+
+// checkSnackOrderEditable allows changes to the snack order of pending and
+// confirmed reservations until cutoff before they start.
+func checkSnackOrderEditable(status models.ReservationStatus, startTime, now time.Time, cutoff time.Duration) error {
+	if status != models.ReservationStatusPending && status != models.ReservationStatusConfirmed {
+		return fmt.Errorf("reservation cannot be edited in status %s", status)
+	}
+	if now.Add(cutoff).After(startTime) {
+		return fmt.Errorf("reservation cannot be edited less than %d minutes before start_time", int(cutoff.Minutes()))
+	}
+	return nil
+}
+
+// lockEditableSnackLine locks a snack line the organiser may still change:
+// ordered on its own and not yet picked up by the kitchen.
+func lockEditableSnackLine(tx *sql.Tx, reservationID, lineID uuid.UUID) (*snackLine, error) {
+	var line snackLine
+	var bundleID uuid.NullUUID
+	var serveAt sql.NullTime
+	err := tx.QueryRow(`
+		SELECT rs.id, rs.snack_id, s.name, rs.quantity, rs.bundle_id, rs.serve_at, rs.prep_status
+		FROM reservation_snacks rs
+		JOIN snacks s ON rs.snack_id = s.id
+		WHERE rs.id = $1 AND rs.reservation_id = $2
+		FOR UPDATE OF rs
+	`, lineID, reservationID).Scan(
+		&line.ID, &line.SnackID, &line.SnackName, &line.Quantity, &bundleID, &serveAt, &line.PrepStatus,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("snack line not found")
+		}
+		return nil, fmt.Errorf("error fetching snack line: %v", err)
+	}
+	if bundleID.Valid {
+		line.BundleID = &bundleID.UUID
+	}
+	if serveAt.Valid {
+		line.ServeAt = &serveAt.Time
+	}
+
+	if line.BundleID != nil {
+		return nil, fmt.Errorf("snack line cannot be edited: it is part of a bundle")
+	}
+	if line.PrepStatus != models.PrepStatusPending {
+		return nil, fmt.Errorf("snack line cannot be edited: it is already %s", line.PrepStatus)
+	}
+
+	return &line, nil
+}
+
+// checkSnackLineUnique rejects a second line of the same snack at the same
+// serve time; the existing line should be updated instead.
+func checkSnackLineUnique(tx *sql.Tx, reservationID, lineID, snackID uuid.UUID, serveAt *time.Time) error {
+	var existingID uuid.UUID
+	err := tx.QueryRow(`
+		SELECT id
+		FROM reservation_snacks
+		WHERE reservation_id = $1
+			AND snack_id = $2
+			AND bundle_id IS NULL
+			AND serve_at IS NOT DISTINCT FROM $3
+			AND id <> $4
+		LIMIT 1
+	`, reservationID, snackID, serveAt, lineID).Scan(&existingID)
+	if err == sql.ErrNoRows {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("error checking snack lines: %v", err)
+	}
+
+	return &models.ValidationError{Fields: []models.FieldError{{
+		Field:   "snack_id",
+		Message: fmt.Sprintf("already ordered for this serve time; update line %s instead", existingID),
+	}}}
+}
+
+// repriceReservation recomputes the snack subtotal and price of a reservation
+// from its stored per-line prices. The room subtotal and adjustments stay as
+// they were booked.
+func repriceReservation(tx *sql.Tx, reservationID uuid.UUID) (models.PriceBreakdown, error) {
+	var breakdown models.PriceBreakdown
+	var snackSubtotal float64
+	err := tx.QueryRow(`
+		SELECT r.hourly_rate, r.billed_hours, r.room_subtotal, r.adjustments,
+			COALESCE((SELECT SUM(rs.price * rs.quantity) FROM reservation_snacks rs WHERE rs.reservation_id = r.id), 0)
+		FROM reservations r
+		WHERE r.id = $1
+	`, reservationID).Scan(
+		&breakdown.HourlyRate, &breakdown.BilledHours, &breakdown.RoomSubtotal, &breakdown.Adjustments, &snackSubtotal,
+	)
+	if err != nil {
+		return breakdown, fmt.Errorf("error fetching reservation price: %v", err)
+	}
+
+	breakdown.SnackSubtotal = roundCurrency(snackSubtotal)
+	breakdown.Total = roundCurrency(breakdown.RoomSubtotal + breakdown.SnackSubtotal + breakdown.Adjustments)
+
+	_, err = tx.Exec(`
+		UPDATE reservations
+		SET snack_subtotal = $1, price = $2, updated_at = NOW()
+		WHERE id = $3
+	`, breakdown.SnackSubtotal, breakdown.Total, reservationID)
+	if err != nil {
+		return breakdown, fmt.Errorf("error updating reservation price: %v", err)
+	}
+
+	return breakdown, nil
+}
+
+// recordReservationHistory appends a change to the reservation's history.
+func recordReservationHistory(tx *sql.Tx, reservationID uuid.UUID, status models.ReservationStatus, changeType, description string, changedBy *uuid.UUID, priceBefore, priceAfter *float64) error {
+	_, err := tx.Exec(`
+		INSERT INTO reservation_history (
+			reservation_id, status, change_type, description, changed_by, price_before, price_after
+		) VALUES ($1, $2, $3, $4, $5, $6, $7)
+	`, reservationID, status, changeType, description, changedBy, priceBefore, priceAfter)
+	if err != nil {
+		return fmt.Errorf("error recording reservation history: %v", err)
+	}
+
+	return nil
+}
+
+// getReservationSnackLine reads one snack line as shown in the reservation detail.
+func getReservationSnackLine(tx *sql.Tx, lineID uuid.UUID) (*models.ReservationSnackLine, error) {
+	var line models.ReservationSnackLine
+	err := tx.QueryRow(`
+		SELECT
+			rs.id, s.id, s.name, s.category, rs.price, rs.quantity, rs.bundle_id,
+			COALESCE(rs.serve_at, r.start_time), s.dietary_tags, s.allergens
+		FROM reservation_snacks rs
+		JOIN reservations r ON rs.reservation_id = r.id
+		JOIN snacks s ON rs.snack_id = s.id
+		WHERE rs.id = $1
+	`, lineID).Scan(
+		&line.LineID, &line.ID, &line.Name, &line.Category, &line.Price, &line.Quantity, &line.BundleID, &line.ServeAt,
+		pq.Array(&line.DietaryTags), pq.Array(&line.Allergens),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching snack line: %v", err)
+	}
+	line.Subtotal = line.Price * float64(line.Quantity)
+
+	return &line, nil
+}
+
+// withoutFieldPrefix strips prefix from the fields of a *models.ValidationError
+// so errors about a single line point into the request body itself.
+func withoutFieldPrefix(err error, prefix string) error {
+	validationErr, ok := err.(*models.ValidationError)
+	if !ok {
+		return err
+	}
+	for i := range validationErr.Fields {
+		validationErr.Fields[i].Field = strings.TrimPrefix(validationErr.Fields[i].Field, prefix)
+	}
+	return validationErr
+}
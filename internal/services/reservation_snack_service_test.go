@@ -0,0 +1,92 @@
+package services
+
+import (
+	"e-meetingproject/internal/models"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckSnackOrderEditable(t *testing.T) {
+	now := time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC)
+	cutoff := 2 * time.Hour
+
+	tests := []struct {
+		name          string
+		status        models.ReservationStatus
+		startTime     time.Time
+		expectedError string
+	}{
+		{name: "Pending before cutoff", status: models.ReservationStatusPending, startTime: now.Add(3 * time.Hour)},
+		{name: "Confirmed at cutoff", status: models.ReservationStatusConfirmed, startTime: now.Add(2 * time.Hour)},
+		{
+			name: "Confirmed after cutoff", status: models.ReservationStatusConfirmed, startTime: now.Add(time.Hour),
+			expectedError: "reservation cannot be edited less than 120 minutes before start_time",
+		},
+		{
+			name: "Cancelled", status: models.ReservationStatusCancelled, startTime: now.Add(24 * time.Hour),
+			expectedError: "reservation cannot be edited in status cancelled",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			err := checkSnackOrderEditable(tc.status, tc.startTime, now, cutoff)
+			if tc.expectedError != "" {
+				assert.EqualError(t, err, tc.expectedError)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestWithoutFieldPrefix(t *testing.T) {
+	err := withoutFieldPrefix(&models.ValidationError{Fields: []models.FieldError{
+		{Field: "snacks[0].quantity", Message: "must be at least 5"},
+		{Field: "visitor_count", Message: "is required"},
+	}}, "snacks[0].")
+
+	assert.Equal(t, &models.ValidationError{Fields: []models.FieldError{
+		{Field: "quantity", Message: "must be at least 5"},
+		{Field: "visitor_count", Message: "is required"},
+	}}, err)
+
+	plain := errors.New("reservation not found")
+	assert.Equal(t, plain, withoutFieldPrefix(plain, "snacks[0]."))
+}
+
+func TestProviderPaymentDue(t *testing.T) {
+	tests := []struct {
+		name          string
+		delta         float64
+		paid          bool
+		expected      float64
+		expectedError string
+	}{
+		{name: "Increase", delta: 12.5, paid: true, expected: 12.5},
+		{name: "Increase before any succeeded payment", delta: 12.5, expected: 12.5},
+		{name: "No change", delta: 0, paid: true},
+		{name: "Decrease while payments are pending or failed", delta: -4},
+		{
+			name:          "Decrease after a succeeded payment",
+			delta:         -4,
+			paid:          true,
+			expectedError: "reservation cannot be edited: snack orders paid through the payment provider cannot be reduced",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			due, err := providerPaymentDue(tt.delta, tt.paid)
+			if tt.expectedError != "" {
+				assert.EqualError(t, err, tt.expectedError)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, due)
+		})
+	}
+}
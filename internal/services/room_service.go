@@ -21,23 +21,29 @@ func NewRoomService() *RoomService {
 	}
 }
 
-func (s *RoomService) CreateRoom(req *models.CreateRoomRequest) (*models.Room, error) {
+func (s *RoomService) CreateRoom(req *models.CreateRoomRequest, tenant Tenant) (*models.Room, error) {
+	organisationID, err := tenant.organisationFor(s.db, req.OrganisationID)
+	if err != nil {
+		return nil, err
+	}
+
 	room := &models.Room{
-		ID:           uuid.New(),
-		Name:         req.Name,
-		Capacity:     req.Capacity,
-		PricePerHour: req.PricePerHour,
-		Status:       req.Status,
-		CreatedAt:    time.Now(),
-		UpdatedAt:    time.Now(),
-	}
-
-	err := s.db.QueryRow(`
-		INSERT INTO rooms (id, name, capacity, price_per_hour, status, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7)
-		RETURNING id, name, capacity, price_per_hour, status, created_at, updated_at`,
-		room.ID, room.Name, room.Capacity, room.PricePerHour, room.Status, room.CreatedAt, room.UpdatedAt,
-	).Scan(&room.ID, &room.Name, &room.Capacity, &room.PricePerHour, &room.Status, &room.CreatedAt, &room.UpdatedAt)
+		ID:             uuid.New(),
+		OrganisationID: organisationID,
+		Name:           req.Name,
+		Capacity:       req.Capacity,
+		PricePerHour:   req.PricePerHour,
+		Status:         req.Status,
+		CreatedAt:      time.Now(),
+		UpdatedAt:      time.Now(),
+	}
+
+	err = s.db.QueryRow(`
+		INSERT INTO rooms (id, organisation_id, name, capacity, price_per_hour, status, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING id, organisation_id, name, capacity, price_per_hour, status, created_at, updated_at`,
+		room.ID, room.OrganisationID, room.Name, room.Capacity, room.PricePerHour, room.Status, room.CreatedAt, room.UpdatedAt,
+	).Scan(&room.ID, &room.OrganisationID, &room.Name, &room.Capacity, &room.PricePerHour, &room.Status, &room.CreatedAt, &room.UpdatedAt)
 
 	if err != nil {
 		return nil, fmt.Errorf("error creating room: %v", err)
@@ -46,7 +52,7 @@ func (s *RoomService) CreateRoom(req *models.CreateRoomRequest) (*models.Room, e
 	return room, nil
 }
 
-func (s *RoomService) UpdateRoom(id uuid.UUID, req *models.UpdateRoomRequest) (*models.Room, error) {
+func (s *RoomService) UpdateRoom(id uuid.UUID, req *models.UpdateRoomRequest, tenant Tenant) (*models.Room, error) {
 	// Start transaction
 	tx, err := s.db.Begin()
 	if err != nil {
@@ -57,10 +63,10 @@ func (s *RoomService) UpdateRoom(id uuid.UUID, req *models.UpdateRoomRequest) (*
 	// First, check if room exists
 	var room models.Room
 	err = tx.QueryRow(`
-		SELECT id, name, capacity, price_per_hour, status, created_at, updated_at
-		FROM rooms WHERE id = $1`,
-		id,
-	).Scan(&room.ID, &room.Name, &room.Capacity, &room.PricePerHour, &room.Status, &room.CreatedAt, &room.UpdatedAt)
+		SELECT id, organisation_id, name, capacity, price_per_hour, status, created_at, updated_at
+		FROM rooms WHERE id = $1 AND `+tenantCondition("organisation_id", 2)+` AND `+roomCondition("id", 3),
+		id, tenant.arg(), tenant.roomsArg(),
+	).Scan(&room.ID, &room.OrganisationID, &room.Name, &room.Capacity, &room.PricePerHour, &room.Status, &room.CreatedAt, &room.UpdatedAt)
 
 	if err != nil {
 		if err == sql.ErrNoRows {
@@ -103,7 +109,7 @@ func (s *RoomService) UpdateRoom(id uuid.UUID, req *models.UpdateRoomRequest) (*
 	return &room, nil
 }
 
-func (s *RoomService) DeleteRoom(id uuid.UUID) error {
+func (s *RoomService) DeleteRoom(id uuid.UUID, tenant Tenant) error {
 	// Start transaction
 	tx, err := s.db.Begin()
 	if err != nil {
@@ -111,6 +117,19 @@ func (s *RoomService) DeleteRoom(id uuid.UUID) error {
 	}
 	defer tx.Rollback()
 
+	// Check if the room exists for the tenant
+	var exists bool
+	err = tx.QueryRow(`
+		SELECT EXISTS(SELECT 1 FROM rooms WHERE id = $1 AND `+tenantCondition("organisation_id", 2)+` AND `+roomCondition("id", 3)+`)`,
+		id, tenant.arg(), tenant.roomsArg(),
+	).Scan(&exists)
+	if err != nil {
+		return fmt.Errorf("error checking room existence: %v", err)
+	}
+	if !exists {
+		return fmt.Errorf("room not found")
+	}
+
 	// Check if room has any reservations
 	var hasReservations bool
 	err = tx.QueryRow(`
@@ -153,7 +172,7 @@ func (s *RoomService) DeleteRoom(id uuid.UUID) error {
 	return nil
 }
 
-func (s *RoomService) GetRooms(filter *models.RoomFilter, pagination *models.PaginationQuery) (*models.RoomListResponse, error) {
+func (s *RoomService) GetRooms(filter *models.RoomFilter, pagination *models.PaginationQuery, tenant Tenant) (*models.RoomListResponse, error) {
 	// Start transaction
 	tx, err := s.db.Begin()
 	if err != nil {
@@ -161,10 +180,10 @@ func (s *RoomService) GetRooms(filter *models.RoomFilter, pagination *models.Pag
 	}
 	defer tx.Rollback()
 
-	// Build query conditions
-	conditions := []string{"1 = 1"} // Always true condition as a starter
-	args := []interface{}{}
-	argCount := 1
+	// Build query conditions, starting with the tenant's rooms
+	conditions := []string{tenantCondition("organisation_id", 1), roomCondition("id", 2)}
+	args := []interface{}{tenant.arg(), tenant.roomsArg()}
+	argCount := 3
 
 	if filter != nil {
 		if filter.Search != nil && *filter.Search != "" {
@@ -220,7 +239,7 @@ func (s *RoomService) GetRooms(filter *models.RoomFilter, pagination *models.Pag
 
 	// Get rooms with pagination
 	query := fmt.Sprintf(`
-		SELECT id, name, capacity, price_per_hour, status, created_at, updated_at
+		SELECT id, organisation_id, name, capacity, price_per_hour, status, created_at, updated_at
 		FROM rooms 
 		WHERE %s
 		ORDER BY name ASC
@@ -244,6 +263,7 @@ func (s *RoomService) GetRooms(filter *models.RoomFilter, pagination *models.Pag
 		var room models.Room
 		err := rows.Scan(
 			&room.ID,
+			&room.OrganisationID,
 			&room.Name,
 			&room.Capacity,
 			&room.PricePerHour,
@@ -275,7 +295,7 @@ func (s *RoomService) GetRooms(filter *models.RoomFilter, pagination *models.Pag
 	}, nil
 }
 
-func (s *RoomService) GetRoomSchedule(roomID uuid.UUID, query *models.RoomScheduleQuery) (*models.RoomScheduleResponse, error) {
+func (s *RoomService) GetRoomSchedule(roomID uuid.UUID, query *models.RoomScheduleQuery, tenant Tenant) (*models.RoomScheduleResponse, error) {
 	// Start transaction
 	tx, err := s.db.Begin()
 	if err != nil {
@@ -285,7 +305,10 @@ func (s *RoomService) GetRoomSchedule(roomID uuid.UUID, query *models.RoomSchedu
 
 	// First, check if room exists
 	var exists bool
-	err = tx.QueryRow(`SELECT EXISTS(SELECT 1 FROM rooms WHERE id = $1)`, roomID).Scan(&exists)
+	err = tx.QueryRow(`
+		SELECT EXISTS(SELECT 1 FROM rooms WHERE id = $1 AND `+tenantCondition("organisation_id", 2)+` AND `+roomCondition("id", 3)+`)`,
+		roomID, tenant.arg(), tenant.roomsArg(),
+	).Scan(&exists)
 	if err != nil {
 		return nil, fmt.Errorf("error checking room existence: %v", err)
 	}
@@ -0,0 +1,562 @@
+package services
+
+import (
+	"database/sql"
+	"e-meetingproject/internal/database"
+	"e-meetingproject/internal/models"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+type SnackBundleService struct {
+	db *sql.DB
+}
+
+func NewSnackBundleService() *SnackBundleService {
+	return &SnackBundleService{
+		db: database.GetDB(),
+	}
+}
+
+// snackBundleColumns lists the columns read by scanSnackBundle.
+const snackBundleColumns = `id, organisation_id, name, description, price, per_visitor, is_available, retired_at, created_at, updated_at`
+
+// GetBundles lists the bundles that are still offered, with their contents.
+func (s *SnackBundleService) GetBundles(tenant Tenant) (*models.SnackBundleListResponse, error) {
+	// Start transaction
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("error starting transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.Query(`
+		SELECT `+snackBundleColumns+`
+		FROM snack_bundles
+		WHERE retired_at IS NULL AND `+tenantCondition("organisation_id", 1)+`
+		ORDER BY name
+	`, tenant.arg())
+	if err != nil {
+		return nil, fmt.Errorf("error querying bundles: %v", err)
+	}
+	defer rows.Close()
+
+	bundles := []models.SnackBundle{}
+	for rows.Next() {
+		bundle, err := scanSnackBundle(rows)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning bundle: %v", err)
+		}
+		bundles = append(bundles, *bundle)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating bundles: %v", err)
+	}
+
+	if err := loadBundleItems(tx, bundles); err != nil {
+		return nil, err
+	}
+
+	// Commit transaction
+	if err = tx.Commit(); err != nil {
+		return nil, fmt.Errorf("error committing transaction: %v", err)
+	}
+
+	return &models.SnackBundleListResponse{Bundles: bundles}, nil
+}
+
+func (s *SnackBundleService) CreateBundle(req *models.CreateSnackBundleRequest, tenant Tenant) (*models.SnackBundle, error) {
+	// Start transaction
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("error starting transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	organisationID, err := tenant.organisationFor(tx, req.OrganisationID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := checkBundleItems(tx, organisationID, req.Items); err != nil {
+		return nil, err
+	}
+
+	bundle, err := scanSnackBundle(tx.QueryRow(`
+		INSERT INTO snack_bundles (name, description, price, per_visitor, organisation_id)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING `+snackBundleColumns,
+		req.Name, req.Description, req.Price, req.PerVisitor, organisationID,
+	))
+	if err != nil {
+		return nil, fmt.Errorf("error creating bundle: %v", err)
+	}
+
+	if err := replaceBundleItems(tx, bundle.ID, req.Items); err != nil {
+		return nil, err
+	}
+
+	bundles := []models.SnackBundle{*bundle}
+	if err := loadBundleItems(tx, bundles); err != nil {
+		return nil, err
+	}
+
+	// Commit transaction
+	if err = tx.Commit(); err != nil {
+		return nil, fmt.Errorf("error committing transaction: %v", err)
+	}
+
+	return &bundles[0], nil
+}
+
+func (s *SnackBundleService) UpdateBundle(id uuid.UUID, req *models.UpdateSnackBundleRequest, tenant Tenant) (*models.SnackBundle, error) {
+	// Start transaction
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("error starting transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	// First, check if bundle exists and is not retired
+	bundle, err := scanSnackBundle(tx.QueryRow(`
+		SELECT `+snackBundleColumns+`
+		FROM snack_bundles
+		WHERE id = $1 AND retired_at IS NULL AND `+tenantCondition("organisation_id", 2)+`
+		FOR UPDATE`,
+		id, tenant.arg(),
+	))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("bundle not found")
+		}
+		return nil, fmt.Errorf("error fetching bundle: %v", err)
+	}
+
+	// Update only provided fields. Booked orders keep the lines they were expanded into.
+	if req.Name != nil {
+		bundle.Name = *req.Name
+	}
+	if req.Description != nil {
+		bundle.Description = *req.Description
+	}
+	if req.Price != nil {
+		bundle.Price = *req.Price
+	}
+	if req.PerVisitor != nil {
+		bundle.PerVisitor = *req.PerVisitor
+	}
+	if req.IsAvailable != nil {
+		bundle.IsAvailable = *req.IsAvailable
+	}
+	bundle.UpdatedAt = time.Now()
+
+	_, err = tx.Exec(`
+		UPDATE snack_bundles
+		SET name = $1, description = $2, price = $3, per_visitor = $4, is_available = $5, updated_at = $6
+		WHERE id = $7`,
+		bundle.Name, bundle.Description, bundle.Price, bundle.PerVisitor, bundle.IsAvailable, bundle.UpdatedAt, bundle.ID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error updating bundle: %v", err)
+	}
+
+	if req.Items != nil {
+		if err := checkBundleItems(tx, bundle.OrganisationID, req.Items); err != nil {
+			return nil, err
+		}
+		if err := replaceBundleItems(tx, bundle.ID, req.Items); err != nil {
+			return nil, err
+		}
+	}
+
+	bundles := []models.SnackBundle{*bundle}
+	if err := loadBundleItems(tx, bundles); err != nil {
+		return nil, err
+	}
+
+	// Commit transaction
+	if err = tx.Commit(); err != nil {
+		return nil, fmt.Errorf("error committing transaction: %v", err)
+	}
+
+	return &bundles[0], nil
+}
+
+// RetireBundle removes a bundle from the catalogue. The row is kept because
+// past snack orders still reference it.
+func (s *SnackBundleService) RetireBundle(id uuid.UUID, tenant Tenant) error {
+	result, err := s.db.Exec(`
+		UPDATE snack_bundles
+		SET is_available = false, retired_at = NOW(), updated_at = NOW()
+		WHERE id = $1 AND retired_at IS NULL AND `+tenantCondition("organisation_id", 2),
+		id, tenant.arg(),
+	)
+	if err != nil {
+		return fmt.Errorf("error retiring bundle: %v", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("error getting rows affected: %v", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("bundle not found")
+	}
+
+	return nil
+}
+
+func scanSnackBundle(row rowScanner) (*models.SnackBundle, error) {
+	var bundle models.SnackBundle
+	var retiredAt sql.NullTime
+	err := row.Scan(
+		&bundle.ID,
+		&bundle.OrganisationID,
+		&bundle.Name,
+		&bundle.Description,
+		&bundle.Price,
+		&bundle.PerVisitor,
+		&bundle.IsAvailable,
+		&retiredAt,
+		&bundle.CreatedAt,
+		&bundle.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if retiredAt.Valid {
+		bundle.RetiredAt = &retiredAt.Time
+	}
+	bundle.Items = []models.SnackBundleItem{}
+
+	return &bundle, nil
+}
+
+// loadBundleItems fills in the contents of the given bundles.
+func loadBundleItems(tx *sql.Tx, bundles []models.SnackBundle) error {
+	if len(bundles) == 0 {
+		return nil
+	}
+
+	index := make(map[uuid.UUID]int, len(bundles))
+	bundleIDs := make([]uuid.UUID, 0, len(bundles))
+	for i, bundle := range bundles {
+		index[bundle.ID] = i
+		bundleIDs = append(bundleIDs, bundle.ID)
+	}
+
+	rows, err := tx.Query(`
+		SELECT bi.bundle_id, bi.snack_id, s.name, bi.quantity
+		FROM snack_bundle_items bi
+		JOIN snacks s ON bi.snack_id = s.id
+		WHERE bi.bundle_id = ANY($1)
+		ORDER BY s.category, s.name
+	`, pq.Array(bundleIDs))
+	if err != nil {
+		return fmt.Errorf("error querying bundle items: %v", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var bundleID uuid.UUID
+		var item models.SnackBundleItem
+		if err := rows.Scan(&bundleID, &item.SnackID, &item.SnackName, &item.Quantity); err != nil {
+			return fmt.Errorf("error scanning bundle item: %v", err)
+		}
+		i := index[bundleID]
+		bundles[i].Items = append(bundles[i].Items, item)
+	}
+
+	if err = rows.Err(); err != nil {
+		return fmt.Errorf("error iterating bundle items: %v", err)
+	}
+
+	return nil
+}
+
+// checkBundleItems rejects bundle contents naming unknown, retired or
+// repeated snacks, or snacks of another organisation, with a
+// *models.ValidationError.
+func checkBundleItems(tx *sql.Tx, organisationID uuid.UUID, items []models.SnackBundleItem) error {
+	snackIDs := make([]uuid.UUID, 0, len(items))
+	for _, item := range items {
+		snackIDs = append(snackIDs, item.SnackID)
+	}
+
+	rows, err := tx.Query(`
+		SELECT id
+		FROM snacks
+		WHERE id = ANY($1) AND retired_at IS NULL AND organisation_id = $2
+	`, pq.Array(snackIDs), organisationID)
+	if err != nil {
+		return fmt.Errorf("error querying snacks: %v", err)
+	}
+	defer rows.Close()
+
+	offered := make(map[uuid.UUID]bool, len(items))
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return fmt.Errorf("error scanning snack: %v", err)
+		}
+		offered[id] = true
+	}
+
+	if err = rows.Err(); err != nil {
+		return fmt.Errorf("error iterating snacks: %v", err)
+	}
+
+	var fieldErrors []models.FieldError
+	seen := make(map[uuid.UUID]int, len(items))
+	for i, item := range items {
+		field := fmt.Sprintf("items[%d].snack_id", i)
+		if first, ok := seen[item.SnackID]; ok {
+			fieldErrors = append(fieldErrors, models.FieldError{
+				Field:   field,
+				Message: fmt.Sprintf("duplicate of items[%d]; combine the quantities instead", first),
+			})
+			continue
+		}
+		seen[item.SnackID] = i
+
+		if !offered[item.SnackID] {
+			fieldErrors = append(fieldErrors, models.FieldError{Field: field, Message: "snack not found"})
+		}
+	}
+
+	if len(fieldErrors) > 0 {
+		return &models.ValidationError{Fields: fieldErrors}
+	}
+
+	return nil
+}
+
+func replaceBundleItems(tx *sql.Tx, bundleID uuid.UUID, items []models.SnackBundleItem) error {
+	_, err := tx.Exec(`DELETE FROM snack_bundle_items WHERE bundle_id = $1`, bundleID)
+	if err != nil {
+		return fmt.Errorf("error clearing bundle items: %v", err)
+	}
+
+	for _, item := range items {
+		_, err = tx.Exec(`
+			INSERT INTO snack_bundle_items (bundle_id, snack_id, quantity)
+			VALUES ($1, $2, $3)
+		`, bundleID, item.SnackID, item.Quantity)
+		if err != nil {
+			return fmt.Errorf("error creating bundle item: %v", err)
+		}
+	}
+
+	return nil
+}
+
+// loadBundleOrder resolves the requested bundles of organisationID within tx
+// and expands them into snack lines priced so they add up to the package
+// price. Cents lost when spreading the package price over the lines are
+// returned as an adjustment. The order is rejected with a
+// *models.ValidationError when a bundle cannot be ordered.
+func loadBundleOrder(tx *sql.Tx, organisationID uuid.UUID, items []models.BundleOrderItem, visitorCount int, startTime, now time.Time) ([]orderedSnack, float64, error) {
+	if len(items) == 0 {
+		return nil, 0, nil
+	}
+
+	bundleIDs := make([]uuid.UUID, 0, len(items))
+	for _, item := range items {
+		bundleIDs = append(bundleIDs, item.BundleID)
+	}
+
+	rows, err := tx.Query(`
+		SELECT `+snackBundleColumns+`
+		FROM snack_bundles
+		WHERE id = ANY($1) AND organisation_id = $2
+	`, pq.Array(bundleIDs), organisationID)
+	if err != nil {
+		return nil, 0, fmt.Errorf("error querying bundles: %v", err)
+	}
+	defer rows.Close()
+
+	var bundles []models.SnackBundle
+	for rows.Next() {
+		bundle, err := scanSnackBundle(rows)
+		if err != nil {
+			return nil, 0, fmt.Errorf("error scanning bundle: %v", err)
+		}
+		bundles = append(bundles, *bundle)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("error iterating bundles: %v", err)
+	}
+
+	if err := loadBundleItems(tx, bundles); err != nil {
+		return nil, 0, err
+	}
+
+	// Load the contained snacks to check and price them
+	var snackIDs []uuid.UUID
+	for _, bundle := range bundles {
+		for _, item := range bundle.Items {
+			snackIDs = append(snackIDs, item.SnackID)
+		}
+	}
+
+	snackRows, err := tx.Query(`
+		SELECT `+snackColumns+`
+		FROM snacks s
+		WHERE s.id = ANY($1)
+	`, pq.Array(snackIDs))
+	if err != nil {
+		return nil, 0, fmt.Errorf("error querying snacks: %v", err)
+	}
+	defer snackRows.Close()
+
+	snacks := make(map[uuid.UUID]models.Snack, len(snackIDs))
+	for snackRows.Next() {
+		snack, err := scanSnack(snackRows)
+		if err != nil {
+			return nil, 0, fmt.Errorf("error scanning snack: %v", err)
+		}
+		snacks[snack.ID] = *snack
+	}
+
+	if err = snackRows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("error iterating snacks: %v", err)
+	}
+
+	catalogue := make(map[uuid.UUID]models.SnackBundle, len(bundles))
+	for _, bundle := range bundles {
+		catalogue[bundle.ID] = bundle
+	}
+
+	if fieldErrors := validateBundleOrder(items, catalogue, snacks, visitorCount, startTime, now); len(fieldErrors) > 0 {
+		return nil, 0, &models.ValidationError{Fields: fieldErrors}
+	}
+
+	var ordered []orderedSnack
+	var adjustment float64
+	for i, item := range items {
+		bundle := catalogue[item.BundleID]
+		lines, remainder := expandBundle(bundle, snacks, bundleUnits(bundle, item, visitorCount))
+		for j := range lines {
+			lines[j].ServeAt = item.ServeAt
+			lines[j].Field = fmt.Sprintf("bundles[%d].quantity", i)
+		}
+		ordered = append(ordered, lines...)
+		adjustment += remainder
+	}
+
+	return ordered, roundCurrency(adjustment), nil
+}
+
+// bundleUnits is how many times a bundle is ordered: its quantity, multiplied
+// by the visitor count for per-visitor bundles.
+func bundleUnits(bundle models.SnackBundle, item models.BundleOrderItem, visitorCount int) int {
+	units := item.Quantity
+	if units == 0 {
+		units = 1
+	}
+	if bundle.PerVisitor {
+		units *= visitorCount
+	}
+	return units
+}
+
+// validateBundleOrder checks every requested bundle against the catalogue and
+// the snacks it contains. Field names point into the request's bundles array.
+func validateBundleOrder(items []models.BundleOrderItem, catalogue map[uuid.UUID]models.SnackBundle, snacks map[uuid.UUID]models.Snack, visitorCount int, startTime, now time.Time) []models.FieldError {
+	var fieldErrors []models.FieldError
+	seen := make(map[orderLineKey]int, len(items))
+
+	for i, item := range items {
+		idField := fmt.Sprintf("bundles[%d].bundle_id", i)
+
+		key := newOrderLineKey(item.BundleID, item.ServeAt)
+		if first, ok := seen[key]; ok {
+			fieldErrors = append(fieldErrors, models.FieldError{
+				Field:   idField,
+				Message: fmt.Sprintf("duplicate of bundles[%d]; combine the quantities instead", first),
+			})
+			continue
+		}
+		seen[key] = i
+
+		bundle, ok := catalogue[item.BundleID]
+		switch {
+		case !ok:
+			fieldErrors = append(fieldErrors, models.FieldError{Field: idField, Message: "bundle not found"})
+			continue
+		case bundle.RetiredAt != nil:
+			fieldErrors = append(fieldErrors, models.FieldError{Field: idField, Message: "bundle is no longer offered"})
+			continue
+		case !bundle.IsAvailable:
+			fieldErrors = append(fieldErrors, models.FieldError{Field: idField, Message: "bundle is currently unavailable"})
+			continue
+		}
+
+		if bundle.PerVisitor && visitorCount == 0 {
+			fieldErrors = append(fieldErrors, models.FieldError{
+				Field:   "visitor_count",
+				Message: fmt.Sprintf("is required for per-visitor bundle %s", bundle.Name),
+			})
+		}
+
+		// A bundle is only as orderable as the snacks it contains
+		cutoffMinutes := 0
+		for _, content := range bundle.Items {
+			snack := snacks[content.SnackID]
+			if snack.RetiredAt != nil || !snack.IsAvailable {
+				fieldErrors = append(fieldErrors, models.FieldError{
+					Field:   idField,
+					Message: fmt.Sprintf("contains %s, which is currently unavailable", content.SnackName),
+				})
+			}
+			if snack.OrderCutoffMinutes > cutoffMinutes {
+				cutoffMinutes = snack.OrderCutoffMinutes
+			}
+		}
+		if cutoff := time.Duration(cutoffMinutes) * time.Minute; cutoff > 0 && now.Add(cutoff).After(startTime) {
+			fieldErrors = append(fieldErrors, models.FieldError{
+				Field:   idField,
+				Message: fmt.Sprintf("must be ordered at least %d minutes before start_time", cutoffMinutes),
+			})
+		}
+	}
+
+	return fieldErrors
+}
+
+// expandBundle turns units of a bundle into one snack line per contained
+// snack. The package price is spread over the lines in proportion to their
+// catalogue value; the unit prices are rounded to cents and the difference
+// to the package price is returned as the remainder.
+func expandBundle(bundle models.SnackBundle, snacks map[uuid.UUID]models.Snack, units int) ([]orderedSnack, float64) {
+	packagePrice := roundCurrency(bundle.Price * float64(units))
+
+	var catalogueValue float64
+	for _, content := range bundle.Items {
+		catalogueValue += snacks[content.SnackID].Price * float64(content.Quantity*units)
+	}
+
+	bundleID := bundle.ID
+	lines := make([]orderedSnack, 0, len(bundle.Items))
+	var linesTotal float64
+	for _, content := range bundle.Items {
+		line := orderedSnack{
+			Snack:    snacks[content.SnackID],
+			Quantity: content.Quantity * units,
+			BundleID: &bundleID,
+		}
+		if catalogueValue > 0 {
+			line.Price = roundCurrency(line.Price * packagePrice / catalogueValue)
+		}
+		lines = append(lines, line)
+		linesTotal += roundCurrency(line.Subtotal())
+	}
+
+	return lines, roundCurrency(packagePrice - linesTotal)
+}
@@ -0,0 +1,136 @@
+package services
+
+import (
+	"e-meetingproject/internal/models"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExpandBundle(t *testing.T) {
+	coffee := models.Snack{ID: uuid.New(), Name: "Coffee", Price: 2.50}
+	cookie := models.Snack{ID: uuid.New(), Name: "Cookie", Price: 1.50}
+	snacks := map[uuid.UUID]models.Snack{coffee.ID: coffee, cookie.ID: cookie}
+	contents := []models.SnackBundleItem{
+		{SnackID: coffee.ID, Quantity: 1},
+		{SnackID: cookie.ID, Quantity: 2},
+	}
+
+	tests := []struct {
+		name              string
+		price             float64
+		units             int
+		expectedPrices    []float64
+		expectedQuantity  []int
+		expectedRemainder float64
+	}{
+		{
+			name:              "Single unit spread by catalogue value",
+			price:             3.00,
+			units:             1,
+			expectedPrices:    []float64{1.36, 0.82},
+			expectedQuantity:  []int{1, 2},
+			expectedRemainder: 0,
+		},
+		{
+			name:              "Scaled to seven visitors",
+			price:             3.00,
+			units:             7,
+			expectedPrices:    []float64{1.36, 0.82},
+			expectedQuantity:  []int{7, 14},
+			expectedRemainder: 0,
+		},
+		{
+			name:              "Rounding remainder returned",
+			price:             3.10,
+			units:             7,
+			expectedPrices:    []float64{1.41, 0.85},
+			expectedQuantity:  []int{7, 14},
+			expectedRemainder: -0.07,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			bundle := models.SnackBundle{ID: uuid.New(), Price: tc.price, PerVisitor: true, Items: contents}
+			lines, remainder := expandBundle(bundle, snacks, tc.units)
+
+			var total float64
+			for i, line := range lines {
+				assert.Equal(t, tc.expectedPrices[i], line.Price)
+				assert.Equal(t, tc.expectedQuantity[i], line.Quantity)
+				assert.Equal(t, bundle.ID, *line.BundleID)
+				total += roundCurrency(line.Subtotal())
+			}
+			assert.InDelta(t, tc.expectedRemainder, remainder, 0.001)
+			assert.InDelta(t, tc.price*float64(tc.units), total+remainder, 0.001)
+		})
+	}
+}
+
+func TestValidateBundleOrder(t *testing.T) {
+	now := time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC)
+	startTime := now.Add(2 * time.Hour)
+
+	coffee := models.Snack{ID: uuid.New(), Name: "Coffee", IsAvailable: true}
+	pastry := models.Snack{ID: uuid.New(), Name: "Pastry", IsAvailable: true,
+		SnackOrderRules: models.SnackOrderRules{OrderCutoffMinutes: 180}}
+	paused := models.Snack{ID: uuid.New(), Name: "Fruit Bowl"}
+	snacks := map[uuid.UUID]models.Snack{coffee.ID: coffee, pastry.ID: pastry, paused.ID: paused}
+
+	coffeeBreak := models.SnackBundle{ID: uuid.New(), Name: "Coffee Break", PerVisitor: true, IsAvailable: true,
+		Items: []models.SnackBundleItem{{SnackID: coffee.ID, SnackName: "Coffee", Quantity: 1}}}
+	breakfast := models.SnackBundle{ID: uuid.New(), Name: "Breakfast", IsAvailable: true,
+		Items: []models.SnackBundleItem{{SnackID: pastry.ID, SnackName: "Pastry", Quantity: 10}}}
+	healthy := models.SnackBundle{ID: uuid.New(), Name: "Healthy", IsAvailable: true,
+		Items: []models.SnackBundleItem{{SnackID: paused.ID, SnackName: "Fruit Bowl", Quantity: 5}}}
+	hidden := models.SnackBundle{ID: uuid.New(), Name: "Hidden"}
+	catalogue := map[uuid.UUID]models.SnackBundle{
+		coffeeBreak.ID: coffeeBreak, breakfast.ID: breakfast, healthy.ID: healthy, hidden.ID: hidden,
+	}
+
+	tests := []struct {
+		name         string
+		items        []models.BundleOrderItem
+		visitorCount int
+		expected     []models.FieldError
+	}{
+		{
+			name:         "Valid order",
+			items:        []models.BundleOrderItem{{BundleID: coffeeBreak.ID}},
+			visitorCount: 4,
+		},
+		{
+			name:  "Unknown, duplicate and unavailable bundles",
+			items: []models.BundleOrderItem{{BundleID: uuid.New()}, {BundleID: hidden.ID}, {BundleID: hidden.ID}},
+			expected: []models.FieldError{
+				{Field: "bundles[0].bundle_id", Message: "bundle not found"},
+				{Field: "bundles[1].bundle_id", Message: "bundle is currently unavailable"},
+				{Field: "bundles[2].bundle_id", Message: "duplicate of bundles[1]; combine the quantities instead"},
+			},
+		},
+		{
+			name:  "Per-visitor bundle without visitor count",
+			items: []models.BundleOrderItem{{BundleID: coffeeBreak.ID}},
+			expected: []models.FieldError{
+				{Field: "visitor_count", Message: "is required for per-visitor bundle Coffee Break"},
+			},
+		},
+		{
+			name:  "Unavailable contents and snack cutoff",
+			items: []models.BundleOrderItem{{BundleID: healthy.ID}, {BundleID: breakfast.ID}},
+			expected: []models.FieldError{
+				{Field: "bundles[0].bundle_id", Message: "contains Fruit Bowl, which is currently unavailable"},
+				{Field: "bundles[1].bundle_id", Message: "must be ordered at least 180 minutes before start_time"},
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, validateBundleOrder(tc.items, catalogue, snacks, tc.visitorCount, startTime, now))
+		})
+	}
+}
@@ -5,9 +5,12 @@ import (
 	"e-meetingproject/internal/database"
 	"e-meetingproject/internal/models"
 	"fmt"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
 type SnackService struct {
@@ -20,7 +23,16 @@ func NewSnackService() *SnackService {
 	}
 }
 
-func (s *SnackService) GetSnacks() (*models.SnackListResponse, error) {
+func (s *SnackService) GetSnacks(filter *models.SnackFilter, pagination *models.PaginationQuery, tenant Tenant) (*models.SnackListResponse, error) {
+	// Stock is reported for the requested day only
+	var stockDate *string
+	if filter != nil && filter.Date != "" {
+		if _, err := time.Parse("2006-01-02", filter.Date); err != nil {
+			return nil, fmt.Errorf("invalid date format (required: YYYY-MM-DD): %v", err)
+		}
+		stockDate = &filter.Date
+	}
+
 	// Start transaction
 	tx, err := s.db.Begin()
 	if err != nil {
@@ -28,12 +40,49 @@ func (s *SnackService) GetSnacks() (*models.SnackListResponse, error) {
 	}
 	defer tx.Rollback()
 
-	// Query all snacks
-	rows, err := tx.Query(`
-		SELECT id, name, category, price, created_at, updated_at
-		FROM snacks
-		ORDER BY category, name
-	`)
+	conditions, args := snackFilterConditions(filter, tenant)
+	argCount := len(args) + 1
+
+	// Calculate offset
+	offset := (pagination.Page - 1) * pagination.PageSize
+
+	// Get total count
+	var totalCount int
+	countQuery := fmt.Sprintf(`
+		SELECT COUNT(*)
+		FROM snacks s
+		WHERE %s`,
+		strings.Join(conditions, " AND "),
+	)
+
+	err = tx.QueryRow(countQuery, args...).Scan(&totalCount)
+	if err != nil {
+		return nil, fmt.Errorf("error getting total count: %v", err)
+	}
+
+	// Calculate total pages
+	totalPages := (totalCount + pagination.PageSize - 1) / pagination.PageSize
+
+	// Query snacks with pagination
+	query := fmt.Sprintf(`
+		SELECT %s, %s
+		FROM snacks s
+		LEFT JOIN snack_stock ss ON ss.snack_id = s.id AND ss.stock_date = $%d
+		WHERE %s
+		ORDER BY s.category, s.name
+		LIMIT $%d OFFSET $%d`,
+		snackColumns,
+		remainingStockColumn,
+		argCount,
+		strings.Join(conditions, " AND "),
+		argCount+1,
+		argCount+2,
+	)
+
+	// Add stock date and pagination parameters
+	args = append(args, stockDate, pagination.PageSize, offset)
+
+	rows, err := tx.Query(query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("error querying snacks: %v", err)
 	}
@@ -41,19 +90,16 @@ func (s *SnackService) GetSnacks() (*models.SnackListResponse, error) {
 
 	var snacks []models.Snack
 	for rows.Next() {
-		var snack models.Snack
-		err := rows.Scan(
-			&snack.ID,
-			&snack.Name,
-			&snack.Category,
-			&snack.Price,
-			&snack.CreatedAt,
-			&snack.UpdatedAt,
-		)
+		var remaining sql.NullInt64
+		snack, err := scanSnack(rows, &remaining)
 		if err != nil {
 			return nil, fmt.Errorf("error scanning snack: %v", err)
 		}
-		snacks = append(snacks, snack)
+		if filter != nil && filter.Date != "" && remaining.Valid {
+			remainingStock := int(remaining.Int64)
+			snack.RemainingStock = &remainingStock
+		}
+		snacks = append(snacks, *snack)
 	}
 
 	if err = rows.Err(); err != nil {
@@ -66,11 +112,15 @@ func (s *SnackService) GetSnacks() (*models.SnackListResponse, error) {
 	}
 
 	return &models.SnackListResponse{
-		Snacks: snacks,
+		Snacks:     snacks,
+		TotalCount: totalCount,
+		Page:       pagination.Page,
+		PageSize:   pagination.PageSize,
+		TotalPages: totalPages,
 	}, nil
 }
 
-func (s *SnackService) CreateSnack(req *models.CreateSnackRequest) (*models.CreateSnackResponse, error) {
+func (s *SnackService) CreateSnack(req *models.CreateSnackRequest, tenant Tenant) (*models.CreateSnackResponse, error) {
 	// Start transaction
 	tx, err := s.db.Begin()
 	if err != nil {
@@ -78,15 +128,41 @@ func (s *SnackService) CreateSnack(req *models.CreateSnackRequest) (*models.Crea
 	}
 	defer tx.Rollback()
 
+	organisationID, err := tenant.organisationFor(tx, req.OrganisationID)
+	if err != nil {
+		return nil, err
+	}
+
 	// Generate new UUID for the snack
 	snackID := uuid.New()
 	createdAt := time.Now()
 
+	rules := models.SnackOrderRules{
+		MinQuantity:        req.MinQuantity,
+		MaxPerVisitor:      req.MaxPerVisitor,
+		OrderCutoffMinutes: req.OrderCutoffMinutes,
+	}
+	if rules.MinQuantity == 0 {
+		rules.MinQuantity = 1
+	}
+
+	stock := models.SnackStockSettings{
+		DailyStock:        req.DailyStock,
+		LowStockThreshold: req.LowStockThreshold,
+	}
+
+	dietaryTags := normalizeTags(req.DietaryTags)
+	allergens := normalizeTags(req.Allergens)
+
 	// Insert new snack
 	_, err = tx.Exec(`
-		INSERT INTO snacks (id, name, category, price, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $5)
-	`, snackID, req.Name, req.Category, req.Price, createdAt)
+		INSERT INTO snacks (
+			id, name, category, price, min_quantity, max_per_visitor, order_cutoff_minutes,
+			daily_stock, low_stock_threshold, dietary_tags, allergens, created_at, updated_at, organisation_id
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $12, $13)
+	`, snackID, req.Name, req.Category, req.Price,
+		rules.MinQuantity, rules.MaxPerVisitor, rules.OrderCutoffMinutes,
+		stock.DailyStock, stock.LowStockThreshold, pq.Array(dietaryTags), pq.Array(allergens), createdAt, organisationID)
 
 	if err != nil {
 		return nil, fmt.Errorf("error creating snack: %v", err)
@@ -98,10 +174,499 @@ func (s *SnackService) CreateSnack(req *models.CreateSnackRequest) (*models.Crea
 	}
 
 	return &models.CreateSnackResponse{
-		ID:        snackID,
-		Name:      req.Name,
-		Category:  req.Category,
-		Price:     req.Price,
-		CreatedAt: createdAt,
+		ID:                 snackID,
+		OrganisationID:     organisationID,
+		Name:               req.Name,
+		Category:           req.Category,
+		Price:              req.Price,
+		DietaryTags:        dietaryTags,
+		Allergens:          allergens,
+		SnackOrderRules:    rules,
+		SnackStockSettings: stock,
+		CreatedAt:          createdAt,
 	}, nil
 }
+
+func (s *SnackService) UpdateSnack(id uuid.UUID, req *models.UpdateSnackRequest, tenant Tenant) (*models.Snack, error) {
+	// Start transaction
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("error starting transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	// First, check if snack exists and is not retired
+	snack, err := scanSnack(tx.QueryRow(`
+		SELECT `+snackColumns+`
+		FROM snacks s
+		WHERE s.id = $1 AND s.retired_at IS NULL AND `+tenantCondition("s.organisation_id", 2)+`
+		FOR UPDATE`,
+		id, tenant.arg(),
+	))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("snack not found")
+		}
+		return nil, fmt.Errorf("error fetching snack: %v", err)
+	}
+
+	// Booked orders keep the price they were made at
+	applySnackUpdate(snack, req)
+	snack.UpdatedAt = time.Now()
+
+	// Update snack
+	_, err = tx.Exec(`
+		UPDATE snacks
+		SET name = $1, category = $2, price = $3, is_available = $4,
+			min_quantity = $5, max_per_visitor = $6, order_cutoff_minutes = $7,
+			daily_stock = $8, low_stock_threshold = $9, dietary_tags = $10, allergens = $11,
+			updated_at = $12
+		WHERE id = $13`,
+		snack.Name, snack.Category, snack.Price, snack.IsAvailable,
+		snack.MinQuantity, snack.MaxPerVisitor, snack.OrderCutoffMinutes,
+		snack.DailyStock, snack.LowStockThreshold, pq.Array(snack.DietaryTags), pq.Array(snack.Allergens),
+		snack.UpdatedAt, snack.ID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error updating snack: %v", err)
+	}
+
+	// Commit transaction
+	if err = tx.Commit(); err != nil {
+		return nil, fmt.Errorf("error committing transaction: %v", err)
+	}
+
+	return snack, nil
+}
+
+// RetireSnack removes a snack from the catalogue. The row is kept because
+// past reservations still reference it.
+func (s *SnackService) RetireSnack(id uuid.UUID, tenant Tenant) error {
+	result, err := s.db.Exec(`
+		UPDATE snacks
+		SET is_available = false, retired_at = NOW(), updated_at = NOW()
+		WHERE id = $1 AND retired_at IS NULL AND `+tenantCondition("organisation_id", 2),
+		id, tenant.arg(),
+	)
+	if err != nil {
+		return fmt.Errorf("error retiring snack: %v", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("error getting rows affected: %v", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("snack not found")
+	}
+
+	return nil
+}
+
+// snackFilterConditions builds the WHERE conditions of GET /snacks, with their
+// arguments numbered from $1.
+func snackFilterConditions(filter *models.SnackFilter, tenant Tenant) ([]string, []interface{}) {
+	conditions := []string{
+		"retired_at IS NULL", // Retired snacks are hidden from the catalogue
+		tenantCondition("s.organisation_id", 1),
+	}
+	args := []interface{}{tenant.arg()}
+	if filter == nil {
+		return conditions, args
+	}
+
+	if filter.Category != nil && *filter.Category != "" {
+		args = append(args, *filter.Category)
+		conditions = append(conditions, fmt.Sprintf("category = $%d", len(args)))
+	}
+
+	if filter.MinPrice != nil {
+		args = append(args, *filter.MinPrice)
+		conditions = append(conditions, fmt.Sprintf("price >= $%d", len(args)))
+	}
+
+	if filter.MaxPrice != nil {
+		args = append(args, *filter.MaxPrice)
+		conditions = append(conditions, fmt.Sprintf("price <= $%d", len(args)))
+	}
+
+	if filter.Available != nil {
+		args = append(args, *filter.Available)
+		conditions = append(conditions, fmt.Sprintf("is_available = $%d", len(args)))
+	}
+
+	if len(filter.Dietary) > 0 {
+		args = append(args, pq.Array(filter.Dietary))
+		conditions = append(conditions, fmt.Sprintf("dietary_tags @> $%d", len(args)))
+	}
+
+	if len(filter.ExcludeAllergens) > 0 {
+		args = append(args, pq.Array(filter.ExcludeAllergens))
+		conditions = append(conditions, fmt.Sprintf("NOT allergens && $%d", len(args)))
+	}
+
+	return conditions, args
+}
+
+// applySnackUpdate sets the fields given in req on snack.
+func applySnackUpdate(snack *models.Snack, req *models.UpdateSnackRequest) {
+	if req.Name != nil {
+		snack.Name = *req.Name
+	}
+	if req.Category != nil {
+		snack.Category = *req.Category
+	}
+	if req.Price != nil {
+		snack.Price = *req.Price
+	}
+	if req.IsAvailable != nil {
+		snack.IsAvailable = *req.IsAvailable
+	}
+	if req.MinQuantity != nil {
+		snack.MinQuantity = *req.MinQuantity
+	}
+	if req.MaxPerVisitor != nil {
+		snack.MaxPerVisitor = req.MaxPerVisitor
+		if *req.MaxPerVisitor == 0 {
+			snack.MaxPerVisitor = nil
+		}
+	}
+	if req.OrderCutoffMinutes != nil {
+		snack.OrderCutoffMinutes = *req.OrderCutoffMinutes
+	}
+	if req.DailyStock != nil {
+		snack.DailyStock = req.DailyStock
+		if *req.DailyStock < 0 {
+			snack.DailyStock = nil
+		}
+	}
+	if req.LowStockThreshold != nil {
+		snack.LowStockThreshold = *req.LowStockThreshold
+	}
+	if req.DietaryTags != nil {
+		snack.DietaryTags = normalizeTags(req.DietaryTags)
+	}
+	if req.Allergens != nil {
+		snack.Allergens = normalizeTags(req.Allergens)
+	}
+}
+
+// snackColumns lists the columns read by scanSnack, for queries aliasing snacks as s.
+const snackColumns = `s.id, s.organisation_id, s.name, s.category, s.price, s.is_available, s.retired_at,
+	s.min_quantity, s.max_per_visitor, s.order_cutoff_minutes,
+	s.daily_stock, s.low_stock_threshold, s.dietary_tags, s.allergens,
+	s.created_at, s.updated_at`
+
+// remainingStockColumn is the stock left on the day joined as ss, NULL when the
+// snack is not stock-limited that day.
+const remainingStockColumn = `CASE
+	WHEN ss.snack_id IS NOT NULL THEN ss.quantity - ss.reserved
+	ELSE s.daily_stock
+END`
+
+// scanSnack scans snackColumns followed by any extra columns of the query.
+func scanSnack(row rowScanner, extra ...interface{}) (*models.Snack, error) {
+	var snack models.Snack
+	var retiredAt sql.NullTime
+	var maxPerVisitor, dailyStock sql.NullInt64
+	dest := []interface{}{
+		&snack.ID,
+		&snack.OrganisationID,
+		&snack.Name,
+		&snack.Category,
+		&snack.Price,
+		&snack.IsAvailable,
+		&retiredAt,
+		&snack.MinQuantity,
+		&maxPerVisitor,
+		&snack.OrderCutoffMinutes,
+		&dailyStock,
+		&snack.LowStockThreshold,
+		pq.Array(&snack.DietaryTags),
+		pq.Array(&snack.Allergens),
+		&snack.CreatedAt,
+		&snack.UpdatedAt,
+	}
+	if err := row.Scan(append(dest, extra...)...); err != nil {
+		return nil, err
+	}
+	if retiredAt.Valid {
+		snack.RetiredAt = &retiredAt.Time
+	}
+	if maxPerVisitor.Valid {
+		limit := int(maxPerVisitor.Int64)
+		snack.MaxPerVisitor = &limit
+	}
+	if dailyStock.Valid {
+		stock := int(dailyStock.Int64)
+		snack.DailyStock = &stock
+	}
+	snack.DietaryTags = normalizeTags(snack.DietaryTags)
+	snack.Allergens = normalizeTags(snack.Allergens)
+
+	return &snack, nil
+}
+
+// orderedSnack is a requested snack line resolved against the catalogue.
+type orderedSnack struct {
+	models.Snack
+	Quantity  int
+	StockDate *string    // Day the quantity was taken from stock, nil when not stock-limited
+	BundleID  *uuid.UUID // Bundle the line was expanded from
+	ServeAt   *time.Time // Requested delivery time, nil for the start of the booking
+	Field     string     // Request field reported when the line cannot be served
+}
+
+// serveTime is when the line is served in a booking starting at startTime.
+func (o orderedSnack) serveTime(startTime time.Time) time.Time {
+	if o.ServeAt != nil {
+		return *o.ServeAt
+	}
+	return startTime
+}
+
+// Subtotal is the line price at the current catalogue price.
+func (o orderedSnack) Subtotal() float64 {
+	return o.Price * float64(o.Quantity)
+}
+
+// loadSnackOrder resolves the requested snack lines against the catalogue of
+// organisationID within tx and rejects the order with a
+// *models.ValidationError when any line breaks the snack rules.
+// visitorCount may be zero when it is not known yet, which skips the
+// per-visitor limits.
+func loadSnackOrder(tx *sql.Tx, organisationID uuid.UUID, items []models.SnackOrderItem, visitorCount int, startTime, now time.Time) ([]orderedSnack, error) {
+	if len(items) == 0 {
+		return nil, nil
+	}
+
+	snackIDs := make([]uuid.UUID, 0, len(items))
+	for _, item := range items {
+		snackIDs = append(snackIDs, item.SnackID)
+	}
+
+	rows, err := tx.Query(`
+		SELECT `+snackColumns+`
+		FROM snacks s
+		WHERE s.id = ANY($1) AND s.organisation_id = $2
+	`, pq.Array(snackIDs), organisationID)
+	if err != nil {
+		return nil, fmt.Errorf("error querying snacks: %v", err)
+	}
+	defer rows.Close()
+
+	catalogue := make(map[uuid.UUID]models.Snack, len(items))
+	for rows.Next() {
+		snack, err := scanSnack(rows)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning snack: %v", err)
+		}
+		catalogue[snack.ID] = *snack
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating snacks: %v", err)
+	}
+
+	if fieldErrors := validateSnackOrder(items, catalogue, visitorCount, startTime, now); len(fieldErrors) > 0 {
+		return nil, &models.ValidationError{Fields: fieldErrors}
+	}
+
+	ordered := make([]orderedSnack, 0, len(items))
+	for i, item := range items {
+		ordered = append(ordered, orderedSnack{
+			Snack:    catalogue[item.SnackID],
+			Quantity: item.Quantity,
+			ServeAt:  item.ServeAt,
+			Field:    fmt.Sprintf("snacks[%d].quantity", i),
+		})
+	}
+
+	return ordered, nil
+}
+
+// validateSnackOrder checks every requested line against the catalogue and
+// the snack's ordering rules. Field names point into the request's snacks array.
+func validateSnackOrder(items []models.SnackOrderItem, catalogue map[uuid.UUID]models.Snack, visitorCount int, startTime, now time.Time) []models.FieldError {
+	var fieldErrors []models.FieldError
+	seen := make(map[orderLineKey]int, len(items))
+
+	for i, item := range items {
+		idField := fmt.Sprintf("snacks[%d].snack_id", i)
+		quantityField := fmt.Sprintf("snacks[%d].quantity", i)
+
+		key := newOrderLineKey(item.SnackID, item.ServeAt)
+		if first, ok := seen[key]; ok {
+			fieldErrors = append(fieldErrors, models.FieldError{
+				Field:   idField,
+				Message: fmt.Sprintf("duplicate of snacks[%d]; combine the quantities instead", first),
+			})
+			continue
+		}
+		seen[key] = i
+
+		snack, ok := catalogue[item.SnackID]
+		switch {
+		case !ok:
+			fieldErrors = append(fieldErrors, models.FieldError{Field: idField, Message: "snack not found"})
+			continue
+		case snack.RetiredAt != nil:
+			fieldErrors = append(fieldErrors, models.FieldError{Field: idField, Message: "snack is no longer offered"})
+			continue
+		case !snack.IsAvailable:
+			fieldErrors = append(fieldErrors, models.FieldError{Field: idField, Message: "snack is currently unavailable"})
+			continue
+		}
+
+		if item.Quantity < snack.MinQuantity {
+			fieldErrors = append(fieldErrors, models.FieldError{
+				Field:   quantityField,
+				Message: fmt.Sprintf("must be at least %d", snack.MinQuantity),
+			})
+		}
+		if snack.MaxPerVisitor != nil && visitorCount > 0 && item.Quantity > *snack.MaxPerVisitor*visitorCount {
+			fieldErrors = append(fieldErrors, models.FieldError{
+				Field:   quantityField,
+				Message: fmt.Sprintf("must not exceed %d per visitor (%d for %d visitors)", *snack.MaxPerVisitor, *snack.MaxPerVisitor*visitorCount, visitorCount),
+			})
+		}
+		if cutoff := time.Duration(snack.OrderCutoffMinutes) * time.Minute; cutoff > 0 && now.Add(cutoff).After(startTime) {
+			fieldErrors = append(fieldErrors, models.FieldError{
+				Field:   idField,
+				Message: fmt.Sprintf("must be ordered at least %d minutes before start_time", snack.OrderCutoffMinutes),
+			})
+		}
+	}
+
+	return fieldErrors
+}
+
+// orderLineKey identifies a requested line. The same snack or bundle may be
+// ordered once per serve time.
+type orderLineKey struct {
+	ID      uuid.UUID
+	ServeAt int64
+}
+
+func newOrderLineKey(id uuid.UUID, serveAt *time.Time) orderLineKey {
+	key := orderLineKey{ID: id}
+	if serveAt != nil {
+		key.ServeAt = serveAt.UnixNano()
+	}
+	return key
+}
+
+// validateServeTimes checks that every requested serve time falls inside the
+// booking, from start_time up to but excluding end_time.
+func validateServeTimes(snacks []models.SnackOrderItem, bundles []models.BundleOrderItem, startTime, endTime time.Time) []models.FieldError {
+	var fieldErrors []models.FieldError
+	outside := func(serveAt *time.Time) bool {
+		return serveAt != nil && (serveAt.Before(startTime) || !serveAt.Before(endTime))
+	}
+
+	for i, item := range snacks {
+		if outside(item.ServeAt) {
+			fieldErrors = append(fieldErrors, models.FieldError{
+				Field:   fmt.Sprintf("snacks[%d].serve_at", i),
+				Message: "must be between start_time and end_time",
+			})
+		}
+	}
+	for i, item := range bundles {
+		if outside(item.ServeAt) {
+			fieldErrors = append(fieldErrors, models.FieldError{
+				Field:   fmt.Sprintf("bundles[%d].serve_at", i),
+				Message: "must be between start_time and end_time",
+			})
+		}
+	}
+
+	return fieldErrors
+}
+
+// dietaryItem is a snack line as seen by summarizeDietary.
+type dietaryItem struct {
+	SnackID     uuid.UUID
+	SnackName   string
+	DietaryTags []string
+	Allergens   []string
+}
+
+func dietaryItemsOf(snacks []orderedSnack) []dietaryItem {
+	items := make([]dietaryItem, 0, len(snacks))
+	for _, snack := range snacks {
+		items = append(items, dietaryItem{
+			SnackID:     snack.ID,
+			SnackName:   snack.Name,
+			DietaryTags: snack.DietaryTags,
+			Allergens:   snack.Allergens,
+		})
+	}
+	return items
+}
+
+// normalizeTags sorts tags and drops duplicates. The result is never nil so
+// it is stored and returned as an empty list.
+func normalizeTags(tags []string) []string {
+	seen := make(map[string]bool, len(tags))
+	normalized := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		if !seen[tag] {
+			seen[tag] = true
+			normalized = append(normalized, tag)
+		}
+	}
+	sort.Strings(normalized)
+	return normalized
+}
+
+// summarizeDietary describes a snack order: the dietary tags every line
+// carries, the allergens any line contains, and a warning for each line
+// containing one of the excluded allergens. An empty order carries no tags.
+func summarizeDietary(items []dietaryItem, excluded []string) models.DietarySummary {
+	summary := models.DietarySummary{
+		DietaryTags:       []string{},
+		Allergens:         []string{},
+		ExcludedAllergens: normalizeTags(excluded),
+		Warnings:          []models.DietaryWarning{},
+	}
+
+	excludedSet := make(map[string]bool, len(excluded))
+	for _, allergen := range excluded {
+		excludedSet[allergen] = true
+	}
+
+	tagCount := make(map[string]int)
+	var allergens []string
+	for _, item := range items {
+		for _, tag := range normalizeTags(item.DietaryTags) {
+			tagCount[tag]++
+		}
+
+		var flagged []string
+		for _, allergen := range item.Allergens {
+			allergens = append(allergens, allergen)
+			if excludedSet[allergen] {
+				flagged = append(flagged, allergen)
+			}
+		}
+		if len(flagged) > 0 {
+			summary.Warnings = append(summary.Warnings, models.DietaryWarning{
+				SnackID:   item.SnackID,
+				SnackName: item.SnackName,
+				Allergens: normalizeTags(flagged),
+			})
+		}
+	}
+
+	for tag, count := range tagCount {
+		if count == len(items) {
+			summary.DietaryTags = append(summary.DietaryTags, tag)
+		}
+	}
+	sort.Strings(summary.DietaryTags)
+	summary.Allergens = normalizeTags(allergens)
+
+	return summary
+}
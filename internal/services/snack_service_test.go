@@ -0,0 +1,272 @@
+package services
+
+import (
+	"e-meetingproject/internal/models"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateSnackOrder(t *testing.T) {
+	now := time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC)
+	startTime := now.Add(2 * time.Hour)
+	lunchTime := startTime.Add(3 * time.Hour)
+	retiredAt := now.Add(-24 * time.Hour)
+	two := 2
+
+	coffee := models.Snack{ID: uuid.New(), Name: "Coffee", IsAvailable: true, SnackOrderRules: models.SnackOrderRules{MinQuantity: 1}}
+	platter := models.Snack{ID: uuid.New(), Name: "Platter", IsAvailable: true, SnackOrderRules: models.SnackOrderRules{MinQuantity: 5, OrderCutoffMinutes: 180}}
+	cookie := models.Snack{ID: uuid.New(), Name: "Cookie", IsAvailable: true, SnackOrderRules: models.SnackOrderRules{MinQuantity: 1, MaxPerVisitor: &two}}
+	paused := models.Snack{ID: uuid.New(), Name: "Juice", IsAvailable: false, SnackOrderRules: models.SnackOrderRules{MinQuantity: 1}}
+	retired := models.Snack{ID: uuid.New(), Name: "Donut", RetiredAt: &retiredAt, SnackOrderRules: models.SnackOrderRules{MinQuantity: 1}}
+
+	catalogue := map[uuid.UUID]models.Snack{}
+	for _, snack := range []models.Snack{coffee, platter, cookie, paused, retired} {
+		catalogue[snack.ID] = snack
+	}
+
+	tests := []struct {
+		name         string
+		items        []models.SnackOrderItem
+		visitorCount int
+		expected     []models.FieldError
+	}{
+		{
+			name:         "Valid order",
+			items:        []models.SnackOrderItem{{SnackID: coffee.ID, Quantity: 3}, {SnackID: cookie.ID, Quantity: 6}},
+			visitorCount: 3,
+		},
+		{
+			name:     "Unknown snack",
+			items:    []models.SnackOrderItem{{SnackID: uuid.New(), Quantity: 1}},
+			expected: []models.FieldError{{Field: "snacks[0].snack_id", Message: "snack not found"}},
+		},
+		{
+			name:  "Duplicate snack",
+			items: []models.SnackOrderItem{{SnackID: coffee.ID, Quantity: 1}, {SnackID: coffee.ID, Quantity: 2}},
+			expected: []models.FieldError{
+				{Field: "snacks[1].snack_id", Message: "duplicate of snacks[0]; combine the quantities instead"},
+			},
+		},
+		{
+			name: "Same snack at different serve times",
+			items: []models.SnackOrderItem{
+				{SnackID: coffee.ID, Quantity: 1},
+				{SnackID: coffee.ID, Quantity: 2, ServeAt: &lunchTime},
+			},
+		},
+		{
+			name:  "Retired and unavailable snacks",
+			items: []models.SnackOrderItem{{SnackID: retired.ID, Quantity: 1}, {SnackID: paused.ID, Quantity: 1}},
+			expected: []models.FieldError{
+				{Field: "snacks[0].snack_id", Message: "snack is no longer offered"},
+				{Field: "snacks[1].snack_id", Message: "snack is currently unavailable"},
+			},
+		},
+		{
+			name:  "Below minimum quantity and past cutoff",
+			items: []models.SnackOrderItem{{SnackID: platter.ID, Quantity: 4}},
+			expected: []models.FieldError{
+				{Field: "snacks[0].quantity", Message: "must be at least 5"},
+				{Field: "snacks[0].snack_id", Message: "must be ordered at least 180 minutes before start_time"},
+			},
+		},
+		{
+			name:         "Above per-visitor limit",
+			items:        []models.SnackOrderItem{{SnackID: cookie.ID, Quantity: 7}},
+			visitorCount: 3,
+			expected: []models.FieldError{
+				{Field: "snacks[0].quantity", Message: "must not exceed 2 per visitor (6 for 3 visitors)"},
+			},
+		},
+		{
+			name:  "Per-visitor limit skipped without visitor count",
+			items: []models.SnackOrderItem{{SnackID: cookie.ID, Quantity: 7}},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, validateSnackOrder(tc.items, catalogue, tc.visitorCount, startTime, now))
+		})
+	}
+}
+
+func TestSummarizeDietary(t *testing.T) {
+	falafel := dietaryItem{
+		SnackID:     uuid.New(),
+		SnackName:   "Falafel Wrap",
+		DietaryTags: []string{"vegan", "halal", "vegetarian"},
+		Allergens:   []string{"sesame", "gluten"},
+	}
+	brownie := dietaryItem{
+		SnackID:     uuid.New(),
+		SnackName:   "Brownie",
+		DietaryTags: []string{"vegetarian", "halal"},
+		Allergens:   []string{"eggs", "milk", "tree_nuts", "gluten"},
+	}
+
+	tests := []struct {
+		name     string
+		items    []dietaryItem
+		excluded []string
+		expected models.DietarySummary
+	}{
+		{
+			name:  "Empty order",
+			items: nil,
+			expected: models.DietarySummary{
+				DietaryTags:       []string{},
+				Allergens:         []string{},
+				ExcludedAllergens: []string{},
+				Warnings:          []models.DietaryWarning{},
+			},
+		},
+		{
+			name:  "Tags shared by every line and all allergens",
+			items: []dietaryItem{falafel, brownie},
+			expected: models.DietarySummary{
+				DietaryTags:       []string{"halal", "vegetarian"},
+				Allergens:         []string{"eggs", "gluten", "milk", "sesame", "tree_nuts"},
+				ExcludedAllergens: []string{},
+				Warnings:          []models.DietaryWarning{},
+			},
+		},
+		{
+			name:     "Warns about excluded allergens per line",
+			items:    []dietaryItem{falafel, brownie},
+			excluded: []string{"tree_nuts", "gluten", "peanuts"},
+			expected: models.DietarySummary{
+				DietaryTags:       []string{"halal", "vegetarian"},
+				Allergens:         []string{"eggs", "gluten", "milk", "sesame", "tree_nuts"},
+				ExcludedAllergens: []string{"gluten", "peanuts", "tree_nuts"},
+				Warnings: []models.DietaryWarning{
+					{SnackID: falafel.SnackID, SnackName: "Falafel Wrap", Allergens: []string{"gluten"}},
+					{SnackID: brownie.SnackID, SnackName: "Brownie", Allergens: []string{"gluten", "tree_nuts"}},
+				},
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, summarizeDietary(tc.items, tc.excluded))
+		})
+	}
+}
+
+func TestValidateServeTimes(t *testing.T) {
+	startTime := time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC)
+	endTime := startTime.Add(8 * time.Hour)
+	at := func(offset time.Duration) *time.Time {
+		serveAt := startTime.Add(offset)
+		return &serveAt
+	}
+
+	tests := []struct {
+		name     string
+		snacks   []models.SnackOrderItem
+		bundles  []models.BundleOrderItem
+		expected []models.FieldError
+	}{
+		{
+			name:    "Default and in-window serve times",
+			snacks:  []models.SnackOrderItem{{SnackID: uuid.New()}, {SnackID: uuid.New(), ServeAt: at(0)}},
+			bundles: []models.BundleOrderItem{{BundleID: uuid.New(), ServeAt: at(3 * time.Hour)}},
+		},
+		{
+			name:    "Before start and at end time",
+			snacks:  []models.SnackOrderItem{{SnackID: uuid.New(), ServeAt: at(-time.Minute)}},
+			bundles: []models.BundleOrderItem{{BundleID: uuid.New()}, {BundleID: uuid.New(), ServeAt: at(8 * time.Hour)}},
+			expected: []models.FieldError{
+				{Field: "snacks[0].serve_at", Message: "must be between start_time and end_time"},
+				{Field: "bundles[1].serve_at", Message: "must be between start_time and end_time"},
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, validateServeTimes(tc.snacks, tc.bundles, startTime, endTime))
+		})
+	}
+}
+
+func TestApplySnackUpdate(t *testing.T) {
+	limit, noLimit := 4, 0
+	price, unavailable := 3.0, false
+
+	tests := []struct {
+		name     string
+		snack    models.Snack
+		req      models.UpdateSnackRequest
+		expected models.Snack
+	}{
+		{
+			name:     "Only provided fields change",
+			snack:    models.Snack{Name: "Coffee", Category: "drinks", Price: 2.5, IsAvailable: true},
+			req:      models.UpdateSnackRequest{Price: &price, IsAvailable: &unavailable},
+			expected: models.Snack{Name: "Coffee", Category: "drinks", Price: 3},
+		},
+		{
+			name:     "Zero removes the per-visitor limit",
+			snack:    models.Snack{Name: "Cookie", SnackOrderRules: models.SnackOrderRules{MaxPerVisitor: &limit}},
+			req:      models.UpdateSnackRequest{MaxPerVisitor: &noLimit},
+			expected: models.Snack{Name: "Cookie"},
+		},
+		{
+			name:     "Dietary tags are deduplicated and sorted",
+			snack:    models.Snack{Name: "Salad", DietaryTags: []string{"vegan"}},
+			req:      models.UpdateSnackRequest{DietaryTags: []string{"vegan", "gluten_free", "vegan"}},
+			expected: models.Snack{Name: "Salad", DietaryTags: []string{"gluten_free", "vegan"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			snack := tt.snack
+			applySnackUpdate(&snack, &tt.req)
+			assert.Equal(t, tt.expected, snack)
+		})
+	}
+}
+
+func TestSnackFilterConditions(t *testing.T) {
+	organisationID := uuid.New()
+	tenant := Tenant{OrganisationID: organisationID}
+	category := "drinks"
+	minPrice := 1.5
+	available := true
+
+	conditions, args := snackFilterConditions(nil, tenant)
+	assert.Equal(t, []string{"retired_at IS NULL", tenantCondition("s.organisation_id", 1)}, conditions)
+	assert.Equal(t, []interface{}{tenant.arg()}, args)
+
+	conditions, args = snackFilterConditions(&models.SnackFilter{
+		Category:         &category,
+		MinPrice:         &minPrice,
+		Available:        &available,
+		ExcludeAllergens: []string{"milk"},
+	}, tenant)
+	assert.Equal(t, []string{
+		"retired_at IS NULL",
+		tenantCondition("s.organisation_id", 1),
+		"category = $2",
+		"price >= $3",
+		"is_available = $4",
+		"NOT allergens && $5",
+	}, conditions)
+	assert.Equal(t, []interface{}{tenant.arg(), "drinks", 1.5, true, pq.Array([]string{"milk"})}, args)
+}
+
+func TestGetSnacksInvalidDate(t *testing.T) {
+	// The date is validated before the database is used
+	service := &SnackService{}
+
+	_, err := service.GetSnacks(&models.SnackFilter{Date: "02/03/2026"}, &models.PaginationQuery{Page: 1, PageSize: 10}, Tenant{})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid date format (required: YYYY-MM-DD)")
+}
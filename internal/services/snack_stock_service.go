@@ -0,0 +1,268 @@
+package services
+
+import (
+	"database/sql"
+	"e-meetingproject/internal/database"
+	"e-meetingproject/internal/models"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type SnackStockService struct {
+	db *sql.DB
+}
+
+func NewSnackStockService() *SnackStockService {
+	return &SnackStockService{
+		db: database.GetDB(),
+	}
+}
+
+// Restock adds units to a snack's stock for one day. A day without stock yet
+// starts from the snack's daily stock.
+func (s *SnackStockService) Restock(snackID uuid.UUID, req *models.RestockRequest, tenant Tenant) (*models.SnackStock, error) {
+	if _, err := time.Parse("2006-01-02", req.Date); err != nil {
+		return nil, fmt.Errorf("invalid date format (required: YYYY-MM-DD): %v", err)
+	}
+
+	// Start transaction
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("error starting transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	stock := models.SnackStock{
+		SnackID: snackID,
+		Date:    req.Date,
+	}
+	err = tx.QueryRow(`
+		SELECT name, low_stock_threshold
+		FROM snacks
+		WHERE id = $1 AND retired_at IS NULL AND `+tenantCondition("organisation_id", 2),
+		snackID, tenant.arg(),
+	).Scan(&stock.SnackName, &stock.LowStockThreshold)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("snack not found")
+		}
+		return nil, fmt.Errorf("error fetching snack: %v", err)
+	}
+
+	err = tx.QueryRow(`
+		INSERT INTO snack_stock (snack_id, stock_date, quantity)
+		SELECT id, $2, COALESCE(daily_stock, 0) + $3
+		FROM snacks
+		WHERE id = $1
+		ON CONFLICT (snack_id, stock_date)
+		DO UPDATE SET quantity = snack_stock.quantity + $3, updated_at = NOW()
+		RETURNING quantity, reserved
+	`, snackID, req.Date, req.Quantity).Scan(&stock.Quantity, &stock.Reserved)
+	if err != nil {
+		return nil, fmt.Errorf("error restocking snack: %v", err)
+	}
+	stock.Remaining = stock.Quantity - stock.Reserved
+
+	// Commit transaction
+	if err = tx.Commit(); err != nil {
+		return nil, fmt.Errorf("error committing transaction: %v", err)
+	}
+
+	return &stock, nil
+}
+
+// GetLowStock lists the days on which a stock-limited snack has no more than
+// its low stock threshold left.
+func (s *SnackStockService) GetLowStock(query *models.LowStockQuery, tenant Tenant) (*models.LowStockResponse, error) {
+	from, to, err := lowStockPeriod(query, time.Now().UTC())
+	if err != nil {
+		return nil, err
+	}
+
+	response := &models.LowStockResponse{
+		From:  from,
+		To:    to,
+		Items: []models.SnackStock{},
+	}
+
+	rows, err := s.db.Query(`
+		SELECT s.id, s.name, TO_CHAR(d.day, 'YYYY-MM-DD'),
+			COALESCE(ss.quantity, s.daily_stock), COALESCE(ss.reserved, 0), s.low_stock_threshold
+		FROM snacks s
+		CROSS JOIN generate_series($1::date, $2::date, INTERVAL '1 day') AS d(day)
+		LEFT JOIN snack_stock ss ON ss.snack_id = s.id AND ss.stock_date = d.day::date
+		WHERE s.retired_at IS NULL
+			AND `+tenantCondition("s.organisation_id", 3)+`
+			AND (ss.snack_id IS NOT NULL OR s.daily_stock IS NOT NULL)
+			AND COALESCE(ss.quantity, s.daily_stock) - COALESCE(ss.reserved, 0) <= s.low_stock_threshold
+		ORDER BY d.day, s.name
+	`, response.From, response.To, tenant.arg())
+	if err != nil {
+		return nil, fmt.Errorf("error querying low stock: %v", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var stock models.SnackStock
+		err := rows.Scan(
+			&stock.SnackID,
+			&stock.SnackName,
+			&stock.Date,
+			&stock.Quantity,
+			&stock.Reserved,
+			&stock.LowStockThreshold,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning low stock: %v", err)
+		}
+		stock.Remaining = stock.Quantity - stock.Reserved
+		response.Items = append(response.Items, stock)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating low stock: %v", err)
+	}
+
+	return response, nil
+}
+
+// lowStockPeriod returns the first and last day (YYYY-MM-DD) a low stock query
+// covers, starting today unless the query gives a day.
+func lowStockPeriod(query *models.LowStockQuery, today time.Time) (string, string, error) {
+	from := today
+	if query.From != "" {
+		var err error
+		from, err = time.Parse("2006-01-02", query.From)
+		if err != nil {
+			return "", "", fmt.Errorf("invalid date format (required: YYYY-MM-DD): %v", err)
+		}
+	}
+	to := from.AddDate(0, 0, query.Days-1)
+
+	return from.Format("2006-01-02"), to.Format("2006-01-02"), nil
+}
+
+// snackStockDate is the day whose stock a reservation starting at startTime
+// uses. Stock days are UTC days, whatever offset the client sent, like the
+// days Restock and GetLowStock work with.
+func snackStockDate(startTime time.Time) string {
+	return startTime.UTC().Format("2006-01-02")
+}
+
+// reserveSnackStock takes the ordered quantities out of the stock of date within
+// tx. Lines of snacks that are not stock-limited on that day are left without a
+// StockDate. Stock rows are locked so concurrent bookings cannot oversell.
+func reserveSnackStock(tx *sql.Tx, snacks []orderedSnack, date string) error {
+	var fieldErrors []models.FieldError
+	for i := range snacks {
+		// Open the day's stock from the daily stock the first time it is used
+		_, err := tx.Exec(`
+			INSERT INTO snack_stock (snack_id, stock_date, quantity)
+			SELECT id, $2, daily_stock
+			FROM snacks
+			WHERE id = $1 AND daily_stock IS NOT NULL
+			ON CONFLICT (snack_id, stock_date) DO NOTHING
+		`, snacks[i].ID, date)
+		if err != nil {
+			return fmt.Errorf("error opening snack stock: %v", err)
+		}
+
+		var remaining int
+		err = tx.QueryRow(`
+			SELECT quantity - reserved
+			FROM snack_stock
+			WHERE snack_id = $1 AND stock_date = $2
+			FOR UPDATE
+		`, snacks[i].ID, date).Scan(&remaining)
+		if err == sql.ErrNoRows {
+			continue // not stock-limited on this day
+		}
+		if err != nil {
+			return fmt.Errorf("error fetching snack stock: %v", err)
+		}
+
+		if snacks[i].Quantity > remaining {
+			fieldErrors = append(fieldErrors, models.FieldError{
+				Field:   snacks[i].Field,
+				Message: fmt.Sprintf("only %d left on %s", remaining, date),
+			})
+			continue
+		}
+
+		_, err = tx.Exec(`
+			UPDATE snack_stock
+			SET reserved = reserved + $3, updated_at = NOW()
+			WHERE snack_id = $1 AND stock_date = $2
+		`, snacks[i].ID, date, snacks[i].Quantity)
+		if err != nil {
+			return fmt.Errorf("error reserving snack stock: %v", err)
+		}
+		stockDate := date
+		snacks[i].StockDate = &stockDate
+	}
+
+	if len(fieldErrors) > 0 {
+		return &models.ValidationError{Fields: fieldErrors}
+	}
+
+	return nil
+}
+
+// releaseSnackStock puts the snack orders of a reservation back into stock.
+// Released lines lose their stock date so a reservation is never released twice.
+func releaseSnackStock(tx *sql.Tx, reservationID uuid.UUID) error {
+	_, err := tx.Exec(`
+		UPDATE snack_stock ss
+		SET reserved = ss.reserved - rs.quantity, updated_at = NOW()
+		FROM (
+			SELECT snack_id, stock_date, SUM(quantity) AS quantity
+			FROM reservation_snacks
+			WHERE reservation_id = $1 AND stock_date IS NOT NULL
+			GROUP BY snack_id, stock_date
+		) rs
+		WHERE rs.snack_id = ss.snack_id AND rs.stock_date = ss.stock_date
+	`, reservationID)
+	if err != nil {
+		return fmt.Errorf("error releasing snack stock: %v", err)
+	}
+
+	_, err = tx.Exec(`
+		UPDATE reservation_snacks
+		SET stock_date = NULL, updated_at = NOW()
+		WHERE reservation_id = $1 AND stock_date IS NOT NULL
+	`, reservationID)
+	if err != nil {
+		return fmt.Errorf("error clearing snack stock dates: %v", err)
+	}
+
+	return nil
+}
+
+// releaseSnackLineStock puts one snack line back into stock, like
+// releaseSnackStock does for a whole reservation.
+func releaseSnackLineStock(tx *sql.Tx, lineID uuid.UUID) error {
+	_, err := tx.Exec(`
+		UPDATE snack_stock ss
+		SET reserved = ss.reserved - rs.quantity, updated_at = NOW()
+		FROM reservation_snacks rs
+		WHERE rs.id = $1
+			AND rs.snack_id = ss.snack_id
+			AND rs.stock_date = ss.stock_date
+	`, lineID)
+	if err != nil {
+		return fmt.Errorf("error releasing snack stock: %v", err)
+	}
+
+	_, err = tx.Exec(`
+		UPDATE reservation_snacks
+		SET stock_date = NULL, updated_at = NOW()
+		WHERE id = $1 AND stock_date IS NOT NULL
+	`, lineID)
+	if err != nil {
+		return fmt.Errorf("error clearing snack stock date: %v", err)
+	}
+
+	return nil
+}
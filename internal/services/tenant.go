@@ -0,0 +1,107 @@
+package services
+
+import (
+	"database/sql"
+	"e-meetingproject/internal/auth"
+	"e-meetingproject/internal/models"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// Tenant is the organisation a request works for. Services only read and
+// change rows of the tenant's organisation, unless AllOrganisations is set
+// for a super admin. RoomIDs further limits an API key to some rooms.
+type Tenant struct {
+	OrganisationID   uuid.UUID
+	AllOrganisations bool
+	RoomIDs          []uuid.UUID
+}
+
+// TenantOf returns the tenant of an authenticated user.
+func TenantOf(claims *auth.Claims) Tenant {
+	return Tenant{
+		OrganisationID:   claims.OrganisationID,
+		AllOrganisations: claims.Role == models.RoleSuperAdmin,
+		RoomIDs:          claims.RoomIDs,
+	}
+}
+
+// arg is the value bound to the placeholder of tenantCondition: the
+// organisation, or NULL when the tenant sees every organisation.
+func (t Tenant) arg() uuid.NullUUID {
+	if t.AllOrganisations {
+		return uuid.NullUUID{}
+	}
+	return uuid.NullUUID{UUID: t.OrganisationID, Valid: true}
+}
+
+// tenantCondition limits column to the tenant bound to placeholder $n.
+func tenantCondition(column string, n int) string {
+	return fmt.Sprintf("($%d::uuid IS NULL OR %s = $%d)", n, column, n)
+}
+
+// roomsArg is the value bound to the placeholder of roomCondition: the
+// rooms, or NULL when the tenant is not limited to some rooms.
+func (t Tenant) roomsArg() interface{} {
+	if len(t.RoomIDs) == 0 {
+		return nil
+	}
+	return pq.Array(t.RoomIDs)
+}
+
+// roomCondition limits column to the rooms of the tenant bound to
+// placeholder $n.
+func roomCondition(column string, n int) string {
+	return fmt.Sprintf("($%d::uuid[] IS NULL OR %s = ANY($%d))", n, column, n)
+}
+
+// canAccess reports whether the tenant may work with rows of organisationID.
+func (t Tenant) canAccess(organisationID uuid.UUID) bool {
+	return t.AllOrganisations || organisationID == t.OrganisationID
+}
+
+// organisationFor returns the organisation new rows of the tenant belong to:
+// its own, or for a super admin the requested one.
+func (t Tenant) organisationFor(q queryRower, requested *uuid.UUID) (uuid.UUID, error) {
+	if requested == nil || *requested == t.OrganisationID {
+		return t.OrganisationID, nil
+	}
+	if !t.AllOrganisations {
+		return uuid.Nil, errors.New("organisation not found")
+	}
+
+	var exists bool
+	err := q.QueryRow(`SELECT EXISTS(SELECT 1 FROM organisations WHERE id = $1)`, *requested).Scan(&exists)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("error checking organisation: %v", err)
+	}
+	if !exists {
+		return uuid.Nil, errors.New("organisation not found")
+	}
+
+	return *requested, nil
+}
+
+// checkUserTenant returns "user not found" unless the user belongs to the
+// tenant. Only super admins may manage super admins.
+func checkUserTenant(q queryRower, userID uuid.UUID, tenant Tenant) error {
+	var organisationID uuid.UUID
+	var role string
+	err := q.QueryRow(`SELECT organisation_id, role FROM users WHERE id = $1`, userID).Scan(&organisationID, &role)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return errors.New("user not found")
+		}
+		return fmt.Errorf("error fetching user: %v", err)
+	}
+	if !tenant.canAccess(organisationID) {
+		return errors.New("user not found")
+	}
+	if role == models.RoleSuperAdmin && !tenant.AllOrganisations {
+		return errors.New("only super admins can manage super admins")
+	}
+	return nil
+}
@@ -0,0 +1,59 @@
+package services
+
+import (
+	"e-meetingproject/internal/auth"
+	"e-meetingproject/internal/models"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTenantOf(t *testing.T) {
+	organisationID := uuid.New()
+
+	admin := TenantOf(&auth.Claims{Role: models.RoleAdmin, OrganisationID: organisationID})
+	assert.Equal(t, Tenant{OrganisationID: organisationID}, admin)
+	assert.Equal(t, uuid.NullUUID{UUID: organisationID, Valid: true}, admin.arg())
+
+	superAdmin := TenantOf(&auth.Claims{Role: models.RoleSuperAdmin, OrganisationID: organisationID})
+	assert.True(t, superAdmin.AllOrganisations)
+	assert.False(t, superAdmin.arg().Valid)
+}
+
+func TestTenantCanAccess(t *testing.T) {
+	own, other := uuid.New(), uuid.New()
+
+	tests := []struct {
+		name     string
+		tenant   Tenant
+		expected bool
+	}{
+		{name: "Own organisation", tenant: Tenant{OrganisationID: other}, expected: true},
+		{name: "Other organisation", tenant: Tenant{OrganisationID: own}},
+		{name: "Super admin", tenant: Tenant{OrganisationID: own, AllOrganisations: true}, expected: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, tt.tenant.canAccess(other))
+		})
+	}
+}
+
+func TestTenantOrganisationFor(t *testing.T) {
+	own, other := uuid.New(), uuid.New()
+	tenant := Tenant{OrganisationID: own}
+
+	// Neither case needs the database
+	organisationID, err := tenant.organisationFor(nil, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, own, organisationID)
+
+	_, err = tenant.organisationFor(nil, &other)
+	assert.EqualError(t, err, "organisation not found")
+}
+
+func TestTenantCondition(t *testing.T) {
+	assert.Equal(t, "($3::uuid IS NULL OR rm.organisation_id = $3)", tenantCondition("rm.organisation_id", 3))
+}
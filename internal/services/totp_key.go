@@ -0,0 +1,84 @@
+package services
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// sealedTOTPPrefix marks TOTP secrets encrypted with a TOTPKey. Secrets stored
+// before a key was configured have no prefix and are read as they are.
+const sealedTOTPPrefix = "enc:v1:"
+
+// TOTPKey encrypts TOTP secrets at rest with AES-256-GCM, so a copy of the
+// database or a backup does not give away the users' second factors. The key
+// must be kept apart from the database, like the token signing keys. A nil
+// TOTPKey stores secrets in plain text.
+type TOTPKey struct {
+	aead cipher.AEAD
+}
+
+// ParseTOTPKey reads a TOTPKey from the standard base64 encoding of 32
+// random bytes, e.g. the output of `openssl rand -base64 32`.
+func ParseTOTPKey(encoded string) (*TOTPKey, error) {
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+	if err != nil {
+		return nil, fmt.Errorf("invalid TOTP key: %v", err)
+	}
+	if len(key) != 32 {
+		return nil, fmt.Errorf("invalid TOTP key: must be 32 bytes, got %d", len(key))
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("invalid TOTP key: %v", err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("invalid TOTP key: %v", err)
+	}
+
+	return &TOTPKey{aead: aead}, nil
+}
+
+// seal returns secret as it is stored.
+func (k *TOTPKey) seal(secret string) (string, error) {
+	if k == nil {
+		return secret, nil
+	}
+
+	nonce := make([]byte, k.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", fmt.Errorf("error generating nonce: %v", err)
+	}
+	sealed := k.aead.Seal(nonce, nonce, []byte(secret), nil)
+
+	return sealedTOTPPrefix + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// open returns the secret of a stored value, and whether it still has to be
+// sealed because it was stored in plain text before the key was configured.
+func (k *TOTPKey) open(stored string) (string, bool, error) {
+	if !strings.HasPrefix(stored, sealedTOTPPrefix) {
+		return stored, k != nil, nil
+	}
+	if k == nil {
+		return "", false, errors.New("two-factor secret is encrypted but no TOTP key is configured")
+	}
+
+	sealed, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(stored, sealedTOTPPrefix))
+	if err != nil || len(sealed) < k.aead.NonceSize() {
+		return "", false, errors.New("invalid encrypted two-factor secret")
+	}
+	nonce, ciphertext := sealed[:k.aead.NonceSize()], sealed[k.aead.NonceSize():]
+	secret, err := k.aead.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return "", false, errors.New("invalid encrypted two-factor secret")
+	}
+
+	return string(secret), false, nil
+}
@@ -0,0 +1,92 @@
+package services
+
+import (
+	"encoding/base64"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testTOTPKey(t *testing.T, fill byte) *TOTPKey {
+	t.Helper()
+	key, err := ParseTOTPKey(base64.StdEncoding.EncodeToString([]byte(strings.Repeat(string(fill), 32))))
+	require.NoError(t, err)
+	return key
+}
+
+func TestParseTOTPKey(t *testing.T) {
+	tests := []struct {
+		name          string
+		encoded       string
+		expectedError string
+	}{
+		{name: "Valid key", encoded: base64.StdEncoding.EncodeToString(make([]byte, 32))},
+		{name: "Too short", encoded: base64.StdEncoding.EncodeToString(make([]byte, 16)), expectedError: "invalid TOTP key: must be 32 bytes, got 16"},
+		{name: "Not base64", encoded: "not a key!", expectedError: "invalid TOTP key"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key, err := ParseTOTPKey(tt.encoded)
+			if tt.expectedError != "" {
+				assert.Error(t, err)
+				assert.Contains(t, err.Error(), tt.expectedError)
+				return
+			}
+			assert.NoError(t, err)
+			assert.NotNil(t, key)
+		})
+	}
+}
+
+func TestTOTPKeySealAndOpen(t *testing.T) {
+	key := testTOTPKey(t, 'k')
+
+	sealed, err := key.seal(rfc6238Secret)
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(sealed, sealedTOTPPrefix))
+	assert.NotContains(t, sealed, rfc6238Secret)
+
+	// Every seal uses a new nonce
+	again, err := key.seal(rfc6238Secret)
+	require.NoError(t, err)
+	assert.NotEqual(t, sealed, again)
+
+	secret, unsealed, err := key.open(sealed)
+	require.NoError(t, err)
+	assert.Equal(t, rfc6238Secret, secret)
+	assert.False(t, unsealed)
+
+	// Another key cannot read it, nor can a tampered value be read
+	_, _, err = testTOTPKey(t, 'x').open(sealed)
+	assert.EqualError(t, err, "invalid encrypted two-factor secret")
+	_, _, err = key.open(sealed[:len(sealed)-4] + "AAAA")
+	assert.EqualError(t, err, "invalid encrypted two-factor secret")
+
+	// An encrypted secret cannot be read without the key
+	var none *TOTPKey
+	_, _, err = none.open(sealed)
+	assert.EqualError(t, err, "two-factor secret is encrypted but no TOTP key is configured")
+}
+
+func TestTOTPKeyPlainSecrets(t *testing.T) {
+	// Without a key secrets are stored as they are
+	var none *TOTPKey
+	stored, err := none.seal(rfc6238Secret)
+	require.NoError(t, err)
+	assert.Equal(t, rfc6238Secret, stored)
+
+	secret, unsealed, err := none.open(stored)
+	require.NoError(t, err)
+	assert.Equal(t, rfc6238Secret, secret)
+	assert.False(t, unsealed)
+
+	// Secrets stored before the key was configured still work, and are
+	// reported for encryption
+	secret, unsealed, err = testTOTPKey(t, 'k').open(stored)
+	require.NoError(t, err)
+	assert.Equal(t, rfc6238Secret, secret)
+	assert.True(t, unsealed)
+}
@@ -0,0 +1,528 @@
+package services
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"database/sql"
+	"e-meetingproject/internal/database"
+	"e-meetingproject/internal/models"
+	"encoding/base32"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/spf13/viper"
+)
+
+// TOTP parameters (RFC 6238), the defaults every authenticator app supports
+const (
+	totpPeriod        = 30 // seconds
+	totpDigits        = 6
+	totpSkew          = 1 // Steps accepted before and after the current one
+	recoveryCodeCount = 10
+)
+
+var totpEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// TwoFactorService manages TOTP enrollment, recovery codes and the roles that
+// must use two-factor authentication. The login step itself is handled by
+// AuthService.
+type TwoFactorService struct {
+	db       *sql.DB
+	issuer   string
+	totpKey  *TOTPKey
+	throttle loginThrottle
+}
+
+func NewTwoFactorService(totpKey *TOTPKey) *TwoFactorService {
+	return &TwoFactorService{
+		db:       database.GetDB(),
+		issuer:   totpIssuer(),
+		totpKey:  totpKey,
+		throttle: newLoginThrottle(),
+	}
+}
+
+// totpIssuer is the account issuer shown in authenticator apps.
+func totpIssuer() string {
+	issuer := viper.GetString("TOTP_ISSUER")
+	if issuer == "" {
+		issuer = "E-Meeting" // default issuer
+	}
+	return issuer
+}
+
+func (s *TwoFactorService) GetStatus(userID uuid.UUID, role string) (*models.TwoFactorStatusResponse, error) {
+	var response models.TwoFactorStatusResponse
+	var err error
+	response.Enabled, response.Required, err = twoFactorState(s.db, userID, role)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.db.QueryRow(`
+		SELECT COUNT(*) FROM user_recovery_codes WHERE user_id = $1 AND used_at IS NULL
+	`, userID).Scan(&response.RecoveryCodesRemaining)
+	if err != nil {
+		return nil, fmt.Errorf("error counting recovery codes: %v", err)
+	}
+
+	return &response, nil
+}
+
+// BeginEnrollment creates a new TOTP secret for the user. It replaces an
+// enrollment that was never confirmed.
+func (s *TwoFactorService) BeginEnrollment(userID uuid.UUID, username string) (*models.TOTPEnrollmentResponse, error) {
+	// Start transaction
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("error starting transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	response, err := beginTOTPEnrollment(tx, s.totpKey, userID, username, s.issuer)
+	if err != nil {
+		return nil, err
+	}
+
+	// Commit transaction
+	if err = tx.Commit(); err != nil {
+		return nil, fmt.Errorf("error committing transaction: %v", err)
+	}
+
+	return response, nil
+}
+
+// ConfirmEnrollment enables two-factor authentication once the user proves
+// the authenticator app works, and returns the user's recovery codes.
+func (s *TwoFactorService) ConfirmEnrollment(userID uuid.UUID, code string) (*models.RecoveryCodesResponse, error) {
+	// Start transaction
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("error starting transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	codes, err := confirmTOTPEnrollment(tx, s.totpKey, userID, code, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	// Commit transaction
+	if err = tx.Commit(); err != nil {
+		return nil, fmt.Errorf("error committing transaction: %v", err)
+	}
+
+	return &models.RecoveryCodesResponse{RecoveryCodes: codes}, nil
+}
+
+// Disable turns two-factor authentication off after checking a current code.
+// Users whose role requires it cannot turn it off.
+func (s *TwoFactorService) Disable(userID uuid.UUID, username, role, code, clientIP string) error {
+	// Start transaction
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("error starting transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	_, required, err := twoFactorState(tx, userID, role)
+	if err != nil {
+		return err
+	}
+	if required {
+		return errors.New("two-factor authentication is required for your role")
+	}
+
+	if err := s.checkCode(tx, userID, username, code, clientIP); err != nil {
+		return err
+	}
+
+	if err := removeTwoFactor(tx, userID); err != nil {
+		return err
+	}
+
+	// Commit transaction
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("error committing transaction: %v", err)
+	}
+
+	return nil
+}
+
+// RegenerateRecoveryCodes replaces the user's recovery codes after checking a
+// current code.
+func (s *TwoFactorService) RegenerateRecoveryCodes(userID uuid.UUID, username, code, clientIP string) (*models.RecoveryCodesResponse, error) {
+	// Start transaction
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("error starting transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	if err := s.checkCode(tx, userID, username, code, clientIP); err != nil {
+		return nil, err
+	}
+
+	codes, err := replaceRecoveryCodes(tx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Commit transaction
+	if err = tx.Commit(); err != nil {
+		return nil, fmt.Errorf("error committing transaction: %v", err)
+	}
+
+	return &models.RecoveryCodesResponse{RecoveryCodes: codes}, nil
+}
+
+// checkCode checks a current code of the signed-in user with
+// checkSecondFactor. Wrong codes are throttled like logins, against the
+// account and clientIP; tx is rolled back when the code is wrong.
+func (s *TwoFactorService) checkCode(tx *sql.Tx, userID uuid.UUID, username, code, clientIP string) error {
+	now := time.Now()
+	if err := s.throttle.check(tx, username, clientIP, now); err != nil {
+		return err
+	}
+
+	err := checkSecondFactor(tx, s.totpKey, userID, code, now)
+	if err != nil && err.Error() == "invalid two-factor code" {
+		tx.Rollback()
+		if recordErr := s.throttle.recordFailure(s.db, username, clientIP, models.AuthEventTwoFactorFailed, now); recordErr != nil {
+			return recordErr
+		}
+	}
+	return err
+}
+
+// ResetUser removes a user's two-factor enrollment, e.g. after the device and
+// the recovery codes were lost. Users of a role that requires 2FA enroll
+// again at their next login.
+func (s *TwoFactorService) ResetUser(userID uuid.UUID, tenant Tenant) error {
+	// Start transaction
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("error starting transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	if err := checkUserTenant(tx, userID, tenant); err != nil {
+		return err
+	}
+
+	if err := removeTwoFactor(tx, userID); err != nil {
+		return err
+	}
+
+	// Commit transaction
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("error committing transaction: %v", err)
+	}
+
+	return nil
+}
+
+// SetRoleRequirement sets whether users of role must use two-factor
+// authentication.
+func (s *TwoFactorService) SetRoleRequirement(role string, required bool) error {
+	_, err := s.db.Exec(`
+		INSERT INTO role_policies (role, require_two_factor, updated_at)
+		VALUES ($1, $2, NOW())
+		ON CONFLICT (role) DO UPDATE
+		SET require_two_factor = EXCLUDED.require_two_factor, updated_at = NOW()
+	`, role, required)
+	if err != nil {
+		return fmt.Errorf("error updating role policy: %v", err)
+	}
+	return nil
+}
+
+// twoFactorState reports whether the user has confirmed a TOTP enrollment and
+// whether the user's role requires one.
+func twoFactorState(q queryRower, userID uuid.UUID, role string) (enabled, required bool, err error) {
+	err = q.QueryRow(`
+		SELECT
+			EXISTS (SELECT 1 FROM user_totp WHERE user_id = $1 AND confirmed_at IS NOT NULL),
+			COALESCE((SELECT require_two_factor FROM role_policies WHERE role::text = $2), FALSE)
+	`, userID, role).Scan(&enabled, &required)
+	if err != nil {
+		return false, false, fmt.Errorf("error checking two-factor status: %v", err)
+	}
+	return enabled, required, nil
+}
+
+func beginTOTPEnrollment(tx *sql.Tx, key *TOTPKey, userID uuid.UUID, username, issuer string) (*models.TOTPEnrollmentResponse, error) {
+	var confirmed bool
+	err := tx.QueryRow(`
+		SELECT confirmed_at IS NOT NULL FROM user_totp WHERE user_id = $1 FOR UPDATE
+	`, userID).Scan(&confirmed)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, fmt.Errorf("database error: %v", err)
+	}
+	if confirmed {
+		return nil, errors.New("two-factor authentication is already enabled")
+	}
+
+	secret, err := generateTOTPSecret()
+	if err != nil {
+		return nil, fmt.Errorf("error generating secret: %v", err)
+	}
+	sealed, err := key.seal(secret)
+	if err != nil {
+		return nil, fmt.Errorf("error encrypting secret: %v", err)
+	}
+
+	_, err = tx.Exec(`
+		INSERT INTO user_totp (user_id, secret)
+		VALUES ($1, $2)
+		ON CONFLICT (user_id) DO UPDATE
+		SET secret = EXCLUDED.secret, last_step = 0, created_at = NOW()
+	`, userID, sealed)
+	if err != nil {
+		return nil, fmt.Errorf("error storing secret: %v", err)
+	}
+
+	return &models.TOTPEnrollmentResponse{
+		Secret:          secret,
+		ProvisioningURI: totpProvisioningURI(issuer, username, secret),
+	}, nil
+}
+
+// confirmTOTPEnrollment checks code against a pending enrollment, enables it
+// and returns new recovery codes.
+func confirmTOTPEnrollment(tx *sql.Tx, key *TOTPKey, userID uuid.UUID, code string, now time.Time) ([]string, error) {
+	var stored string
+	var confirmedAt sql.NullTime
+	var lastStep int64
+	err := tx.QueryRow(`
+		SELECT secret, confirmed_at, last_step FROM user_totp WHERE user_id = $1 FOR UPDATE
+	`, userID).Scan(&stored, &confirmedAt, &lastStep)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.New("two-factor enrollment not started")
+		}
+		return nil, fmt.Errorf("database error: %v", err)
+	}
+	if confirmedAt.Valid {
+		return nil, errors.New("two-factor authentication is already enabled")
+	}
+
+	secret, _, err := key.open(stored)
+	if err != nil {
+		return nil, err
+	}
+
+	step, ok := verifyTOTP(secret, code, now, lastStep)
+	if !ok {
+		return nil, errors.New("invalid two-factor code")
+	}
+
+	_, err = tx.Exec(`
+		UPDATE user_totp SET confirmed_at = NOW(), last_step = $1 WHERE user_id = $2
+	`, step, userID)
+	if err != nil {
+		return nil, fmt.Errorf("error confirming enrollment: %v", err)
+	}
+
+	return replaceRecoveryCodes(tx, userID)
+}
+
+// checkSecondFactor accepts a TOTP code of the user's confirmed enrollment or
+// an unused recovery code, which is used up.
+func checkSecondFactor(tx *sql.Tx, key *TOTPKey, userID uuid.UUID, code string, now time.Time) error {
+	var stored string
+	var lastStep int64
+	err := tx.QueryRow(`
+		SELECT secret, last_step
+		FROM user_totp
+		WHERE user_id = $1 AND confirmed_at IS NOT NULL
+		FOR UPDATE
+	`, userID).Scan(&stored, &lastStep)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return errors.New("two-factor authentication is not enabled")
+		}
+		return fmt.Errorf("database error: %v", err)
+	}
+
+	if isTOTPCode(code) {
+		secret, unsealed, err := key.open(stored)
+		if err != nil {
+			return err
+		}
+		step, ok := verifyTOTP(secret, code, now, lastStep)
+		if !ok {
+			return errors.New("invalid two-factor code")
+		}
+
+		// Secrets stored before the key was configured are encrypted on first use
+		if unsealed {
+			if stored, err = key.seal(secret); err != nil {
+				return fmt.Errorf("error encrypting secret: %v", err)
+			}
+		}
+		_, err = tx.Exec(`UPDATE user_totp SET last_step = $1, secret = $2 WHERE user_id = $3`, step, stored, userID)
+		if err != nil {
+			return fmt.Errorf("error updating last step: %v", err)
+		}
+		return nil
+	}
+
+	result, err := tx.Exec(`
+		UPDATE user_recovery_codes
+		SET used_at = NOW()
+		WHERE user_id = $1 AND code_hash = $2 AND used_at IS NULL
+	`, userID, hashToken(normalizeRecoveryCode(code)))
+	if err != nil {
+		return fmt.Errorf("error using recovery code: %v", err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("error getting rows affected: %v", err)
+	}
+	if rowsAffected == 0 {
+		return errors.New("invalid two-factor code")
+	}
+
+	return nil
+}
+
+func replaceRecoveryCodes(tx *sql.Tx, userID uuid.UUID) ([]string, error) {
+	codes, err := generateRecoveryCodes(recoveryCodeCount)
+	if err != nil {
+		return nil, fmt.Errorf("error generating recovery codes: %v", err)
+	}
+
+	_, err = tx.Exec(`DELETE FROM user_recovery_codes WHERE user_id = $1`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("error deleting recovery codes: %v", err)
+	}
+	for _, code := range codes {
+		_, err = tx.Exec(`
+			INSERT INTO user_recovery_codes (user_id, code_hash) VALUES ($1, $2)
+		`, userID, hashToken(normalizeRecoveryCode(code)))
+		if err != nil {
+			return nil, fmt.Errorf("error storing recovery code: %v", err)
+		}
+	}
+
+	return codes, nil
+}
+
+func removeTwoFactor(tx *sql.Tx, userID uuid.UUID) error {
+	_, err := tx.Exec(`DELETE FROM user_totp WHERE user_id = $1`, userID)
+	if err != nil {
+		return fmt.Errorf("error removing two-factor secret: %v", err)
+	}
+	_, err = tx.Exec(`DELETE FROM user_recovery_codes WHERE user_id = $1`, userID)
+	if err != nil {
+		return fmt.Errorf("error removing recovery codes: %v", err)
+	}
+	return nil
+}
+
+func generateTOTPSecret() (string, error) {
+	b := make([]byte, 20) // 160 bits, as recommended by RFC 4226
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return totpEncoding.EncodeToString(b), nil
+}
+
+// totpCode computes the code of secret for a time step (RFC 6238 with
+// HMAC-SHA1).
+func totpCode(secret string, step int64) (string, error) {
+	key, err := totpEncoding.DecodeString(strings.ToUpper(secret))
+	if err != nil {
+		return "", fmt.Errorf("invalid TOTP secret: %v", err)
+	}
+
+	var counter [8]byte
+	binary.BigEndian.PutUint64(counter[:], uint64(step))
+	mac := hmac.New(sha1.New, key)
+	mac.Write(counter[:])
+	sum := mac.Sum(nil)
+
+	// Dynamic truncation
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+
+	return fmt.Sprintf("%0*d", totpDigits, value%1000000), nil
+}
+
+// verifyTOTP checks code against the steps around now and returns the
+// matching step. Steps up to lastStep were already used and are rejected.
+func verifyTOTP(secret, code string, now time.Time, lastStep int64) (int64, bool) {
+	if !isTOTPCode(code) {
+		return 0, false
+	}
+
+	current := now.Unix() / totpPeriod
+	for step := current - totpSkew; step <= current+totpSkew; step++ {
+		if step <= lastStep {
+			continue
+		}
+		expected, err := totpCode(secret, step)
+		if err != nil {
+			return 0, false
+		}
+		if hmac.Equal([]byte(expected), []byte(code)) {
+			return step, true
+		}
+	}
+
+	return 0, false
+}
+
+// totpProvisioningURI is the otpauth:// URI authenticator apps scan from a QR
+// code.
+func totpProvisioningURI(issuer, account, secret string) string {
+	query := url.Values{
+		"secret":    {secret},
+		"issuer":    {issuer},
+		"algorithm": {"SHA1"},
+		"digits":    {fmt.Sprint(totpDigits)},
+		"period":    {fmt.Sprint(totpPeriod)},
+	}
+	label := url.PathEscape(issuer + ":" + account)
+	return "otpauth://totp/" + label + "?" + query.Encode()
+}
+
+// generateRecoveryCodes returns codes formatted as xxxx-xxxx.
+func generateRecoveryCodes(n int) ([]string, error) {
+	codes := make([]string, n)
+	for i := range codes {
+		b := make([]byte, 5)
+		if _, err := rand.Read(b); err != nil {
+			return nil, err
+		}
+		code := strings.ToLower(totpEncoding.EncodeToString(b))
+		codes[i] = code[:4] + "-" + code[4:]
+	}
+	return codes, nil
+}
+
+// normalizeRecoveryCode ignores case, dashes and spaces.
+func normalizeRecoveryCode(code string) string {
+	code = strings.ToLower(code)
+	code = strings.ReplaceAll(code, "-", "")
+	return strings.ReplaceAll(code, " ", "")
+}
+
+func isTOTPCode(code string) bool {
+	if len(code) != totpDigits {
+		return false
+	}
+	for _, r := range code {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
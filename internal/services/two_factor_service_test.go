@@ -0,0 +1,104 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// rfc6238Secret is the SHA-1 test key of RFC 6238, "12345678901234567890".
+const rfc6238Secret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestTOTPCode(t *testing.T) {
+	// The RFC lists 8-digit codes; these are their last 6 digits
+	tests := []struct {
+		unix     int64
+		expected string
+	}{
+		{unix: 59, expected: "287082"},
+		{unix: 1111111109, expected: "081804"},
+		{unix: 1111111111, expected: "050471"},
+		{unix: 1234567890, expected: "005924"},
+		{unix: 2000000000, expected: "279037"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expected, func(t *testing.T) {
+			code, err := totpCode(rfc6238Secret, tt.unix/totpPeriod)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, code)
+		})
+	}
+
+	_, err := totpCode("not base32!", 1)
+	assert.Error(t, err)
+}
+
+func TestVerifyTOTP(t *testing.T) {
+	now := time.Unix(1111111109, 0)
+	step := now.Unix() / totpPeriod
+	previous, err := totpCode(rfc6238Secret, step-1)
+	require.NoError(t, err)
+	tooOld, err := totpCode(rfc6238Secret, step-2)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name         string
+		code         string
+		lastStep     int64
+		expectedStep int64
+		expectedOK   bool
+	}{
+		{name: "Current code", code: "081804", expectedStep: step, expectedOK: true},
+		{name: "Previous step within skew", code: previous, expectedStep: step - 1, expectedOK: true},
+		{name: "Outside skew", code: tooOld},
+		{name: "Already used", code: "081804", lastStep: step},
+		{name: "Wrong code", code: "123456"},
+		{name: "Not a TOTP code", code: "abcd-efgh"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			matched, ok := verifyTOTP(rfc6238Secret, tt.code, now, tt.lastStep)
+			assert.Equal(t, tt.expectedOK, ok)
+			if tt.expectedOK {
+				assert.Equal(t, tt.expectedStep, matched)
+			}
+		})
+	}
+}
+
+func TestTOTPProvisioningURI(t *testing.T) {
+	uri := totpProvisioningURI("E-Meeting", "jane doe", "JBSWY3DPEHPK3PXP")
+
+	assert.Equal(t,
+		"otpauth://totp/E-Meeting:jane%20doe?algorithm=SHA1&digits=6&issuer=E-Meeting&period=30&secret=JBSWY3DPEHPK3PXP",
+		uri)
+}
+
+func TestRecoveryCodes(t *testing.T) {
+	codes, err := generateRecoveryCodes(recoveryCodeCount)
+	require.NoError(t, err)
+	require.Len(t, codes, recoveryCodeCount)
+
+	seen := map[string]bool{}
+	for _, code := range codes {
+		assert.Regexp(t, `^[a-z2-7]{4}-[a-z2-7]{4}$`, code)
+		assert.False(t, isTOTPCode(code))
+		seen[code] = true
+	}
+	assert.Len(t, seen, recoveryCodeCount)
+
+	assert.Equal(t, "abcdefgh", normalizeRecoveryCode(" ABCD-efgh"))
+}
+
+func TestGenerateTOTPSecret(t *testing.T) {
+	secret, err := generateTOTPSecret()
+	require.NoError(t, err)
+	assert.Len(t, secret, 32)
+
+	_, err = totpCode(secret, 1)
+	assert.NoError(t, err)
+}
@@ -6,9 +6,11 @@ import (
 	"e-meetingproject/internal/models"
 	"errors"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 	"golang.org/x/crypto/bcrypt"
 )
 
@@ -152,3 +154,392 @@ func (s *UserService) UpdateProfile(userID string, req *models.UpdateProfileRequ
 
 	return &profile, nil
 }
+
+// adminUserColumns are scanned by scanAdminUser.
+const adminUserColumns = `id, organisation_id, username, COALESCE(email, ''), role, status, email_verified_at, locked_until, created_at, updated_at`
+
+func scanAdminUser(row rowScanner) (*models.AdminUserResponse, error) {
+	var user models.AdminUserResponse
+	var emailVerifiedAt, lockedUntil sql.NullTime
+	err := row.Scan(
+		&user.ID,
+		&user.OrganisationID,
+		&user.Username,
+		&user.Email,
+		&user.Role,
+		&user.Status,
+		&emailVerifiedAt,
+		&lockedUntil,
+		&user.CreatedAt,
+		&user.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if emailVerifiedAt.Valid {
+		user.EmailVerifiedAt = &emailVerifiedAt.Time
+	}
+	if lockedUntil.Valid {
+		user.LockedUntil = &lockedUntil.Time
+	}
+	return &user, nil
+}
+
+// ListUsers searches the tenant's users by username or email, optionally
+// filtered by role and status, ordered by username.
+func (s *UserService) ListUsers(query *models.AdminUserQuery, tenant Tenant) (*models.AdminUserListResponse, error) {
+	// Set default pagination values
+	page := 1
+	pageSize := 20
+	if query.Page > 0 {
+		page = query.Page
+	}
+	if query.PageSize > 0 {
+		pageSize = query.PageSize
+	}
+
+	var conditions []string
+	var args []interface{}
+	addCondition := func(condition string, arg interface{}) {
+		args = append(args, arg)
+		conditions = append(conditions, strings.ReplaceAll(condition, "$?", fmt.Sprintf("$%d", len(args))))
+	}
+
+	if !tenant.AllOrganisations {
+		addCondition("organisation_id = $?", tenant.OrganisationID)
+	}
+	if search := strings.TrimSpace(query.Search); search != "" {
+		addCondition(`(username ILIKE $? OR email ILIKE $?)`, "%"+likeEscaper.Replace(search)+"%")
+	}
+	if query.Role != "" {
+		addCondition("role = $?", query.Role)
+	}
+	if query.Status != "" {
+		addCondition("status = $?", query.Status)
+	}
+
+	where := ""
+	if len(conditions) > 0 {
+		where = "WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	var totalItems int
+	err := s.db.QueryRow(`SELECT COUNT(*) FROM users `+where, args...).Scan(&totalItems)
+	if err != nil {
+		return nil, fmt.Errorf("error counting users: %v", err)
+	}
+
+	args = append(args, pageSize, (page-1)*pageSize)
+	rows, err := s.db.Query(fmt.Sprintf(`
+		SELECT %s
+		FROM users
+		%s
+		ORDER BY username
+		LIMIT $%d OFFSET $%d
+	`, adminUserColumns, where, len(args)-1, len(args)), args...)
+	if err != nil {
+		return nil, fmt.Errorf("error querying users: %v", err)
+	}
+	defer rows.Close()
+
+	users := []models.AdminUserResponse{}
+	for rows.Next() {
+		user, err := scanAdminUser(rows)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning user: %v", err)
+		}
+		users = append(users, *user)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating users: %v", err)
+	}
+
+	return &models.AdminUserListResponse{
+		Users:      users,
+		Page:       page,
+		PageSize:   pageSize,
+		TotalItems: totalItems,
+		TotalPages: (totalItems + pageSize - 1) / pageSize,
+	}, nil
+}
+
+// likeEscaper escapes the wildcards of a LIKE pattern.
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// CreateUser creates an active account on behalf of the admin actorID. The
+// admin vouches for the email address, so it is not verified.
+func (s *UserService) CreateUser(req *models.CreateUserRequest, actorID uuid.UUID, tenant Tenant) (*models.AdminUserResponse, error) {
+	if req.Role == models.RoleSuperAdmin && !tenant.AllOrganisations {
+		return nil, errors.New("only super admins can grant the super_admin role")
+	}
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, fmt.Errorf("error hashing password: %v", err)
+	}
+
+	// Start transaction
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("error starting transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	organisationID, err := tenant.organisationFor(tx, req.OrganisationID)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := scanAdminUser(tx.QueryRow(`
+		INSERT INTO users (id, organisation_id, username, email, password, role, status, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, NOW(), NOW())
+		RETURNING `+adminUserColumns,
+		uuid.New(), organisationID, req.Username, req.Email, hashedPassword, req.Role, models.UserStatusActive,
+	))
+	if err != nil {
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code.Name() == "unique_violation" {
+			switch pqErr.Constraint {
+			case "users_username_unique":
+				return nil, errors.New("username already exists")
+			case "users_email_unique":
+				return nil, errors.New("email already exists")
+			}
+		}
+		return nil, fmt.Errorf("error creating user: %v", err)
+	}
+
+	err = recordAuthEvent(tx, authAuditEntry{
+		Event:    models.AuthEventUserCreated,
+		UserID:   user.ID,
+		Username: user.Username,
+		ActorID:  actorID,
+		Detail:   "role " + user.Role,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Commit transaction
+	if err = tx.Commit(); err != nil {
+		return nil, fmt.Errorf("error committing transaction: %v", err)
+	}
+
+	return user, nil
+}
+
+// errServiceAccount refuses changes that do not apply to service accounts,
+// which have no password and act only through the permissions of their API keys.
+var errServiceAccount = errors.New("service accounts are managed through their API keys")
+
+// UpdateRole changes the role of a user, e.g. to grant catering staff access.
+// The user's sessions end so the new role applies at once.
+func (s *UserService) UpdateRole(userID uuid.UUID, req *models.UpdateUserRoleRequest, actorID uuid.UUID, tenant Tenant) error {
+	if userID == actorID {
+		return errors.New("cannot change your own role")
+	}
+	if req.Role == models.RoleSuperAdmin && !tenant.AllOrganisations {
+		return errors.New("only super admins can grant the super_admin role")
+	}
+
+	// Start transaction
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("error starting transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	if err := checkUserTenant(tx, userID, tenant); err != nil {
+		return err
+	}
+
+	var username, previous string
+	var serviceAccount bool
+	err = tx.QueryRow(`
+		SELECT username, role, is_service_account FROM users WHERE id = $1 FOR UPDATE
+	`, userID).Scan(&username, &previous, &serviceAccount)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return errors.New("user not found")
+		}
+		return fmt.Errorf("error fetching user: %v", err)
+	}
+	if serviceAccount {
+		return errServiceAccount
+	}
+	if previous == req.Role {
+		return nil
+	}
+
+	_, err = tx.Exec(`
+		UPDATE users
+		SET role = $1, updated_at = NOW()
+		WHERE id = $2`,
+		req.Role, userID,
+	)
+	if err != nil {
+		return fmt.Errorf("error updating user role: %v", err)
+	}
+
+	if err := revokeUserSessions(tx, userID); err != nil {
+		return err
+	}
+
+	err = recordAuthEvent(tx, authAuditEntry{
+		Event:    models.AuthEventRoleChanged,
+		UserID:   userID,
+		Username: username,
+		ActorID:  actorID,
+		Detail:   fmt.Sprintf("%s to %s", previous, req.Role),
+	})
+	if err != nil {
+		return err
+	}
+
+	// Commit transaction
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("error committing transaction: %v", err)
+	}
+
+	return nil
+}
+
+// SuspendUser stops a user from signing in and ends their sessions.
+func (s *UserService) SuspendUser(userID, actorID uuid.UUID, tenant Tenant) error {
+	if userID == actorID {
+		return errors.New("cannot suspend your own account")
+	}
+
+	return s.setStatus(userID, actorID, tenant, models.UserStatusSuspended, models.AuthEventUserSuspended)
+}
+
+// ReactivateUser lets a suspended user sign in again.
+func (s *UserService) ReactivateUser(userID, actorID uuid.UUID, tenant Tenant) error {
+	return s.setStatus(userID, actorID, tenant, models.UserStatusActive, models.AuthEventUserReactivated)
+}
+
+func (s *UserService) setStatus(userID, actorID uuid.UUID, tenant Tenant, status, event string) error {
+	// Start transaction
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("error starting transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	if err := checkUserTenant(tx, userID, tenant); err != nil {
+		return err
+	}
+
+	var username, previous string
+	err = tx.QueryRow(`SELECT username, status FROM users WHERE id = $1 FOR UPDATE`, userID).Scan(&username, &previous)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return errors.New("user not found")
+		}
+		return fmt.Errorf("error fetching user: %v", err)
+	}
+
+	switch {
+	case status == models.UserStatusSuspended && previous == models.UserStatusSuspended:
+		return errors.New("user is already suspended")
+	case status == models.UserStatusActive && previous != models.UserStatusSuspended:
+		return errors.New("user is not suspended")
+	}
+
+	_, err = tx.Exec(`UPDATE users SET status = $1, updated_at = NOW() WHERE id = $2`, status, userID)
+	if err != nil {
+		return fmt.Errorf("error updating user status: %v", err)
+	}
+
+	if status == models.UserStatusSuspended {
+		if err := revokeUserSessions(tx, userID); err != nil {
+			return err
+		}
+	}
+
+	err = recordAuthEvent(tx, authAuditEntry{
+		Event:    event,
+		UserID:   userID,
+		Username: username,
+		ActorID:  actorID,
+	})
+	if err != nil {
+		return err
+	}
+
+	// Commit transaction
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("error committing transaction: %v", err)
+	}
+
+	return nil
+}
+
+// ForcePasswordReset makes a user choose a new password: the current one
+// stops working, their sessions end and a reset link is emailed to them.
+func (s *UserService) ForcePasswordReset(userID, actorID uuid.UUID, tenant Tenant) error {
+	// Start transaction
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("error starting transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	if err := checkUserTenant(tx, userID, tenant); err != nil {
+		return err
+	}
+
+	var serviceAccount bool
+	err = tx.QueryRow(`SELECT is_service_account FROM users WHERE id = $1 FOR UPDATE`, userID).Scan(&serviceAccount)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return errors.New("user not found")
+		}
+		return fmt.Errorf("error fetching user: %v", err)
+	}
+	if serviceAccount {
+		return errServiceAccount
+	}
+
+	var username, language string
+	var email sql.NullString
+	err = tx.QueryRow(`
+		UPDATE users
+		SET password = '', updated_at = NOW()
+		WHERE id = $1
+		RETURNING username, email, COALESCE(language, '')
+	`, userID).Scan(&username, &email, &language)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return errors.New("user not found")
+		}
+		return fmt.Errorf("error clearing password: %v", err)
+	}
+	if !email.Valid || email.String == "" {
+		return errors.New("user has no email address")
+	}
+
+	if err := revokeUserSessions(tx, userID); err != nil {
+		return err
+	}
+
+	if err := queuePasswordReset(tx, userID, username, email.String, language); err != nil {
+		return err
+	}
+
+	err = recordAuthEvent(tx, authAuditEntry{
+		Event:    models.AuthEventPasswordResetForced,
+		UserID:   userID,
+		Username: username,
+		ActorID:  actorID,
+	})
+	if err != nil {
+		return err
+	}
+
+	// Commit transaction
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("error committing transaction: %v", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,23 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLikeEscaper(t *testing.T) {
+	tests := []struct {
+		search   string
+		expected string
+	}{
+		{search: "jane", expected: "jane"},
+		{search: "100%", expected: `100\%`},
+		{search: "first_last", expected: `first\_last`},
+		{search: `back\slash`, expected: `back\\slash`},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.expected, likeEscaper.Replace(tt.search))
+	}
+}
@@ -0,0 +1,711 @@
+package services
+
+import (
+	"database/sql"
+	"e-meetingproject/internal/database"
+	"e-meetingproject/internal/models"
+	"fmt"
+	"math"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/spf13/viper"
+)
+
+type WalletService struct {
+	db       *sql.DB
+	currency string
+}
+
+func NewWalletService() *WalletService {
+	currency := viper.GetString("PAYMENT_CURRENCY")
+	if currency == "" {
+		currency = "IDR" // default currency
+	}
+
+	return &WalletService{
+		db:       database.GetDB(),
+		currency: currency,
+	}
+}
+
+// GetUserWallets returns the user's own wallet and the wallets of the user's teams.
+func (s *WalletService) GetUserWallets(userID uuid.UUID) (*models.WalletListResponse, error) {
+	rows, err := s.db.Query(`
+		SELECT id, user_id, team_id, currency, balance, held, created_at, updated_at
+		FROM wallets
+		WHERE user_id = $1
+			OR team_id IN (SELECT team_id FROM team_members WHERE user_id = $1)
+		ORDER BY user_id NULLS LAST, created_at ASC
+	`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("error querying wallets: %v", err)
+	}
+	defer rows.Close()
+
+	wallets := []models.Wallet{}
+	for rows.Next() {
+		wallet, err := scanWallet(rows)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning wallet: %v", err)
+		}
+		wallets = append(wallets, *wallet)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating wallets: %v", err)
+	}
+
+	return &models.WalletListResponse{
+		Wallets: wallets,
+	}, nil
+}
+
+// GetWallet returns a wallet the user may spend from. Billing managers may
+// read any wallet of their tenant.
+func (s *WalletService) GetWallet(walletID, userID uuid.UUID, canManage bool, tenant Tenant) (*models.Wallet, error) {
+	if canManage {
+		if err := checkWalletTenant(s.db, walletID, tenant); err != nil {
+			return nil, err
+		}
+	} else if err := checkWalletAccess(s.db, walletID, userID); err != nil {
+		return nil, err
+	}
+
+	wallet, err := scanWallet(s.db.QueryRow(`
+		SELECT id, user_id, team_id, currency, balance, held, created_at, updated_at
+		FROM wallets
+		WHERE id = $1
+	`, walletID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("wallet not found")
+		}
+		return nil, fmt.Errorf("error fetching wallet: %v", err)
+	}
+
+	return wallet, nil
+}
+
+// GetTransactions returns the ledger of a wallet, newest first.
+func (s *WalletService) GetTransactions(walletID, userID uuid.UUID, canManage bool, tenant Tenant, query *models.PaginationQuery) (*models.WalletTransactionListResponse, error) {
+	if _, err := s.GetWallet(walletID, userID, canManage, tenant); err != nil {
+		return nil, err
+	}
+
+	var totalCount int
+	err := s.db.QueryRow(`SELECT COUNT(*) FROM wallet_transactions WHERE wallet_id = $1`, walletID).Scan(&totalCount)
+	if err != nil {
+		return nil, fmt.Errorf("error counting wallet transactions: %v", err)
+	}
+
+	rows, err := s.db.Query(`
+		SELECT id, wallet_id, reservation_id, type, amount, balance_after, held_after,
+			COALESCE(description, ''), created_by, created_at
+		FROM wallet_transactions
+		WHERE wallet_id = $1
+		ORDER BY created_at DESC, id
+		LIMIT $2 OFFSET $3
+	`, walletID, query.PageSize, (query.Page-1)*query.PageSize)
+	if err != nil {
+		return nil, fmt.Errorf("error querying wallet transactions: %v", err)
+	}
+	defer rows.Close()
+
+	transactions := []models.WalletTransaction{}
+	for rows.Next() {
+		var transaction models.WalletTransaction
+		var reservationID, createdBy uuid.NullUUID
+		err := rows.Scan(
+			&transaction.ID,
+			&transaction.WalletID,
+			&reservationID,
+			&transaction.Type,
+			&transaction.Amount,
+			&transaction.BalanceAfter,
+			&transaction.HeldAfter,
+			&transaction.Description,
+			&createdBy,
+			&transaction.CreatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning wallet transaction: %v", err)
+		}
+		if reservationID.Valid {
+			transaction.ReservationID = &reservationID.UUID
+		}
+		if createdBy.Valid {
+			transaction.CreatedBy = &createdBy.UUID
+		}
+		transactions = append(transactions, transaction)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating wallet transactions: %v", err)
+	}
+
+	return &models.WalletTransactionListResponse{
+		WalletID:     walletID,
+		Transactions: transactions,
+		TotalCount:   totalCount,
+		Page:         query.Page,
+		PageSize:     query.PageSize,
+		TotalPages:   int(math.Ceil(float64(totalCount) / float64(query.PageSize))),
+	}, nil
+}
+
+// CreateWallet opens an empty wallet for exactly one user or team of the tenant.
+func (s *WalletService) CreateWallet(req *models.CreateWalletRequest, tenant Tenant) (*models.Wallet, error) {
+	if (req.UserID == nil) == (req.TeamID == nil) {
+		return nil, fmt.Errorf("invalid wallet owner: exactly one of user_id or team_id is required")
+	}
+
+	// Owners of other organisations are reported as not found
+	var err error
+	if req.UserID != nil {
+		err = checkUserTenant(s.db, *req.UserID, tenant)
+	} else {
+		err = checkTeamTenant(s.db, *req.TeamID, tenant)
+	}
+	if err != nil {
+		if err.Error() == "user not found" || err.Error() == "team not found" {
+			return nil, fmt.Errorf("wallet owner not found")
+		}
+		return nil, err
+	}
+
+	wallet, err := scanWallet(s.db.QueryRow(`
+		INSERT INTO wallets (user_id, team_id, currency)
+		VALUES ($1, $2, $3)
+		RETURNING id, user_id, team_id, currency, balance, held, created_at, updated_at
+	`, req.UserID, req.TeamID, s.currency))
+	if err != nil {
+		if pqErr, ok := err.(*pq.Error); ok {
+			switch pqErr.Code.Name() {
+			case "unique_violation":
+				return nil, fmt.Errorf("wallet already exists for this owner")
+			case "foreign_key_violation":
+				return nil, fmt.Errorf("wallet owner not found")
+			}
+		}
+		return nil, fmt.Errorf("error creating wallet: %v", err)
+	}
+
+	return wallet, nil
+}
+
+// TopUp credits a wallet of the tenant with purchased booking credit.
+func (s *WalletService) TopUp(walletID, adminID uuid.UUID, req *models.WalletTopUpRequest, tenant Tenant) (*models.WalletTransaction, error) {
+	// Start transaction
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("error starting transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	if err := checkWalletTenant(tx, walletID, tenant); err != nil {
+		return nil, err
+	}
+
+	wallet, err := lockWallet(tx, walletID)
+	if err != nil {
+		return nil, err
+	}
+
+	transaction, err := applyWalletTransaction(tx, wallet, models.WalletTransactionTopUp, roundCurrency(req.Amount), nil, req.Description, &adminID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Commit transaction
+	if err = tx.Commit(); err != nil {
+		return nil, fmt.Errorf("error committing transaction: %v", err)
+	}
+
+	return transaction, nil
+}
+
+func (s *WalletService) GetTeams(tenant Tenant) (*models.TeamListResponse, error) {
+	rows, err := s.db.Query(`
+		SELECT t.id, t.organisation_id, t.name, t.created_at, t.updated_at,
+			COALESCE(ARRAY_AGG(tm.user_id ORDER BY tm.created_at) FILTER (WHERE tm.user_id IS NOT NULL), '{}')
+		FROM teams t
+		LEFT JOIN team_members tm ON tm.team_id = t.id
+		WHERE `+tenantCondition("t.organisation_id", 1)+`
+		GROUP BY t.id
+		ORDER BY t.name ASC
+	`, tenant.arg())
+	if err != nil {
+		return nil, fmt.Errorf("error querying teams: %v", err)
+	}
+	defer rows.Close()
+
+	teams := []models.Team{}
+	for rows.Next() {
+		var team models.Team
+		var memberIDs []string
+		err := rows.Scan(&team.ID, &team.OrganisationID, &team.Name, &team.CreatedAt, &team.UpdatedAt, pq.Array(&memberIDs))
+		if err != nil {
+			return nil, fmt.Errorf("error scanning team: %v", err)
+		}
+
+		team.MemberIDs = make([]uuid.UUID, 0, len(memberIDs))
+		for _, id := range memberIDs {
+			memberID, err := uuid.Parse(id)
+			if err != nil {
+				return nil, fmt.Errorf("error parsing team member ID: %v", err)
+			}
+			team.MemberIDs = append(team.MemberIDs, memberID)
+		}
+		teams = append(teams, team)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating teams: %v", err)
+	}
+
+	return &models.TeamListResponse{
+		Teams: teams,
+	}, nil
+}
+
+// CreateTeam creates a team in the tenant's organisation.
+func (s *WalletService) CreateTeam(req *models.CreateTeamRequest, tenant Tenant) (*models.Team, error) {
+	team := models.Team{
+		OrganisationID: tenant.OrganisationID,
+		Name:           req.Name,
+		MemberIDs:      []uuid.UUID{},
+	}
+
+	err := s.db.QueryRow(`
+		INSERT INTO teams (name, organisation_id)
+		VALUES ($1, $2)
+		RETURNING id, created_at, updated_at
+	`, req.Name, team.OrganisationID).Scan(&team.ID, &team.CreatedAt, &team.UpdatedAt)
+	if err != nil {
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code.Name() == "unique_violation" {
+			return nil, fmt.Errorf("team name already exists")
+		}
+		return nil, fmt.Errorf("error creating team: %v", err)
+	}
+
+	return &team, nil
+}
+
+// AddTeamMember adds a user to a team; both must belong to the tenant.
+func (s *WalletService) AddTeamMember(teamID uuid.UUID, req *models.TeamMemberRequest, tenant Tenant) error {
+	if err := checkTeamTenant(s.db, teamID, tenant); err != nil {
+		if err.Error() == "team not found" {
+			return fmt.Errorf("team or user not found")
+		}
+		return err
+	}
+	if err := checkUserTenant(s.db, req.UserID, tenant); err != nil {
+		if err.Error() == "user not found" {
+			return fmt.Errorf("team or user not found")
+		}
+		return err
+	}
+
+	_, err := s.db.Exec(`
+		INSERT INTO team_members (team_id, user_id)
+		VALUES ($1, $2)
+		ON CONFLICT (team_id, user_id) DO NOTHING
+	`, teamID, req.UserID)
+	if err != nil {
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code.Name() == "foreign_key_violation" {
+			return fmt.Errorf("team or user not found")
+		}
+		return fmt.Errorf("error adding team member: %v", err)
+	}
+
+	return nil
+}
+
+func (s *WalletService) RemoveTeamMember(teamID, userID uuid.UUID, tenant Tenant) error {
+	result, err := s.db.Exec(`
+		DELETE FROM team_members tm
+		USING teams t
+		WHERE tm.team_id = $1 AND tm.user_id = $2 AND t.id = tm.team_id
+			AND `+tenantCondition("t.organisation_id", 3),
+		teamID, userID, tenant.arg(),
+	)
+	if err != nil {
+		return fmt.Errorf("error removing team member: %v", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("error getting rows affected: %v", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("team member not found")
+	}
+
+	return nil
+}
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanWallet scans the wallet columns in the order selected by the queries above.
+func scanWallet(row rowScanner) (*models.Wallet, error) {
+	var wallet models.Wallet
+	var userID, teamID uuid.NullUUID
+	err := row.Scan(
+		&wallet.ID,
+		&userID,
+		&teamID,
+		&wallet.Currency,
+		&wallet.Balance,
+		&wallet.Held,
+		&wallet.CreatedAt,
+		&wallet.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if userID.Valid {
+		wallet.UserID = &userID.UUID
+	}
+	if teamID.Valid {
+		wallet.TeamID = &teamID.UUID
+	}
+
+	return &wallet, nil
+}
+
+type queryRower interface {
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
+// checkTeamTenant returns "team not found" unless the team belongs to the tenant.
+func checkTeamTenant(q queryRower, teamID uuid.UUID, tenant Tenant) error {
+	var organisationID uuid.UUID
+	err := q.QueryRow(`SELECT organisation_id FROM teams WHERE id = $1`, teamID).Scan(&organisationID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return fmt.Errorf("team not found")
+		}
+		return fmt.Errorf("error fetching team: %v", err)
+	}
+	if !tenant.canAccess(organisationID) {
+		return fmt.Errorf("team not found")
+	}
+	return nil
+}
+
+// checkWalletTenant ensures the owner of the wallet, a user or a team,
+// belongs to the tenant. Wallets of other organisations are reported as not found.
+func checkWalletTenant(q queryRower, walletID uuid.UUID, tenant Tenant) error {
+	var organisationID uuid.UUID
+	err := q.QueryRow(`
+		SELECT COALESCE(u.organisation_id, t.organisation_id)
+		FROM wallets w
+		LEFT JOIN users u ON u.id = w.user_id
+		LEFT JOIN teams t ON t.id = w.team_id
+		WHERE w.id = $1
+	`, walletID).Scan(&organisationID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return fmt.Errorf("wallet not found")
+		}
+		return fmt.Errorf("error fetching wallet: %v", err)
+	}
+	if !tenant.canAccess(organisationID) {
+		return fmt.Errorf("wallet not found")
+	}
+	return nil
+}
+
+// checkWalletAccess ensures the wallet belongs to the user or to one of the
+// user's teams. Wallets of others are reported as not found.
+func checkWalletAccess(q queryRower, walletID, userID uuid.UUID) error {
+	var allowed bool
+	err := q.QueryRow(`
+		SELECT EXISTS(
+			SELECT 1 FROM wallets
+			WHERE id = $1
+				AND (user_id = $2 OR team_id IN (SELECT team_id FROM team_members WHERE user_id = $2))
+		)
+	`, walletID, userID).Scan(&allowed)
+	if err != nil {
+		return fmt.Errorf("error checking wallet access: %v", err)
+	}
+	if !allowed {
+		return fmt.Errorf("wallet not found")
+	}
+
+	return nil
+}
+
+// lockWallet fetches a wallet and locks it until tx ends so balance changes
+// are serialised.
+func lockWallet(tx *sql.Tx, walletID uuid.UUID) (*models.Wallet, error) {
+	wallet, err := scanWallet(tx.QueryRow(`
+		SELECT id, user_id, team_id, currency, balance, held, created_at, updated_at
+		FROM wallets
+		WHERE id = $1
+		FOR UPDATE
+	`, walletID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("wallet not found")
+		}
+		return nil, fmt.Errorf("error locking wallet: %v", err)
+	}
+
+	return wallet, nil
+}
+
+// walletBalancesAfter returns the balance and held amounts of a wallet after a
+// ledger entry. Holds move funds from the balance to held, captures spend held
+// funds, releases return them to the balance, top-ups and refunds add to it.
+func walletBalancesAfter(balance, held float64, txType models.WalletTransactionType, amount float64) (float64, float64, error) {
+	if amount <= 0 {
+		return 0, 0, fmt.Errorf("invalid wallet transaction: amount must be positive")
+	}
+
+	switch txType {
+	case models.WalletTransactionTopUp, models.WalletTransactionRefund:
+		balance += amount
+	case models.WalletTransactionHold:
+		if amount > balance {
+			return 0, 0, fmt.Errorf("insufficient wallet funds")
+		}
+		balance -= amount
+		held += amount
+	case models.WalletTransactionCapture:
+		if amount > held {
+			return 0, 0, fmt.Errorf("invalid wallet transaction: amount exceeds held funds")
+		}
+		held -= amount
+	case models.WalletTransactionRelease:
+		if amount > held {
+			return 0, 0, fmt.Errorf("invalid wallet transaction: amount exceeds held funds")
+		}
+		held -= amount
+		balance += amount
+	default:
+		return 0, 0, fmt.Errorf("invalid wallet transaction type: %s", txType)
+	}
+
+	return roundCurrency(balance), roundCurrency(held), nil
+}
+
+// applyWalletTransaction records a ledger entry on a wallet locked by
+// lockWallet and updates its balances within tx.
+func applyWalletTransaction(tx *sql.Tx, wallet *models.Wallet, txType models.WalletTransactionType, amount float64, reservationID *uuid.UUID, description string, createdBy *uuid.UUID) (*models.WalletTransaction, error) {
+	balance, held, err := walletBalancesAfter(wallet.Balance, wallet.Held, txType, amount)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = tx.Exec(`
+		UPDATE wallets
+		SET balance = $1, held = $2, updated_at = NOW()
+		WHERE id = $3`,
+		balance, held, wallet.ID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error updating wallet balance: %v", err)
+	}
+	wallet.Balance = balance
+	wallet.Held = held
+
+	transaction := models.WalletTransaction{
+		WalletID:      wallet.ID,
+		ReservationID: reservationID,
+		Type:          txType,
+		Amount:        amount,
+		BalanceAfter:  balance,
+		HeldAfter:     held,
+		Description:   description,
+		CreatedBy:     createdBy,
+	}
+	err = tx.QueryRow(`
+		INSERT INTO wallet_transactions (
+			wallet_id, reservation_id, type, amount, balance_after, held_after, description, created_by
+		) VALUES ($1, $2, $3, $4, $5, $6, NULLIF($7, ''), $8)
+		RETURNING id, created_at
+	`, wallet.ID, reservationID, txType, amount, balance, held, description, createdBy).Scan(&transaction.ID, &transaction.CreatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("error recording wallet transaction: %v", err)
+	}
+
+	return &transaction, nil
+}
+
+// holdReservationFunds reserves the price of a new reservation on its wallet.
+// Access to the wallet is checked by the caller.
+func holdReservationFunds(tx *sql.Tx, walletID, userID, reservationID uuid.UUID, amount float64) error {
+	if amount <= 0 {
+		return nil
+	}
+
+	wallet, err := lockWallet(tx, walletID)
+	if err != nil {
+		return err
+	}
+
+	_, err = applyWalletTransaction(tx, wallet, models.WalletTransactionHold, amount, &reservationID, "reservation hold", &userID)
+	return err
+}
+
+// walletSettlement is the ledger movement needed when a wallet-paid
+// reservation changes status.
+type walletSettlement struct {
+	Hold    float64
+	Capture float64
+	Release float64
+	Refund  float64
+}
+
+// planWalletSettlement decides what happens to a reservation's wallet funds.
+// Confirming or completing captures whatever is still held. Cancelling charges
+// the fee from held funds first, releases the rest of the hold, and refunds
+// captured funds beyond the remaining fee.
+func planWalletSettlement(status models.ReservationStatus, held, captured, fee float64) walletSettlement {
+	var settlement walletSettlement
+
+	switch status {
+	case models.ReservationStatusConfirmed, models.ReservationStatusCompleted:
+		settlement.Capture = held
+	case models.ReservationStatusCancelled:
+		settlement.Capture = math.Min(held, fee)
+		settlement.Release = held - settlement.Capture
+		settlement.Refund = math.Max(captured-(fee-settlement.Capture), 0)
+	}
+
+	settlement.Capture = roundCurrency(settlement.Capture)
+	settlement.Release = roundCurrency(settlement.Release)
+	settlement.Refund = roundCurrency(settlement.Refund)
+	return settlement
+}
+
+// reservationWalletFunds returns what a reservation currently holds on its
+// wallet and what has been captured from it net of refunds.
+func reservationWalletFunds(tx *sql.Tx, reservationID uuid.UUID) (float64, float64, error) {
+	var held, captured float64
+	err := tx.QueryRow(`
+		SELECT
+			COALESCE(SUM(CASE type WHEN 'hold' THEN amount WHEN 'capture' THEN -amount WHEN 'release' THEN -amount ELSE 0 END), 0),
+			COALESCE(SUM(CASE type WHEN 'capture' THEN amount WHEN 'refund' THEN -amount ELSE 0 END), 0)
+		FROM wallet_transactions
+		WHERE reservation_id = $1
+	`, reservationID).Scan(&held, &captured)
+	if err != nil {
+		return 0, 0, fmt.Errorf("error fetching reservation wallet funds: %v", err)
+	}
+
+	return roundCurrency(held), roundCurrency(captured), nil
+}
+
+// settleReservationWallet applies the wallet movements of a status change to
+// a reservation paid from a wallet. fee is the cancellation fee when the
+// reservation is being cancelled.
+func settleReservationWallet(tx *sql.Tx, reservationID uuid.UUID, status models.ReservationStatus, fee float64) error {
+	var walletID uuid.NullUUID
+	err := tx.QueryRow(`SELECT wallet_id FROM reservations WHERE id = $1`, reservationID).Scan(&walletID)
+	if err != nil {
+		return fmt.Errorf("error fetching reservation wallet: %v", err)
+	}
+	if !walletID.Valid {
+		return nil
+	}
+
+	wallet, err := lockWallet(tx, walletID.UUID)
+	if err != nil {
+		return err
+	}
+
+	held, captured, err := reservationWalletFunds(tx, reservationID)
+	if err != nil {
+		return err
+	}
+
+	settlement := planWalletSettlement(status, held, captured, fee)
+	steps := []struct {
+		txType      models.WalletTransactionType
+		amount      float64
+		description string
+	}{
+		{models.WalletTransactionCapture, settlement.Capture, fmt.Sprintf("reservation %s", status)},
+		{models.WalletTransactionRelease, settlement.Release, "reservation cancelled"},
+		{models.WalletTransactionRefund, settlement.Refund, "reservation cancelled"},
+	}
+	for _, step := range steps {
+		if step.amount <= 0 {
+			continue
+		}
+		_, err := applyWalletTransaction(tx, wallet, step.txType, step.amount, &reservationID, step.description, nil)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// planWalletAdjustment plans the wallet movements after the price of a
+// pending or confirmed reservation changed by delta. Pending reservations
+// hold or release the difference; confirmed ones capture it right away or
+// refund it, never more than was captured.
+func planWalletAdjustment(status models.ReservationStatus, held, captured, delta float64) walletSettlement {
+	var adjustment walletSettlement
+
+	switch {
+	case delta > 0:
+		adjustment.Hold = delta
+		if status == models.ReservationStatusConfirmed {
+			adjustment.Capture = delta
+		}
+	case delta < 0 && status == models.ReservationStatusConfirmed:
+		adjustment.Refund = math.Min(-delta, captured)
+	case delta < 0:
+		adjustment.Release = math.Min(-delta, held)
+	}
+
+	adjustment.Hold = roundCurrency(adjustment.Hold)
+	adjustment.Capture = roundCurrency(adjustment.Capture)
+	adjustment.Release = roundCurrency(adjustment.Release)
+	adjustment.Refund = roundCurrency(adjustment.Refund)
+	return adjustment
+}
+
+// adjustReservationWallet moves the wallet funds of a reservation whose price
+// changed by delta within tx.
+func adjustReservationWallet(tx *sql.Tx, reservationID, walletID, userID uuid.UUID, status models.ReservationStatus, delta float64) error {
+	wallet, err := lockWallet(tx, walletID)
+	if err != nil {
+		return err
+	}
+
+	held, captured, err := reservationWalletFunds(tx, reservationID)
+	if err != nil {
+		return err
+	}
+
+	adjustment := planWalletAdjustment(status, held, captured, delta)
+	steps := []struct {
+		txType models.WalletTransactionType
+		amount float64
+	}{
+		{models.WalletTransactionHold, adjustment.Hold},
+		{models.WalletTransactionCapture, adjustment.Capture},
+		{models.WalletTransactionRelease, adjustment.Release},
+		{models.WalletTransactionRefund, adjustment.Refund},
+	}
+	for _, step := range steps {
+		if step.amount <= 0 {
+			continue
+		}
+		_, err := applyWalletTransaction(tx, wallet, step.txType, step.amount, &reservationID, "snack order changed", &userID)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,107 @@
+package services
+
+import (
+	"e-meetingproject/internal/models"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWalletBalancesAfter(t *testing.T) {
+	tests := []struct {
+		name            string
+		balance, held   float64
+		txType          models.WalletTransactionType
+		amount          float64
+		expectedBalance float64
+		expectedHeld    float64
+		expectedErr     string
+	}{
+		{name: "Top up", balance: 10, held: 5, txType: models.WalletTransactionTopUp, amount: 100, expectedBalance: 110, expectedHeld: 5},
+		{name: "Hold", balance: 100, held: 0, txType: models.WalletTransactionHold, amount: 40, expectedBalance: 60, expectedHeld: 40},
+		{name: "Hold whole balance", balance: 40, held: 0, txType: models.WalletTransactionHold, amount: 40, expectedBalance: 0, expectedHeld: 40},
+		{name: "Hold more than balance", balance: 39.99, held: 0, txType: models.WalletTransactionHold, amount: 40, expectedErr: "insufficient wallet funds"},
+		{name: "Capture", balance: 60, held: 40, txType: models.WalletTransactionCapture, amount: 40, expectedBalance: 60, expectedHeld: 0},
+		{name: "Release", balance: 60, held: 40, txType: models.WalletTransactionRelease, amount: 30, expectedBalance: 90, expectedHeld: 10},
+		{name: "Refund", balance: 60, held: 0, txType: models.WalletTransactionRefund, amount: 20, expectedBalance: 80, expectedHeld: 0},
+		{
+			name: "Capture more than held", balance: 60, held: 40, txType: models.WalletTransactionCapture, amount: 50,
+			expectedErr: "invalid wallet transaction: amount exceeds held funds",
+		},
+		{
+			name: "Non-positive amount", balance: 60, held: 40, txType: models.WalletTransactionTopUp, amount: 0,
+			expectedErr: "invalid wallet transaction: amount must be positive",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			balance, held, err := walletBalancesAfter(tc.balance, tc.held, tc.txType, tc.amount)
+			if tc.expectedErr != "" {
+				assert.EqualError(t, err, tc.expectedErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expectedBalance, balance)
+			assert.Equal(t, tc.expectedHeld, held)
+		})
+	}
+}
+
+func TestPlanWalletSettlement(t *testing.T) {
+	tests := []struct {
+		name     string
+		status   models.ReservationStatus
+		held     float64
+		captured float64
+		fee      float64
+		expected walletSettlement
+	}{
+		{name: "Confirm captures the hold", status: models.ReservationStatusConfirmed, held: 100, expected: walletSettlement{Capture: 100}},
+		{name: "Complete after confirm", status: models.ReservationStatusCompleted, captured: 100, expected: walletSettlement{}},
+		{name: "Cancel pending for free", status: models.ReservationStatusCancelled, held: 100, expected: walletSettlement{Release: 100}},
+		{
+			name: "Cancel pending with fee", status: models.ReservationStatusCancelled, held: 100, fee: 50,
+			expected: walletSettlement{Capture: 50, Release: 50},
+		},
+		{
+			name: "Cancel confirmed with fee", status: models.ReservationStatusCancelled, captured: 100, fee: 50,
+			expected: walletSettlement{Refund: 50},
+		},
+		{
+			name: "Cancel confirmed with full fee", status: models.ReservationStatusCancelled, captured: 100, fee: 100,
+			expected: walletSettlement{},
+		},
+		{name: "Back to pending", status: models.ReservationStatusPending, held: 100, expected: walletSettlement{}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, planWalletSettlement(tc.status, tc.held, tc.captured, tc.fee))
+		})
+	}
+}
+
+func TestPlanWalletAdjustment(t *testing.T) {
+	tests := []struct {
+		name     string
+		status   models.ReservationStatus
+		held     float64
+		captured float64
+		delta    float64
+		expected walletSettlement
+	}{
+		{name: "Pending increase holds more", status: models.ReservationStatusPending, held: 100, delta: 20, expected: walletSettlement{Hold: 20}},
+		{name: "Pending decrease releases", status: models.ReservationStatusPending, held: 100, delta: -20, expected: walletSettlement{Release: 20}},
+		{name: "Pending decrease capped at hold", status: models.ReservationStatusPending, held: 10, delta: -20, expected: walletSettlement{Release: 10}},
+		{name: "Confirmed increase captures", status: models.ReservationStatusConfirmed, captured: 100, delta: 20, expected: walletSettlement{Hold: 20, Capture: 20}},
+		{name: "Confirmed decrease refunds", status: models.ReservationStatusConfirmed, captured: 100, delta: -20, expected: walletSettlement{Refund: 20}},
+		{name: "No change", status: models.ReservationStatusConfirmed, captured: 100, expected: walletSettlement{}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, planWalletAdjustment(tc.status, tc.held, tc.captured, tc.delta))
+		})
+	}
+}